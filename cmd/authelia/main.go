@@ -1,9 +1,13 @@
 package main
 
 import (
+	"crypto/x509"
+	"expvar"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -12,6 +16,7 @@ import (
 	"github.com/authelia/authelia/internal/authorization"
 	"github.com/authelia/authelia/internal/commands"
 	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/notification"
@@ -99,13 +104,17 @@ func startServer() {
 		err          error
 	)
 
+	userProviders := newUserProviders(config.AuthenticationBackend, autheliaCertPool)
+
 	switch {
-	case config.AuthenticationBackend.File != nil:
-		userProvider = authentication.NewFileUserProvider(config.AuthenticationBackend.File)
-	case config.AuthenticationBackend.LDAP != nil:
-		userProvider, err = authentication.NewLDAPUserProvider(config.AuthenticationBackend, autheliaCertPool)
+	case config.AuthenticationBackend.Chain != nil:
+		userProvider, err = authentication.NewChainUserProvider(config.AuthenticationBackend.Chain, userProviders)
 		if err != nil {
-			logger.Fatalf("Failed to Check LDAP Authentication Backend: %v", err)
+			logger.Fatalf("Failed to Chain Authentication Backends: %v", err)
+		}
+	case len(userProviders) == 1:
+		for _, provider := range userProviders {
+			userProvider = provider
 		}
 	default:
 		logger.Fatalf("Unrecognized authentication backend")
@@ -139,19 +148,130 @@ func startServer() {
 		logger.Fatalf("Error initializing OpenID Connect Provider: %+v", err)
 	}
 
+	var clientCertificates *authentication.ClientCertificateVerifier
+
+	if config.Server.ClientCertificates != nil {
+		clientCertificates, err = authentication.NewClientCertificateVerifier(*config.Server.ClientCertificates, clock)
+		if err != nil {
+			logger.Fatalf("Error initializing client certificates authentication: %v", err)
+		}
+	}
+
+	var trustedHeader *authentication.TrustedHeaderVerifier
+
+	if config.Server.TrustedHeader != nil {
+		trustedHeader, err = authentication.NewTrustedHeaderVerifier(*config.Server.TrustedHeader, clock)
+		if err != nil {
+			logger.Fatalf("Error initializing trusted header authentication: %v", err)
+		}
+	}
+
 	providers := middlewares.Providers{
-		Authorizer:      authorizer,
-		UserProvider:    userProvider,
-		Regulator:       regulator,
-		OpenIDConnect:   oidcProvider,
-		StorageProvider: storageProvider,
-		Notifier:        notifier,
-		SessionProvider: sessionProvider,
+		Authorizer:         authorizer,
+		UserProvider:       userProvider,
+		ClientCertificates: clientCertificates,
+		TrustedHeader:      trustedHeader,
+		Regulator:          regulator,
+		OpenIDConnect:      oidcProvider,
+		StorageProvider:    storageProvider,
+		Notifier:           notifier,
+		SessionProvider:    sessionProvider,
 	}
 
+	reloadAccessControlOnSignal(authorizer)
+
 	server.StartServer(*config, providers)
 }
 
+// reloadAccessControlOnSignal reloads the access control rules from the configuration file each time the process
+// receives SIGHUP.
+func reloadAccessControlOnSignal(authorizer *authorization.Authorizer) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			reloadAccessControl(authorizer)
+		}
+	}()
+}
+
+// reloadAccessControl validates the configuration file and swaps the access control rules of the authorizer. The
+// previous rules are kept when the configuration is invalid. Other sections of the configuration still require a
+// restart to be applied.
+func reloadAccessControl(authorizer *authorization.Authorizer) {
+	logger := logging.Logger()
+
+	logger.Info("Reloading the access control rules")
+
+	config, errs := configuration.Read(configPathFlag)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			logger.Error(err)
+		}
+
+		logger.Error("The configuration is invalid, keeping the previous access control rules")
+
+		return
+	}
+
+	authorizer.ReloadAccessControl(config.AccessControl)
+
+	logger.Infof("Reloaded %d access control rules with the default policy %s",
+		len(config.AccessControl.Rules), config.AccessControl.DefaultPolicy)
+}
+
+// newUserProviders creates a user provider for each configured authentication backend indexed by backend name.
+func newUserProviders(config schema.AuthenticationBackendConfiguration, certPool *x509.CertPool) map[string]authentication.UserProvider {
+	logger := logging.Logger()
+	providers := make(map[string]authentication.UserProvider)
+
+	if config.File != nil {
+		providers[schema.AuthenticationBackendFile] = authentication.NewFileUserProvider(config.File)
+	}
+
+	if config.LDAP != nil {
+		provider, err := authentication.NewLDAPUserProvider(config, certPool)
+		if err != nil {
+			logger.Fatalf("Failed to Check LDAP Authentication Backend: %v", err)
+		}
+
+		publishLDAPConnectionPoolStats(provider)
+
+		providers[schema.AuthenticationBackendLDAP] = provider
+	}
+
+	if config.SQL != nil {
+		db, err := storage.OpenSQLDatabase(config.SQL.MySQL, config.SQL.PostgreSQL)
+		if err != nil {
+			logger.Fatalf("Failed to Open SQL Authentication Backend: %v", err)
+		}
+
+		provider, err := authentication.NewSQLUserProvider(config.SQL, db)
+		if err != nil {
+			logger.Fatalf("Failed to Check SQL Authentication Backend: %v", err)
+		}
+
+		providers[schema.AuthenticationBackendSQL] = provider
+	}
+
+	return providers
+}
+
+// publishLDAPConnectionPoolStats publishes the metrics of the pool of LDAP connections as an expvar, exposed on the
+// /debug/vars endpoint when the expvars are enabled.
+func publishLDAPConnectionPoolStats(provider *authentication.LDAPUserProvider) {
+	if _, ok := provider.PoolStats(); !ok {
+		return
+	}
+
+	expvar.Publish("ldap_connection_pool", expvar.Func(func() interface{} {
+		stats, _ := provider.PoolStats()
+
+		return stats
+	}))
+}
+
 func main() {
 	logger := logging.Logger()
 
@@ -180,7 +300,7 @@ func main() {
 
 	rootCmd.AddCommand(buildCmd, commands.HashPasswordCmd,
 		commands.ValidateConfigCmd, commands.CertificatesCmd,
-		commands.RSACmd)
+		commands.RSACmd, commands.AccessControlCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		logger.Fatal(err)
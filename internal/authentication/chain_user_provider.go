@@ -0,0 +1,136 @@
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// chainedUserProvider is a backend of the chain with the pattern the usernames must match for it to be queried.
+type chainedUserProvider struct {
+	name            string
+	provider        UserProvider
+	usernamePattern *regexp.Regexp
+}
+
+// ChainUserProvider is a provider querying several user providers in order until one of them knows the user.
+type ChainUserProvider struct {
+	providers        []*chainedUserProvider
+	rejectDuplicates bool
+}
+
+// NewChainUserProvider creates a new instance of ChainUserProvider chaining the given providers in the order defined
+// by the configuration. The providers are indexed by backend name.
+func NewChainUserProvider(configuration *schema.ChainAuthenticationBackendConfiguration, providers map[string]UserProvider) (*ChainUserProvider, error) {
+	chain := &ChainUserProvider{
+		rejectDuplicates: configuration.Duplicates == schema.ChainDuplicatesReject,
+	}
+
+	for _, backend := range configuration.Backends {
+		provider, ok := providers[backend.Backend]
+		if !ok {
+			return nil, fmt.Errorf("Authentication backend %s is chained but not configured", backend.Backend)
+		}
+
+		chained := &chainedUserProvider{name: backend.Backend, provider: provider}
+
+		if backend.UsernamePattern != "" {
+			pattern, err := regexp.Compile(backend.UsernamePattern)
+			if err != nil {
+				return nil, fmt.Errorf("Username pattern of authentication backend %s is invalid: %v", backend.Backend, err)
+			}
+
+			chained.usernamePattern = pattern
+		}
+
+		chain.providers = append(chain.providers, chained)
+	}
+
+	return chain, nil
+}
+
+// CheckUserPassword checks if provided password matches for the given user. The providers are tried in order and the
+// next one is only queried when the user is not found, a wrong password never falls through.
+func (p *ChainUserProvider) CheckUserPassword(username string, password string) (bool, error) {
+	if p.rejectDuplicates {
+		owner, _, err := p.lookup(username)
+		if err != nil {
+			return false, err
+		}
+
+		return owner.provider.CheckUserPassword(username, password)
+	}
+
+	for _, chained := range p.candidates(username) {
+		ok, err := chained.provider.CheckUserPassword(username, password)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+
+		return ok, err
+	}
+
+	return false, ErrUserNotFound
+}
+
+// GetDetails retrieve the details of the user from the provider which owns the user.
+func (p *ChainUserProvider) GetDetails(username string) (*UserDetails, error) {
+	_, details, err := p.lookup(username)
+
+	return details, err
+}
+
+// UpdatePassword update the password of the given user in the provider which owns the user.
+func (p *ChainUserProvider) UpdatePassword(username string, oldPassword string, newPassword string) error {
+	owner, _, err := p.lookup(username)
+	if err != nil {
+		return err
+	}
+
+	return owner.provider.UpdatePassword(username, oldPassword, newPassword)
+}
+
+// lookup returns the provider owning the user along with the details of the user. The owner is the first provider
+// whose pattern matches and which knows the user. When duplicates are rejected every candidate is queried to ensure
+// the user is known by a single provider.
+func (p *ChainUserProvider) lookup(username string) (owner *chainedUserProvider, details *UserDetails, err error) {
+	for _, chained := range p.candidates(username) {
+		d, err := chained.provider.GetDetails(username)
+
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			continue
+		case err != nil:
+			return nil, nil, err
+		}
+
+		if owner != nil {
+			return nil, nil, fmt.Errorf("User %s exists in both %s and %s authentication backends", username, owner.name, chained.name)
+		}
+
+		owner, details = chained, d
+
+		if !p.rejectDuplicates {
+			break
+		}
+	}
+
+	if owner == nil {
+		return nil, nil, ErrUserNotFound
+	}
+
+	return owner, details, nil
+}
+
+// candidates returns the providers whose username pattern matches the username.
+func (p *ChainUserProvider) candidates(username string) (candidates []*chainedUserProvider) {
+	for _, chained := range p.providers {
+		if chained.usernamePattern == nil || chained.usernamePattern.MatchString(username) {
+			candidates = append(candidates, chained)
+		}
+	}
+
+	return candidates
+}
@@ -0,0 +1,157 @@
+package authentication
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// staticUserProvider is a user provider backed by a map of usernames to passwords.
+type staticUserProvider struct {
+	passwords map[string]string
+	err       error
+}
+
+func (p *staticUserProvider) CheckUserPassword(username string, password string) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+
+	expected, ok := p.passwords[username]
+	if !ok {
+		return false, ErrUserNotFound
+	}
+
+	return expected == password, nil
+}
+
+func (p *staticUserProvider) GetDetails(username string) (*UserDetails, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if _, ok := p.passwords[username]; !ok {
+		return nil, ErrUserNotFound
+	}
+
+	return &UserDetails{Username: username}, nil
+}
+
+func (p *staticUserProvider) UpdatePassword(username string, oldPassword string, newPassword string) error {
+	if _, err := p.GetDetails(username); err != nil {
+		return err
+	}
+
+	p.passwords[username] = newPassword
+
+	return nil
+}
+
+func newTestChainUserProvider(t *testing.T, duplicates string) (*ChainUserProvider, *staticUserProvider, *staticUserProvider) {
+	file := &staticUserProvider{passwords: map[string]string{"svc-backup": "backup", "john": "local"}}
+	ldap := &staticUserProvider{passwords: map[string]string{"john": "corporate", "harry": "corporate", "svc-ci": "ci"}}
+
+	provider, err := NewChainUserProvider(&schema.ChainAuthenticationBackendConfiguration{
+		Duplicates: duplicates,
+		Backends: []schema.ChainedAuthenticationBackendConfiguration{
+			{Backend: schema.AuthenticationBackendFile},
+			{Backend: schema.AuthenticationBackendLDAP, UsernamePattern: "^[a-z]+$"},
+		},
+	}, map[string]UserProvider{
+		schema.AuthenticationBackendFile: file,
+		schema.AuthenticationBackendLDAP: ldap,
+	})
+	require.NoError(t, err)
+
+	return provider, file, ldap
+}
+
+func TestChainShouldFallThroughWhenUserNotFound(t *testing.T) {
+	provider, _, _ := newTestChainUserProvider(t, schema.ChainDuplicatesFirst)
+
+	ok, err := provider.CheckUserPassword("svc-backup", "backup")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = provider.CheckUserPassword("harry", "corporate")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	details, err := provider.GetDetails("harry")
+	require.NoError(t, err)
+	assert.Equal(t, "harry", details.Username)
+}
+
+func TestChainShouldNotFallThroughOnWrongPassword(t *testing.T) {
+	provider, _, _ := newTestChainUserProvider(t, schema.ChainDuplicatesFirst)
+
+	ok, err := provider.CheckUserPassword("john", "corporate")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = provider.CheckUserPassword("john", "local")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestChainShouldNotFallThroughOnError(t *testing.T) {
+	provider, file, _ := newTestChainUserProvider(t, schema.ChainDuplicatesFirst)
+	file.err = errors.New("unable to read database")
+
+	ok, err := provider.CheckUserPassword("harry", "corporate")
+	assert.EqualError(t, err, "unable to read database")
+	assert.False(t, ok)
+}
+
+func TestChainShouldSkipBackendsNotMatchingUsernamePattern(t *testing.T) {
+	provider, _, _ := newTestChainUserProvider(t, schema.ChainDuplicatesFirst)
+
+	ok, err := provider.CheckUserPassword("svc-ci", "ci")
+	assert.EqualError(t, err, "user not found")
+	assert.False(t, ok)
+
+	details, err := provider.GetDetails("svc-ci")
+	assert.EqualError(t, err, "user not found")
+	assert.Nil(t, details)
+}
+
+func TestChainShouldRejectDuplicates(t *testing.T) {
+	provider, _, _ := newTestChainUserProvider(t, schema.ChainDuplicatesReject)
+
+	ok, err := provider.CheckUserPassword("john", "local")
+	assert.EqualError(t, err, "User john exists in both file and ldap authentication backends")
+	assert.False(t, ok)
+
+	details, err := provider.GetDetails("john")
+	assert.EqualError(t, err, "User john exists in both file and ldap authentication backends")
+	assert.Nil(t, details)
+
+	ok, err = provider.CheckUserPassword("harry", "corporate")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestChainShouldUpdatePasswordInOwningBackend(t *testing.T) {
+	provider, file, ldap := newTestChainUserProvider(t, schema.ChainDuplicatesFirst)
+
+	require.NoError(t, provider.UpdatePassword("john", "", "newpassword"))
+	require.NoError(t, provider.UpdatePassword("harry", "", "newpassword"))
+
+	assert.Equal(t, "newpassword", file.passwords["john"])
+	assert.Equal(t, "corporate", ldap.passwords["john"])
+	assert.Equal(t, "newpassword", ldap.passwords["harry"])
+
+	assert.EqualError(t, provider.UpdatePassword("fake", "", "newpassword"), "user not found")
+}
+
+func TestChainShouldFailWhenBackendNotProvided(t *testing.T) {
+	_, err := NewChainUserProvider(&schema.ChainAuthenticationBackendConfiguration{
+		Backends: []schema.ChainedAuthenticationBackendConfiguration{{Backend: schema.AuthenticationBackendSQL}},
+	}, map[string]UserProvider{})
+
+	assert.EqualError(t, err, "Authentication backend sql is chained but not configured")
+}
@@ -0,0 +1,177 @@
+package authentication
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ClientCertificateVerifier verifies the client certificates against the configured certificate authorities and maps
+// them to the username of the user they belong to.
+type ClientCertificateVerifier struct {
+	roots             *x509.CertPool
+	usernameAttribute string
+	level             Level
+
+	header          string
+	trustedNetworks []*net.IPNet
+
+	clock utils.Clock
+}
+
+// NewClientCertificateVerifier creates a verifier trusting the certificate authorities of the configuration.
+func NewClientCertificateVerifier(configuration schema.ClientCertificatesConfiguration, clock utils.Clock) (*ClientCertificateVerifier, error) {
+	data, err := ioutil.ReadFile(configuration.CertificateAuthority)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the client certificate authorities: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("No certificate found in the client certificate authorities file %s", configuration.CertificateAuthority)
+	}
+
+	verifier := &ClientCertificateVerifier{
+		roots:             roots,
+		usernameAttribute: configuration.UsernameAttribute,
+		level:             OneFactor,
+		header:            configuration.Header,
+		clock:             clock,
+	}
+
+	if configuration.AuthenticationLevel == schema.AuthenticationLevelTwoFactor {
+		verifier.level = TwoFactor
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		ipNet, err := utils.ParseNetwork(network)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse the client certificates trusted network %s: %w", network, err)
+		}
+
+		verifier.trustedNetworks = append(verifier.trustedNetworks, ipNet)
+	}
+
+	return verifier, nil
+}
+
+// CertificateAuthorities returns the pool of the certificate authorities the client certificates are verified against.
+func (v *ClientCertificateVerifier) CertificateAuthorities() *x509.CertPool {
+	return v.roots
+}
+
+// Level returns the authentication level of the users authenticated with a client certificate.
+func (v *ClientCertificateVerifier) Level() Level {
+	return v.level
+}
+
+// Header returns the name of the header the proxies forward the client certificate in, if any.
+func (v *ClientCertificateVerifier) Header() string {
+	return v.header
+}
+
+// IsTrustedProxy returns true if the client certificate header is trusted when sent from the given IP.
+func (v *ClientCertificateVerifier) IsTrustedProxy(ip net.IP) bool {
+	return utils.IsIPInNetworks(ip, v.trustedNetworks)
+}
+
+// ParseHeader parses the certificates forwarded by a proxy in the client certificate header. The header holds either
+// the URL encoded PEM of the certificates or their comma separated base64 encoded DER, the first certificate being
+// the client certificate.
+func (v *ClientCertificateVerifier) ParseHeader(value []byte) (certificates []*x509.Certificate, err error) {
+	decoded, err := url.PathUnescape(string(value))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode the client certificate header: %w", err)
+	}
+
+	if strings.Contains(decoded, "-----BEGIN") {
+		rest := []byte(decoded)
+
+		for {
+			var block *pem.Block
+
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse the client certificate header: %w", err)
+			}
+
+			certificates = append(certificates, certificate)
+		}
+	} else {
+		for _, encoded := range strings.Split(decoded, ",") {
+			der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+			if err != nil {
+				return nil, fmt.Errorf("Unable to decode the client certificate header: %w", err)
+			}
+
+			certificate, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse the client certificate header: %w", err)
+			}
+
+			certificates = append(certificates, certificate)
+		}
+	}
+
+	if len(certificates) == 0 {
+		return nil, errors.New("No certificate found in the client certificate header")
+	}
+
+	return certificates, nil
+}
+
+// Verify verifies the chain of the client certificate, the first of the certificates, and returns the username it's
+// mapped to.
+func (v *ClientCertificateVerifier) Verify(certificates []*x509.Certificate) (username string, err error) {
+	if len(certificates) == 0 {
+		return "", errors.New("No client certificate provided")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err = certificates[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.clock.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to verify the client certificate %s: %w", certificates[0].Subject, err)
+	}
+
+	switch v.usernameAttribute {
+	case schema.ClientCertificateUsernameEmail:
+		if len(certificates[0].EmailAddresses) != 0 {
+			username = certificates[0].EmailAddresses[0]
+		}
+	case schema.ClientCertificateUsernameDNS:
+		if len(certificates[0].DNSNames) != 0 {
+			username = certificates[0].DNSNames[0]
+		}
+	default:
+		username = certificates[0].Subject.CommonName
+	}
+
+	if username == "" {
+		return "", fmt.Errorf("The client certificate %s has no %s to identify the user", certificates[0].Subject, v.usernameAttribute)
+	}
+
+	return username, nil
+}
@@ -0,0 +1,157 @@
+package authentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func newTestCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return certificate, key
+}
+
+func newTestClientCertificates(t *testing.T, now time.Time) (ca, client *x509.Certificate, caPath string) {
+	ca, caKey := newTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Clients CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	client, _ = newTestCertificate(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		Subject:        pkix.Name{CommonName: "john"},
+		EmailAddresses: []string{"john@example.com"},
+		NotBefore:      now.Add(-time.Hour),
+		NotAfter:       now.Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	dir, err := ioutil.TempDir("", "client-certificates")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	caPath = filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600))
+
+	return ca, client, caPath
+}
+
+func TestShouldVerifyClientCertificate(t *testing.T) {
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+	_, client, caPath := newTestClientCertificates(t, clock.now)
+
+	verifier, err := NewClientCertificateVerifier(schema.ClientCertificatesConfiguration{
+		CertificateAuthority: caPath,
+		UsernameAttribute:    schema.ClientCertificateUsernameCommonName,
+		AuthenticationLevel:  schema.AuthenticationLevelTwoFactor,
+	}, clock)
+	require.NoError(t, err)
+
+	assert.Equal(t, TwoFactor, verifier.Level())
+
+	username, err := verifier.Verify([]*x509.Certificate{client})
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+
+	verifier.usernameAttribute = schema.ClientCertificateUsernameEmail
+
+	username, err = verifier.Verify([]*x509.Certificate{client})
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", username)
+
+	verifier.usernameAttribute = schema.ClientCertificateUsernameDNS
+
+	_, err = verifier.Verify([]*x509.Certificate{client})
+	assert.EqualError(t, err, "The client certificate CN=john has no dns to identify the user")
+
+	clock.now = clock.now.Add(2 * time.Hour)
+
+	_, err = verifier.Verify([]*x509.Certificate{client})
+	assert.Error(t, err)
+}
+
+func TestShouldNotVerifyClientCertificateOfAnotherAuthority(t *testing.T) {
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+	_, client, _ := newTestClientCertificates(t, clock.now)
+	_, _, otherCAPath := newTestClientCertificates(t, clock.now)
+
+	verifier, err := NewClientCertificateVerifier(schema.ClientCertificatesConfiguration{CertificateAuthority: otherCAPath}, clock)
+	require.NoError(t, err)
+
+	_, err = verifier.Verify([]*x509.Certificate{client})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unable to verify the client certificate CN=john: x509: certificate signed by unknown authority")
+
+	_, err = verifier.Verify(nil)
+	assert.EqualError(t, err, "No client certificate provided")
+}
+
+func TestShouldParseClientCertificateHeader(t *testing.T) {
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+	ca, client, caPath := newTestClientCertificates(t, clock.now)
+
+	verifier, err := NewClientCertificateVerifier(schema.ClientCertificatesConfiguration{
+		CertificateAuthority: caPath,
+		Header:               "X-Client-Cert",
+		TrustedNetworks:      []string{"10.0.0.0/8", "192.168.1.1"},
+	}, clock)
+	require.NoError(t, err)
+
+	assert.True(t, verifier.IsTrustedProxy(net.ParseIP("10.1.2.3")))
+	assert.True(t, verifier.IsTrustedProxy(net.ParseIP("192.168.1.1")))
+	assert.False(t, verifier.IsTrustedProxy(net.ParseIP("192.168.1.2")))
+
+	escapedPEM := url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: client.Raw})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})))
+
+	certificates, err := verifier.ParseHeader([]byte(escapedPEM))
+	require.NoError(t, err)
+	assert.Equal(t, []*x509.Certificate{client, ca}, certificates)
+
+	certificates, err = verifier.ParseHeader([]byte(base64.StdEncoding.EncodeToString(client.Raw)))
+	require.NoError(t, err)
+	assert.Equal(t, []*x509.Certificate{client}, certificates)
+
+	_, err = verifier.ParseHeader([]byte("not a certificate"))
+	assert.Error(t, err)
+}
+
+func TestShouldFailToCreateClientCertificateVerifierWithoutAuthority(t *testing.T) {
+	_, err := NewClientCertificateVerifier(schema.ClientCertificatesConfiguration{CertificateAuthority: "/path/not/found.pem"}, nil)
+	assert.EqualError(t, err, "Unable to read the client certificate authorities: open /path/not/found.pem: no such file or directory")
+}
@@ -2,6 +2,8 @@ package authentication
 
 import (
 	"errors"
+	"regexp"
+	"time"
 )
 
 // Level is the type representing a level of authentication.
@@ -23,13 +25,43 @@ const (
 	U2F = "u2f"
 	// Push Method using Duo application to receive push notifications.
 	Push = "mobile_push"
+	// Passwordless Method using a security key verifying the user instead of the password and the second factor.
+	Passwordless = "passwordless"
 )
 
 const (
 	ldapSupportedExtensionAttribute = "supportedExtension"
 	ldapOIDPasswdModifyExtension    = "1.3.6.1.4.1.4203.1.11.1" // http://oidref.com/1.3.6.1.4.1.4203.1.11.1
+
+	// ldapMemberOfAttribute is the attribute of the users holding the DN of the groups they are a member of.
+	ldapMemberOfAttribute = "memberOf"
+
+	// ldapMatchingRuleInChain is the Active Directory matching rule walking the chain of ancestry of an attribute,
+	// see https://docs.microsoft.com/en-us/windows/win32/adsi/search-filter-syntax.
+	ldapMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+	// ldapsPort is the well-known port of LDAP over TLS.
+	ldapsPort = 636
+
+	// ldapSRVRefreshInterval is the interval at which the LDAP servers discovered from SRV records are refreshed.
+	ldapSRVRefreshInterval = 5 * time.Minute
+
+	// ldapNoAttributes is the special attribute name requesting no attributes (RFC4511 section 4.5.1.8).
+	ldapNoAttributes = "1.1"
+
+	ldapUnicodePasswordAttribute = "unicodePwd"
 )
 
+// ldapActiveDirectoryBindErrors are the errors matching the sub-error codes given by Active Directory in the
+// diagnostic message of a bind refused although the password is correct, see
+// https://ldapwiki.com/wiki/Common%20Active%20Directory%20Bind%20Errors.
+var ldapActiveDirectoryBindErrors = map[string]error{
+	"532": ErrPasswordExpired,
+	"773": ErrPasswordMustChange,
+}
+
+var ldapActiveDirectoryBindErrorRegexp = regexp.MustCompile(`data ([0-9a-fA-F]+)`)
+
 // PossibleMethods is the set of all possible 2FA methods.
 var PossibleMethods = []string{TOTP, U2F, Push}
 
@@ -41,6 +73,14 @@ const (
 	HashingAlgorithmArgon2id CryptAlgo = argon2id
 	// HashingAlgorithmSHA512 SHA512 hash identifier.
 	HashingAlgorithmSHA512 CryptAlgo = "6"
+	// HashingAlgorithmBCrypt bcrypt hash identifier, covers the $2a$, $2b$ and $2y$ variants.
+	HashingAlgorithmBCrypt CryptAlgo = "2b"
+	// HashingAlgorithmScrypt scrypt hash identifier.
+	HashingAlgorithmScrypt CryptAlgo = "scrypt"
+	// HashingAlgorithmPBKDF2SHA256 PBKDF2 with HMAC-SHA256 hash identifier.
+	HashingAlgorithmPBKDF2SHA256 CryptAlgo = "pbkdf2-sha256"
+	// HashingAlgorithmPBKDF2SHA512 PBKDF2 with HMAC-SHA512 hash identifier.
+	HashingAlgorithmPBKDF2SHA512 CryptAlgo = "pbkdf2-sha512"
 )
 
 // These are the default values from the upstream crypt module we use them to for GetInt
@@ -51,17 +91,49 @@ const (
 	HashingDefaultArgon2idParallelism = 4
 	HashingDefaultArgon2idKeyLength   = 32
 	HashingDefaultSHA512Iterations    = 5000
+
+	// hashingMaxSHA512SaltLength is the number of characters SHA512-crypt keeps from the encoded salt.
+	hashingMaxSHA512SaltLength = 16
 )
 
 // HashingPossibleSaltCharacters represents valid hashing runes.
 var HashingPossibleSaltCharacters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789+/")
 
+// errNoLDAPServer indicates no LDAP server has been discovered.
+var errNoLDAPServer = errors.New("No LDAP server is available")
+
 // ErrUserNotFound indicates the user wasn't found in the authentication backend.
 var ErrUserNotFound = errors.New("user not found")
 
+// ErrUserAlreadyExists indicates a user with the same username already exists in the authentication backend.
+var ErrUserAlreadyExists = errors.New("user already exists")
+
+// ErrIncorrectPassword indicates the old password given to change the password of the user is incorrect.
+var ErrIncorrectPassword = errors.New("incorrect password")
+
+// ErrPasswordExpired indicates the password of the user is correct but has expired and must be changed.
+var ErrPasswordExpired = errors.New("password expired")
+
+// ErrPasswordMustChange indicates the password of the user is correct but must be changed, typically because it has
+// been reset by an administrator.
+var ErrPasswordMustChange = errors.New("password must be changed")
+
+// ErrAccountLocked indicates the account of the user is locked by the password policy.
+var ErrAccountLocked = errors.New("account locked")
+
 const argon2id = "argon2id"
 const sha512 = "sha512"
 
+const (
+	prefixBCryptA       = "$2a$"
+	prefixBCryptB       = "$2b$"
+	prefixBCryptY       = "$2y$"
+	prefixScrypt        = "$scrypt$"
+	prefixPBKDF2        = "$pbkdf2-"
+	prefixDjangoPBKDF2  = "pbkdf2_"
+	scryptMaxCostFactor = 24
+)
+
 const testPassword = "my;secure*password"
 
 const fileAuthenticationMode = 0600
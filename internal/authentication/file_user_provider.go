@@ -19,7 +19,7 @@ import (
 type FileUserProvider struct {
 	configuration *schema.FileAuthenticationBackendConfiguration
 	database      *DatabaseModel
-	lock          *sync.Mutex
+	lock          *sync.RWMutex
 }
 
 // UserDetailsModel is the model of user details in the file database.
@@ -28,6 +28,8 @@ type UserDetailsModel struct {
 	DisplayName    string   `yaml:"displayname" valid:"required"`
 	Email          string   `yaml:"email"`
 	Groups         []string `yaml:"groups"`
+
+	Attributes map[string]string `yaml:"attributes,omitempty"`
 }
 
 // DatabaseModel is the model of users file database.
@@ -63,7 +65,7 @@ func NewFileUserProvider(configuration *schema.FileAuthenticationBackendConfigur
 	return &FileUserProvider{
 		configuration: configuration,
 		database:      database,
-		lock:          &sync.Mutex{},
+		lock:          &sync.RWMutex{},
 	}
 }
 
@@ -142,66 +144,171 @@ func readDatabase(path string) (*DatabaseModel, error) {
 
 // CheckUserPassword checks if provided password matches for the given user.
 func (p *FileUserProvider) CheckUserPassword(username string, password string) (bool, error) {
-	if details, ok := p.database.Users[username]; ok {
+	p.lock.RLock()
+	details, ok := p.database.Users[username]
+	p.lock.RUnlock()
+
+	if ok {
 		ok, err := CheckPassword(password, details.HashedPassword)
 		if err != nil {
 			return false, err
 		}
 
+		if ok {
+			p.rehashPassword(username, password, details.HashedPassword)
+		}
+
 		return ok, nil
 	}
 
 	return false, ErrUserNotFound
 }
 
+// rehashPassword replaces the hash of a user which was just successfully authenticated when the hash parameters are
+// weaker than the configured ones, or when the hash uses an algorithm other than the configured one and the rehash
+// option is enabled. Failures are logged but never prevent the user from logging in.
+func (p *FileUserProvider) rehashPassword(username, password, hash string) {
+	current, err := ParseHash(hash)
+	if err != nil || !NeedsRehash(current, p.configuration.Password) {
+		return
+	}
+
+	logger := logging.Logger()
+
+	logger.Debugf("Rehashing password of user %s as the stored %s hash doesn't match the configured parameters", username, current.Algorithm)
+
+	newHash, err := hashPasswordWithConfiguration(password, p.configuration.Password)
+	if err != nil {
+		logger.Errorf("Unable to rehash password of user %s: %v", username, err)
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	details, ok := p.database.Users[username]
+
+	// The password was changed concurrently, the newest hash wins.
+	if !ok || details.HashedPassword != hash {
+		return
+	}
+
+	details.HashedPassword = newHash
+	p.database.Users[username] = details
+
+	if err = p.writeDatabase(); err != nil {
+		logger.Errorf("Unable to rehash password of user %s: %v", username, err)
+	}
+}
+
 // GetDetails retrieve the groups a user belongs to.
 func (p *FileUserProvider) GetDetails(username string) (*UserDetails, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
 	if details, ok := p.database.Users[username]; ok {
+		var attributes map[string][]string
+
+		if len(details.Attributes) != 0 {
+			attributes = make(map[string][]string, len(details.Attributes))
+
+			for name, value := range details.Attributes {
+				attributes[name] = []string{value}
+			}
+		}
+
 		return &UserDetails{
 			Username:    username,
 			DisplayName: details.DisplayName,
 			Groups:      details.Groups,
 			Emails:      []string{details.Email},
+			Attributes:  attributes,
 		}, nil
 	}
 
-	return nil, fmt.Errorf("User '%s' does not exist in database", username)
+	return nil, ErrUserNotFound
 }
 
 // UpdatePassword update the password of the given user.
-func (p *FileUserProvider) UpdatePassword(username string, newPassword string) error {
+func (p *FileUserProvider) UpdatePassword(username string, oldPassword string, newPassword string) error {
+	p.lock.RLock()
 	details, ok := p.database.Users[username]
+	p.lock.RUnlock()
+
 	if !ok {
 		return ErrUserNotFound
 	}
 
-	algorithm, err := ConfigAlgoToCryptoAlgo(p.configuration.Password.Algorithm)
+	if oldPassword != "" {
+		ok, err := CheckPassword(oldPassword, details.HashedPassword)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return ErrIncorrectPassword
+		}
+	}
+
+	hash, err := hashPasswordWithConfiguration(newPassword, p.configuration.Password)
 	if err != nil {
 		return err
 	}
 
-	hash, err := HashPassword(
-		newPassword, "", algorithm, p.configuration.Password.Iterations,
-		p.configuration.Password.Memory*1024, p.configuration.Password.Parallelism,
-		p.configuration.Password.KeyLength, p.configuration.Password.SaltLength)
+	p.lock.Lock()
+	defer p.lock.Unlock()
 
-	if err != nil {
-		return err
+	details, ok = p.database.Users[username]
+	if !ok {
+		return ErrUserNotFound
 	}
 
 	details.HashedPassword = hash
+	p.database.Users[username] = details
+
+	return p.writeDatabase()
+}
+
+// HashPassword hashes the password with the password configuration of the file backend.
+func (p *FileUserProvider) HashPassword(password string) (string, error) {
+	return hashPasswordWithConfiguration(password, p.configuration.Password)
+}
 
+// CreateUser adds the user to the file database.
+func (p *FileUserProvider) CreateUser(details UserDetails, passwordHash string) error {
 	p.lock.Lock()
-	p.database.Users[username] = details
+	defer p.lock.Unlock()
+
+	if _, ok := p.database.Users[details.Username]; ok {
+		return ErrUserAlreadyExists
+	}
+
+	user := UserDetailsModel{
+		HashedPassword: passwordHash,
+		DisplayName:    details.DisplayName,
+		Groups:         details.Groups,
+	}
+
+	if len(details.Emails) != 0 {
+		user.Email = details.Emails[0]
+	}
+
+	p.database.Users[details.Username] = user
 
+	if err := p.writeDatabase(); err != nil {
+		delete(p.database.Users, details.Username)
+		return err
+	}
+
+	return nil
+}
+
+// writeDatabase persists the database to the file, the caller must hold the write lock.
+func (p *FileUserProvider) writeDatabase() error {
 	b, err := yaml.Marshal(p.database)
 	if err != nil {
-		p.lock.Unlock()
 		return err
 	}
 
-	err = ioutil.WriteFile(p.configuration.Path, b, fileAuthenticationMode)
-	p.lock.Unlock()
-
-	return err
+	return ioutil.WriteFile(p.configuration.Path, b, fileAuthenticationMode)
 }
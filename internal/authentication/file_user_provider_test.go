@@ -133,12 +133,28 @@ func TestShouldRetrieveUserDetails(t *testing.T) {
 	})
 }
 
+func TestShouldRetrieveUserAttributes(t *testing.T) {
+	WithDatabase(UserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		provider := NewFileUserProvider(&config)
+
+		details, err := provider.GetDetails("bob")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]string{"department": {"music"}, "employee_id": {"1941"}}, details.Attributes)
+
+		details, err = provider.GetDetails("john")
+		assert.NoError(t, err)
+		assert.Nil(t, details.Attributes)
+	})
+}
+
 func TestShouldUpdatePassword(t *testing.T) {
 	WithDatabase(UserDatabaseContent, func(path string) {
 		config := DefaultFileAuthenticationBackendConfiguration
 		config.Path = path
 		provider := NewFileUserProvider(&config)
-		err := provider.UpdatePassword("harry", "newpassword")
+		err := provider.UpdatePassword("harry", "", "newpassword")
 		assert.NoError(t, err)
 
 		// Reset the provider to force a read from disk.
@@ -149,6 +165,58 @@ func TestShouldUpdatePassword(t *testing.T) {
 	})
 }
 
+func TestShouldUpdatePasswordOnlyWithCorrectOldPassword(t *testing.T) {
+	WithDatabase(UserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		provider := NewFileUserProvider(&config)
+
+		err := provider.UpdatePassword("harry", "wrongpassword", "newpassword")
+		assert.Equal(t, ErrIncorrectPassword, err)
+
+		err = provider.UpdatePassword("harry", "password", "newpassword")
+		assert.NoError(t, err)
+
+		ok, err := provider.CheckUserPassword("harry", "newpassword")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestShouldCreateUser(t *testing.T) {
+	WithDatabase(UserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		provider := NewFileUserProvider(&config)
+
+		hash, err := provider.HashPassword("password")
+		require.NoError(t, err)
+
+		err = provider.CreateUser(UserDetails{
+			Username:    "alice",
+			DisplayName: "Alice Cooper",
+			Emails:      []string{"alice@example.com"},
+			Groups:      []string{"users"},
+		}, hash)
+		assert.NoError(t, err)
+
+		err = provider.CreateUser(UserDetails{Username: "harry"}, hash)
+		assert.Equal(t, ErrUserAlreadyExists, err)
+
+		// Reset the provider to force a read from disk.
+		provider = NewFileUserProvider(&config)
+		ok, err := provider.CheckUserPassword("alice", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		details, err := provider.GetDetails("alice")
+		require.NoError(t, err)
+		assert.Equal(t, "Alice Cooper", details.DisplayName)
+		assert.Equal(t, []string{"alice@example.com"}, details.Emails)
+		assert.Equal(t, []string{"users"}, details.Groups)
+	})
+}
+
 // Checks both that the hashing algo changes and that it removes {CRYPT} from the start.
 func TestShouldUpdatePasswordHashingAlgorithmToArgon2id(t *testing.T) {
 	WithDatabase(UserDatabaseContent, func(path string) {
@@ -156,7 +224,7 @@ func TestShouldUpdatePasswordHashingAlgorithmToArgon2id(t *testing.T) {
 		config.Path = path
 		provider := NewFileUserProvider(&config)
 		assert.True(t, strings.HasPrefix(provider.database.Users["harry"].HashedPassword, "$6$"))
-		err := provider.UpdatePassword("harry", "newpassword")
+		err := provider.UpdatePassword("harry", "", "newpassword")
 		assert.NoError(t, err)
 
 		// Reset the provider to force a read from disk.
@@ -177,7 +245,7 @@ func TestShouldUpdatePasswordHashingAlgorithmToSHA512(t *testing.T) {
 
 		provider := NewFileUserProvider(&config)
 		assert.True(t, strings.HasPrefix(provider.database.Users["john"].HashedPassword, "$argon2id$"))
-		err := provider.UpdatePassword("john", "newpassword")
+		err := provider.UpdatePassword("john", "", "newpassword")
 		assert.NoError(t, err)
 
 		// Reset the provider to force a read from disk.
@@ -189,6 +257,114 @@ func TestShouldUpdatePasswordHashingAlgorithmToSHA512(t *testing.T) {
 	})
 }
 
+func TestShouldRehashImportedPasswordOnSuccessfulLogin(t *testing.T) {
+	WithDatabase(ImportedHashUserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		password := schema.DefaultCIPasswordConfiguration
+		password.Rehash = true
+		config.Password = &password
+
+		provider := NewFileUserProvider(&config)
+
+		ok, err := provider.CheckUserPassword("john", "wrong_password")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.True(t, strings.HasPrefix(provider.database.Users["john"].HashedPassword, "$2a$"))
+
+		ok, err = provider.CheckUserPassword("john", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Reset the provider to force a read from disk.
+		provider = NewFileUserProvider(&config)
+		assert.True(t, strings.HasPrefix(provider.database.Users["john"].HashedPassword, "$argon2id$"))
+
+		ok, err = provider.CheckUserPassword("john", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestShouldNotRehashImportedPasswordWhenDisabled(t *testing.T) {
+	WithDatabase(ImportedHashUserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		provider := NewFileUserProvider(&config)
+
+		ok, err := provider.CheckUserPassword("harry", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Reset the provider to force a read from disk.
+		provider = NewFileUserProvider(&config)
+		assert.True(t, strings.HasPrefix(provider.database.Users["harry"].HashedPassword, "pbkdf2_sha256$"))
+	})
+}
+
+func TestShouldRehashPasswordWithWeakerParametersOnSuccessfulLogin(t *testing.T) {
+	WithDatabase(UserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		password := schema.DefaultCIPasswordConfiguration
+		config.Password = &password
+
+		provider := NewFileUserProvider(&config)
+
+		hash, err := ParseHash(provider.database.Users["john"].HashedPassword)
+		require.NoError(t, err)
+		assert.Equal(t, 2, hash.Parallelism)
+
+		ok, err := provider.CheckUserPassword("john", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Reset the provider to force a read from disk.
+		provider = NewFileUserProvider(&config)
+
+		hash, err = ParseHash(provider.database.Users["john"].HashedPassword)
+		require.NoError(t, err)
+		assert.False(t, hash.IsWeakerThan(&password))
+		assert.Equal(t, password.Parallelism, hash.Parallelism)
+		assert.Equal(t, password.Memory*1024, hash.Memory)
+
+		// Hashes using another algorithm are left untouched unless the rehash option is enabled.
+		ok, err = provider.CheckUserPassword("harry", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		provider = NewFileUserProvider(&config)
+		assert.True(t, strings.HasPrefix(provider.database.Users["harry"].HashedPassword, "$6$"))
+	})
+}
+
+func TestShouldNotRehashPasswordWithEqualParameters(t *testing.T) {
+	WithDatabase(UserDatabaseContent, func(path string) {
+		config := DefaultFileAuthenticationBackendConfiguration
+		config.Path = path
+		password := schema.PasswordConfiguration{
+			Algorithm:   argon2id,
+			Iterations:  3,
+			Memory:      64,
+			Parallelism: 2,
+			KeyLength:   32,
+			SaltLength:  12,
+		}
+		config.Password = &password
+
+		provider := NewFileUserProvider(&config)
+		expected := provider.database.Users["john"].HashedPassword
+
+		ok, err := provider.CheckUserPassword("john", "password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Reset the provider to force a read from disk.
+		provider = NewFileUserProvider(&config)
+		assert.Equal(t, expected, provider.database.Users["john"].HashedPassword)
+	})
+}
+
 func TestShouldRaiseWhenLoadingMalformedDatabaseForFirstTime(t *testing.T) {
 	WithDatabase(MalformedUserDatabaseContent, func(path string) {
 		config := DefaultFileAuthenticationBackendConfiguration
@@ -297,6 +473,9 @@ users:
     email: bob.dylan@authelia.com
     groups:
       - dev
+    attributes:
+      department: music
+      employee_id: "1941"
 
   james:
     displayname: "James Dean"
@@ -310,6 +489,23 @@ users:
     email: james.dean@authelia.com
 `)
 
+var ImportedHashUserDatabaseContent = []byte(`
+users:
+  john:
+    displayname: "John Doe"
+    password: "$2a$10$IQ5vqF9hJTfRYt4Nlk.4tedaRxymOQHK5PfpqtgrCbaVhpdUNBILi"
+    email: john.doe@authelia.com
+    groups:
+      - admins
+      - dev
+
+  harry:
+    displayname: "Harry Potter"
+    password: "pbkdf2_sha256$260000$c2FsdHNhbHRzYWx0$YOS7ImdQNfamRlWW3FeJSwo/dmp62o+R+IAsDRfzRiM="
+    email: harry.potter@authelia.com
+    groups: []
+`)
+
 var MalformedUserDatabaseContent = []byte(`
 users
 john
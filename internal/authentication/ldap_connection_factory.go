@@ -11,6 +11,7 @@ import (
 // LDAPConnection interface representing a connection to the ldap.
 type LDAPConnection interface {
 	Bind(username, password string) error
+	SimpleBind(simpleBindRequest *ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error)
 	Close()
 
 	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
@@ -34,6 +35,12 @@ func (lc *LDAPConnectionImpl) Bind(username, password string) error {
 	return lc.conn.Bind(username, password)
 }
 
+// SimpleBind binds ldap connection with a simple bind request, the result holds the controls returned by the server
+// even when the bind fails.
+func (lc *LDAPConnectionImpl) SimpleBind(simpleBindRequest *ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error) {
+	return lc.conn.SimpleBind(simpleBindRequest)
+}
+
 // Close closes a ldap connection.
 func (lc *LDAPConnectionImpl) Close() {
 	lc.conn.Close()
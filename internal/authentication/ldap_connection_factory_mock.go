@@ -47,6 +47,21 @@ func (mr *MockLDAPConnectionMockRecorder) Bind(username, password interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bind", reflect.TypeOf((*MockLDAPConnection)(nil).Bind), username, password)
 }
 
+// SimpleBind mocks base method
+func (m *MockLDAPConnection) SimpleBind(simpleBindRequest *ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SimpleBind", simpleBindRequest)
+	ret0, _ := ret[0].(*ldap.SimpleBindResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimpleBind indicates an expected call of SimpleBind
+func (mr *MockLDAPConnectionMockRecorder) SimpleBind(simpleBindRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimpleBind", reflect.TypeOf((*MockLDAPConnection)(nil).SimpleBind), simpleBindRequest)
+}
+
 // Close mocks base method
 func (m *MockLDAPConnection) Close() {
 	m.ctrl.T.Helper()
@@ -0,0 +1,210 @@
+package authentication
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// LDAPConnectionPoolStats represents the metrics of a pool of LDAP connections.
+type LDAPConnectionPoolStats struct {
+	MaxConnections int    `json:"max_connections"`
+	Open           int    `json:"open"`
+	Idle           int    `json:"idle"`
+	Dials          uint64 `json:"dials"`
+	Reuses         uint64 `json:"reuses"`
+	Discarded      uint64 `json:"discarded"`
+}
+
+// ldapPooledConnection is a connection of the pool along with the times used to expire and health check it.
+type ldapPooledConnection struct {
+	LDAPConnection
+
+	reused    bool
+	idleSince time.Time
+	checkedAt time.Time
+}
+
+// ldapConnectionPool is a bounded pool of connections bound with the LDAP user. Idle connections are closed once
+// they exceed the idle timeout, health checked before being reused and discarded on network errors.
+type ldapConnectionPool struct {
+	dial   func() (LDAPConnection, error)
+	clock  utils.Clock
+	logger *logrus.Logger
+
+	idleTimeout         time.Duration
+	healthCheckInterval time.Duration
+
+	slots chan struct{}
+
+	mutex sync.Mutex
+	idle  []*ldapPooledConnection
+	stats LDAPConnectionPoolStats
+}
+
+func newLDAPConnectionPool(configuration *schema.LDAPConnectionPoolConfiguration, dial func() (LDAPConnection, error), clock utils.Clock) *ldapConnectionPool {
+	// The configuration is validated at startup so the durations are known to be valid.
+	idleTimeout, _ := utils.ParseDurationString(configuration.IdleTimeout)
+	healthCheckInterval, _ := utils.ParseDurationString(configuration.HealthCheckInterval)
+
+	return &ldapConnectionPool{
+		dial:                dial,
+		clock:               clock,
+		logger:              logging.Logger(),
+		idleTimeout:         idleTimeout,
+		healthCheckInterval: healthCheckInterval,
+		slots:               make(chan struct{}, configuration.MaxConnections),
+		stats:               LDAPConnectionPoolStats{MaxConnections: configuration.MaxConnections},
+	}
+}
+
+// Get returns a healthy idle connection or dials a new one. It blocks while all the connections are in use.
+func (p *ldapConnectionPool) Get() (*ldapPooledConnection, error) {
+	p.slots <- struct{}{}
+
+	for {
+		conn := p.pop()
+		if conn == nil {
+			break
+		}
+
+		now := p.clock.Now()
+
+		if p.idleTimeout > 0 && now.Sub(conn.idleSince) > p.idleTimeout {
+			p.discard(conn, "it exceeded the idle timeout")
+			continue
+		}
+
+		if now.Sub(conn.checkedAt) > p.healthCheckInterval {
+			if err := ldapHealthCheck(conn); err != nil {
+				p.discard(conn, "it failed the health check")
+				continue
+			}
+
+			conn.checkedAt = now
+		}
+
+		p.mutex.Lock()
+		p.stats.Reuses++
+		p.mutex.Unlock()
+
+		conn.reused = true
+
+		return conn, nil
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		<-p.slots
+
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	p.stats.Dials++
+	p.stats.Open++
+	p.mutex.Unlock()
+
+	now := p.clock.Now()
+
+	return &ldapPooledConnection{LDAPConnection: conn, idleSince: now, checkedAt: now}, nil
+}
+
+// Put returns a connection to the pool. The connection is discarded when the error returned by the last operation
+// indicates the connection is no longer usable.
+func (p *ldapConnectionPool) Put(conn *ldapPooledConnection, err error) {
+	defer func() { <-p.slots }()
+
+	if isLDAPNetworkError(err) {
+		p.discard(conn, "of a network error")
+		return
+	}
+
+	now := p.clock.Now()
+	conn.idleSince = now
+	conn.reused = false
+
+	p.mutex.Lock()
+	p.idle = append(p.idle, conn)
+	expired := p.expire(now)
+	p.stats.Idle = len(p.idle)
+	p.mutex.Unlock()
+
+	for _, c := range expired {
+		p.discard(c, "it exceeded the idle timeout")
+	}
+}
+
+// Stats returns the metrics of the pool.
+func (p *ldapConnectionPool) Stats() LDAPConnectionPoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.stats
+}
+
+// pop removes the most recently used idle connection from the pool.
+func (p *ldapConnectionPool) pop() (conn *ldapPooledConnection) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.idle) == 0 {
+		return nil
+	}
+
+	conn = p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	p.stats.Idle = len(p.idle)
+
+	return conn
+}
+
+// expire removes the connections which exceeded the idle timeout, the caller must hold the mutex. Connections are
+// pushed in the order they became idle so the expired connections are always the first ones.
+func (p *ldapConnectionPool) expire(now time.Time) (expired []*ldapPooledConnection) {
+	if p.idleTimeout <= 0 {
+		return nil
+	}
+
+	i := 0
+	for i < len(p.idle) && now.Sub(p.idle[i].idleSince) > p.idleTimeout {
+		i++
+	}
+
+	expired, p.idle = p.idle[:i:i], p.idle[i:]
+
+	return expired
+}
+
+func (p *ldapConnectionPool) discard(conn *ldapPooledConnection, reason string) {
+	conn.Close()
+
+	p.mutex.Lock()
+	p.stats.Open--
+	p.stats.Discarded++
+	p.mutex.Unlock()
+
+	p.logger.Debugf("LDAP connection discarded from the pool because %s", reason)
+}
+
+// ldapHealthCheck ensures the connection is still usable by reading the root DSE without any attribute.
+func ldapHealthCheck(conn LDAPConnection) error {
+	_, err := conn.Search(ldap.NewSearchRequest("", ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		1, 0, false, "(objectClass=*)", []string{ldapNoAttributes}, nil))
+
+	return err
+}
+
+// isLDAPNetworkError returns true if the error, or any error it wraps, is an LDAP network error.
+func isLDAPNetworkError(err error) bool {
+	var ldapErr *ldap.Error
+
+	return errors.As(err, &ldapErr) && ldapErr.ResultCode == ldap.ErrorNetwork
+}
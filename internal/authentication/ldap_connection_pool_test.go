@@ -0,0 +1,272 @@
+package authentication
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// poolTestClock is a clock which only moves when told to.
+type poolTestClock struct {
+	now time.Time
+}
+
+func (c *poolTestClock) Now() time.Time {
+	return c.now
+}
+
+func (c *poolTestClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func newTestLDAPConnectionPool(conns ...LDAPConnection) (*ldapConnectionPool, *poolTestClock) {
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+
+	dial := func() (LDAPConnection, error) {
+		if len(conns) == 0 {
+			return nil, errors.New("no more connections")
+		}
+
+		conn := conns[0]
+		conns = conns[1:]
+
+		return conn, nil
+	}
+
+	return newLDAPConnectionPool(&schema.LDAPConnectionPoolConfiguration{
+		MaxConnections:      1,
+		IdleTimeout:         "5m",
+		HealthCheckInterval: "1m",
+	}, dial, clock), clock
+}
+
+func TestLDAPPoolShouldReuseIdleConnection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConn := NewMockLDAPConnection(ctrl)
+	pool, _ := newTestLDAPConnectionPool(mockConn)
+
+	conn, err := pool.Get()
+	require.NoError(t, err)
+	assert.False(t, conn.reused)
+
+	pool.Put(conn, nil)
+
+	conn, err = pool.Get()
+	require.NoError(t, err)
+	assert.True(t, conn.reused)
+	assert.Equal(t, mockConn, conn.LDAPConnection)
+
+	pool.Put(conn, ErrUserNotFound)
+
+	assert.Equal(t, LDAPConnectionPoolStats{MaxConnections: 1, Open: 1, Idle: 1, Dials: 1, Reuses: 1}, pool.Stats())
+}
+
+func TestLDAPPoolShouldDiscardConnectionOnNetworkError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConn := NewMockLDAPConnection(ctrl)
+	mockConnFresh := NewMockLDAPConnection(ctrl)
+	pool, _ := newTestLDAPConnectionPool(mockConn, mockConnFresh)
+
+	mockConn.EXPECT().Close()
+
+	conn, err := pool.Get()
+	require.NoError(t, err)
+
+	pool.Put(conn, ldap.NewError(ldap.ErrorNetwork, errors.New("ldap: connection closed")))
+
+	conn, err = pool.Get()
+	require.NoError(t, err)
+	assert.Equal(t, mockConnFresh, conn.LDAPConnection)
+
+	assert.Equal(t, LDAPConnectionPoolStats{MaxConnections: 1, Open: 1, Dials: 2, Discarded: 1}, pool.Stats())
+}
+
+func TestLDAPPoolShouldCloseConnectionsExceedingIdleTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConn := NewMockLDAPConnection(ctrl)
+	mockConnFresh := NewMockLDAPConnection(ctrl)
+	pool, clock := newTestLDAPConnectionPool(mockConn, mockConnFresh)
+
+	mockConn.EXPECT().Close()
+
+	conn, err := pool.Get()
+	require.NoError(t, err)
+
+	pool.Put(conn, nil)
+
+	clock.now = clock.now.Add(6 * time.Minute)
+
+	conn, err = pool.Get()
+	require.NoError(t, err)
+	assert.Equal(t, mockConnFresh, conn.LDAPConnection)
+	assert.False(t, conn.reused)
+}
+
+func TestLDAPPoolShouldHealthCheckConnectionsBeforeReuse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConn := NewMockLDAPConnection(ctrl)
+	mockConnFresh := NewMockLDAPConnection(ctrl)
+	pool, clock := newTestLDAPConnectionPool(mockConn, mockConnFresh)
+
+	gomock.InOrder(
+		mockConn.EXPECT().Search(gomock.Any()).Return(&ldap.SearchResult{}, nil),
+		mockConn.EXPECT().Search(gomock.Any()).Return(nil, errors.New("ldap: connection timed out")),
+		mockConn.EXPECT().Close(),
+	)
+
+	conn, err := pool.Get()
+	require.NoError(t, err)
+
+	pool.Put(conn, nil)
+
+	// The connection was checked less than a minute ago so it's reused without any health check.
+	conn, err = pool.Get()
+	require.NoError(t, err)
+	assert.Equal(t, mockConn, conn.LDAPConnection)
+
+	pool.Put(conn, nil)
+
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	conn, err = pool.Get()
+	require.NoError(t, err)
+	assert.Equal(t, mockConn, conn.LDAPConnection)
+
+	pool.Put(conn, nil)
+
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	conn, err = pool.Get()
+	require.NoError(t, err)
+	assert.Equal(t, mockConnFresh, conn.LDAPConnection)
+}
+
+func TestLDAPPoolShouldBlockWhenAllConnectionsAreInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConn := NewMockLDAPConnection(ctrl)
+	pool, _ := newTestLDAPConnectionPool(mockConn)
+
+	conn, err := pool.Get()
+	require.NoError(t, err)
+
+	acquired := make(chan *ldapPooledConnection)
+
+	go func() {
+		c, _ := pool.Get()
+		acquired <- c
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("a connection was acquired while the pool was exhausted")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Put(conn, nil)
+
+	select {
+	case c := <-acquired:
+		assert.Equal(t, mockConn, c.LDAPConnection)
+	case <-time.After(time.Second):
+		t.Fatal("no connection was acquired after one was returned to the pool")
+	}
+}
+
+func TestShouldRetryOnAnotherPooledConnectionAfterNetworkError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+	mockConn := NewMockLDAPConnection(ctrl)
+	mockConnFresh := NewMockLDAPConnection(ctrl)
+
+	ldapClient := newLDAPUserProvider(
+		schema.LDAPAuthenticationBackendConfiguration{
+			URL:                  "ldap://127.0.0.1:389",
+			User:                 "cn=admin,dc=example,dc=com",
+			Password:             "password",
+			UsernameAttribute:    "uid",
+			MailAttribute:        "mail",
+			DisplayNameAttribute: "displayname",
+			UsersFilter:          "uid={input}",
+			AdditionalUsersDN:    "ou=users",
+			BaseDN:               "dc=example,dc=com",
+			Pool:                 &schema.DefaultLDAPConnectionPoolConfiguration,
+		},
+		nil,
+		mockFactory)
+
+	searchProfileResult := &ldap.SearchResult{
+		Entries: []*ldap.Entry{
+			{
+				DN: "uid=test,dc=example,dc=com",
+				Attributes: []*ldap.EntryAttribute{
+					{
+						Name:   "uid",
+						Values: []string{"john"},
+					},
+				},
+			},
+		},
+	}
+
+	gomock.InOrder(
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
+			Return(mockConn, nil),
+		mockConn.EXPECT().
+			Bind(gomock.Eq("cn=admin,dc=example,dc=com"), gomock.Eq("password")).
+			Return(nil),
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			Return(searchProfileResult, nil),
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			Return(createSearchResultWithAttributeValues("group1"), nil),
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			Return(nil, ldap.NewError(ldap.ErrorNetwork, errors.New("ldap: connection closed"))),
+		mockConn.EXPECT().Close(),
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
+			Return(mockConnFresh, nil),
+		mockConnFresh.EXPECT().
+			Bind(gomock.Eq("cn=admin,dc=example,dc=com"), gomock.Eq("password")).
+			Return(nil),
+		mockConnFresh.EXPECT().
+			Search(gomock.Any()).
+			Return(searchProfileResult, nil),
+		mockConnFresh.EXPECT().
+			Search(gomock.Any()).
+			Return(createSearchResultWithAttributeValues("group1"), nil),
+	)
+
+	details, err := ldapClient.GetDetails("john")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"group1"}, details.Groups)
+
+	details, err = ldapClient.GetDetails("john")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"group1"}, details.Groups)
+
+	stats, ok := ldapClient.PoolStats()
+	require.True(t, ok)
+	assert.Equal(t, LDAPConnectionPoolStats{MaxConnections: 5, Open: 1, Idle: 1, Dials: 2, Reuses: 1, Discarded: 1}, stats)
+}
@@ -0,0 +1,172 @@
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// getGroups retrieves the names of the groups the user is a member of according to the group search mode, including
+// the groups the user is a member of through other groups when nested groups are enabled.
+func (p *LDAPUserProvider) getGroups(conn LDAPConnection, inputUsername string, profile *ldapUserProfile) ([]string, error) {
+	switch {
+	case p.configuration.GroupSearchMode == schema.LDAPGroupSearchModeMemberOf:
+		return p.getGroupsFromMemberOf(profile), nil
+	case p.configuration.NestedGroups && p.configuration.Implementation == schema.LDAPImplementationActiveDirectory:
+		return p.getGroupsInChain(conn, inputUsername, profile)
+	case p.configuration.NestedGroups:
+		return p.getNestedGroups(conn, inputUsername, profile)
+	}
+
+	groupsFilter, err := p.resolveGroupsFilter(inputUsername, profile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create group filter for user %s. Cause: %s", inputUsername, err)
+	}
+
+	sr, err := p.searchGroups(conn, groupsFilter)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve groups of user %s. Cause: %w", inputUsername, err)
+	}
+
+	groups := make([]string, 0)
+
+	for _, res := range sr.Entries {
+		if len(res.Attributes) == 0 {
+			p.logger.Warningf("No groups retrieved from LDAP for user %s", inputUsername)
+			break
+		}
+		// Append all values of the document. Normally there should be only one per document.
+		groups = append(groups, res.Attributes[0].Values...)
+	}
+
+	return groups, nil
+}
+
+// getGroupsFromMemberOf retrieves the names of the groups from the DNs held by the memberOf attribute of the user, no
+// additional search is made.
+func (p *LDAPUserProvider) getGroupsFromMemberOf(profile *ldapUserProfile) []string {
+	groups := make([]string, 0, len(profile.MemberOf))
+
+	for _, dn := range profile.MemberOf {
+		name, err := p.groupNameFromDN(dn)
+		if err != nil {
+			p.logger.Warnf("Unable to retrieve the name of group %s of user %s: %v", dn, profile.Username, err)
+			continue
+		}
+
+		groups = append(groups, name)
+	}
+
+	return groups
+}
+
+// getGroupsInChain retrieves the groups of the user in a single search using the Active Directory matching rule
+// walking the whole chain of memberships.
+func (p *LDAPUserProvider) getGroupsInChain(conn LDAPConnection, inputUsername string, profile *ldapUserProfile) ([]string, error) {
+	filter := fmt.Sprintf("(&(objectClass=group)(member:%s:=%s))", ldapMatchingRuleInChain, ldap.EscapeFilter(profile.DN))
+
+	p.logger.Tracef("Computed nested groups filter is %s", filter)
+
+	sr, err := p.searchGroups(conn, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve groups of user %s. Cause: %w", inputUsername, err)
+	}
+
+	groups := make([]string, 0, len(sr.Entries))
+
+	for _, entry := range sr.Entries {
+		if name := entry.GetAttributeValue(p.configuration.GroupNameAttribute); name != "" {
+			groups = append(groups, name)
+		}
+	}
+
+	return groups, nil
+}
+
+// getNestedGroups retrieves the groups of the user level by level: the direct groups are searched with the groups
+// filter, then the groups containing the groups of the previous level are searched by replacing the {dn} placeholder
+// of the groups filter with their DN until no new group is found. Groups already visited are skipped so membership
+// cycles end the search.
+func (p *LDAPUserProvider) getNestedGroups(conn LDAPConnection, inputUsername string, profile *ldapUserProfile) ([]string, error) {
+	groups := make([]string, 0)
+	visited := make(map[string]bool)
+
+	filter, err := p.resolveGroupsFilter(inputUsername, profile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create group filter for user %s. Cause: %s", inputUsername, err)
+	}
+
+	for filter != "" {
+		sr, err := p.searchGroups(conn, filter)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve groups of user %s. Cause: %w", inputUsername, err)
+		}
+
+		var filters []string
+
+		for _, entry := range sr.Entries {
+			dn := strings.ToLower(entry.DN)
+			if visited[dn] {
+				continue
+			}
+
+			visited[dn] = true
+
+			if name := entry.GetAttributeValue(p.configuration.GroupNameAttribute); name != "" {
+				groups = append(groups, name)
+			}
+
+			groupFilter, err := p.resolveGroupsFilter(inputUsername, &ldapUserProfile{DN: entry.DN, Username: profile.Username})
+			if err != nil {
+				return nil, fmt.Errorf("Unable to create group filter for user %s. Cause: %s", inputUsername, err)
+			}
+
+			filters = append(filters, groupFilter)
+		}
+
+		switch len(filters) {
+		case 0:
+			filter = ""
+		case 1:
+			filter = filters[0]
+		default:
+			filter = "(|" + strings.Join(filters, "") + ")"
+		}
+	}
+
+	return groups, nil
+}
+
+func (p *LDAPUserProvider) searchGroups(conn LDAPConnection, filter string) (*ldap.SearchResult, error) {
+	searchGroupRequest := ldap.NewSearchRequest(
+		p.groupsBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, 0, false, filter, []string{p.configuration.GroupNameAttribute}, nil,
+	)
+
+	return conn.Search(searchGroupRequest)
+}
+
+// groupNameFromDN returns the value of the group name attribute from the RDN of the group, or the value of the first
+// attribute of the RDN when it's made of other attributes.
+func (p *LDAPUserProvider) groupNameFromDN(dn string) (string, error) {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return "", err
+	}
+
+	if len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return "", errors.New("the DN has no RDN")
+	}
+
+	for _, attribute := range parsed.RDNs[0].Attributes {
+		if strings.EqualFold(attribute.Type, p.configuration.GroupNameAttribute) {
+			return attribute.Value, nil
+		}
+	}
+
+	return parsed.RDNs[0].Attributes[0].Value, nil
+}
@@ -0,0 +1,211 @@
+package authentication
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func newTestGroupsLDAPUserProvider(t *testing.T, configuration schema.LDAPAuthenticationBackendConfiguration) (*LDAPUserProvider, *MockLDAPConnection) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+	mockConn := NewMockLDAPConnection(ctrl)
+
+	configuration.URL = "ldap://127.0.0.1:389"
+	configuration.User = "cn=admin,dc=example,dc=com"
+	configuration.Password = "password"
+	configuration.UsernameAttribute = "uid"
+	configuration.MailAttribute = "mail"
+	configuration.DisplayNameAttribute = "displayName"
+	configuration.GroupNameAttribute = "cn"
+	configuration.UsersFilter = "(uid={input})"
+	configuration.BaseDN = "dc=example,dc=com"
+
+	provider := newLDAPUserProvider(configuration, nil, mockFactory)
+
+	gomock.InOrder(
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
+			Return(mockConn, nil),
+		mockConn.EXPECT().
+			Bind(gomock.Eq("cn=admin,dc=example,dc=com"), gomock.Eq("password")).
+			Return(nil),
+	)
+
+	mockConn.EXPECT().Close()
+
+	return provider, mockConn
+}
+
+func newTestGroupEntry(dn, name string) *ldap.Entry {
+	return ldap.NewEntry(dn, map[string][]string{"cn": {name}})
+}
+
+func TestShouldReadGroupsFromMemberOfAttribute(t *testing.T) {
+	provider, mockConn := newTestGroupsLDAPUserProvider(t, schema.LDAPAuthenticationBackendConfiguration{
+		GroupSearchMode: schema.LDAPGroupSearchModeMemberOf,
+	})
+
+	mockConn.EXPECT().
+		Search(gomock.Any()).
+		DoAndReturn(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			assert.Contains(t, request.Attributes, "memberOf")
+
+			return &ldap.SearchResult{
+				Entries: []*ldap.Entry{
+					ldap.NewEntry("uid=john,dc=example,dc=com", map[string][]string{
+						"uid": {"john"},
+						"memberOf": {
+							"cn=admins,ou=groups,dc=example,dc=com",
+							"ou=dev+cn=developers,ou=groups,dc=example,dc=com",
+							"ou=contractors,dc=example,dc=com",
+							"not a dn",
+						},
+					}),
+				},
+			}, nil
+		})
+
+	details, err := provider.GetDetails("john")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"admins", "developers", "contractors"}, details.Groups)
+}
+
+func TestShouldResolveNestedGroupsWithMatchingRuleInChain(t *testing.T) {
+	provider, mockConn := newTestGroupsLDAPUserProvider(t, schema.LDAPAuthenticationBackendConfiguration{
+		Implementation: schema.LDAPImplementationActiveDirectory,
+		GroupsFilter:   "(&(member={dn})(objectClass=group))",
+		NestedGroups:   true,
+	})
+
+	gomock.InOrder(
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			Return(&ldap.SearchResult{
+				Entries: []*ldap.Entry{
+					ldap.NewEntry("CN=John,OU=Users,DC=example,DC=com", map[string][]string{"uid": {"john"}}),
+				},
+			}, nil),
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			DoAndReturn(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+				assert.Equal(t, "(&(objectClass=group)(member:1.2.840.113556.1.4.1941:=CN=John,OU=Users,DC=example,DC=com))", request.Filter)
+
+				return &ldap.SearchResult{
+					Entries: []*ldap.Entry{
+						newTestGroupEntry("CN=Dev,OU=Groups,DC=example,DC=com", "dev"),
+						newTestGroupEntry("CN=Staff,OU=Groups,DC=example,DC=com", "staff"),
+					},
+				}, nil
+			}),
+	)
+
+	details, err := provider.GetDetails("john")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"dev", "staff"}, details.Groups)
+}
+
+func TestShouldResolveNestedGroupsIteratively(t *testing.T) {
+	provider, mockConn := newTestGroupsLDAPUserProvider(t, schema.LDAPAuthenticationBackendConfiguration{
+		GroupsFilter: "(member={dn})",
+		NestedGroups: true,
+	})
+
+	// dev and ops are members of staff, staff is a member of everyone which is a member of staff.
+	members := map[string][]*ldap.Entry{
+		"uid=john,dc=example,dc=com": {
+			newTestGroupEntry("cn=dev,dc=example,dc=com", "dev"),
+			newTestGroupEntry("cn=ops,dc=example,dc=com", "ops"),
+		},
+		"cn=dev,dc=example,dc=com":      {newTestGroupEntry("cn=staff,dc=example,dc=com", "staff")},
+		"cn=ops,dc=example,dc=com":      {newTestGroupEntry("cn=staff,dc=example,dc=com", "staff")},
+		"cn=staff,dc=example,dc=com":    {newTestGroupEntry("cn=everyone,dc=example,dc=com", "everyone")},
+		"cn=everyone,dc=example,dc=com": {newTestGroupEntry("CN=Staff,DC=example,DC=com", "staff")},
+	}
+
+	var filters []string
+
+	gomock.InOrder(
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			Return(&ldap.SearchResult{
+				Entries: []*ldap.Entry{
+					ldap.NewEntry("uid=john,dc=example,dc=com", map[string][]string{"uid": {"john"}}),
+				},
+			}, nil),
+		mockConn.EXPECT().
+			Search(gomock.Any()).
+			DoAndReturn(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+				filters = append(filters, request.Filter)
+
+				result := &ldap.SearchResult{}
+
+				for dn, groups := range members {
+					if strings.Contains(request.Filter, fmt.Sprintf("(member=%s)", dn)) {
+						result.Entries = append(result.Entries, groups...)
+					}
+				}
+
+				return result, nil
+			}).
+			Times(4),
+	)
+
+	details, err := provider.GetDetails("john")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"dev", "ops", "staff", "everyone"}, details.Groups)
+	assert.Equal(t, []string{
+		"(member=uid=john,dc=example,dc=com)",
+		"(|(member=cn=dev,dc=example,dc=com)(member=cn=ops,dc=example,dc=com))",
+		"(member=cn=staff,dc=example,dc=com)",
+		"(member=cn=everyone,dc=example,dc=com)",
+	}, filters)
+}
+
+func TestShouldRetrieveUserAttributesFromLDAP(t *testing.T) {
+	provider, mockConn := newTestGroupsLDAPUserProvider(t, schema.LDAPAuthenticationBackendConfiguration{
+		GroupSearchMode: schema.LDAPGroupSearchModeMemberOf,
+	})
+
+	provider.attributes = []schema.UserAttributeConfiguration{
+		{Name: "department", LDAPAttribute: "department"},
+		{Name: "employee_id", LDAPAttribute: "employeeNumber"},
+		{Name: "phone", LDAPAttribute: "telephoneNumber"},
+	}
+
+	mockConn.EXPECT().
+		Search(gomock.Any()).
+		DoAndReturn(func(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+			assert.Subset(t, request.Attributes, []string{"department", "employeeNumber", "telephoneNumber"})
+
+			return &ldap.SearchResult{
+				Entries: []*ldap.Entry{
+					ldap.NewEntry("uid=john,dc=example,dc=com", map[string][]string{
+						"uid":            {"john"},
+						"Department":     {"engineering", "research"},
+						"employeeNumber": {"1234"},
+					}),
+				},
+			}, nil
+		})
+
+	details, err := provider.GetDetails("john")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"department":  {"engineering", "research"},
+		"employee_id": {"1234"},
+	}, details.Attributes)
+}
@@ -0,0 +1,83 @@
+package authentication
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// bindUser binds the connection with the user requesting the password policy control (draft-behera-ldap-password-policy)
+// and returns the password policy error along with the bind error. The password policy error is deduced from the
+// control returned by the server and, for Active Directory, from the sub-error code of a refused bind.
+func (p *LDAPUserProvider) bindUser(conn LDAPConnection, profile *ldapUserProfile, password string) (errPolicy, err error) {
+	request := ldap.NewSimpleBindRequest(profile.DN, password, []ldap.Control{ldap.NewControlBeheraPasswordPolicy()})
+
+	result, err := conn.SimpleBind(request)
+
+	graceLogins := -1
+
+	var policyErr error
+
+	if result != nil {
+		if control, ok := ldap.FindControl(result.Controls, ldap.ControlTypeBeheraPasswordPolicy).(*ldap.ControlBeheraPasswordPolicy); ok {
+			graceLogins, policyErr = p.passwordPolicyControlError(profile, control, err)
+		}
+	}
+
+	if policyErr == nil && err != nil && p.configuration.Implementation == schema.LDAPImplementationActiveDirectory {
+		policyErr = activeDirectoryBindError(err)
+	}
+
+	if policyErr == nil {
+		return nil, err
+	}
+
+	return &PasswordPolicyError{Username: profile.Username, Err: policyErr, GraceLogins: graceLogins}, err
+}
+
+// passwordPolicyControlError returns the error matching the password policy control returned by the server along with
+// the number of remaining grace logins when the user authenticated with an expired password, or -1.
+func (p *LDAPUserProvider) passwordPolicyControlError(profile *ldapUserProfile, control *ldap.ControlBeheraPasswordPolicy, errBind error) (graceLogins int, err error) {
+	switch control.Error {
+	case ldap.BeheraPasswordExpired:
+		return -1, ErrPasswordExpired
+	case ldap.BeheraChangeAfterReset:
+		return -1, ErrPasswordMustChange
+	case ldap.BeheraAccountLocked:
+		return -1, ErrAccountLocked
+	}
+
+	if errBind != nil {
+		return -1, nil
+	}
+
+	if control.Grace >= 0 {
+		return int(control.Grace), ErrPasswordExpired
+	}
+
+	if control.Expire >= 0 {
+		p.logger.Infof("Password of user %s expires in %s", profile.Username, time.Duration(control.Expire)*time.Second)
+	}
+
+	return -1, nil
+}
+
+// activeDirectoryBindError returns the error matching the sub-error code of a bind refused by Active Directory. Only the
+// sub-error codes given once the password has been verified are mapped, any other refused bind is reported as incorrect
+// credentials so the state of the account isn't disclosed to someone who doesn't know the password.
+func activeDirectoryBindError(errBind error) error {
+	var ldapErr *ldap.Error
+
+	if !errors.As(errBind, &ldapErr) || ldapErr.ResultCode != ldap.LDAPResultInvalidCredentials || ldapErr.Err == nil {
+		return nil
+	}
+
+	if match := ldapActiveDirectoryBindErrorRegexp.FindStringSubmatch(ldapErr.Err.Error()); match != nil {
+		return ldapActiveDirectoryBindErrors[match[1]]
+	}
+
+	return nil
+}
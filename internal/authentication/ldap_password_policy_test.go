@@ -0,0 +1,216 @@
+package authentication
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// newTestPasswordPolicyLDAPUserProvider returns a provider expecting the service connection to be opened and the
+// profile of john to be searched, userConn is the connection returned when binding the user.
+func newTestPasswordPolicyLDAPUserProvider(t *testing.T, implementation string, profile *ldap.Entry) (provider *LDAPUserProvider, serviceConn, userConn *MockLDAPConnection) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+	serviceConn = NewMockLDAPConnection(ctrl)
+	userConn = NewMockLDAPConnection(ctrl)
+
+	provider = newLDAPUserProvider(
+		schema.LDAPAuthenticationBackendConfiguration{
+			Implementation:       implementation,
+			URL:                  "ldap://127.0.0.1:389",
+			User:                 "cn=admin,dc=example,dc=com",
+			Password:             "password",
+			UsernameAttribute:    "uid",
+			MailAttribute:        "mail",
+			DisplayNameAttribute: "displayName",
+			UsersFilter:          "(uid={input})",
+			BaseDN:               "dc=example,dc=com",
+		},
+		nil,
+		mockFactory)
+
+	gomock.InOrder(
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
+			Return(serviceConn, nil),
+		serviceConn.EXPECT().
+			Bind(gomock.Eq("cn=admin,dc=example,dc=com"), gomock.Eq("password")).
+			Return(nil),
+		serviceConn.EXPECT().
+			Search(gomock.Any()).
+			Return(&ldap.SearchResult{Entries: []*ldap.Entry{profile}}, nil),
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
+			Return(userConn, nil),
+	)
+
+	serviceConn.EXPECT().Close()
+
+	return provider, serviceConn, userConn
+}
+
+func newTestPasswordPolicyBindResult(control *ldap.ControlBeheraPasswordPolicy) *ldap.SimpleBindResult {
+	return &ldap.SimpleBindResult{Controls: []ldap.Control{control}}
+}
+
+var testJohnEntry = ldap.NewEntry("uid=john,dc=example,dc=com", map[string][]string{"uid": {"john"}})
+
+func TestShouldReturnPasswordExpiredFromPasswordPolicyControl(t *testing.T) {
+	provider, _, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationCustom, testJohnEntry)
+
+	control := ldap.NewControlBeheraPasswordPolicy()
+	control.Error = ldap.BeheraPasswordExpired
+
+	userConn.EXPECT().
+		SimpleBind(NewSimpleBindRequestMatcher("uid=john,dc=example,dc=com", "password")).
+		Return(newTestPasswordPolicyBindResult(control), ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("")))
+
+	valid, err := provider.CheckUserPassword("john", "password")
+	assert.False(t, valid)
+
+	require.EqualError(t, err, "Password policy of user john: password expired")
+	assert.True(t, errors.Is(err, ErrPasswordExpired))
+	assert.True(t, IsPasswordChangeRequired(err))
+}
+
+func TestShouldReturnPasswordMustChangeAfterSuccessfulBind(t *testing.T) {
+	provider, _, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationCustom, testJohnEntry)
+
+	control := ldap.NewControlBeheraPasswordPolicy()
+	control.Error = ldap.BeheraChangeAfterReset
+
+	gomock.InOrder(
+		userConn.EXPECT().
+			SimpleBind(NewSimpleBindRequestMatcher("uid=john,dc=example,dc=com", "password")).
+			Return(newTestPasswordPolicyBindResult(control), nil),
+		userConn.EXPECT().Close(),
+	)
+
+	valid, err := provider.CheckUserPassword("john", "password")
+	assert.False(t, valid)
+	assert.True(t, errors.Is(err, ErrPasswordMustChange))
+}
+
+func TestShouldReturnRemainingGraceLogins(t *testing.T) {
+	provider, _, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationCustom, testJohnEntry)
+
+	control := ldap.NewControlBeheraPasswordPolicy()
+	control.Grace = 2
+
+	gomock.InOrder(
+		userConn.EXPECT().
+			SimpleBind(gomock.Any()).
+			Return(newTestPasswordPolicyBindResult(control), nil),
+		userConn.EXPECT().Close(),
+	)
+
+	_, err := provider.CheckUserPassword("john", "password")
+
+	var policyErr *PasswordPolicyError
+
+	require.True(t, errors.As(err, &policyErr))
+	assert.Equal(t, ErrPasswordExpired, policyErr.Err)
+	assert.Equal(t, 2, policyErr.GraceLogins)
+	assert.EqualError(t, err, "Password policy of user john: password expired (2 grace logins remaining)")
+}
+
+func TestShouldAuthenticateWhenPasswordIsAboutToExpire(t *testing.T) {
+	provider, _, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationCustom, testJohnEntry)
+
+	control := ldap.NewControlBeheraPasswordPolicy()
+	control.Expire = 3600
+
+	gomock.InOrder(
+		userConn.EXPECT().
+			SimpleBind(gomock.Any()).
+			Return(newTestPasswordPolicyBindResult(control), nil),
+		userConn.EXPECT().Close(),
+	)
+
+	valid, err := provider.CheckUserPassword("john", "password")
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestShouldReturnActiveDirectoryBindErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		message  string
+		expected error
+	}{
+		{"PasswordExpired", "80090308: LdapErr: DSID-0C09042A, comment: AcceptSecurityContext error, data 532, v3839", ErrPasswordExpired},
+		{"MustChange", "80090308: LdapErr: DSID-0C09042A, comment: AcceptSecurityContext error, data 773, v3839", ErrPasswordMustChange},
+		{"Locked", "80090308: LdapErr: DSID-0C09042A, comment: AcceptSecurityContext error, data 775, v3839", nil},
+		{"Disabled", "80090308: LdapErr: DSID-0C09042A, comment: AcceptSecurityContext error, data 533, v3839", nil},
+		{"InvalidCredentials", "80090308: LdapErr: DSID-0C09042A, comment: AcceptSecurityContext error, data 52e, v3839", nil},
+		{"NoSubErrorCode", "", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := activeDirectoryBindError(ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New(tc.message)))
+			assert.Equal(t, tc.expected, err)
+		})
+	}
+
+	assert.Nil(t, activeDirectoryBindError(errors.New("connection closed")))
+}
+
+func TestShouldChangeExpiredPasswordWithServiceConnection(t *testing.T) {
+	provider, serviceConn, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationCustom, testJohnEntry)
+	provider.supportExtensionPasswdModify = true
+
+	control := ldap.NewControlBeheraPasswordPolicy()
+	control.Error = ldap.BeheraPasswordExpired
+
+	gomock.InOrder(
+		userConn.EXPECT().
+			SimpleBind(NewSimpleBindRequestMatcher("uid=john,dc=example,dc=com", "oldpassword")).
+			Return(newTestPasswordPolicyBindResult(control), ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New(""))),
+		serviceConn.EXPECT().
+			PasswordModify(ldap.NewPasswordModifyRequest("uid=john,dc=example,dc=com", "oldpassword", "newpassword")).
+			Return(nil),
+	)
+
+	require.NoError(t, provider.UpdatePassword("john", "oldpassword", "newpassword"))
+}
+
+func TestShouldChangePasswordAsUserWithActiveDirectory(t *testing.T) {
+	entry := ldap.NewEntry("CN=John,DC=example,DC=com", map[string][]string{"uid": {"john"}})
+	provider, _, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationActiveDirectory, entry)
+
+	modifyRequest := ldap.NewModifyRequest("CN=John,DC=example,DC=com", nil)
+	modifyRequest.Delete("unicodePwd", []string{encodeActiveDirectoryPassword("oldpassword")})
+	modifyRequest.Add("unicodePwd", []string{encodeActiveDirectoryPassword("newpassword")})
+
+	gomock.InOrder(
+		userConn.EXPECT().
+			SimpleBind(NewSimpleBindRequestMatcher("CN=John,DC=example,DC=com", "oldpassword")).
+			Return(&ldap.SimpleBindResult{}, nil),
+		userConn.EXPECT().
+			Modify(modifyRequest).
+			Return(nil),
+		userConn.EXPECT().Close(),
+	)
+
+	require.NoError(t, provider.UpdatePassword("john", "oldpassword", "newpassword"))
+}
+
+func TestShouldNotChangePasswordWhenOldPasswordIsIncorrect(t *testing.T) {
+	provider, _, userConn := newTestPasswordPolicyLDAPUserProvider(t, schema.LDAPImplementationCustom, testJohnEntry)
+
+	userConn.EXPECT().
+		SimpleBind(gomock.Any()).
+		Return(&ldap.SimpleBindResult{}, ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials")))
+
+	err := provider.UpdatePassword("john", "wrongpassword", "newpassword")
+	assert.EqualError(t, err, "Unable to update password. Cause: Authentication of user john failed. Cause: LDAP Result Code 49 \"Invalid Credentials\": invalid credentials")
+}
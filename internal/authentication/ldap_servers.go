@@ -0,0 +1,227 @@
+package authentication
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ldapServer is a LDAP server along with the state of its circuit breaker.
+type ldapServer struct {
+	url       string
+	tlsConfig *tls.Config
+	dialOpts  ldap.DialOpt
+
+	failures         int
+	unavailableUntil time.Time
+}
+
+// ldapServerSelector selects the order in which the LDAP servers are tried and marks the servers failing repeatedly
+// as unavailable for a cooldown period so they are only tried as a last resort.
+type ldapServerSelector struct {
+	clock  utils.Clock
+	logger *logrus.Logger
+
+	tlsConfig *tls.Config
+
+	roundRobin       bool
+	failureThreshold int
+	cooldown         time.Duration
+
+	srvDomain string
+	lookupSRV func(service, proto, name string) (cname string, addrs []*net.SRV, err error)
+
+	mutex        sync.Mutex
+	servers      []*ldapServer
+	next         int
+	discoveredAt time.Time
+	discovering  bool
+}
+
+func newLDAPServerSelector(configuration schema.LDAPAuthenticationBackendConfiguration, tlsConfig *tls.Config, clock utils.Clock) *ldapServerSelector {
+	selector := &ldapServerSelector{
+		clock:      clock,
+		logger:     logging.Logger(),
+		tlsConfig:  tlsConfig,
+		roundRobin: configuration.ServerSelection == schema.LDAPServerSelectionRoundRobin,
+		srvDomain:  configuration.SRVDomain,
+		lookupSRV:  net.LookupSRV,
+	}
+
+	if configuration.CircuitBreaker != nil {
+		selector.failureThreshold = configuration.CircuitBreaker.Failures
+		// The configuration is validated at startup so the duration is known to be valid.
+		selector.cooldown, _ = utils.ParseDurationString(configuration.CircuitBreaker.Cooldown)
+	}
+
+	urls := configuration.URLs
+	if len(urls) == 0 && configuration.URL != "" {
+		urls = []string{configuration.URL}
+	}
+
+	for _, u := range urls {
+		selector.servers = append(selector.servers, selector.newServer(u, len(urls) > 1))
+	}
+
+	return selector
+}
+
+// newServer creates a server using the TLS configuration of the provider. When several servers are configured and no
+// server name is set, the TLS configuration is cloned to verify the certificate against the hostname of each server.
+func (s *ldapServerSelector) newServer(serverURL string, multiple bool) *ldapServer {
+	server := &ldapServer{url: serverURL, tlsConfig: s.tlsConfig}
+
+	if multiple && s.tlsConfig != nil && s.tlsConfig.ServerName == "" {
+		if parsedURL, err := url.Parse(serverURL); err == nil {
+			server.tlsConfig = s.tlsConfig.Clone()
+			server.tlsConfig.ServerName = parsedURL.Hostname()
+		}
+	}
+
+	if server.tlsConfig != nil {
+		server.dialOpts = ldap.DialWithTLSConfig(server.tlsConfig)
+	}
+
+	return server
+}
+
+// Candidates returns the servers in the order they must be tried. The servers marked as unavailable are returned
+// last so they are still tried when every other server is failing.
+func (s *ldapServerSelector) Candidates() (candidates []*ldapServer) {
+	if s.srvDomain != "" {
+		s.discover()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	n := len(s.servers)
+	start := 0
+
+	if s.roundRobin && n != 0 {
+		start = s.next % n
+		s.next++
+	}
+
+	now := s.clock.Now()
+
+	var unavailable []*ldapServer
+
+	for i := 0; i < n; i++ {
+		server := s.servers[(start+i)%n]
+
+		if now.Before(server.unavailableUntil) {
+			unavailable = append(unavailable, server)
+			continue
+		}
+
+		candidates = append(candidates, server)
+	}
+
+	return append(candidates, unavailable...)
+}
+
+// Success resets the circuit breaker of the server.
+func (s *ldapServerSelector) Success(server *ldapServer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failureThreshold > 0 && server.failures >= s.failureThreshold {
+		s.logger.Infof("LDAP server %s is available again", server.url)
+	}
+
+	server.failures = 0
+	server.unavailableUntil = time.Time{}
+}
+
+// Failure records a failure of the server and marks it as unavailable once it reaches the failure threshold.
+func (s *ldapServerSelector) Failure(server *ldapServer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	server.failures++
+
+	if s.failureThreshold > 0 && server.failures >= s.failureThreshold {
+		server.unavailableUntil = s.clock.Now().Add(s.cooldown)
+
+		s.logger.Warnf("LDAP server %s is marked as unavailable for %s after %d consecutive failures", server.url, s.cooldown, server.failures)
+	}
+}
+
+// discover refreshes the servers from the SRV records of the domain. The lookup is made without holding the mutex so
+// a slow DNS server doesn't stall the concurrent LDAP operations, which keep using the previous servers meanwhile. The
+// state of the circuit breaker of the servers which are still advertised is kept, and the previous servers are kept
+// on failure.
+func (s *ldapServerSelector) discover() {
+	s.mutex.Lock()
+
+	now := s.clock.Now()
+
+	if len(s.servers) != 0 && (s.discovering || now.Sub(s.discoveredAt) < ldapSRVRefreshInterval) {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.discoveredAt = now
+	s.discovering = true
+
+	s.mutex.Unlock()
+
+	_, records, err := s.lookupSRV("ldap", "tcp", s.srvDomain)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.discovering = false
+
+	if err != nil || len(records) == 0 {
+		s.logger.Errorf("Unable to discover the LDAP servers of %s from the _ldap._tcp SRV records: %v", s.srvDomain, err)
+		return
+	}
+
+	known := make(map[string]*ldapServer, len(s.servers))
+	for _, server := range s.servers {
+		known[server.url] = server
+	}
+
+	servers := make([]*ldapServer, 0, len(records))
+	urls := make([]string, 0, len(records))
+
+	for _, record := range records {
+		serverURL := ldapSRVRecordURL(record)
+
+		server, ok := known[serverURL]
+		if !ok {
+			server = s.newServer(serverURL, true)
+		}
+
+		servers = append(servers, server)
+		urls = append(urls, serverURL)
+	}
+
+	s.servers = servers
+
+	s.logger.Debugf("Discovered LDAP servers of %s: %s", s.srvDomain, strings.Join(urls, ", "))
+}
+
+// ldapSRVRecordURL returns the URL of the server of a SRV record, the LDAPS scheme is used for the well-known port.
+func ldapSRVRecordURL(record *net.SRV) string {
+	scheme := "ldap"
+	if record.Port == ldapsPort {
+		scheme = "ldaps"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))))
+}
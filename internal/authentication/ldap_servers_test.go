@@ -0,0 +1,267 @@
+package authentication
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func newTestMultipleServersLDAPUserProvider(factory LDAPConnectionFactory, selection string) (*LDAPUserProvider, *poolTestClock) {
+	provider := newLDAPUserProvider(
+		schema.LDAPAuthenticationBackendConfiguration{
+			URLs:            []string{"ldap://dc1.example.com", "ldap://dc2.example.com"},
+			ServerSelection: selection,
+			CircuitBreaker:  &schema.LDAPCircuitBreakerConfiguration{Failures: 2, Cooldown: "30s"},
+			User:            "cn=admin,dc=example,dc=com",
+			Password:        "password",
+			StartTLS:        true,
+		},
+		nil,
+		factory)
+
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+	provider.servers.clock = clock
+
+	return provider, clock
+}
+
+func candidateURLs(selector *ldapServerSelector) (urls []string) {
+	for _, server := range selector.Candidates() {
+		urls = append(urls, server.url)
+	}
+
+	return urls
+}
+
+func TestShouldFailoverToNextLDAPServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+	mockConn := NewMockLDAPConnection(ctrl)
+
+	provider, _ := newTestMultipleServersLDAPUserProvider(mockFactory, schema.LDAPServerSelectionPriority)
+
+	gomock.InOrder(
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://dc1.example.com"), gomock.Any()).
+			Return(nil, errors.New("connection refused")),
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://dc2.example.com"), gomock.Any()).
+			Return(mockConn, nil),
+		mockConn.EXPECT().
+			StartTLS(provider.servers.servers[1].tlsConfig),
+		mockConn.EXPECT().
+			Bind(gomock.Eq("cn=admin,dc=example,dc=com"), gomock.Eq("password")).
+			Return(nil),
+	)
+
+	conn, err := provider.connect("cn=admin,dc=example,dc=com", "password")
+	require.NoError(t, err)
+	assert.Equal(t, mockConn, conn)
+
+	assert.Equal(t, 1, provider.servers.servers[0].failures)
+	assert.Equal(t, "dc2.example.com", provider.servers.servers[1].tlsConfig.ServerName)
+}
+
+func TestShouldNotFailoverWhenBindIsRefused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+	mockConn := NewMockLDAPConnection(ctrl)
+
+	provider, _ := newTestMultipleServersLDAPUserProvider(mockFactory, schema.LDAPServerSelectionPriority)
+
+	gomock.InOrder(
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://dc1.example.com"), gomock.Any()).
+			Return(mockConn, nil),
+		mockConn.EXPECT().
+			StartTLS(gomock.Any()),
+		mockConn.EXPECT().
+			Bind(gomock.Eq("uid=john,dc=example,dc=com"), gomock.Eq("wrong")).
+			Return(ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))),
+	)
+
+	_, err := provider.connect("uid=john,dc=example,dc=com", "wrong")
+	assert.EqualError(t, err, "LDAP Result Code 49 \"Invalid Credentials\": invalid credentials")
+	assert.Equal(t, 0, provider.servers.servers[0].failures)
+}
+
+func TestShouldReturnLastErrorWhenAllLDAPServersFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFactory := NewMockLDAPConnectionFactory(ctrl)
+
+	provider, _ := newTestMultipleServersLDAPUserProvider(mockFactory, schema.LDAPServerSelectionPriority)
+
+	gomock.InOrder(
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://dc1.example.com"), gomock.Any()).
+			Return(nil, errors.New("dc1 is down")),
+		mockFactory.EXPECT().
+			DialURL(gomock.Eq("ldap://dc2.example.com"), gomock.Any()).
+			Return(nil, errors.New("dc2 is down")),
+	)
+
+	_, err := provider.connect("cn=admin,dc=example,dc=com", "password")
+	assert.EqualError(t, err, "dc2 is down")
+}
+
+func TestShouldTryUnavailableLDAPServersLast(t *testing.T) {
+	provider, clock := newTestMultipleServersLDAPUserProvider(nil, schema.LDAPServerSelectionPriority)
+	selector := provider.servers
+	dc1 := selector.servers[0]
+
+	selector.Failure(dc1)
+	assert.Equal(t, []string{"ldap://dc1.example.com", "ldap://dc2.example.com"}, candidateURLs(selector))
+
+	selector.Failure(dc1)
+	assert.Equal(t, []string{"ldap://dc2.example.com", "ldap://dc1.example.com"}, candidateURLs(selector))
+
+	clock.now = clock.now.Add(31 * time.Second)
+	assert.Equal(t, []string{"ldap://dc1.example.com", "ldap://dc2.example.com"}, candidateURLs(selector))
+
+	selector.Failure(dc1)
+	assert.Equal(t, []string{"ldap://dc2.example.com", "ldap://dc1.example.com"}, candidateURLs(selector))
+
+	selector.Success(dc1)
+	assert.Equal(t, []string{"ldap://dc1.example.com", "ldap://dc2.example.com"}, candidateURLs(selector))
+}
+
+func TestShouldSelectLDAPServersInRoundRobin(t *testing.T) {
+	provider, _ := newTestMultipleServersLDAPUserProvider(nil, schema.LDAPServerSelectionRoundRobin)
+
+	assert.Equal(t, []string{"ldap://dc1.example.com", "ldap://dc2.example.com"}, candidateURLs(provider.servers))
+	assert.Equal(t, []string{"ldap://dc2.example.com", "ldap://dc1.example.com"}, candidateURLs(provider.servers))
+	assert.Equal(t, []string{"ldap://dc1.example.com", "ldap://dc2.example.com"}, candidateURLs(provider.servers))
+}
+
+func TestShouldDiscoverLDAPServersFromSRVRecords(t *testing.T) {
+	provider := newLDAPUserProvider(
+		schema.LDAPAuthenticationBackendConfiguration{
+			SRVDomain: "example.com",
+		},
+		nil,
+		nil)
+
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+	selector := provider.servers
+	selector.clock = clock
+
+	lookups := 0
+	records := []*net.SRV{
+		{Target: "dc1.example.com.", Port: 389, Priority: 0},
+		{Target: "dc2.example.com.", Port: 636, Priority: 10},
+	}
+
+	selector.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		lookups++
+
+		assert.Equal(t, "ldap", service)
+		assert.Equal(t, "tcp", proto)
+		assert.Equal(t, "example.com", name)
+
+		if records == nil {
+			return "", nil, errors.New("no such host")
+		}
+
+		return "_ldap._tcp.example.com.", records, nil
+	}
+
+	assert.Equal(t, []string{"ldap://dc1.example.com:389", "ldaps://dc2.example.com:636"}, candidateURLs(selector))
+	assert.Equal(t, "dc2.example.com", selector.servers[1].tlsConfig.ServerName)
+
+	// The records are cached until the refresh interval elapses.
+	selector.Failure(selector.servers[0])
+	assert.Equal(t, []string{"ldap://dc1.example.com:389", "ldaps://dc2.example.com:636"}, candidateURLs(selector))
+	assert.Equal(t, 1, lookups)
+
+	// The state of the servers still advertised is kept when refreshing.
+	clock.now = clock.now.Add(6 * time.Minute)
+	records = records[:1]
+
+	assert.Equal(t, []string{"ldap://dc1.example.com:389"}, candidateURLs(selector))
+	assert.Equal(t, 1, selector.servers[0].failures)
+	assert.Equal(t, 2, lookups)
+
+	// The previous servers are kept when the lookup fails.
+	clock.now = clock.now.Add(6 * time.Minute)
+	records = nil
+
+	assert.Equal(t, []string{"ldap://dc1.example.com:389"}, candidateURLs(selector))
+	assert.Equal(t, 3, lookups)
+}
+
+func TestShouldNotHoldLockWhileDiscoveringLDAPServers(t *testing.T) {
+	provider := newLDAPUserProvider(
+		schema.LDAPAuthenticationBackendConfiguration{
+			SRVDomain: "example.com",
+		},
+		nil,
+		nil)
+
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+	selector := provider.servers
+	selector.clock = clock
+
+	selector.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		return "_ldap._tcp.example.com.", []*net.SRV{{Target: "dc1.example.com.", Port: 389}}, nil
+	}
+
+	require.Equal(t, []string{"ldap://dc1.example.com:389"}, candidateURLs(selector))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	selector.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		close(started)
+		<-release
+
+		return "_ldap._tcp.example.com.", []*net.SRV{{Target: "dc2.example.com.", Port: 389}}, nil
+	}
+
+	clock.now = clock.now.Add(6 * time.Minute)
+
+	done := make(chan []string)
+
+	go func() {
+		done <- candidateURLs(selector)
+	}()
+
+	<-started
+
+	// The concurrent operations use the previous servers while the lookup is in progress.
+	assert.Equal(t, []string{"ldap://dc1.example.com:389"}, candidateURLs(selector))
+	selector.Failure(selector.servers[0])
+
+	close(release)
+
+	assert.Equal(t, []string{"ldap://dc2.example.com:389"}, <-done)
+}
+
+func TestShouldReturnErrorWhenNoLDAPServerIsDiscovered(t *testing.T) {
+	provider := newLDAPUserProvider(
+		schema.LDAPAuthenticationBackendConfiguration{
+			SRVDomain: "example.com",
+		},
+		nil,
+		nil)
+
+	provider.servers.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		return "", nil, errors.New("no such host")
+	}
+
+	_, err := provider.connect("cn=admin,dc=example,dc=com", "password")
+	assert.EqualError(t, err, "No LDAP server is available")
+}
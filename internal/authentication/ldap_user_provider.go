@@ -19,11 +19,13 @@ import (
 type LDAPUserProvider struct {
 	configuration     schema.LDAPAuthenticationBackendConfiguration
 	tlsConfig         *tls.Config
-	dialOpts          ldap.DialOpt
 	logger            *logrus.Logger
 	connectionFactory LDAPConnectionFactory
+	pool              *ldapConnectionPool
+	servers           *ldapServerSelector
 	usersBaseDN       string
 	groupsBaseDN      string
+	attributes        []schema.UserAttributeConfiguration
 
 	supportExtensionPasswdModify bool
 }
@@ -31,6 +33,7 @@ type LDAPUserProvider struct {
 // NewLDAPUserProvider creates a new instance of LDAPUserProvider.
 func NewLDAPUserProvider(configuration schema.AuthenticationBackendConfiguration, certPool *x509.CertPool) (provider *LDAPUserProvider, err error) {
 	provider = newLDAPUserProvider(*configuration.LDAP, certPool, nil)
+	provider.attributes = configuration.Attributes
 
 	err = provider.checkServer()
 	if err != nil {
@@ -54,12 +57,6 @@ func newLDAPUserProvider(configuration schema.LDAPAuthenticationBackendConfigura
 
 	tlsConfig := utils.NewTLSConfig(configuration.TLS, tls.VersionTLS12, certPool)
 
-	var dialOpts ldap.DialOpt
-
-	if tlsConfig != nil {
-		dialOpts = ldap.DialWithTLSConfig(tlsConfig)
-	}
-
 	if factory == nil {
 		factory = NewLDAPConnectionFactoryImpl()
 	}
@@ -67,11 +64,16 @@ func newLDAPUserProvider(configuration schema.LDAPAuthenticationBackendConfigura
 	provider = &LDAPUserProvider{
 		configuration:     configuration,
 		tlsConfig:         tlsConfig,
-		dialOpts:          dialOpts,
 		logger:            logging.Logger(),
 		connectionFactory: factory,
 	}
 
+	provider.servers = newLDAPServerSelector(configuration, tlsConfig, utils.RealClock{})
+
+	if configuration.Pool != nil && !configuration.Pool.Disable && configuration.Pool.MaxConnections > 0 {
+		provider.pool = newLDAPConnectionPool(configuration.Pool, provider.connectService, utils.RealClock{})
+	}
+
 	provider.parseDynamicConfiguration()
 
 	return provider
@@ -140,43 +142,156 @@ func (p *LDAPUserProvider) checkServer() (err error) {
 	return nil
 }
 
-func (p *LDAPUserProvider) connect(userDN string, password string) (LDAPConnection, error) {
-	conn, err := p.connectionFactory.DialURL(p.configuration.URL, p.dialOpts)
-	if err != nil {
-		return nil, err
+// connectService creates a new connection bound with the LDAP user.
+func (p *LDAPUserProvider) connectService() (LDAPConnection, error) {
+	return p.connect(p.configuration.User, p.configuration.Password)
+}
+
+// withServiceConnection runs the operation with a connection bound with the LDAP user. When the pool is enabled the
+// connection is taken from the pool and the operation is retried on another connection whenever a reused connection
+// turns out to be broken, otherwise a new connection is created for the operation.
+func (p *LDAPUserProvider) withServiceConnection(operation func(conn LDAPConnection) error) error {
+	if p.pool == nil {
+		conn, err := p.connectService()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return operation(conn)
 	}
 
-	if p.configuration.StartTLS {
-		if err := conn.StartTLS(p.tlsConfig); err != nil {
+	for {
+		conn, err := p.pool.Get()
+		if err != nil {
+			return err
+		}
+
+		reused := conn.reused
+
+		err = operation(conn)
+
+		p.pool.Put(conn, err)
+
+		if !reused || !isLDAPNetworkError(err) {
+			return err
+		}
+
+		p.logger.Debugf("Retrying LDAP operation on another connection after a network error: %v", err)
+	}
+}
+
+// PoolStats returns the metrics of the pool of connections bound with the LDAP user, ok is false when the pool is
+// disabled.
+func (p *LDAPUserProvider) PoolStats() (stats LDAPConnectionPoolStats, ok bool) {
+	if p.pool == nil {
+		return stats, false
+	}
+
+	return p.pool.Stats(), true
+}
+
+// connect creates a new connection bound with the given user.
+func (p *LDAPUserProvider) connect(userDN string, password string) (conn LDAPConnection, err error) {
+	return p.connectWithBind(userDN, func(conn LDAPConnection) error {
+		return conn.Bind(userDN, password)
+	})
+}
+
+// connectUser creates a new connection bound with the user requesting the password policy of the server. The password
+// policy error is set when the server requires the password to be changed or refuses the account, which may happen
+// even though the bind succeeded.
+func (p *LDAPUserProvider) connectUser(profile *ldapUserProfile, password string) (conn LDAPConnection, errPolicy, err error) {
+	conn, err = p.connectWithBind(profile.DN, func(conn LDAPConnection) (err error) {
+		errPolicy, err = p.bindUser(conn, profile, password)
+		return err
+	})
+
+	return conn, errPolicy, err
+}
+
+// connectWithBind creates a new connection bound with the bind function. The servers are tried in the order given by
+// the server selector until one of them accepts the connection, a bind refused by a server is returned without trying
+// the others.
+func (p *LDAPUserProvider) connectWithBind(userDN string, bind func(conn LDAPConnection) error) (conn LDAPConnection, err error) {
+	for _, server := range p.servers.Candidates() {
+		var serverErr bool
+
+		conn, serverErr, err = p.connectServer(server, bind)
+
+		switch {
+		case err == nil:
+			p.servers.Success(server)
+			p.logger.Debugf("LDAP server %s accepted the bind of %s", server.url, userDN)
+
+			return conn, nil
+		case !serverErr:
+			p.servers.Success(server)
+
 			return nil, err
 		}
+
+		p.servers.Failure(server)
+		p.logger.Warnf("Unable to connect to LDAP server %s: %v", server.url, err)
 	}
 
-	if err := conn.Bind(userDN, password); err != nil {
-		return nil, err
+	if err == nil {
+		err = errNoLDAPServer
 	}
 
-	return conn, nil
+	return nil, err
 }
 
-// CheckUserPassword checks if provided password matches for the given user.
-func (p *LDAPUserProvider) CheckUserPassword(inputUsername string, password string) (bool, error) {
-	conn, err := p.connect(p.configuration.User, p.configuration.Password)
+// connectServer creates a new connection to the server bound with the bind function, serverErr indicates the error is
+// a failure of the server rather than a refused bind.
+func (p *LDAPUserProvider) connectServer(server *ldapServer, bind func(conn LDAPConnection) error) (conn LDAPConnection, serverErr bool, err error) {
+	conn, err = p.connectionFactory.DialURL(server.url, server.dialOpts)
 	if err != nil {
-		return false, err
+		return nil, true, err
 	}
-	defer conn.Close()
 
-	profile, err := p.getUserProfile(conn, inputUsername)
-	if err != nil {
-		return false, err
+	if p.configuration.StartTLS {
+		if err = conn.StartTLS(server.tlsConfig); err != nil {
+			return nil, true, err
+		}
 	}
 
-	userConn, err := p.connect(profile.DN, password)
-	if err != nil {
-		return false, fmt.Errorf("Authentication of user %s failed. Cause: %s", inputUsername, err)
+	if err = bind(conn); err != nil {
+		return nil, isLDAPNetworkError(err), err
+	}
+
+	return conn, false, nil
+}
+
+// CheckUserPassword checks if provided password matches for the given user. A PasswordPolicyError is returned when the
+// server requires the password to be changed or refuses the account.
+func (p *LDAPUserProvider) CheckUserPassword(inputUsername string, password string) (bool, error) {
+	var errBind, errPolicy error
+
+	err := p.withServiceConnection(func(conn LDAPConnection) error {
+		profile, err := p.getUserProfile(conn, inputUsername)
+		if err != nil {
+			return err
+		}
+
+		// The user bind is made on a short-lived connection so the service connection stays bound with the LDAP user.
+		var userConn LDAPConnection
+
+		if userConn, errPolicy, errBind = p.connectUser(profile, password); errBind == nil {
+			userConn.Close()
+		}
+
+		return nil
+	})
+
+	switch {
+	case err != nil:
+		return false, err
+	case errPolicy != nil:
+		return false, errPolicy
+	case errBind != nil:
+		return false, fmt.Errorf("Authentication of user %s failed. Cause: %s", inputUsername, errBind)
 	}
-	defer userConn.Close()
 
 	return true, nil
 }
@@ -195,6 +310,9 @@ type ldapUserProfile struct {
 	Emails      []string
 	DisplayName string
 	Username    string
+	MemberOf    []string
+
+	Attributes map[string][]string
 }
 
 func (p *LDAPUserProvider) resolveUsersFilter(userFilter string, inputUsername string) string {
@@ -216,6 +334,14 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 		p.configuration.MailAttribute,
 		p.configuration.UsernameAttribute}
 
+	if p.configuration.GroupSearchMode == schema.LDAPGroupSearchModeMemberOf {
+		attributes = append(attributes, ldapMemberOfAttribute)
+	}
+
+	for _, attribute := range p.attributes {
+		attributes = append(attributes, attribute.LDAPAttribute)
+	}
+
 	// Search for the given username.
 	searchRequest := ldap.NewSearchRequest(
 		p.usersBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
@@ -224,7 +350,7 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 
 	sr, err := conn.Search(searchRequest)
 	if err != nil {
-		return nil, fmt.Errorf("Cannot find user DN of user %s. Cause: %s", inputUsername, err)
+		return nil, fmt.Errorf("Cannot find user DN of user %s. Cause: %w", inputUsername, err)
 	}
 
 	if len(sr.Entries) == 0 {
@@ -239,6 +365,16 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 		DN: sr.Entries[0].DN,
 	}
 
+	if len(p.attributes) != 0 {
+		userProfile.Attributes = make(map[string][]string, len(p.attributes))
+
+		for _, attribute := range p.attributes {
+			if values := sr.Entries[0].GetEqualFoldAttributeValues(attribute.LDAPAttribute); len(values) != 0 {
+				userProfile.Attributes[attribute.Name] = values
+			}
+		}
+	}
+
 	for _, attr := range sr.Entries[0].Attributes {
 		if attr.Name == p.configuration.DisplayNameAttribute {
 			userProfile.DisplayName = attr.Values[0]
@@ -256,6 +392,10 @@ func (p *LDAPUserProvider) getUserProfile(conn LDAPConnection, inputUsername str
 
 			userProfile.Username = attr.Values[0]
 		}
+
+		if strings.EqualFold(attr.Name, ldapMemberOfAttribute) {
+			userProfile.MemberOf = attr.Values
+		}
 	}
 
 	if userProfile.DN == "" {
@@ -282,44 +422,27 @@ func (p *LDAPUserProvider) resolveGroupsFilter(inputUsername string, profile *ld
 }
 
 // GetDetails retrieve the groups a user belongs to.
-func (p *LDAPUserProvider) GetDetails(inputUsername string) (*UserDetails, error) {
-	conn, err := p.connect(p.configuration.User, p.configuration.Password)
+func (p *LDAPUserProvider) GetDetails(inputUsername string) (details *UserDetails, err error) {
+	err = p.withServiceConnection(func(conn LDAPConnection) (err error) {
+		details, err = p.getDetails(conn, inputUsername)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
 
+	return details, nil
+}
+
+func (p *LDAPUserProvider) getDetails(conn LDAPConnection, inputUsername string) (*UserDetails, error) {
 	profile, err := p.getUserProfile(conn, inputUsername)
 	if err != nil {
 		return nil, err
 	}
 
-	groupsFilter, err := p.resolveGroupsFilter(inputUsername, profile)
+	groups, err := p.getGroups(conn, inputUsername, profile)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to create group filter for user %s. Cause: %s", inputUsername, err)
-	}
-
-	// Search for the given username.
-	searchGroupRequest := ldap.NewSearchRequest(
-		p.groupsBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
-		0, 0, false, groupsFilter, []string{p.configuration.GroupNameAttribute}, nil,
-	)
-
-	sr, err := conn.Search(searchGroupRequest)
-
-	if err != nil {
-		return nil, fmt.Errorf("Unable to retrieve groups of user %s. Cause: %s", inputUsername, err)
-	}
-
-	groups := make([]string, 0)
-
-	for _, res := range sr.Entries {
-		if len(res.Attributes) == 0 {
-			p.logger.Warningf("No groups retrieved from LDAP for user %s", inputUsername)
-			break
-		}
-		// Append all values of the document. Normally there should be only one per document.
-		groups = append(groups, res.Attributes[0].Values...)
+		return nil, err
 	}
 
 	return &UserDetails{
@@ -327,51 +450,95 @@ func (p *LDAPUserProvider) GetDetails(inputUsername string) (*UserDetails, error
 		DisplayName: profile.DisplayName,
 		Emails:      profile.Emails,
 		Groups:      groups,
+		Attributes:  profile.Attributes,
 	}, nil
 }
 
-// UpdatePassword update the password of the given user.
-func (p *LDAPUserProvider) UpdatePassword(inputUsername string, newPassword string) error {
-	conn, err := p.connect(p.configuration.User, p.configuration.Password)
+// UpdatePassword update the password of the given user. When the old password is given the user is bound to change
+// its own password so the password policy of the server applies, unless the server refuses the bind because the
+// password has expired in which case the password is changed by the LDAP user along with the old password.
+func (p *LDAPUserProvider) UpdatePassword(inputUsername string, oldPassword string, newPassword string) error {
+	err := p.withServiceConnection(func(conn LDAPConnection) error {
+		return p.updatePassword(conn, inputUsername, oldPassword, newPassword)
+	})
 	if err != nil {
-		return fmt.Errorf("Unable to update password. Cause: %s", err)
+		return fmt.Errorf("Unable to update password. Cause: %w", err)
 	}
-	defer conn.Close()
 
-	profile, err := p.getUserProfile(conn, inputUsername)
+	return nil
+}
 
+func (p *LDAPUserProvider) updatePassword(conn LDAPConnection, inputUsername string, oldPassword string, newPassword string) error {
+	profile, err := p.getUserProfile(conn, inputUsername)
 	if err != nil {
-		return fmt.Errorf("Unable to update password. Cause: %s", err)
+		return err
 	}
 
+	if oldPassword == "" {
+		return p.modifyPassword(conn, profile, "", newPassword)
+	}
+
+	userConn, errPolicy, err := p.connectUser(profile, oldPassword)
+
+	switch {
+	case err == nil:
+		defer userConn.Close()
+
+		return p.modifyPassword(userConn, profile, oldPassword, newPassword)
+	case IsPasswordChangeRequired(errPolicy):
+		return p.modifyPassword(conn, profile, oldPassword, newPassword)
+	case errPolicy != nil:
+		return errPolicy
+	}
+
+	return fmt.Errorf("Authentication of user %s failed. Cause: %s", inputUsername, err)
+}
+
+// modifyPassword sets the new password of the user. When the old password is given the old value is removed in the
+// same request so the server handles it as a change of the password checking the old password rather than a reset.
+func (p *LDAPUserProvider) modifyPassword(conn LDAPConnection, profile *ldapUserProfile, oldPassword string, newPassword string) (err error) {
 	switch {
 	case p.supportExtensionPasswdModify:
 		modifyRequest := ldap.NewPasswordModifyRequest(
 			profile.DN,
-			"",
+			oldPassword,
 			newPassword,
 		)
 
 		err = conn.PasswordModify(modifyRequest)
 	case p.configuration.Implementation == schema.LDAPImplementationActiveDirectory:
 		modifyRequest := ldap.NewModifyRequest(profile.DN, nil)
-		utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
-		// The password needs to be enclosed in quotes
-		// https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-adts/6e803168-f140-4d23-b2d3-c3a8ab5917d2
-		pwdEncoded, _ := utf16.NewEncoder().String(fmt.Sprintf("\"%s\"", newPassword))
-		modifyRequest.Replace("unicodePwd", []string{pwdEncoded})
+
+		if oldPassword == "" {
+			modifyRequest.Replace(ldapUnicodePasswordAttribute, []string{encodeActiveDirectoryPassword(newPassword)})
+		} else {
+			modifyRequest.Delete(ldapUnicodePasswordAttribute, []string{encodeActiveDirectoryPassword(oldPassword)})
+			modifyRequest.Add(ldapUnicodePasswordAttribute, []string{encodeActiveDirectoryPassword(newPassword)})
+		}
 
 		err = conn.Modify(modifyRequest)
 	default:
 		modifyRequest := ldap.NewModifyRequest(profile.DN, nil)
-		modifyRequest.Replace("userPassword", []string{newPassword})
+
+		if oldPassword == "" {
+			modifyRequest.Replace("userPassword", []string{newPassword})
+		} else {
+			modifyRequest.Delete("userPassword", []string{oldPassword})
+			modifyRequest.Add("userPassword", []string{newPassword})
+		}
 
 		err = conn.Modify(modifyRequest)
 	}
 
-	if err != nil {
-		return fmt.Errorf("Unable to update password. Cause: %s", err)
-	}
+	return err
+}
 
-	return nil
+// encodeActiveDirectoryPassword encodes the password as expected by the unicodePwd attribute.
+func encodeActiveDirectoryPassword(password string) string {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	// The password needs to be enclosed in quotes
+	// https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-adts/6e803168-f140-4d23-b2d3-c3a8ab5917d2
+	pwdEncoded, _ := utf16.NewEncoder().String(fmt.Sprintf("\"%s\"", password))
+
+	return pwdEncoded
 }
@@ -365,6 +365,24 @@ func (srm *SearchRequestMatcher) String() string {
 	return ""
 }
 
+type SimpleBindRequestMatcher struct {
+	username, password string
+}
+
+func NewSimpleBindRequestMatcher(username, password string) *SimpleBindRequestMatcher {
+	return &SimpleBindRequestMatcher{username, password}
+}
+
+func (sbrm *SimpleBindRequestMatcher) Matches(x interface{}) bool {
+	sbr := x.(*ldap.SimpleBindRequest)
+	return sbr.Username == sbrm.username && sbr.Password == sbrm.password &&
+		ldap.FindControl(sbr.Controls, ldap.ControlTypeBeheraPasswordPolicy) != nil
+}
+
+func (sbrm *SimpleBindRequestMatcher) String() string {
+	return fmt.Sprintf("is a simple bind of %s requesting the password policy control", sbrm.username)
+}
+
 func TestShouldEscapeUserInput(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -741,7 +759,7 @@ func TestShouldUpdateUserPasswordPasswdModifyExtension(t *testing.T) {
 	err := ldapClient.checkServer()
 	require.NoError(t, err)
 
-	err = ldapClient.UpdatePassword("john", "password")
+	err = ldapClient.UpdatePassword("john", "", "password")
 	require.NoError(t, err)
 }
 
@@ -847,7 +865,7 @@ func TestShouldUpdateUserPasswordActiveDirectory(t *testing.T) {
 	err := ldapClient.checkServer()
 	require.NoError(t, err)
 
-	err = ldapClient.UpdatePassword("john", "password")
+	err = ldapClient.UpdatePassword("john", "", "password")
 	require.NoError(t, err)
 }
 
@@ -950,7 +968,7 @@ func TestShouldUpdateUserPasswordBasic(t *testing.T) {
 	err := ldapClient.checkServer()
 	require.NoError(t, err)
 
-	err = ldapClient.UpdatePassword("john", "password")
+	err = ldapClient.UpdatePassword("john", "", "password")
 	require.NoError(t, err)
 }
 
@@ -1010,8 +1028,8 @@ func TestShouldCheckValidUserPassword(t *testing.T) {
 			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
 			Return(mockConn, nil),
 		mockConn.EXPECT().
-			Bind(gomock.Eq("uid=test,dc=example,dc=com"), gomock.Eq("password")).
-			Return(nil),
+			SimpleBind(NewSimpleBindRequestMatcher("uid=test,dc=example,dc=com", "password")).
+			Return(&ldap.SimpleBindResult{}, nil),
 		mockConn.EXPECT().Close().Times(2),
 	)
 
@@ -1077,8 +1095,8 @@ func TestShouldCheckInvalidUserPassword(t *testing.T) {
 			DialURL(gomock.Eq("ldap://127.0.0.1:389"), gomock.Any()).
 			Return(mockConn, nil),
 		mockConn.EXPECT().
-			Bind(gomock.Eq("uid=test,dc=example,dc=com"), gomock.Eq("password")).
-			Return(errors.New("Invalid username or password")),
+			SimpleBind(NewSimpleBindRequestMatcher("uid=test,dc=example,dc=com", "password")).
+			Return(&ldap.SimpleBindResult{}, errors.New("Invalid username or password")),
 		mockConn.EXPECT().Close(),
 	)
 
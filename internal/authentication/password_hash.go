@@ -9,11 +9,13 @@ import (
 
 	"github.com/simia-tech/crypt"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/utils"
 )
 
 // PasswordHash represents all characteristics of a password hash.
-// Authelia only supports salted SHA512 or salted argon2id method, i.e., $6$ mode or $argon2id$ mode.
+// Authelia only generates salted SHA512 or salted argon2id hashes, i.e., $6$ mode or $argon2id$ mode. It is however
+// able to verify bcrypt, scrypt and PBKDF2 hashes imported from other systems.
 type PasswordHash struct {
 	Algorithm   CryptAlgo
 	Iterations  int
@@ -22,6 +24,11 @@ type PasswordHash struct {
 	KeyLength   int
 	Memory      int
 	Parallelism int
+	BlockSize   int
+
+	encoded   string
+	saltBytes []byte
+	keyBytes  []byte
 }
 
 // ConfigAlgoToCryptoAlgo returns a CryptAlgo and nil error if valid, otherwise it returns argon2id and an error.
@@ -38,6 +45,17 @@ func ConfigAlgoToCryptoAlgo(fromConfig string) (CryptAlgo, error) {
 
 // ParseHash extracts all characteristics of a hash given its string representation.
 func ParseHash(hash string) (passwordHash *PasswordHash, err error) {
+	switch {
+	case strings.HasPrefix(hash, prefixBCryptA), strings.HasPrefix(hash, prefixBCryptB), strings.HasPrefix(hash, prefixBCryptY):
+		return parseBCryptHash(hash)
+	case strings.HasPrefix(hash, prefixScrypt):
+		return parseScryptHash(hash)
+	case strings.HasPrefix(hash, prefixPBKDF2):
+		return parsePBKDF2Hash(hash)
+	case strings.HasPrefix(hash, prefixDjangoPBKDF2):
+		return parseDjangoPBKDF2Hash(hash)
+	}
+
 	parts := strings.Split(hash, "$")
 
 	// This error can be ignored as it's always nil.
@@ -97,12 +115,57 @@ func ParseHash(hash string) (passwordHash *PasswordHash, err error) {
 			return nil, fmt.Errorf("Argon2id key length parameter (%d) does not match the actual key length (%d)", h.KeyLength, len(decodedKey))
 		}
 	default:
-		return nil, fmt.Errorf("Authelia only supports salted SHA512 hashing ($6$) and salted argon2id ($argon2id$), not $%s$", code)
+		return nil, fmt.Errorf("Authelia only supports salted SHA512 hashing ($6$), salted argon2id ($argon2id$), bcrypt ($2b$), scrypt ($scrypt$) and PBKDF2 ($pbkdf2-sha256$), not $%s$", code)
 	}
 
 	return h, nil
 }
 
+// IsWeakerThan returns true if the hash was generated with the configured algorithm but with parameters weaker than
+// the configured ones. Hashes using another algorithm are never considered weaker as they are not comparable.
+func (h *PasswordHash) IsWeakerThan(configuration *schema.PasswordConfiguration) bool {
+	algorithm, err := ConfigAlgoToCryptoAlgo(configuration.Algorithm)
+	if err != nil || h.Algorithm != algorithm {
+		return false
+	}
+
+	// The salts are compared in encoded characters since SHA512-crypt truncates the encoded salt.
+	saltLength := crypt.Base64Encoding.EncodedLen(configuration.SaltLength)
+	if h.Algorithm == HashingAlgorithmSHA512 && saltLength > hashingMaxSHA512SaltLength {
+		saltLength = hashingMaxSHA512SaltLength
+	}
+
+	if len(h.Salt) < saltLength {
+		return true
+	}
+
+	switch h.Algorithm {
+	case HashingAlgorithmArgon2id:
+		return h.Iterations < configuration.Iterations || h.Memory < configuration.Memory*1024 ||
+			h.Parallelism < configuration.Parallelism || h.KeyLength < configuration.KeyLength
+	case HashingAlgorithmSHA512:
+		return h.Iterations < configuration.Iterations
+	}
+
+	return false
+}
+
+// NeedsRehash returns true if a hash which successfully verified a password should be replaced by a hash generated
+// with the configuration, i.e. when its parameters are weaker than the configured ones or when it uses an algorithm
+// other than the configured one and the rehash option is enabled.
+func NeedsRehash(hash *PasswordHash, configuration *schema.PasswordConfiguration) bool {
+	algorithm, err := ConfigAlgoToCryptoAlgo(configuration.Algorithm)
+	if err != nil {
+		return false
+	}
+
+	if hash.Algorithm != algorithm {
+		return configuration.Rehash
+	}
+
+	return hash.IsWeakerThan(configuration)
+}
+
 // HashPassword generate a salt and hash the password with the salt and a constant number of rounds.
 func HashPassword(password, salt string, algorithm CryptAlgo, iterations, memory, parallelism, keyLength, saltLength int) (hash string, err error) {
 	var settings string
@@ -135,6 +198,20 @@ func HashPassword(password, salt string, algorithm CryptAlgo, iterations, memory
 	return hash, nil
 }
 
+// hashPasswordWithConfiguration hashes a password with a random salt using the algorithm and parameters of the
+// configuration.
+func hashPasswordWithConfiguration(password string, configuration *schema.PasswordConfiguration) (hash string, err error) {
+	algorithm, err := ConfigAlgoToCryptoAlgo(configuration.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	return HashPassword(
+		password, "", algorithm, configuration.Iterations,
+		configuration.Memory*1024, configuration.Parallelism,
+		configuration.KeyLength, configuration.SaltLength)
+}
+
 // CheckPassword check a password against a hash.
 func CheckPassword(password, hash string) (ok bool, err error) {
 	expectedHash, err := ParseHash(hash)
@@ -142,6 +219,13 @@ func CheckPassword(password, hash string) (ok bool, err error) {
 		return false, err
 	}
 
+	switch expectedHash.Algorithm {
+	case HashingAlgorithmBCrypt:
+		return checkBCryptPassword(password, expectedHash)
+	case HashingAlgorithmScrypt, HashingAlgorithmPBKDF2SHA256, HashingAlgorithmPBKDF2SHA512:
+		return checkDerivedKeyPassword(password, expectedHash)
+	}
+
 	passwordHashString, err := HashPassword(password, expectedHash.Salt, expectedHash.Algorithm, expectedHash.Iterations, expectedHash.Memory, expectedHash.Parallelism, expectedHash.KeyLength, len(expectedHash.Salt))
 	if err != nil {
 		return false, err
@@ -0,0 +1,220 @@
+package authentication
+
+import (
+	"crypto/sha256"
+	hashsha512 "crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The hashes in this file are never generated by Authelia, they are only verified so that users imported from other
+// systems (htpasswd, Django, passlib, etc.) are able to login without resetting their password.
+
+// adaptedBase64Encoding is the base64 variant used by passlib, it replaces + with . and omits padding.
+var adaptedBase64Encoding = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789./").WithPadding(base64.NoPadding)
+
+// parseBCryptHash parses a hash in the modular crypt format $2<a|b|y>$<cost>$<salt><key>.
+func parseBCryptHash(hash string) (passwordHash *PasswordHash, err error) {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return nil, fmt.Errorf("BCrypt hash is malformed (%s): %v", hash, err)
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || len(parts[3]) != 53 {
+		return nil, fmt.Errorf("BCrypt hash is malformed (%s)", hash)
+	}
+
+	return &PasswordHash{
+		Algorithm:  HashingAlgorithmBCrypt,
+		Iterations: cost,
+		Salt:       parts[3][:22],
+		Key:        parts[3][22:],
+		encoded:    hash,
+	}, nil
+}
+
+// parseScryptHash parses a hash in the passlib format $scrypt$ln=<log2 N>,r=<block size>,p=<parallelism>$<salt>$<key>.
+func parseScryptHash(hash string) (passwordHash *PasswordHash, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("Scrypt hash is malformed (%s)", hash)
+	}
+
+	h := &PasswordHash{
+		Algorithm: HashingAlgorithmScrypt,
+		Salt:      parts[3],
+		Key:       parts[4],
+	}
+
+	for _, parameter := range strings.Split(parts[2], ",") {
+		kv := strings.SplitN(parameter, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Scrypt parameter '%s' is malformed (%s)", parameter, hash)
+		}
+
+		value, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("Scrypt parameter '%s' is not numeric (%s)", kv[0], hash)
+		}
+
+		switch kv[0] {
+		case "ln":
+			h.Iterations = value
+		case "r":
+			h.BlockSize = value
+		case "p":
+			h.Parallelism = value
+		default:
+			return nil, fmt.Errorf("Scrypt parameter '%s' is unknown (%s)", kv[0], hash)
+		}
+	}
+
+	if h.Iterations < 1 || h.Iterations > scryptMaxCostFactor {
+		return nil, fmt.Errorf("Scrypt cost factor (ln) must be between 1 and %d (hash has %d)", scryptMaxCostFactor, h.Iterations)
+	}
+
+	if h.BlockSize < 1 || h.Parallelism < 1 {
+		return nil, fmt.Errorf("Scrypt block size (r) and parallelism (p) must be 1 or more (%s)", hash)
+	}
+
+	if err = h.decodeSaltAndKey(adaptedBase64Encoding); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// parsePBKDF2Hash parses a hash in the passlib format $pbkdf2-<digest>$<iterations>$<salt>$<key>.
+func parsePBKDF2Hash(hash string) (passwordHash *PasswordHash, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("PBKDF2 hash is malformed (%s)", hash)
+	}
+
+	h := &PasswordHash{
+		Algorithm: CryptAlgo(parts[1]),
+		Salt:      parts[3],
+		Key:       parts[4],
+	}
+
+	if h.Iterations, err = parsePBKDF2Iterations(parts[2]); err != nil {
+		return nil, err
+	}
+
+	if err = h.checkPBKDF2Algorithm(); err != nil {
+		return nil, err
+	}
+
+	if err = h.decodeSaltAndKey(adaptedBase64Encoding); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// parseDjangoPBKDF2Hash parses a hash in the Django format pbkdf2_<digest>$<iterations>$<salt>$<key> where the salt is
+// used verbatim and the key is encoded with padded standard base64.
+func parseDjangoPBKDF2Hash(hash string) (passwordHash *PasswordHash, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("PBKDF2 hash is malformed (%s)", hash)
+	}
+
+	h := &PasswordHash{
+		Algorithm: CryptAlgo(strings.Replace(parts[0], "_", "-", 1)),
+		Salt:      parts[2],
+		Key:       parts[3],
+		saltBytes: []byte(parts[2]),
+	}
+
+	if h.Iterations, err = parsePBKDF2Iterations(parts[1]); err != nil {
+		return nil, err
+	}
+
+	if err = h.checkPBKDF2Algorithm(); err != nil {
+		return nil, err
+	}
+
+	if h.Salt == "" {
+		return nil, fmt.Errorf("Hash salt contains no characters (%s)", hash)
+	}
+
+	if h.keyBytes, err = base64.StdEncoding.DecodeString(h.Key); err != nil || len(h.keyBytes) == 0 {
+		return nil, errors.New("Hash key contains invalid base64 characters")
+	}
+
+	h.KeyLength = len(h.keyBytes)
+
+	return h, nil
+}
+
+func parsePBKDF2Iterations(iterations string) (int, error) {
+	value, err := strconv.Atoi(iterations)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("PBKDF2 iterations must be a number greater than 0 (%s)", iterations)
+	}
+
+	return value, nil
+}
+
+func (h *PasswordHash) checkPBKDF2Algorithm() error {
+	if h.Algorithm != HashingAlgorithmPBKDF2SHA256 && h.Algorithm != HashingAlgorithmPBKDF2SHA512 {
+		return fmt.Errorf("PBKDF2 digest '%s' is not supported, only %s and %s are supported", h.Algorithm, HashingAlgorithmPBKDF2SHA256, HashingAlgorithmPBKDF2SHA512)
+	}
+
+	return nil
+}
+
+func (h *PasswordHash) decodeSaltAndKey(encoding *base64.Encoding) (err error) {
+	if h.saltBytes, err = encoding.DecodeString(h.Salt); err != nil || len(h.saltBytes) == 0 {
+		return errors.New("Salt contains invalid base64 characters")
+	}
+
+	if h.keyBytes, err = encoding.DecodeString(h.Key); err != nil || len(h.keyBytes) == 0 {
+		return errors.New("Hash key contains invalid base64 characters")
+	}
+
+	h.KeyLength = len(h.keyBytes)
+
+	return nil
+}
+
+func checkBCryptPassword(password string, hash *PasswordHash) (ok bool, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(hash.encoded), []byte(password))
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func checkDerivedKeyPassword(password string, hash *PasswordHash) (ok bool, err error) {
+	var key []byte
+
+	switch hash.Algorithm {
+	case HashingAlgorithmScrypt:
+		key, err = scrypt.Key([]byte(password), hash.saltBytes, 1<<hash.Iterations, hash.BlockSize, hash.Parallelism, hash.KeyLength)
+		if err != nil {
+			return false, err
+		}
+	case HashingAlgorithmPBKDF2SHA256:
+		key = pbkdf2.Key([]byte(password), hash.saltBytes, hash.Iterations, hash.KeyLength, sha256.New)
+	case HashingAlgorithmPBKDF2SHA512:
+		key = pbkdf2.Key([]byte(password), hash.saltBytes, hash.Iterations, hash.KeyLength, hashsha512.New)
+	}
+
+	return subtle.ConstantTimeCompare(key, hash.keyBytes) == 1, nil
+}
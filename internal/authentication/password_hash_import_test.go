@@ -0,0 +1,88 @@
+package authentication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBCryptHash       = "$2a$10$IQ5vqF9hJTfRYt4Nlk.4tedaRxymOQHK5PfpqtgrCbaVhpdUNBILi"
+	testScryptHash       = "$scrypt$ln=14,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$GM/8plVTNY2Jr5.H.TMEUW0SMD0/pCcA0XgAgW7i7jw"
+	testPBKDF2SHA512Hash = "$pbkdf2-sha512$25000$c2FsdHNhbHRzYWx0c2FsdA$EkdKHGe4sOjpcyqUxy0aCmgL/1yGsJKsXejSYKXhLRsX414emkfeDL2hb.MRorp2fvhEuJxaG4k7DcKp2EdJQA"
+	testDjangoPBKDF2Hash = "pbkdf2_sha256$260000$c2FsdHNhbHRzYWx0$YOS7ImdQNfamRlWW3FeJSwo/dmp62o+R+IAsDRfzRiM="
+)
+
+func TestShouldCheckImportedPasswordHashes(t *testing.T) {
+	testCases := []struct {
+		name, hash string
+		algorithm  CryptAlgo
+	}{
+		{"BCrypt2a", testBCryptHash, HashingAlgorithmBCrypt},
+		{"BCrypt2b", "$2b$" + testBCryptHash[4:], HashingAlgorithmBCrypt},
+		{"BCrypt2y", "$2y$" + testBCryptHash[4:], HashingAlgorithmBCrypt},
+		{"Scrypt", testScryptHash, HashingAlgorithmScrypt},
+		{"PBKDF2SHA512", testPBKDF2SHA512Hash, HashingAlgorithmPBKDF2SHA512},
+		{"DjangoPBKDF2SHA256", testDjangoPBKDF2Hash, HashingAlgorithmPBKDF2SHA256},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := ParseHash(tc.hash)
+			require.NoError(t, err)
+			assert.Equal(t, tc.algorithm, hash.Algorithm)
+
+			ok, err := CheckPassword("password", tc.hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = CheckPassword("wrong_password", tc.hash)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestShouldParseImportedPasswordHashParameters(t *testing.T) {
+	hash, err := ParseHash(testBCryptHash)
+	require.NoError(t, err)
+	assert.Equal(t, 10, hash.Iterations)
+	assert.Equal(t, "IQ5vqF9hJTfRYt4Nlk.4te", hash.Salt)
+
+	hash, err = ParseHash(testScryptHash)
+	require.NoError(t, err)
+	assert.Equal(t, 14, hash.Iterations)
+	assert.Equal(t, 8, hash.BlockSize)
+	assert.Equal(t, 1, hash.Parallelism)
+	assert.Equal(t, 32, hash.KeyLength)
+
+	hash, err = ParseHash(testPBKDF2SHA512Hash)
+	require.NoError(t, err)
+	assert.Equal(t, 25000, hash.Iterations)
+	assert.Equal(t, 64, hash.KeyLength)
+}
+
+func TestShouldNotParseMalformedImportedPasswordHashes(t *testing.T) {
+	testCases := []struct {
+		name, hash, err string
+	}{
+		{"BCryptTruncated", "$2b$10$IQ5vqF9hJTfRYt4Nlk", "BCrypt hash is malformed ($2b$10$IQ5vqF9hJTfRYt4Nlk): crypto/bcrypt: hashedSecret too short to be a bcrypted password"},
+		{"ScryptMissingKey", "$scrypt$ln=14,r=8,p=1$c2FsdA", "Scrypt hash is malformed ($scrypt$ln=14,r=8,p=1$c2FsdA)"},
+		{"ScryptUnknownParameter", "$scrypt$ln=14,r=8,x=1$c2FsdA$c2FsdA", "Scrypt parameter 'x' is unknown ($scrypt$ln=14,r=8,x=1$c2FsdA$c2FsdA)"},
+		{"ScryptCostTooHigh", "$scrypt$ln=30,r=8,p=1$c2FsdA$c2FsdA", "Scrypt cost factor (ln) must be between 1 and 24 (hash has 30)"},
+		{"ScryptBadSalt", "$scrypt$ln=14,r=8,p=1$^^$c2FsdA", "Salt contains invalid base64 characters"},
+		{"PBKDF2UnknownDigest", "$pbkdf2-md5$1000$c2FsdA$c2FsdA", "PBKDF2 digest 'pbkdf2-md5' is not supported, only pbkdf2-sha256 and pbkdf2-sha512 are supported"},
+		{"PBKDF2BadIterations", "$pbkdf2-sha256$abc$c2FsdA$c2FsdA", "PBKDF2 iterations must be a number greater than 0 (abc)"},
+		{"DjangoBadKey", "pbkdf2_sha256$1000$salt$^^", "Hash key contains invalid base64 characters"},
+		{"DjangoUnknownDigest", "pbkdf2_sha1$1000$salt$c2FsdA==", "PBKDF2 digest 'pbkdf2-sha1' is not supported, only pbkdf2-sha256 and pbkdf2-sha512 are supported"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := CheckPassword("password", tc.hash)
+			assert.EqualError(t, err, tc.err)
+			assert.False(t, ok)
+		})
+	}
+}
@@ -256,7 +256,7 @@ func TestCannotParseArgon2idHash(t *testing.T) {
 func TestOnlySupportSHA512AndArgon2id(t *testing.T) {
 	ok, err := CheckPassword("password", "$8$rounds=50000$aFr56HjK3DrB8t3S$zhPQiS85cgBlNhUKKE6n/AHMlpqrvYSnSL3fEVkK0yHFQ.oFFAd8D4OhPAy18K5U61Z2eBhxQXExGU/eknXlY1")
 
-	assert.EqualError(t, err, "Authelia only supports salted SHA512 hashing ($6$) and salted argon2id ($argon2id$), not $8$")
+	assert.EqualError(t, err, "Authelia only supports salted SHA512 hashing ($6$), salted argon2id ($argon2id$), bcrypt ($2b$), scrypt ($scrypt$) and PBKDF2 ($pbkdf2-sha256$), not $8$")
 	assert.False(t, ok)
 }
 
@@ -322,3 +322,62 @@ func TestShouldCheckPasswordSHA512HashedWithAuthelia(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, equal)
 }
+
+func TestShouldDetermineIfHashIsWeakerThanConfiguration(t *testing.T) {
+	argon2idHash := "$argon2id$v=19$m=65536,t=3,p=2$BpLnfgDsc2WD8F2q$o/vzA4myCqZZ36bUGsDY//8mKUYNZZaR0t4MFFSs+iM"
+	sha512Hash := "$6$rounds=50000$aFr56HjK3DrB8t3S$zhPQiS85cgBlNhUKKE6n/AHMlpqrvYSnSL3fEVkK0yHFQ.oFFAd8D4OhPAy18K5U61Z2eBhxQXExGU/eknXlY1"
+
+	testCases := []struct {
+		name          string
+		hash          string
+		configuration schema.PasswordConfiguration
+		expected      bool
+	}{
+		{"Argon2idEqual", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 3, Memory: 64, Parallelism: 2, KeyLength: 32, SaltLength: 12}, false},
+		{"Argon2idStronger", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 1, Memory: 32, Parallelism: 1, KeyLength: 16, SaltLength: 8}, false},
+		{"Argon2idWeakerIterations", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 4, Memory: 64, Parallelism: 2, KeyLength: 32, SaltLength: 12}, true},
+		{"Argon2idWeakerMemory", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 3, Memory: 128, Parallelism: 2, KeyLength: 32, SaltLength: 12}, true},
+		{"Argon2idWeakerParallelism", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 3, Memory: 64, Parallelism: 4, KeyLength: 32, SaltLength: 12}, true},
+		{"Argon2idWeakerKeyLength", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 3, Memory: 64, Parallelism: 2, KeyLength: 64, SaltLength: 12}, true},
+		{"Argon2idWeakerSaltLength", argon2idHash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 3, Memory: 64, Parallelism: 2, KeyLength: 32, SaltLength: 16}, true},
+		{"SHA512Equal", sha512Hash, schema.PasswordConfiguration{Algorithm: sha512, Iterations: 50000, SaltLength: 12}, false},
+		{"SHA512EqualTruncatedSaltLength", sha512Hash, schema.PasswordConfiguration{Algorithm: sha512, Iterations: 50000, SaltLength: 32}, false},
+		{"SHA512WeakerIterations", sha512Hash, schema.PasswordConfiguration{Algorithm: sha512, Iterations: 100000, SaltLength: 12}, true},
+		{"DifferentAlgorithm", sha512Hash, schema.PasswordConfiguration{Algorithm: argon2id, Iterations: 100, Memory: 1024, Parallelism: 8, KeyLength: 64, SaltLength: 16}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := ParseHash(tc.hash)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, hash.IsWeakerThan(&tc.configuration))
+		})
+	}
+}
+
+func TestShouldNotRehashFreshlyGeneratedHash(t *testing.T) {
+	configurations := map[string]schema.PasswordConfiguration{
+		"Argon2idDefault": schema.DefaultPasswordConfiguration,
+		"SHA512Default":   schema.DefaultPasswordSHA512Configuration,
+		"Argon2idSalt8":   {Algorithm: argon2id, Iterations: 1, Memory: 64, Parallelism: 2, KeyLength: 32, SaltLength: 8},
+		"Argon2idSalt32":  {Algorithm: argon2id, Iterations: 1, Memory: 64, Parallelism: 2, KeyLength: 32, SaltLength: 32},
+		"SHA512Salt8":     {Algorithm: sha512, Iterations: 5000, SaltLength: 8},
+		"SHA512Salt12":    {Algorithm: sha512, Iterations: 5000, SaltLength: 12},
+		"SHA512Salt32":    {Algorithm: sha512, Iterations: 5000, SaltLength: 32},
+	}
+
+	for name, configuration := range configurations {
+		configuration := configuration
+
+		t.Run(name, func(t *testing.T) {
+			hashed, err := hashPasswordWithConfiguration("password", &configuration)
+			require.NoError(t, err)
+
+			hash, err := ParseHash(hashed)
+			require.NoError(t, err)
+
+			assert.False(t, NeedsRehash(hash, &configuration))
+		})
+	}
+}
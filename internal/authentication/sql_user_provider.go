@@ -0,0 +1,217 @@
+package authentication
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/logging"
+)
+
+// SQLUserProvider is a provider using a SQL database as a user database.
+type SQLUserProvider struct {
+	configuration *schema.SQLAuthenticationBackendConfiguration
+	db            *sql.DB
+	logger        *logrus.Logger
+}
+
+// NewSQLUserProvider creates a new instance of SQLUserProvider using the given database handle.
+func NewSQLUserProvider(configuration *schema.SQLAuthenticationBackendConfiguration, db *sql.DB) (provider *SQLUserProvider, err error) {
+	provider = newSQLUserProvider(configuration, db)
+
+	if err = db.Ping(); err != nil {
+		return provider, fmt.Errorf("Unable to connect to SQL database: %v", err)
+	}
+
+	return provider, nil
+}
+
+func newSQLUserProvider(configuration *schema.SQLAuthenticationBackendConfiguration, db *sql.DB) *SQLUserProvider {
+	return &SQLUserProvider{
+		configuration: configuration,
+		db:            db,
+		logger:        logging.Logger(),
+	}
+}
+
+// CheckUserPassword checks if provided password matches for the given user.
+func (p *SQLUserProvider) CheckUserPassword(username string, password string) (bool, error) {
+	var hash string
+
+	err := p.db.QueryRow(p.configuration.Queries.Password, username).Scan(&hash)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, ErrUserNotFound
+	case err != nil:
+		return false, fmt.Errorf("Unable to retrieve password hash of user %s: %v", username, err)
+	}
+
+	ok, err := CheckPassword(password, hash)
+	if err != nil {
+		return false, err
+	}
+
+	if ok {
+		p.rehashPassword(username, password, hash)
+	}
+
+	return ok, nil
+}
+
+// rehashPassword replaces the hash of a user which was just successfully authenticated when required by the password
+// configuration. Failures are logged but never prevent the user from logging in.
+func (p *SQLUserProvider) rehashPassword(username, password, hash string) {
+	if p.configuration.Queries.UpdatePassword == "" {
+		return
+	}
+
+	current, err := ParseHash(hash)
+	if err != nil || !NeedsRehash(current, p.configuration.Password) {
+		return
+	}
+
+	p.logger.Debugf("Rehashing password of user %s as the stored %s hash doesn't match the configured parameters", username, current.Algorithm)
+
+	if err = p.UpdatePassword(username, "", password); err != nil {
+		p.logger.Errorf("Unable to rehash password of user %s: %v", username, err)
+	}
+}
+
+// GetDetails retrieve the groups a user belongs to.
+func (p *SQLUserProvider) GetDetails(username string) (*UserDetails, error) {
+	details := &UserDetails{}
+
+	err := p.db.QueryRow(p.configuration.Queries.Details, username).Scan(&details.Username, &details.DisplayName)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrUserNotFound
+	case err != nil:
+		return nil, fmt.Errorf("Unable to retrieve details of user %s: %v", username, err)
+	}
+
+	if details.Emails, err = p.queryStrings(p.configuration.Queries.Emails, username); err != nil {
+		return nil, fmt.Errorf("Unable to retrieve emails of user %s: %v", username, err)
+	}
+
+	if details.Groups, err = p.queryStrings(p.configuration.Queries.Groups, username); err != nil {
+		return nil, fmt.Errorf("Unable to retrieve groups of user %s: %v", username, err)
+	}
+
+	return details, nil
+}
+
+// UpdatePassword update the password of the given user.
+func (p *SQLUserProvider) UpdatePassword(username string, oldPassword string, newPassword string) error {
+	if oldPassword != "" {
+		ok, err := p.CheckUserPassword(username, oldPassword)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return ErrIncorrectPassword
+		}
+	}
+
+	hash, err := hashPasswordWithConfiguration(newPassword, p.configuration.Password)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.db.Exec(p.configuration.Queries.UpdatePassword, hash, username)
+	if err != nil {
+		return fmt.Errorf("Unable to update password. Cause: %v", err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// HashPassword hashes the password with the password configuration of the SQL backend.
+func (p *SQLUserProvider) HashPassword(password string) (string, error) {
+	return hashPasswordWithConfiguration(password, p.configuration.Password)
+}
+
+// CreateUser inserts the user, its emails and its groups in a single transaction.
+func (p *SQLUserProvider) CreateUser(details UserDetails, passwordHash string) error {
+	if _, err := p.GetDetails(details.Username); err == nil {
+		return ErrUserAlreadyExists
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Unable to create user %s: %v", details.Username, err)
+	}
+
+	if err = p.createUser(tx, details, passwordHash); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			p.logger.Errorf("Unable to rollback the creation of user %s: %v", details.Username, rollbackErr)
+		}
+
+		return fmt.Errorf("Unable to create user %s: %v", details.Username, err)
+	}
+
+	return tx.Commit()
+}
+
+func (p *SQLUserProvider) createUser(tx *sql.Tx, details UserDetails, passwordHash string) error {
+	if _, err := tx.Exec(p.configuration.Queries.CreateUser, details.Username, details.DisplayName, passwordHash); err != nil {
+		return err
+	}
+
+	if p.configuration.Queries.CreateEmail != "" {
+		for _, email := range details.Emails {
+			if _, err := tx.Exec(p.configuration.Queries.CreateEmail, details.Username, email); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.configuration.Queries.CreateGroup != "" {
+		for _, group := range details.Groups {
+			if _, err := tx.Exec(p.configuration.Queries.CreateGroup, details.Username, group); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// queryStrings runs an optional query taking the username as parameter and returns the first column of every row.
+func (p *SQLUserProvider) queryStrings(query, username string) (values []string, err error) {
+	values = make([]string, 0)
+
+	if query == "" {
+		return values, nil
+	}
+
+	rows, err := p.db.Query(query, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var value string
+
+	for rows.Next() {
+		if err = rows.Scan(&value); err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
@@ -0,0 +1,270 @@
+package authentication
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+const (
+	testSQLQueryPassword       = "SELECT password FROM users WHERE username=?"
+	testSQLQueryDetails        = "SELECT username, display_name FROM users WHERE username=?"
+	testSQLQueryEmails         = "SELECT email FROM user_emails WHERE username=?"
+	testSQLQueryGroups         = "SELECT group_name FROM user_groups WHERE username=?"
+	testSQLQueryUpdatePassword = "UPDATE users SET password=? WHERE username=?"
+	testSQLQueryCreateUser     = "INSERT INTO users (username, display_name, password) VALUES (?, ?, ?)"
+	testSQLQueryCreateEmail    = "INSERT INTO user_emails (username, email) VALUES (?, ?)"
+	testSQLQueryCreateGroup    = "INSERT INTO user_groups (username, group_name) VALUES (?, ?)"
+)
+
+func newTestSQLUserProvider(t *testing.T) (*SQLUserProvider, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	password := schema.DefaultCIPasswordConfiguration
+
+	provider := newSQLUserProvider(&schema.SQLAuthenticationBackendConfiguration{
+		Password: &password,
+		Queries: schema.SQLAuthenticationBackendQueries{
+			Password:       testSQLQueryPassword,
+			Details:        testSQLQueryDetails,
+			Emails:         testSQLQueryEmails,
+			Groups:         testSQLQueryGroups,
+			UpdatePassword: testSQLQueryUpdatePassword,
+			CreateUser:     testSQLQueryCreateUser,
+			CreateEmail:    testSQLQueryCreateEmail,
+			CreateGroup:    testSQLQueryCreateGroup,
+		},
+	}, db)
+
+	return provider, mock
+}
+
+func TestSQLShouldCheckUserPassword(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectQuery(testSQLQueryPassword).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(testBCryptHash))
+
+	ok, err := provider.CheckUserPassword("john", "wrong_password")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// The hash uses another algorithm and the rehash option is disabled so no update is expected.
+	mock.ExpectQuery(testSQLQueryPassword).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(testBCryptHash))
+
+	ok, err = provider.CheckUserPassword("john", "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldRehashPasswordWithAnotherAlgorithmWhenEnabled(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+	provider.configuration.Password.Rehash = true
+
+	mock.ExpectQuery(testSQLQueryPassword).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(testBCryptHash))
+	mock.ExpectExec(testSQLQueryUpdatePassword).
+		WithArgs(passwordHashArgument("password"), "john").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ok, err := provider.CheckUserPassword("john", "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldReturnUserNotFound(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectQuery(testSQLQueryPassword).
+		WithArgs("fake").
+		WillReturnRows(sqlmock.NewRows([]string{"password"}))
+	mock.ExpectQuery(testSQLQueryDetails).
+		WithArgs("fake").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "display_name"}))
+	mock.ExpectExec(testSQLQueryUpdatePassword).
+		WithArgs(sqlmock.AnyArg(), "fake").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := provider.CheckUserPassword("fake", "password")
+	assert.EqualError(t, err, "user not found")
+	assert.False(t, ok)
+
+	details, err := provider.GetDetails("fake")
+	assert.EqualError(t, err, "user not found")
+	assert.Nil(t, details)
+
+	err = provider.UpdatePassword("fake", "", "password")
+	assert.EqualError(t, err, "user not found")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldReturnErrorOnQueryFailure(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectQuery(testSQLQueryPassword).
+		WithArgs("john").
+		WillReturnError(errors.New("connection refused"))
+
+	ok, err := provider.CheckUserPassword("john", "password")
+	assert.EqualError(t, err, "Unable to retrieve password hash of user john: connection refused")
+	assert.False(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldRetrieveUserDetails(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectQuery(testSQLQueryDetails).
+		WithArgs("John").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "display_name"}).AddRow("john", "John Doe"))
+	mock.ExpectQuery(testSQLQueryEmails).
+		WithArgs("John").
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("john.doe@authelia.com").AddRow("jdoe@authelia.com"))
+	mock.ExpectQuery(testSQLQueryGroups).
+		WithArgs("John").
+		WillReturnRows(sqlmock.NewRows([]string{"group_name"}).AddRow("admins").AddRow("dev"))
+
+	details, err := provider.GetDetails("John")
+	require.NoError(t, err)
+
+	assert.Equal(t, "john", details.Username)
+	assert.Equal(t, "John Doe", details.DisplayName)
+	assert.Equal(t, []string{"john.doe@authelia.com", "jdoe@authelia.com"}, details.Emails)
+	assert.Equal(t, []string{"admins", "dev"}, details.Groups)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldRetrieveUserDetailsWithoutOptionalQueries(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+	provider.configuration.Queries.Emails = ""
+	provider.configuration.Queries.Groups = ""
+
+	mock.ExpectQuery(testSQLQueryDetails).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "display_name"}).AddRow("john", "John Doe"))
+
+	details, err := provider.GetDetails("john")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{}, details.Emails)
+	assert.Equal(t, []string{}, details.Groups)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldUpdatePassword(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectExec(testSQLQueryUpdatePassword).
+		WithArgs(passwordHashArgument("newpassword"), "john").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := provider.UpdatePassword("john", "", "newpassword")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldCreateUser(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	hash, err := provider.HashPassword("password")
+	require.NoError(t, err)
+
+	mock.ExpectQuery(testSQLQueryDetails).
+		WithArgs("bob").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "display_name"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(testSQLQueryCreateUser).
+		WithArgs("bob", "Bob Dylan", passwordHashArgument("password")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(testSQLQueryCreateEmail).
+		WithArgs("bob", "bob@example.com").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(testSQLQueryCreateGroup).
+		WithArgs("bob", "users").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = provider.CreateUser(UserDetails{
+		Username:    "bob",
+		DisplayName: "Bob Dylan",
+		Emails:      []string{"bob@example.com"},
+		Groups:      []string{"users"},
+	}, hash)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldRollbackUserCreationOnFailure(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectQuery(testSQLQueryDetails).
+		WithArgs("bob").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "display_name"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(testSQLQueryCreateUser).
+		WithArgs("bob", "Bob Dylan", "hash").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(testSQLQueryCreateEmail).
+		WithArgs("bob", "bob@example.com").
+		WillReturnError(errors.New("duplicate email"))
+	mock.ExpectRollback()
+
+	err := provider.CreateUser(UserDetails{
+		Username:    "bob",
+		DisplayName: "Bob Dylan",
+		Emails:      []string{"bob@example.com"},
+	}, "hash")
+	assert.EqualError(t, err, "Unable to create user bob: duplicate email")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLShouldNotCreateExistingUser(t *testing.T) {
+	provider, mock := newTestSQLUserProvider(t)
+
+	mock.ExpectQuery(testSQLQueryDetails).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "display_name"}).AddRow("john", "John Doe"))
+	mock.ExpectQuery(testSQLQueryEmails).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"email"}))
+	mock.ExpectQuery(testSQLQueryGroups).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"group_name"}))
+
+	err := provider.CreateUser(UserDetails{Username: "john"}, "hash")
+	assert.Equal(t, ErrUserAlreadyExists, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// passwordHashArgument matches a query argument which is a valid hash of the password.
+type passwordHashArgument string
+
+func (a passwordHashArgument) Match(value driver.Value) bool {
+	hash, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	ok, err := CheckPassword(string(a), hash)
+
+	return err == nil && ok
+}
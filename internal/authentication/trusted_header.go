@@ -0,0 +1,135 @@
+package authentication
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// TrustedHeaderVerifier verifies the username forwarded in a header by an upstream gateway which already authenticated
+// the user.
+type TrustedHeaderVerifier struct {
+	header          string
+	signatureHeader string
+	secret          []byte
+	lifetime        time.Duration
+	level           Level
+
+	trustedNetworks []*net.IPNet
+	clock           utils.Clock
+}
+
+// NewTrustedHeaderVerifier creates a verifier trusting the header sent by the gateways of the trusted networks.
+func NewTrustedHeaderVerifier(configuration schema.TrustedHeaderConfiguration, clock utils.Clock) (*TrustedHeaderVerifier, error) {
+	verifier := &TrustedHeaderVerifier{
+		header:          configuration.Header,
+		signatureHeader: configuration.SignatureHeader,
+		level:           OneFactor,
+		clock:           clock,
+	}
+
+	if configuration.Secret != "" {
+		lifetime, err := utils.ParseDurationString(configuration.SignatureLifetime)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse the trusted header signature lifetime %s: %w", configuration.SignatureLifetime, err)
+		}
+
+		verifier.secret = []byte(configuration.Secret)
+		verifier.lifetime = lifetime
+	}
+
+	if configuration.AuthenticationLevel == schema.AuthenticationLevelTwoFactor {
+		verifier.level = TwoFactor
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		ipNet, err := utils.ParseNetwork(network)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse the trusted header trusted network %s: %w", network, err)
+		}
+
+		verifier.trustedNetworks = append(verifier.trustedNetworks, ipNet)
+	}
+
+	return verifier, nil
+}
+
+// Header returns the name of the header the gateways forward the username in.
+func (v *TrustedHeaderVerifier) Header() string {
+	return v.header
+}
+
+// SignatureHeader returns the name of the header the gateways forward the signature of the username in.
+func (v *TrustedHeaderVerifier) SignatureHeader() string {
+	return v.signatureHeader
+}
+
+// Level returns the authentication level of the users authenticated with the trusted header.
+func (v *TrustedHeaderVerifier) Level() Level {
+	return v.level
+}
+
+// Verify returns the username forwarded by the gateway once it checked the request has been sent by a trusted gateway
+// and, when a secret is configured, that the signature is valid. The signature has the form <expiry>:<mac> where expiry
+// is a unix timestamp and mac the hex encoded HMAC-SHA256 of <expiry>:<username>, so a captured signature is only
+// accepted until it expires and never for longer than the signature lifetime.
+func (v *TrustedHeaderVerifier) Verify(remoteIP net.IP, username, signature []byte) (string, error) {
+	if !utils.IsIPInNetworks(remoteIP, v.trustedNetworks) {
+		return "", fmt.Errorf("Trusted header %s has been sent by %s which is not a trusted gateway", v.header, remoteIP)
+	}
+
+	if v.secret != nil {
+		if err := v.verifySignature(username, string(signature)); err != nil {
+			return "", err
+		}
+	}
+
+	return string(username), nil
+}
+
+func (v *TrustedHeaderVerifier) verifySignature(username []byte, signature string) error {
+	parts := strings.SplitN(signature, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("The signature of the trusted header %s is malformed", v.header)
+	}
+
+	timestamp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("Unable to parse the expiry of the signature of the trusted header %s: %w", v.header, err)
+	}
+
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("Unable to decode the signature of the trusted header %s: %w", v.header, err)
+	}
+
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(parts[0]))
+	mac.Write([]byte(":"))
+	mac.Write(username)
+
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("The signature of the trusted header %s does not match the username %s", v.header, username)
+	}
+
+	now := v.clock.Now()
+	expiry := time.Unix(timestamp, 0)
+
+	if !now.Before(expiry) {
+		return fmt.Errorf("The signature of the trusted header %s has expired at %s", v.header, expiry)
+	}
+
+	if expiry.Sub(now) > v.lifetime {
+		return fmt.Errorf("The signature of the trusted header %s expires at %s which exceeds the lifetime of %s", v.header, expiry, v.lifetime)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package authentication
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldVerifyTrustedHeaderFromTrustedGateway(t *testing.T) {
+	verifier, err := NewTrustedHeaderVerifier(schema.TrustedHeaderConfiguration{
+		Header:              "X-Authenticated-User",
+		TrustedNetworks:     []string{"10.0.0.0/8", "192.168.1.1"},
+		AuthenticationLevel: schema.AuthenticationLevelTwoFactor,
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, TwoFactor, verifier.Level())
+
+	username, err := verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+
+	username, err = verifier.Verify(net.ParseIP("192.168.1.1"), []byte("john"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+
+	_, err = verifier.Verify(net.ParseIP("192.168.1.2"), []byte("john"), nil)
+	assert.EqualError(t, err, "Trusted header X-Authenticated-User has been sent by 192.168.1.2 which is not a trusted gateway")
+}
+
+func signTrustedHeader(secret, username string, expiry time.Time) []byte {
+	timestamp := fmt.Sprintf("%d", expiry.Unix())
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + ":" + username))
+
+	return []byte(timestamp + ":" + hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestShouldVerifySignatureOfTrustedHeader(t *testing.T) {
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+
+	verifier, err := NewTrustedHeaderVerifier(schema.TrustedHeaderConfiguration{
+		Header:            "X-Authenticated-User",
+		SignatureHeader:   "X-Authenticated-User-Signature",
+		TrustedNetworks:   []string{"10.0.0.0/8"},
+		Secret:            "a_secret",
+		SignatureLifetime: "1m",
+	}, clock)
+	require.NoError(t, err)
+
+	assert.Equal(t, OneFactor, verifier.Level())
+
+	signature := signTrustedHeader("a_secret", "john", clock.now.Add(30*time.Second))
+
+	username, err := verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), signature)
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("harry"), signature)
+	assert.EqualError(t, err, "The signature of the trusted header X-Authenticated-User does not match the username harry")
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), signTrustedHeader("other_secret", "john", clock.now.Add(30*time.Second)))
+	assert.EqualError(t, err, "The signature of the trusted header X-Authenticated-User does not match the username john")
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), []byte("1600000030:not hex"))
+	assert.Error(t, err)
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), []byte("tomorrow:abcd"))
+	assert.Error(t, err)
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), nil)
+	assert.EqualError(t, err, "The signature of the trusted header X-Authenticated-User is malformed")
+}
+
+func TestShouldRejectStaleSignatureOfTrustedHeader(t *testing.T) {
+	clock := &poolTestClock{now: time.Unix(1600000000, 0)}
+
+	verifier, err := NewTrustedHeaderVerifier(schema.TrustedHeaderConfiguration{
+		Header:            "X-Authenticated-User",
+		TrustedNetworks:   []string{"10.0.0.0/8"},
+		Secret:            "a_secret",
+		SignatureLifetime: "1m",
+	}, clock)
+	require.NoError(t, err)
+
+	signature := signTrustedHeader("a_secret", "john", clock.now.Add(30*time.Second))
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), signature)
+	require.NoError(t, err)
+
+	// A captured signature is rejected once it expired.
+	clock.now = clock.now.Add(30 * time.Second)
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), signature)
+	assert.EqualError(t, err, fmt.Sprintf("The signature of the trusted header X-Authenticated-User has expired at %s", clock.now))
+
+	// A signature can't be valid for longer than the lifetime.
+	expiry := clock.now.Add(time.Hour)
+
+	_, err = verifier.Verify(net.ParseIP("10.1.2.3"), []byte("john"), signTrustedHeader("a_secret", "john", expiry))
+	assert.EqualError(t, err, fmt.Sprintf("The signature of the trusted header X-Authenticated-User expires at %s which exceeds the lifetime of 1m0s", expiry))
+}
+
+func TestShouldFailToCreateTrustedHeaderVerifierWithInvalidNetwork(t *testing.T) {
+	_, err := NewTrustedHeaderVerifier(schema.TrustedHeaderConfiguration{TrustedNetworks: []string{"10.0.0.0/33"}}, nil)
+	assert.EqualError(t, err, "Unable to parse the trusted header trusted network 10.0.0.0/33: invalid CIDR address: 10.0.0.0/33")
+}
+
+func TestShouldFailToCreateTrustedHeaderVerifierWithInvalidSignatureLifetime(t *testing.T) {
+	_, err := NewTrustedHeaderVerifier(schema.TrustedHeaderConfiguration{Secret: "a_secret", SignatureLifetime: "forever"}, nil)
+	assert.EqualError(t, err, "Unable to parse the trusted header signature lifetime forever: could not convert the input string of forever into a duration")
+}
@@ -1,9 +1,48 @@
 package authentication
 
+import (
+	"errors"
+	"fmt"
+)
+
 // UserDetails represent the details retrieved for a given user.
 type UserDetails struct {
 	Username    string
 	DisplayName string
 	Emails      []string
 	Groups      []string
+
+	// Attributes are the additional attributes of the user indexed by the name configured in the user attributes.
+	Attributes map[string][]string
+}
+
+// PasswordPolicyError is returned when the password of the user is correct but the password policy of the
+// authentication backend requires the user to change it before signing in, or refuses the account.
+type PasswordPolicyError struct {
+	Username string
+
+	// Err is one of ErrPasswordExpired, ErrPasswordMustChange or ErrAccountLocked.
+	Err error
+
+	// GraceLogins is the number of remaining authentications allowed with the expired password, it's negative when
+	// the user didn't authenticate with a grace login.
+	GraceLogins int
+}
+
+func (e *PasswordPolicyError) Error() string {
+	if e.GraceLogins >= 0 {
+		return fmt.Sprintf("Password policy of user %s: %v (%d grace logins remaining)", e.Username, e.Err, e.GraceLogins)
+	}
+
+	return fmt.Sprintf("Password policy of user %s: %v", e.Username, e.Err)
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return e.Err
+}
+
+// IsPasswordChangeRequired returns true when the error indicates the password of the user is correct but must be
+// changed before the user can sign in.
+func IsPasswordChangeRequired(err error) bool {
+	return errors.Is(err, ErrPasswordExpired) || errors.Is(err, ErrPasswordMustChange)
 }
@@ -5,5 +5,18 @@ package authentication
 type UserProvider interface {
 	CheckUserPassword(username string, password string) (bool, error)
 	GetDetails(username string) (*UserDetails, error)
-	UpdatePassword(username string, newPassword string) error
+
+	// UpdatePassword updates the password of the user. The old password is empty when the password is reset after the
+	// identity of the user has been verified, otherwise the old password is checked before the password is changed.
+	UpdatePassword(username string, oldPassword string, newPassword string) error
+}
+
+// UserRegistrar is implemented by the user providers able to create the users registering themselves.
+type UserRegistrar interface {
+	// HashPassword hashes the password the way the provider stores it.
+	HashPassword(password string) (string, error)
+
+	// CreateUser creates the user with the given password hash. ErrUserAlreadyExists is returned when the username is
+	// already taken.
+	CreateUser(details UserDetails, passwordHash string) error
 }
@@ -2,6 +2,7 @@ package authorization
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/authelia/authelia/internal/utils"
@@ -9,15 +10,23 @@ import (
 
 // AccessControlDomain represents an ACL domain.
 type AccessControlDomain struct {
+	// Name is the domain to match. For a wildcard domain or a pattern built from a domain with wildcards it's the
+	// suffix starting with a dot the matching domains end with, which is empty for a domain regex.
 	Name          string
 	Wildcard      bool
 	UserWildcard  bool
 	GroupWildcard bool
+
+	// Pattern is the regular expression of a domain regex or a domain with wildcards within, its named groups are
+	// compared to the subject.
+	Pattern *regexp.Regexp
 }
 
 // IsMatch returns true if the ACL domain matches the object domain.
 func (acd AccessControlDomain) IsMatch(subject Subject, object Object) (match bool) {
 	switch {
+	case acd.Pattern != nil:
+		return acd.isMatchForPattern(subject, object)
 	case acd.Wildcard:
 		return strings.HasSuffix(object.Domain, acd.Name)
 	case acd.UserWildcard:
@@ -30,3 +39,38 @@ func (acd AccessControlDomain) IsMatch(subject Subject, object Object) (match bo
 		return object.Domain == acd.Name
 	}
 }
+
+// isMatchForPattern returns true if the pattern matches the object domain and each named group matches the subject:
+// the User group matches the username, the Group group matches one of the groups and any other group matches one of
+// the values of the user attribute of the same name, all of them case insensitively.
+func (acd AccessControlDomain) isMatchForPattern(subject Subject, object Object) (match bool) {
+	submatches := acd.Pattern.FindStringSubmatch(object.Domain)
+	if submatches == nil {
+		return false
+	}
+
+	for i, name := range acd.Pattern.SubexpNames() {
+		if name == "" {
+			continue
+		}
+
+		value := submatches[i]
+
+		switch name {
+		case domainRegexGroupUser:
+			if !strings.EqualFold(value, subject.Username) {
+				return false
+			}
+		case domainRegexGroupGroup:
+			if !utils.IsStringInSliceFold(value, subject.Groups) {
+				return false
+			}
+		default:
+			if !utils.IsStringInSliceFold(value, subject.Attributes[name]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,131 @@
+package authorization
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// accessControlIndex narrows the rules to evaluate for a request down to the ones whose domains may match the domain
+// of the request. The rules are indexed by the exact domains, by the parent domain of the {user} and {group} domains
+// and in a trie of the suffixes of the wildcard domains, while the rules which can't be indexed are always evaluated.
+type accessControlIndex struct {
+	rules []*AccessControlRule
+
+	exact     map[string][]int
+	parents   map[string][]int
+	suffixes  *domainSuffixNode
+	wildcards []int
+}
+
+// domainSuffixNode is a node of the trie of the domain suffixes whose edges are the labels of the domains from the top
+// level one, the rules of a node match the domains strictly below the suffix the node represents.
+type domainSuffixNode struct {
+	children map[string]*domainSuffixNode
+	rules    []int
+}
+
+func newAccessControlIndex(rules []*AccessControlRule) (index *accessControlIndex) {
+	index = &accessControlIndex{
+		rules:    rules,
+		exact:    map[string][]int{},
+		parents:  map[string][]int{},
+		suffixes: &domainSuffixNode{},
+	}
+
+	for i, rule := range rules {
+		if len(rule.Domains) == 0 {
+			index.wildcards = append(index.wildcards, i)
+			continue
+		}
+
+		for _, domain := range rule.Domains {
+			if !index.add(i, domain) {
+				index.wildcards = append(index.wildcards, i)
+				break
+			}
+		}
+	}
+
+	return index
+}
+
+// add indexes the rule at position i by the domain, it returns false when the domain can't be indexed.
+func (idx *accessControlIndex) add(i int, domain AccessControlDomain) bool {
+	switch {
+	case domain.Wildcard, domain.Pattern != nil:
+		labels := strings.Split(strings.TrimPrefix(domain.Name, "."), ".")
+
+		if !strings.HasPrefix(domain.Name, ".") || utils.IsStringInSlice("", labels) {
+			return false
+		}
+
+		idx.suffixes.insert(labels, i)
+	case domain.UserWildcard, domain.GroupWildcard:
+		idx.parents[domain.Name] = append(idx.parents[domain.Name], i)
+	default:
+		idx.exact[domain.Name] = append(idx.exact[domain.Name], i)
+	}
+
+	return true
+}
+
+// Candidates returns the rules whose domains may match the domain in the order they must be evaluated, the other rules
+// are guaranteed not to match it.
+func (idx *accessControlIndex) Candidates(domain string) (rules []*AccessControlRule) {
+	_, parent := domainToPrefixSuffix(domain)
+
+	positions := make([]int, 0, len(idx.wildcards)+8)
+	positions = append(positions, idx.wildcards...)
+	positions = append(positions, idx.exact[domain]...)
+	positions = append(positions, idx.parents[parent]...)
+	positions = idx.suffixes.collect(strings.Split(domain, "."), positions)
+
+	sort.Ints(positions)
+
+	for i, position := range positions {
+		if i != 0 && position == positions[i-1] {
+			continue
+		}
+
+		rules = append(rules, idx.rules[position])
+	}
+
+	return rules
+}
+
+func (n *domainSuffixNode) insert(labels []string, i int) {
+	node := n
+
+	for j := len(labels) - 1; j >= 0; j-- {
+		if node.children == nil {
+			node.children = map[string]*domainSuffixNode{}
+		}
+
+		child, ok := node.children[labels[j]]
+		if !ok {
+			child = &domainSuffixNode{}
+			node.children[labels[j]] = child
+		}
+
+		node = child
+	}
+
+	node.rules = append(node.rules, i)
+}
+
+// collect appends the rules of the suffixes of the domain to positions, excluding the domain itself.
+func (n *domainSuffixNode) collect(labels []string, positions []int) []int {
+	node := n
+
+	for j := len(labels) - 1; j > 0; j-- {
+		if node = node.children[labels[j]]; node == nil {
+			break
+		}
+
+		positions = append(positions, node.rules...)
+	}
+
+	return positions
+}
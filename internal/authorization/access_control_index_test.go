@@ -0,0 +1,139 @@
+package authorization
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// generateAccessControlConfiguration generates a configuration of about n rules mixing all the kinds of domains.
+func generateAccessControlConfiguration(n int) schema.AccessControlConfiguration {
+	config := schema.AccessControlConfiguration{DefaultPolicy: deny}
+
+	for i := 0; i < n/8; i++ {
+		config.Rules = append(config.Rules,
+			schema.ACLRule{Domains: []string{fmt.Sprintf("app%d.example.com", i)}, Policy: bypass, Resources: []string{"^/public/.*$"}},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("app%d.example.com", i)}, Policy: twoFactor, Subjects: [][]string{{"group:admins"}}},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("app%d.example.com", i), fmt.Sprintf("www.app%d.example.com", i)}, Policy: oneFactor},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("*.team%d.example.com", i)}, Policy: oneFactor, Methods: []string{"GET"}},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("{user}.home%d.example.com", i)}, Policy: oneFactor},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("{group}.groups%d.example.com", i)}, Policy: twoFactor},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("api-*.svc%d.example.com", i)}, Policy: twoFactor},
+			schema.ACLRule{Domains: []string{fmt.Sprintf("**.zone%d.example.com", i)}, Policy: oneFactor},
+		)
+	}
+
+	config.Rules = append(config.Rules,
+		schema.ACLRule{DomainsRegex: []string{`^(?P<User>\w+)\.regex\.example\.com$`}, Policy: oneFactor},
+		schema.ACLRule{Domains: []string{"example.*"}, Policy: bypass},
+		schema.ACLRule{Policy: oneFactor, Subjects: [][]string{{"group:dev"}}},
+	)
+
+	return config
+}
+
+// getRequirementsLinear evaluates all the rules in order, as the authorizer did before indexing them.
+func getRequirementsLinear(authorizer *Authorizer, subject Subject, object Object) Level {
+	for _, rule := range authorizer.rules {
+		if rule.IsMatch(subject, object) {
+			return rule.Policy
+		}
+	}
+
+	return authorizer.defaultPolicy
+}
+
+func TestShouldIndexRulesByDomain(t *testing.T) {
+	rules := NewAccessControlRules(schema.AccessControlConfiguration{
+		Rules: []schema.ACLRule{
+			{Domains: []string{"example.com"}},
+			{Domains: []string{"*.example.com"}},
+			{Domains: []string{"{user}.example.com", "{group}.example.com"}},
+			{Domains: []string{"api-*.svc.example.com"}},
+			{DomainsRegex: []string{`^.*\.example\.com$`}},
+			{Domains: []string{"other.com", "example.*"}},
+			{},
+		},
+	}, utils.RealClock{})
+
+	index := newAccessControlIndex(rules)
+
+	assert.Equal(t, map[string][]int{"example.com": {0}, "other.com": {5}}, index.exact)
+	assert.Equal(t, map[string][]int{"example.com": {2, 2}}, index.parents)
+	assert.Equal(t, []int{4, 5, 6}, index.wildcards)
+
+	positions := func(domain string) (positions []int) {
+		for _, rule := range index.Candidates(domain) {
+			positions = append(positions, rule.Position)
+		}
+
+		return positions
+	}
+
+	assert.Equal(t, []int{1, 5, 6, 7}, positions("example.com"))
+	assert.Equal(t, []int{2, 3, 5, 6, 7}, positions("john.example.com"))
+	assert.Equal(t, []int{2, 4, 5, 6, 7}, positions("api-1.svc.example.com"))
+	assert.Equal(t, []int{2, 5, 6, 7}, positions("a.b.example.com"))
+	assert.Equal(t, []int{5, 6, 7}, positions("example.org"))
+}
+
+func TestShouldIndexedAuthorizerMatchLinearEvaluation(t *testing.T) {
+	authorizer := NewAuthorizer(&schema.Configuration{AccessControl: generateAccessControlConfiguration(400)})
+
+	subjects := []Subject{
+		{},
+		{Username: "john", Groups: []string{"dev"}},
+		{Username: "harry", Groups: []string{"admins"}},
+	}
+
+	domains := []string{
+		"app3.example.com", "www.app3.example.com", "x.team7.example.com", "a.b.team7.example.com", "team7.example.com",
+		"john.home2.example.com", "harry.home2.example.com", "dev.groups9.example.com", "admins.groups9.example.com",
+		"api-v1.svc4.example.com", "api-.svc4.example.com", "a.b.zone1.example.com", "zone1.example.com",
+		"john.regex.example.com", "example.org", "unknown.com", "app3.example.com.evil.com",
+	}
+
+	for _, subject := range subjects {
+		for _, domain := range domains {
+			for _, path := range []string{"/", "/public/index.html"} {
+				for _, method := range []string{"GET", "POST"} {
+					object := NewObject(&url.URL{Scheme: "https", Host: domain, Path: path}, method)
+
+					require.Equal(t, getRequirementsLinear(authorizer, subject, object), authorizer.GetRequiredLevel(subject, object),
+						"subject %s, object %s, method %s", subject.String(), object.String(), method)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkAuthorizer(b *testing.B, linear bool) {
+	authorizer := NewAuthorizer(&schema.Configuration{AccessControl: generateAccessControlConfiguration(2000)})
+	subject := Subject{Username: "john", Groups: []string{"dev"}}
+	object := NewObject(&url.URL{Scheme: "https", Host: "x.team200.example.com", Path: "/"}, "GET")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if linear {
+			getRequirementsLinear(authorizer, subject, object)
+		} else {
+			authorizer.GetRequiredLevel(subject, object)
+		}
+	}
+}
+
+func BenchmarkAuthorizerLinear(b *testing.B) {
+	benchmarkAuthorizer(b, true)
+}
+
+func BenchmarkAuthorizerIndexed(b *testing.B) {
+	benchmarkAuthorizer(b, false)
+}
@@ -0,0 +1,38 @@
+package authorization
+
+import (
+	"regexp"
+)
+
+// AccessControlMatcher represents an ACL matcher of a request header or a query parameter.
+type AccessControlMatcher struct {
+	Key      string
+	Operator string
+	Value    string
+	Pattern  *regexp.Regexp
+}
+
+// IsMatch returns true if the values of the header or the query parameter satisfy the matcher. The values are empty
+// when the header or the query parameter is absent.
+func (acm AccessControlMatcher) IsMatch(values []string) (match bool) {
+	switch acm.Operator {
+	case operatorPresent:
+		return len(values) != 0
+	case operatorAbsent:
+		return len(values) == 0
+	case operatorEqual:
+		for _, value := range values {
+			if value == acm.Value {
+				return true
+			}
+		}
+	case operatorPattern:
+		for _, value := range values {
+			if acm.Pattern.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
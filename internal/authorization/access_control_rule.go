@@ -2,32 +2,51 @@ package authorization
 
 import (
 	"net"
+	"time"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/utils"
 )
 
-// NewAccessControlRules converts a schema.AccessControlConfiguration into an AccessControlRule slice.
-func NewAccessControlRules(config schema.AccessControlConfiguration) (rules []*AccessControlRule) {
+// NewAccessControlRules converts a schema.AccessControlConfiguration into an AccessControlRule slice, the clock is used
+// to evaluate the time windows of the rules.
+func NewAccessControlRules(config schema.AccessControlConfiguration, clock utils.Clock) (rules []*AccessControlRule) {
 	networksMap, networksCacheMap := parseSchemaNetworks(config.Networks)
 
 	for i, schemaRule := range config.Rules {
-		rules = append(rules, NewAccessControlRule(i+1, schemaRule, networksMap, networksCacheMap))
+		rules = append(rules, NewAccessControlRule(i+1, schemaRule, networksMap, networksCacheMap, clock))
 	}
 
 	return rules
 }
 
 // NewAccessControlRule parses a schema ACL and generates an internal ACL.
-func NewAccessControlRule(pos int, rule schema.ACLRule, networksMap map[string][]*net.IPNet, networksCacheMap map[string]*net.IPNet) *AccessControlRule {
+func NewAccessControlRule(pos int, rule schema.ACLRule, networksMap map[string][]*net.IPNet, networksCacheMap map[string]*net.IPNet, clock utils.Clock) *AccessControlRule {
+	// Skip Error Check since validator checks it.
+	maxAge, _ := utils.ParseDurationString(rule.MaxAge)
+
 	return &AccessControlRule{
 		Position:  pos,
-		Domains:   schemaDomainsToACL(rule.Domains),
+		Domains:   append(schemaDomainsToACL(rule.Domains), schemaDomainsRegexToACL(rule.DomainsRegex)...),
 		Resources: schemaResourcesToACL(rule.Resources),
 		Methods:   schemaMethodsToACL(rule.Methods),
+		Headers:   schemaMatchersToACL(rule.Headers),
+		Query:     schemaMatchersToACL(rule.Query),
 		Networks:  schemaNetworksToACL(rule.Networks, networksMap, networksCacheMap),
 		Subjects:  schemaSubjectsToACL(rule.Subjects),
 		Policy:    PolicyToLevel(rule.Policy),
+
+		Name:        rule.Name,
+		Description: rule.Description,
+
+		RejectPasswordless: rule.Passwordless == passwordlessReject,
+		MaxAge:             maxAge,
+
+		SecondFactorMethods: rule.SecondFactorMethods,
+
+		TimeWindow: schemaTimeWindowToACL(rule.TimeWindow),
+
+		clock: clock,
 	}
 }
 
@@ -37,9 +56,23 @@ type AccessControlRule struct {
 	Domains   []AccessControlDomain
 	Resources []AccessControlResource
 	Methods   []string
+	Headers   []AccessControlMatcher
+	Query     []AccessControlMatcher
 	Networks  []*net.IPNet
 	Subjects  []AccessControlSubjects
 	Policy    Level
+
+	Name        string
+	Description string
+
+	RejectPasswordless bool
+	MaxAge             time.Duration
+
+	SecondFactorMethods []string
+
+	TimeWindow *AccessControlTimeWindow
+
+	clock utils.Clock
 }
 
 // IsMatch returns true if all elements of an AccessControlRule match the object and subject.
@@ -56,6 +89,14 @@ func (acr *AccessControlRule) IsMatch(subject Subject, object Object) (match boo
 		return false
 	}
 
+	if !isMatchForHeaders(object, acr) {
+		return false
+	}
+
+	if !isMatchForQuery(object, acr) {
+		return false
+	}
+
 	if !isMatchForNetworks(subject, acr) {
 		return false
 	}
@@ -64,6 +105,10 @@ func (acr *AccessControlRule) IsMatch(subject Subject, object Object) (match boo
 		return false
 	}
 
+	if !isMatchForTimeWindow(acr) {
+		return false
+	}
+
 	return true
 }
 
@@ -108,6 +153,45 @@ func isMatchForMethods(object Object, acl *AccessControlRule) (match bool) {
 	return utils.IsStringInSlice(object.Method, acl.Methods)
 }
 
+func isMatchForHeaders(object Object, acl *AccessControlRule) (match bool) {
+	// All the header matchers of the rule must match, there are none when the headers are not checked.
+	for _, matcher := range acl.Headers {
+		var values []string
+
+		if object.Headers != nil {
+			if value := object.Headers.Peek(matcher.Key); len(value) != 0 {
+				values = []string{string(value)}
+			}
+		}
+
+		if !matcher.IsMatch(values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isMatchForQuery(object Object, acl *AccessControlRule) (match bool) {
+	// All the query matchers of the rule must match, there are none when the query is not checked.
+	for _, matcher := range acl.Query {
+		if !matcher.IsMatch(object.Query[matcher.Key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isMatchForTimeWindow(acl *AccessControlRule) (match bool) {
+	// If there is no time window in this rule then the time window condition is a match.
+	if acl.TimeWindow == nil {
+		return true
+	}
+
+	return acl.TimeWindow.IsMatch(acl.clock.Now())
+}
+
 func isMatchForNetworks(subject Subject, acl *AccessControlRule) (match bool) {
 	// If there are no networks in this rule then the network condition is a match.
 	if len(acl.Networks) == 0 {
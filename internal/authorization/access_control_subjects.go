@@ -1,10 +1,13 @@
 package authorization
 
 import (
+	"strings"
+
 	"github.com/authelia/authelia/internal/utils"
 )
 
-// AccessControlSubject abstracts an ACL subject of type `group:` or `user:`.
+// AccessControlSubject abstracts an ACL subject of type `user:`, `group:`, `email:`, `attribute:` or `oauth2:client:`,
+// optionally negated with `!`.
 type AccessControlSubject interface {
 	IsMatch(subject Subject) (match bool)
 }
@@ -53,3 +56,60 @@ type AccessControlGroup struct {
 func (acg AccessControlGroup) IsMatch(subject Subject) (match bool) {
 	return utils.IsStringInSlice(acg.Name, subject.Groups)
 }
+
+// AccessControlEmail represents an ACL subject of type `email:`, the address is either an email address or a domain
+// prefixed with `*@`.
+type AccessControlEmail struct {
+	Address string
+}
+
+// IsMatch returns true if the AccessControlEmail address matches one of the emails of the Subject, case insensitively.
+func (ace AccessControlEmail) IsMatch(subject Subject) (match bool) {
+	for _, email := range subject.Emails {
+		if strings.HasPrefix(ace.Address, "*@") {
+			if strings.HasSuffix(strings.ToLower(email), strings.ToLower(ace.Address[1:])) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.EqualFold(email, ace.Address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AccessControlAttribute represents an ACL subject of type `attribute:`.
+type AccessControlAttribute struct {
+	Name  string
+	Value string
+}
+
+// IsMatch returns true if the AccessControlAttribute value is one of the values of the Subject attribute of the same
+// name.
+func (aca AccessControlAttribute) IsMatch(subject Subject) (match bool) {
+	return utils.IsStringInSlice(aca.Value, subject.Attributes[aca.Name])
+}
+
+// AccessControlOAuth2Client represents an ACL subject of type `oauth2:client:`.
+type AccessControlOAuth2Client struct {
+	ID string
+}
+
+// IsMatch returns true if the Subject makes the request through the OAuth 2.0 client.
+func (acc AccessControlOAuth2Client) IsMatch(subject Subject) (match bool) {
+	return subject.ClientID != "" && subject.ClientID == acc.ID
+}
+
+// AccessControlNegation represents an ACL subject prefixed with `!`.
+type AccessControlNegation struct {
+	Subject AccessControlSubject
+}
+
+// IsMatch returns true if the negated ACL subject doesn't match the Subject.
+func (acn AccessControlNegation) IsMatch(subject Subject) (match bool) {
+	return !acn.Subject.IsMatch(subject)
+}
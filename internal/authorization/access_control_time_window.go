@@ -0,0 +1,59 @@
+package authorization
+
+import (
+	"time"
+)
+
+// AccessControlTimeWindow represents an ACL time window, i.e. the days, the times of day and the dates during which a
+// rule applies.
+type AccessControlTimeWindow struct {
+	Location *time.Location
+	Days     []time.Weekday
+
+	// Start and End are the durations elapsed since midnight, the window spans midnight when End is before Start.
+	Start time.Duration
+	End   time.Duration
+
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// IsMatch returns true if the time is within the time window.
+func (actw AccessControlTimeWindow) IsMatch(now time.Time) (match bool) {
+	if !actw.NotBefore.IsZero() && now.Before(actw.NotBefore) {
+		return false
+	}
+
+	if !actw.NotAfter.IsZero() && !now.Before(actw.NotAfter) {
+		return false
+	}
+
+	now = now.In(actw.Location)
+
+	if len(actw.Days) != 0 && !isWeekdayInSlice(now.Weekday(), actw.Days) {
+		return false
+	}
+
+	if actw.Start == actw.End {
+		return true
+	}
+
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute +
+		time.Duration(now.Second())*time.Second
+
+	if actw.Start < actw.End {
+		return timeOfDay >= actw.Start && timeOfDay < actw.End
+	}
+
+	return timeOfDay >= actw.Start || timeOfDay < actw.End
+}
+
+func isWeekdayInSlice(day time.Weekday, days []time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
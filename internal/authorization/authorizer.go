@@ -1,28 +1,65 @@
 package authorization
 
 import (
+	"sync"
+
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/logging"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // Authorizer the component in charge of checking whether a user can access a given resource.
 type Authorizer struct {
 	defaultPolicy Level
 	rules         []*AccessControlRule
+	index         *accessControlIndex
 	configuration *schema.Configuration
+	clock         utils.Clock
+
+	// mutex guards the default policy and the rules which may be swapped by ReloadAccessControl.
+	mutex sync.RWMutex
 }
 
 // NewAuthorizer create an instance of authorizer with a given access control configuration.
 func NewAuthorizer(configuration *schema.Configuration) *Authorizer {
+	return NewAuthorizerWithClock(configuration, utils.RealClock{})
+}
+
+// NewAuthorizerWithClock create an instance of authorizer with a given access control configuration and the clock
+// used to evaluate the time windows of the rules.
+func NewAuthorizerWithClock(configuration *schema.Configuration, clock utils.Clock) *Authorizer {
+	rules := NewAccessControlRules(configuration.AccessControl, clock)
+
 	return &Authorizer{
 		defaultPolicy: PolicyToLevel(configuration.AccessControl.DefaultPolicy),
-		rules:         NewAccessControlRules(configuration.AccessControl),
+		rules:         rules,
+		index:         newAccessControlIndex(rules),
 		configuration: configuration,
+		clock:         clock,
 	}
 }
 
+// ReloadAccessControl replaces the default policy and the rules by the ones of the given access control configuration.
+// The configuration must have been validated beforehand. The requests being checked meanwhile are evaluated against
+// either the previous or the new rules, never a mix of both.
+func (p *Authorizer) ReloadAccessControl(configuration schema.AccessControlConfiguration) {
+	defaultPolicy := PolicyToLevel(configuration.DefaultPolicy)
+	rules := NewAccessControlRules(configuration, p.clock)
+	index := newAccessControlIndex(rules)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.defaultPolicy = defaultPolicy
+	p.rules = rules
+	p.index = index
+}
+
 // IsSecondFactorEnabled return true if at least one policy is set to second factor.
-func (p Authorizer) IsSecondFactorEnabled() bool {
+func (p *Authorizer) IsSecondFactorEnabled() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	if p.defaultPolicy == TwoFactor {
 		return true
 	}
@@ -45,17 +82,36 @@ func (p Authorizer) IsSecondFactorEnabled() bool {
 }
 
 // GetRequiredLevel retrieve the required level of authorization to access the object.
-func (p Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
+func (p *Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
+	return p.GetRequirements(subject, object).Level
+}
+
+// GetRequirements retrieve the requirements the subject must satisfy to access the object, i.e. the required level of
+// authorization, the maximum age of the authentication and the accepted second factor methods, along with the rule
+// they come from.
+func (p *Authorizer) GetRequirements(subject Subject, object Object) Requirements {
 	logger := logging.Logger()
 
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	logger.Debugf("Check authorization of subject %s and object %s (method %s).",
 		subject.String(), object.String(), object.Method)
 
-	for _, rule := range p.rules {
+	// Only the rules whose domains may match the object are evaluated, in the order they are defined.
+	for _, rule := range p.index.Candidates(object.Domain) {
 		if rule.IsMatch(subject, object) {
 			logger.Tracef(traceFmtACLHitMiss, "HIT", rule.Position, subject.String(), object.String(), object.Method)
 
-			return rule.Policy
+			if subject.Passwordless && rule.RejectPasswordless && (rule.Policy == OneFactor || rule.Policy == TwoFactor) {
+				logger.Debugf("Rule %d rejects the passwordless session of subject %s.", rule.Position, subject.String())
+
+				return Requirements{Level: Denied, RulePosition: rule.Position, RuleName: rule.Name,
+					RuleDescription: rule.Description}
+			}
+
+			return Requirements{Level: rule.Policy, MaxAge: rule.MaxAge, SecondFactorMethods: rule.SecondFactorMethods,
+				RulePosition: rule.Position, RuleName: rule.Name, RuleDescription: rule.Description}
 		}
 
 		logger.Tracef(traceFmtACLHitMiss, "MISS", rule.Position, subject.String(), object.String(), object.Method)
@@ -64,5 +120,37 @@ func (p Authorizer) GetRequiredLevel(subject Subject, object Object) Level {
 	logger.Debugf("No matching rule for subject %s and url %s... Applying default policy.",
 		subject.String(), object.String())
 
+	return Requirements{Level: p.defaultPolicy}
+}
+
+// GetRuleMatchResults returns how each rule matches the subject and the object, in the order the rules are evaluated.
+// Unlike GetRequirements it evaluates all the criteria of all the rules, which is only useful to explain a decision.
+func (p *Authorizer) GetRuleMatchResults(subject Subject, object Object) (results []RuleMatchResult) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for _, rule := range p.rules {
+		results = append(results, RuleMatchResult{
+			Rule: rule,
+
+			MatchDomain:     isMatchForDomains(subject, object, rule),
+			MatchResources:  isMatchForResources(object, rule),
+			MatchMethods:    isMatchForMethods(object, rule),
+			MatchHeaders:    isMatchForHeaders(object, rule),
+			MatchQuery:      isMatchForQuery(object, rule),
+			MatchNetworks:   isMatchForNetworks(subject, rule),
+			MatchSubjects:   isMatchForSubjects(subject, rule),
+			MatchTimeWindow: isMatchForTimeWindow(rule),
+		})
+	}
+
+	return results
+}
+
+// GetDefaultPolicy returns the level applied when no rule matches.
+func (p *Authorizer) GetDefaultPolicy() Level {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	return p.defaultPolicy
 }
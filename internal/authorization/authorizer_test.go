@@ -4,12 +4,15 @@ import (
 	"net"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/valyala/fasthttp"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 type AuthorizerSuite struct {
@@ -20,13 +23,13 @@ type AuthorizerTester struct {
 	*Authorizer
 }
 
-func NewAuthorizerTester(config schema.AccessControlConfiguration) *AuthorizerTester {
+func NewAuthorizerTester(config schema.AccessControlConfiguration, clock utils.Clock) *AuthorizerTester {
 	fullConfig := &schema.Configuration{
 		AccessControl: config,
 	}
 
 	return &AuthorizerTester{
-		NewAuthorizer(fullConfig),
+		NewAuthorizerWithClock(fullConfig, clock),
 	}
 }
 
@@ -47,10 +50,16 @@ func (s *AuthorizerTester) CheckAuthorizations(t *testing.T, subject Subject, re
 
 type AuthorizerTesterBuilder struct {
 	config schema.AccessControlConfiguration
+	clock  utils.Clock
 }
 
 func NewAuthorizerBuilder() *AuthorizerTesterBuilder {
-	return &AuthorizerTesterBuilder{}
+	return &AuthorizerTesterBuilder{clock: utils.RealClock{}}
+}
+
+func (b *AuthorizerTesterBuilder) WithClock(clock utils.Clock) *AuthorizerTesterBuilder {
+	b.clock = clock
+	return b
 }
 
 func (b *AuthorizerTesterBuilder) WithDefaultPolicy(policy string) *AuthorizerTesterBuilder {
@@ -64,7 +73,19 @@ func (b *AuthorizerTesterBuilder) WithRule(rule schema.ACLRule) *AuthorizerTeste
 }
 
 func (b *AuthorizerTesterBuilder) Build() *AuthorizerTester {
-	return NewAuthorizerTester(b.config)
+	return NewAuthorizerTester(b.config, b.clock)
+}
+
+type TestingClock struct {
+	now time.Time
+}
+
+func (c *TestingClock) Now() time.Time {
+	return c.now
+}
+
+func (c *TestingClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
 }
 
 var AnonymousUser = Subject{
@@ -161,6 +182,124 @@ func (s *AuthorizerSuite) TestShouldCheckDynamicDomainRules() {
 	tester.CheckAuthorizations(s.T(), UserWithGroups, "https://othergroup.example.com/", "GET", Denied)
 }
 
+func (s *AuthorizerSuite) TestShouldCheckRicherSubjectMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"intranet.example.com"},
+			Policy:   oneFactor,
+			Subjects: [][]string{{"group:dev", "!group:contractors"}, {"email:*@Example.com"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"tenant.example.com"},
+			Policy:   twoFactor,
+			Subjects: [][]string{{"attribute:tenant:acme"}, {"email:bob@partner.com"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"grafana.example.com"},
+			Policy:   twoFactor,
+			Subjects: [][]string{{"oauth2:client:grafana"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"grafana.example.com"},
+			Policy:   oneFactor,
+			Subjects: [][]string{{"!oauth2:client:grafana"}},
+		}).
+		Build()
+
+	contractor := Subject{Username: "harry", Groups: []string{"dev", "contractors"}, Emails: []string{"harry@contractor.com"}}
+	employee := Subject{Username: "harry", Groups: []string{"dev", "contractors"}, Emails: []string{"harry@example.com"}}
+	tenant := Subject{Username: "alice", Attributes: map[string][]string{"tenant": {"acme"}}}
+	partner := Subject{Username: "bob", Emails: []string{"Bob@Partner.com"}}
+	grafana := Subject{Username: "john", Groups: []string{"dev"}, ClientID: "grafana"}
+
+	tester.CheckAuthorizations(s.T(), John, "https://intranet.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), contractor, "https://intranet.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), employee, "https://intranet.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://intranet.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), tenant, "https://tenant.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), partner, "https://tenant.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://tenant.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), grafana, "https://grafana.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://grafana.example.com/", "GET", OneFactor)
+}
+
+func (s *AuthorizerSuite) TestShouldCheckDomainRegexRules() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithRule(schema.ACLRule{
+			DomainsRegex: []string{`^(?P<User>\w+)\.home\.example\.com$`},
+			Policy:       oneFactor,
+		}).
+		WithRule(schema.ACLRule{
+			DomainsRegex: []string{`^(?P<Group>\w+)\.groups\.example\.com$`},
+			Policy:       twoFactor,
+		}).
+		WithRule(schema.ACLRule{
+			DomainsRegex: []string{`^(?P<tenant>[a-z]+)\.(eu|us)\.example\.com$`},
+			Policy:       twoFactor,
+		}).
+		WithRule(schema.ACLRule{
+			Domains:      []string{"legacy.example.com"},
+			DomainsRegex: []string{`^app-\d+\.example\.com$`},
+			Policy:       bypass,
+		}).
+		Build()
+
+	tenant := Subject{
+		Username:   "harry",
+		Groups:     []string{"users"},
+		Attributes: map[string][]string{"tenant": {"ACME", "initech"}},
+	}
+
+	tester.CheckAuthorizations(s.T(), John, "https://john.home.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://JOHN.home.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://bob.home.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://john.home.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://admins.groups.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), Bob, "https://admins.groups.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), tenant, "https://acme.eu.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), tenant, "https://initech.us.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), tenant, "https://umbrella.eu.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://acme.eu.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://legacy.example.com/", "GET", Bypass)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://app-42.example.com/", "GET", Bypass)
+	tester.CheckAuthorizations(s.T(), AnonymousUser, "https://app-x.example.com/", "GET", Denied)
+}
+
+func (s *AuthorizerSuite) TestShouldCheckDomainWildcardRules() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithRule(schema.ACLRule{
+			Domains: []string{"*.*.internal.example.com"},
+			Policy:  twoFactor,
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"**.eu.example.com"},
+			Policy:  oneFactor,
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"app-*.example.com"},
+			Policy:  bypass,
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"*.legacy.example.com"},
+			Policy:  bypass,
+		}).
+		Build()
+
+	tester.CheckAuthorizations(s.T(), John, "https://a.b.internal.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://a.internal.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://a.b.c.internal.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://a.eu.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://a.b.c.eu.example.com/", "GET", OneFactor)
+	tester.CheckAuthorizations(s.T(), John, "https://eu.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://app-grafana.example.com/", "GET", Bypass)
+	tester.CheckAuthorizations(s.T(), John, "https://app-.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://app-a.b.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), John, "https://a.b.legacy.example.com/", "GET", Bypass)
+}
+
 func (s *AuthorizerSuite) TestShouldCheckMultipleDomainRule() {
 	tester := NewAuthorizerBuilder().
 		WithDefaultPolicy(deny).
@@ -227,6 +366,177 @@ func (s *AuthorizerSuite) TestShouldCheckRulePrecedence() {
 	tester.CheckAuthorizations(s.T(), John, "https://public.example.com/", "GET", TwoFactor)
 }
 
+func (s *AuthorizerSuite) TestShouldCheckPasswordlessRejection() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(twoFactor).
+		WithRule(schema.ACLRule{
+			Domains:      []string{"public.example.com"},
+			Policy:       bypass,
+			Passwordless: "reject",
+		}).
+		WithRule(schema.ACLRule{
+			Domains:      []string{"protected.example.com"},
+			Policy:       twoFactor,
+			Passwordless: "reject",
+		}).
+		WithRule(schema.ACLRule{
+			Domains:      []string{"accepted.example.com"},
+			Policy:       twoFactor,
+			Passwordless: "accept",
+		}).
+		Build()
+
+	passwordlessJohn := John
+	passwordlessJohn.Passwordless = true
+
+	tester.CheckAuthorizations(s.T(), John, "https://protected.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), passwordlessJohn, "https://protected.example.com/", "GET", Denied)
+	tester.CheckAuthorizations(s.T(), passwordlessJohn, "https://public.example.com/", "GET", Bypass)
+	tester.CheckAuthorizations(s.T(), passwordlessJohn, "https://accepted.example.com/", "GET", TwoFactor)
+	tester.CheckAuthorizations(s.T(), passwordlessJohn, "https://example.com/", "GET", TwoFactor)
+}
+
+func (s *AuthorizerSuite) TestShouldReturnMaxAgeOfMatchingRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(oneFactor).
+		WithRule(schema.ACLRule{
+			Domains: []string{"payroll.example.com"},
+			Policy:  twoFactor,
+			MaxAge:  "15m",
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"protected.example.com"},
+			Policy:  twoFactor,
+		}).
+		Build()
+
+	object := Object{Scheme: "https", Domain: "payroll.example.com", Path: "/", Method: "GET"}
+	s.Assert().Equal(Requirements{Level: TwoFactor, MaxAge: 15 * time.Minute, RulePosition: 1}, tester.GetRequirements(John, object))
+
+	object.Domain = "protected.example.com"
+	s.Assert().Equal(Requirements{Level: TwoFactor, RulePosition: 2}, tester.GetRequirements(John, object))
+
+	object.Domain = "example.com"
+	s.Assert().Equal(Requirements{Level: OneFactor}, tester.GetRequirements(John, object))
+}
+
+func (s *AuthorizerSuite) TestShouldReturnMatchingRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithRule(schema.ACLRule{
+			Domains: []string{"public.example.com"},
+			Policy:  bypass,
+		}).
+		WithRule(schema.ACLRule{
+			Name:         "admin",
+			Description:  "The administration is restricted to the administrators.",
+			Domains:      []string{"admin.example.com"},
+			Policy:       twoFactor,
+			Passwordless: "reject",
+		}).
+		Build()
+
+	object := Object{Scheme: "https", Domain: "public.example.com", Path: "/", Method: "GET"}
+	s.Assert().Equal(Requirements{Level: Bypass, RulePosition: 1}, tester.GetRequirements(John, object))
+
+	object.Domain = "admin.example.com"
+	s.Assert().Equal(Requirements{Level: TwoFactor, RulePosition: 2, RuleName: "admin",
+		RuleDescription: "The administration is restricted to the administrators."}, tester.GetRequirements(John, object))
+
+	subject := John
+	subject.Passwordless = true
+	s.Assert().Equal(Requirements{Level: Denied, RulePosition: 2, RuleName: "admin",
+		RuleDescription: "The administration is restricted to the administrators."}, tester.GetRequirements(subject, object))
+
+	object.Domain = "example.com"
+	s.Assert().Equal(Requirements{Level: Denied}, tester.GetRequirements(John, object))
+}
+
+func (s *AuthorizerSuite) TestShouldReturnSecondFactorMethodsOfMatchingRule() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(oneFactor).
+		WithRule(schema.ACLRule{
+			Domains:             []string{"admin.example.com"},
+			Policy:              twoFactor,
+			SecondFactorMethods: []string{"u2f", "passwordless"},
+		}).
+		Build()
+
+	object := Object{Scheme: "https", Domain: "admin.example.com", Path: "/", Method: "GET"}
+	requirements := tester.GetRequirements(John, object)
+
+	s.Assert().Equal(Requirements{Level: TwoFactor, SecondFactorMethods: []string{"u2f", "passwordless"}, RulePosition: 1}, requirements)
+	s.Assert().True(requirements.IsSecondFactorMethodAccepted([]string{"totp", "u2f"}))
+	s.Assert().True(requirements.IsSecondFactorMethodAccepted([]string{"passwordless"}))
+	s.Assert().False(requirements.IsSecondFactorMethodAccepted([]string{"totp", "mobile_push"}))
+	s.Assert().False(requirements.IsSecondFactorMethodAccepted(nil))
+
+	object.Domain = "example.com"
+	requirements = tester.GetRequirements(John, object)
+
+	s.Assert().Equal(Requirements{Level: OneFactor}, requirements)
+	s.Assert().True(requirements.IsSecondFactorMethodAccepted(nil))
+}
+
+func (s *AuthorizerSuite) TestShouldCheckHeaderAndQueryMatching() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithRule(schema.ACLRule{
+			Domains: []string{"api.example.com"},
+			Policy:  bypass,
+			Query: []schema.ACLRuleMatcher{
+				{Key: "token", Operator: "present"},
+				{Key: "debug", Operator: "absent"},
+			},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"api.example.com"},
+			Policy:  oneFactor,
+			Headers: []schema.ACLRuleMatcher{
+				{Key: "content-type", Value: "application/json"},
+			},
+			Query: []schema.ACLRuleMatcher{
+				{Key: "version", Operator: "pattern", Value: "^v[12]$"},
+			},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"api.example.com"},
+			Policy:  twoFactor,
+			Headers: []schema.ACLRuleMatcher{
+				{Key: "X-Forwarded-Proto", Operator: "equal", Value: "https"},
+			},
+		}).
+		Build()
+
+	object := func(rawURL string, headers map[string]string) Object {
+		targetURL, err := url.ParseRequestURI(rawURL)
+		s.Require().NoError(err)
+
+		header := &fasthttp.RequestHeader{}
+		for key, value := range headers {
+			header.Set(key, value)
+		}
+
+		return NewObjectRaw(targetURL, []byte("GET"), header)
+	}
+
+	json := map[string]string{"Content-Type": "application/json", "X-Forwarded-Proto": "https"}
+	html := map[string]string{"Content-Type": "text/html", "X-Forwarded-Proto": "https"}
+
+	s.Assert().Equal(Bypass, tester.GetRequiredLevel(John, object("https://api.example.com/?token=abc", nil)))
+	s.Assert().Equal(Denied, tester.GetRequiredLevel(John, object("https://api.example.com/?token=abc&debug=1", nil)))
+	s.Assert().Equal(OneFactor, tester.GetRequiredLevel(John, object("https://api.example.com/?version=v2", json)))
+	s.Assert().Equal(OneFactor, tester.GetRequiredLevel(John, object("https://api.example.com/?version=v3&version=v1", json)))
+	s.Assert().Equal(TwoFactor, tester.GetRequiredLevel(John, object("https://api.example.com/?version=v3", json)))
+	s.Assert().Equal(TwoFactor, tester.GetRequiredLevel(John, object("https://api.example.com/?version=v2", html)))
+	s.Assert().Equal(Denied, tester.GetRequiredLevel(John, object("https://api.example.com/?version=v2", nil)))
+
+	// The headers are unknown when the object is not built from a request.
+	targetURL, err := url.ParseRequestURI("https://api.example.com/?version=v2")
+	s.Require().NoError(err)
+	s.Assert().Equal(Denied, tester.GetRequiredLevel(John, NewObject(targetURL, "GET")))
+}
+
 func (s *AuthorizerSuite) TestShouldCheckUserMatching() {
 	tester := NewAuthorizerBuilder().
 		WithDefaultPolicy(deny).
@@ -492,6 +802,126 @@ func (s *AuthorizerSuite) TestPolicyToLevel() {
 	s.Assert().Equal(Denied, PolicyToLevel("whatever"))
 }
 
+func (s *AuthorizerSuite) TestShouldCheckTimeWindowMatching() {
+	paris, err := time.LoadLocation("Europe/Paris")
+	s.Require().NoError(err)
+
+	clock := &TestingClock{}
+
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithClock(clock).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"intranet.example.com"},
+			Policy:   oneFactor,
+			Subjects: [][]string{{"group:dev"}},
+			TimeWindow: &schema.ACLRuleTimeWindow{
+				Timezone:  "Europe/Paris",
+				Days:      []string{"monday", "tue", "Wednesday", "thursday", "friday"},
+				StartTime: "08:00",
+				EndTime:   "18:30",
+				NotAfter:  "2021-06-30",
+			},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"backup.example.com"},
+			Policy:  twoFactor,
+			TimeWindow: &schema.ACLRuleTimeWindow{
+				Timezone:  "UTC",
+				StartTime: "22:00",
+				EndTime:   "06:00",
+				NotBefore: "2021-06-01T00:00:00Z",
+			},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"intranet.example.com"},
+			Policy:  twoFactor,
+		}).
+		Build()
+
+	checks := []struct {
+		now      time.Time
+		url      string
+		expected Level
+	}{
+		// Tuesday during business hours.
+		{time.Date(2021, 6, 1, 8, 0, 0, 0, paris), "https://intranet.example.com/", OneFactor},
+		{time.Date(2021, 6, 1, 18, 29, 59, 0, paris), "https://intranet.example.com/", OneFactor},
+		// Tuesday out of business hours, the next rule applies.
+		{time.Date(2021, 6, 1, 7, 59, 0, 0, paris), "https://intranet.example.com/", TwoFactor},
+		{time.Date(2021, 6, 1, 18, 30, 0, 0, paris), "https://intranet.example.com/", TwoFactor},
+		// 8:00 in Paris is 6:00 in UTC, the time of day is evaluated in the timezone of the window.
+		{time.Date(2021, 6, 1, 6, 0, 0, 0, time.UTC), "https://intranet.example.com/", OneFactor},
+		// Saturday.
+		{time.Date(2021, 6, 5, 10, 0, 0, 0, paris), "https://intranet.example.com/", TwoFactor},
+		// The whole last day is included but not the day after.
+		{time.Date(2021, 6, 30, 18, 0, 0, 0, paris), "https://intranet.example.com/", OneFactor},
+		{time.Date(2021, 7, 1, 10, 0, 0, 0, paris), "https://intranet.example.com/", TwoFactor},
+		// The window spans midnight.
+		{time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC), "https://backup.example.com/", TwoFactor},
+		{time.Date(2021, 6, 2, 5, 59, 0, 0, time.UTC), "https://backup.example.com/", TwoFactor},
+		{time.Date(2021, 6, 2, 6, 0, 0, 0, time.UTC), "https://backup.example.com/", Denied},
+		{time.Date(2021, 6, 2, 21, 59, 0, 0, time.UTC), "https://backup.example.com/", Denied},
+		// Before the window opens.
+		{time.Date(2021, 5, 31, 23, 0, 0, 0, time.UTC), "https://backup.example.com/", Denied},
+	}
+
+	for _, check := range checks {
+		clock.now = check.now
+
+		tester.CheckAuthorizations(s.T(), John, check.url, "GET", check.expected)
+	}
+}
+
+func (s *AuthorizerSuite) TestShouldExplainRuleMatchResults() {
+	tester := NewAuthorizerBuilder().
+		WithDefaultPolicy(deny).
+		WithRule(schema.ACLRule{
+			Domains:   []string{"public.example.com"},
+			Resources: []string{"^/admin"},
+			Policy:    bypass,
+		}).
+		WithRule(schema.ACLRule{
+			Domains:  []string{"public.example.com"},
+			Policy:   twoFactor,
+			Methods:  []string{"POST"},
+			Networks: []string{"192.168.1.0/24"},
+			Subjects: [][]string{{"group:admins"}},
+		}).
+		WithRule(schema.ACLRule{
+			Domains: []string{"*.example.com"},
+			Policy:  oneFactor,
+		}).
+		Build()
+
+	object := NewObject(&url.URL{Scheme: "https", Host: "public.example.com", Path: "/"}, "GET")
+
+	results := tester.GetRuleMatchResults(Bob, object)
+
+	s.Require().Len(results, 3)
+
+	s.Assert().Equal(1, results[0].Rule.Position)
+	s.Assert().True(results[0].MatchDomain)
+	s.Assert().False(results[0].MatchResources)
+	s.Assert().False(results[0].IsMatch())
+
+	s.Assert().Equal(RuleMatchResult{
+		Rule:            results[1].Rule,
+		MatchDomain:     true,
+		MatchResources:  true,
+		MatchMethods:    false,
+		MatchHeaders:    true,
+		MatchQuery:      true,
+		MatchNetworks:   false,
+		MatchSubjects:   false,
+		MatchTimeWindow: true,
+	}, results[1])
+
+	s.Assert().True(results[2].IsMatch())
+	s.Assert().Equal(OneFactor, tester.GetRequiredLevel(Bob, object))
+	s.Assert().Equal(Denied, tester.GetDefaultPolicy())
+}
+
 func TestRunSuite(t *testing.T) {
 	s := AuthorizerSuite{}
 	suite.Run(t, &s)
@@ -596,3 +1026,109 @@ func TestAuthorizerIsSecondFactorEnabledRuleWithOIDC(t *testing.T) {
 
 	assert.True(t, authorizer.IsSecondFactorEnabled())
 }
+
+func TestShouldReloadAccessControl(t *testing.T) {
+	config := &schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: deny,
+			Rules: []schema.ACLRule{
+				{
+					Domains: []string{"public.example.com"},
+					Policy:  bypass,
+				},
+			},
+		},
+	}
+
+	authorizer := NewAuthorizer(config)
+	subject := Subject{Username: "john", Groups: []string{"dev"}}
+	publicObject := NewObject(&url.URL{Scheme: "https", Host: "public.example.com", Path: "/"}, "GET")
+	adminObject := NewObject(&url.URL{Scheme: "https", Host: "admin.example.com", Path: "/"}, "GET")
+
+	assert.Equal(t, Bypass, authorizer.GetRequiredLevel(subject, publicObject))
+	assert.Equal(t, Denied, authorizer.GetRequiredLevel(subject, adminObject))
+	assert.False(t, authorizer.IsSecondFactorEnabled())
+
+	authorizer.ReloadAccessControl(schema.AccessControlConfiguration{
+		DefaultPolicy: oneFactor,
+		Rules: []schema.ACLRule{
+			{
+				Domains: []string{"admin.example.com"},
+				Policy:  twoFactor,
+			},
+		},
+	})
+
+	assert.Equal(t, OneFactor, authorizer.GetRequiredLevel(subject, publicObject))
+	assert.Equal(t, TwoFactor, authorizer.GetRequiredLevel(subject, adminObject))
+	assert.Equal(t, OneFactor, authorizer.GetDefaultPolicy())
+	assert.True(t, authorizer.IsSecondFactorEnabled())
+
+	// The configuration the authorizer was created with is left untouched.
+	assert.Equal(t, deny, config.AccessControl.DefaultPolicy)
+}
+
+func TestShouldReloadAccessControlWhileCheckingRequests(t *testing.T) {
+	authorizer := NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: deny,
+			Rules: []schema.ACLRule{
+				{
+					Domains: []string{"example.com"},
+					Policy:  oneFactor,
+				},
+			},
+		},
+	})
+
+	configurations := []schema.AccessControlConfiguration{
+		{
+			DefaultPolicy: deny,
+			Rules:         []schema.ACLRule{{Domains: []string{"example.com"}, Policy: oneFactor}},
+		},
+		{
+			DefaultPolicy: deny,
+			Rules:         []schema.ACLRule{{Domains: []string{"example.com"}, Policy: twoFactor}},
+		},
+	}
+
+	subject := Subject{Username: "john"}
+	object := NewObject(&url.URL{Scheme: "https", Host: "example.com", Path: "/"}, "GET")
+
+	done := make(chan struct{})
+	errs := make(chan Level, 1)
+
+	for i := 0; i < 4; i++ {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// Each request must be evaluated against one of the rule sets, never against none of them.
+				if level := authorizer.GetRequiredLevel(subject, object); level != OneFactor && level != TwoFactor {
+					select {
+					case errs <- level:
+					default:
+					}
+
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 1000; i++ {
+		authorizer.ReloadAccessControl(configurations[i%2])
+	}
+
+	close(done)
+
+	select {
+	case level := <-errs:
+		t.Fatalf("Request evaluated to the unexpected level %d during a reload", level)
+	default:
+	}
+}
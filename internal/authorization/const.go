@@ -16,10 +16,28 @@ const (
 
 const userPrefix = "user:"
 const groupPrefix = "group:"
+const emailPrefix = "email:"
+const attributePrefix = "attribute:"
+const oauth2ClientPrefix = "oauth2:client:"
+const negationPrefix = "!"
 
 const bypass = "bypass"
 const oneFactor = "one_factor"
 const twoFactor = "two_factor"
 const deny = "deny"
 
+const passwordlessReject = "reject"
+
+const (
+	domainRegexGroupUser  = "User"
+	domainRegexGroupGroup = "Group"
+)
+
+const (
+	operatorPresent = "present"
+	operatorAbsent  = "absent"
+	operatorEqual   = "equal"
+	operatorPattern = "pattern"
+)
+
 const traceFmtACLHitMiss = "ACL %s Position %d for subject %s and object %s (Method %s)"
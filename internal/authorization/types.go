@@ -5,13 +5,27 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"time"
+
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // Subject represents the identity of a user for the purposes of ACL matching.
 type Subject struct {
 	Username string
 	Groups   []string
+	Emails   []string
 	IP       net.IP
+
+	// Attributes are the additional attributes of the user indexed by their name.
+	Attributes map[string][]string
+
+	// ClientID is the identifier of the OAuth 2.0 client the request is made through, empty when the user made the
+	// request directly.
+	ClientID string
+
+	// Passwordless is true if the user signed in with a security key only.
+	Passwordless bool
 }
 
 // String returns a string representation of the Subject.
@@ -24,12 +38,88 @@ func (s Subject) IsAnonymous() bool {
 	return s.Username == "" && len(s.Groups) == 0
 }
 
+// Requirements represents what a subject must satisfy to access an object.
+type Requirements struct {
+	Level Level
+
+	// MaxAge is the maximum time elapsed since the subject authenticated with the factor of the required level, zero
+	// means that the authentication never gets too old.
+	MaxAge time.Duration
+
+	// SecondFactorMethods are the second factor methods accepted by the rule, any method is accepted when it's empty.
+	SecondFactorMethods []string
+
+	// RulePosition is the position of the rule the requirements come from, zero when the default policy applies.
+	RulePosition int
+
+	// RuleName and RuleDescription are the optional name and description of the rule the requirements come from.
+	RuleName        string
+	RuleDescription string
+}
+
+// RuleString returns a string representation of the rule the requirements come from.
+func (r Requirements) RuleString() string {
+	switch {
+	case r.RulePosition == 0:
+		return "default policy"
+	case r.RuleName != "":
+		return fmt.Sprintf("rule #%d (%s)", r.RulePosition, r.RuleName)
+	default:
+		return fmt.Sprintf("rule #%d", r.RulePosition)
+	}
+}
+
+// IsSecondFactorMethodAccepted returns true if one of the second factor methods completed by the subject is accepted.
+func (r Requirements) IsSecondFactorMethodAccepted(methods []string) bool {
+	if len(r.SecondFactorMethods) == 0 {
+		return true
+	}
+
+	for _, method := range methods {
+		if utils.IsStringInSlice(method, r.SecondFactorMethods) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RuleMatchResult describes which criteria of a rule match a subject and an object.
+type RuleMatchResult struct {
+	Rule *AccessControlRule
+
+	MatchDomain     bool
+	MatchResources  bool
+	MatchMethods    bool
+	MatchHeaders    bool
+	MatchQuery      bool
+	MatchNetworks   bool
+	MatchSubjects   bool
+	MatchTimeWindow bool
+}
+
+// IsMatch returns true if all the criteria of the rule match.
+func (r RuleMatchResult) IsMatch() bool {
+	return r.MatchDomain && r.MatchResources && r.MatchMethods && r.MatchHeaders && r.MatchQuery && r.MatchNetworks &&
+		r.MatchSubjects && r.MatchTimeWindow
+}
+
+// Headers gives access to the headers of a request, e.g. a *fasthttp.RequestHeader.
+type Headers interface {
+	Peek(key string) []byte
+}
+
 // Object represents a protected object for the purposes of ACL matching.
 type Object struct {
 	Scheme string
 	Domain string
 	Path   string
 	Method string
+
+	// Query are the parsed query parameters of the URL.
+	Query url.Values
+	// Headers are the headers of the request, nil when they are unknown.
+	Headers Headers
 }
 
 // String is a string representation of the Object.
@@ -37,9 +127,12 @@ func (o Object) String() string {
 	return fmt.Sprintf("%s://%s%s", o.Scheme, o.Domain, o.Path)
 }
 
-// NewObjectRaw creates a new Object type from a URL and a method header.
-func NewObjectRaw(targetURL *url.URL, method []byte) (object Object) {
-	return NewObject(targetURL, string(method))
+// NewObjectRaw creates a new Object type from a URL, a method header and the headers of the request.
+func NewObjectRaw(targetURL *url.URL, method []byte, headers Headers) (object Object) {
+	object = NewObject(targetURL, string(method))
+	object.Headers = headers
+
+	return object
 }
 
 // NewObject creates a new Object type from a URL and a method header.
@@ -48,6 +141,7 @@ func NewObject(targetURL *url.URL, method string) (object Object) {
 		Scheme: targetURL.Scheme,
 		Domain: targetURL.Hostname(),
 		Method: method,
+		Query:  targetURL.Query(),
 	}
 
 	if targetURL.RawQuery == "" {
@@ -19,4 +19,12 @@ func TestShouldAppendQueryParamToURL(t *testing.T) {
 	assert.Equal(t, "GET", object.Method)
 	assert.Equal(t, "/api?type=none", object.Path)
 	assert.Equal(t, "https", object.Scheme)
+	assert.Equal(t, url.Values{"type": []string{"none"}}, object.Query)
+	assert.Nil(t, object.Headers)
+}
+
+func TestShouldDescribeRuleOfRequirements(t *testing.T) {
+	assert.Equal(t, "default policy", Requirements{Level: Denied}.RuleString())
+	assert.Equal(t, "rule #3", Requirements{Level: OneFactor, RulePosition: 3}.RuleString())
+	assert.Equal(t, "rule #3 (admin)", Requirements{Level: OneFactor, RulePosition: 3, RuleName: "admin"}.RuleString())
 }
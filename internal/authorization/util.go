@@ -4,9 +4,11 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // PolicyToLevel converts a string policy to int authorization level.
@@ -25,7 +27,32 @@ func PolicyToLevel(policy string) Level {
 	return Denied
 }
 
+// LevelToPolicy converts an int authorization level to a string policy.
+func LevelToPolicy(level Level) (policy string) {
+	switch level {
+	case Bypass:
+		return bypass
+	case OneFactor:
+		return oneFactor
+	case TwoFactor:
+		return twoFactor
+	case Denied:
+		return deny
+	}
+
+	return deny
+}
+
 func schemaSubjectToACLSubject(subjectRule string) (subject AccessControlSubject) {
+	if strings.HasPrefix(subjectRule, negationPrefix) {
+		subject = schemaSubjectToACLSubject(strings.TrimPrefix(subjectRule, negationPrefix))
+		if subject == nil {
+			return nil
+		}
+
+		return AccessControlNegation{Subject: subject}
+	}
+
 	if strings.HasPrefix(subjectRule, userPrefix) {
 		user := strings.Trim(subjectRule[len(userPrefix):], " ")
 
@@ -38,6 +65,27 @@ func schemaSubjectToACLSubject(subjectRule string) (subject AccessControlSubject
 		return AccessControlGroup{Name: group}
 	}
 
+	if strings.HasPrefix(subjectRule, emailPrefix) {
+		email := strings.Trim(subjectRule[len(emailPrefix):], " ")
+
+		return AccessControlEmail{Address: email}
+	}
+
+	if strings.HasPrefix(subjectRule, attributePrefix) {
+		attribute := strings.SplitN(strings.Trim(subjectRule[len(attributePrefix):], " "), ":", 2)
+		if len(attribute) != 2 {
+			return nil
+		}
+
+		return AccessControlAttribute{Name: attribute[0], Value: attribute[1]}
+	}
+
+	if strings.HasPrefix(subjectRule, oauth2ClientPrefix) {
+		client := strings.Trim(subjectRule[len(oauth2ClientPrefix):], " ")
+
+		return AccessControlOAuth2Client{ID: client}
+	}
+
 	return nil
 }
 
@@ -48,9 +96,12 @@ func schemaDomainsToACL(domainRules []string) (domains []AccessControlDomain) {
 		domainRule = strings.ToLower(domainRule)
 
 		switch {
-		case strings.HasPrefix(domainRule, "*."):
+		case strings.HasPrefix(domainRule, "*.") && !strings.Contains(domainRule[2:], "*"):
 			domain.Wildcard = true
 			domain.Name = domainRule[1:]
+		case strings.Contains(domainRule, "*"):
+			domain.Pattern = domainWildcardsToRegexp(domainRule)
+			domain.Name = domainWildcardsSuffix(domainRule)
 		case strings.HasPrefix(domainRule, "{user}"):
 			domain.UserWildcard = true
 			domain.Name = domainRule[7:]
@@ -67,6 +118,52 @@ func schemaDomainsToACL(domainRules []string) (domains []AccessControlDomain) {
 	return domains
 }
 
+func schemaDomainsRegexToACL(domainRegexRules []string) (domains []AccessControlDomain) {
+	for _, domainRegexRule := range domainRegexRules {
+		domains = append(domains, AccessControlDomain{Pattern: regexp.MustCompile(domainRegexRule)})
+	}
+
+	return domains
+}
+
+// domainWildcardsToRegexp converts a domain with wildcards to a regular expression where * matches any part of a single
+// level of the domain and ** matches any number of levels.
+func domainWildcardsToRegexp(domain string) *regexp.Regexp {
+	pattern := &strings.Builder{}
+
+	pattern.WriteString("^")
+
+	for i, part := range strings.Split(domain, "**") {
+		if i != 0 {
+			pattern.WriteString(".+")
+		}
+
+		for j, subpart := range strings.Split(part, "*") {
+			if j != 0 {
+				pattern.WriteString("[^.]+")
+			}
+
+			pattern.WriteString(regexp.QuoteMeta(subpart))
+		}
+	}
+
+	pattern.WriteString("$")
+
+	return regexp.MustCompile(pattern.String())
+}
+
+// domainWildcardsSuffix returns the literal suffix starting with a dot which all the domains matching a domain with
+// wildcards end with, or an empty string if there is none.
+func domainWildcardsSuffix(domain string) string {
+	suffix := domain[strings.LastIndex(domain, "*")+1:]
+
+	if i := strings.Index(suffix, "."); i != -1 {
+		return suffix[i:]
+	}
+
+	return ""
+}
+
 func schemaResourcesToACL(resourceRules []string) (resources []AccessControlResource) {
 	for _, resourceRule := range resourceRules {
 		resources = append(resources, AccessControlResource{regexp.MustCompile(resourceRule)})
@@ -75,6 +172,74 @@ func schemaResourcesToACL(resourceRules []string) (resources []AccessControlReso
 	return resources
 }
 
+func schemaMatchersToACL(matcherRules []schema.ACLRuleMatcher) (matchers []AccessControlMatcher) {
+	for _, matcherRule := range matcherRules {
+		matcher := AccessControlMatcher{
+			Key:      matcherRule.Key,
+			Operator: matcherRule.Operator,
+			Value:    matcherRule.Value,
+		}
+
+		// The operator defaults to equal when a value is given and to present otherwise.
+		if matcher.Operator == "" {
+			if matcher.Value == "" {
+				matcher.Operator = operatorPresent
+			} else {
+				matcher.Operator = operatorEqual
+			}
+		}
+
+		if matcher.Operator == operatorPattern {
+			matcher.Pattern = regexp.MustCompile(matcher.Value)
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	return matchers
+}
+
+func schemaTimeWindowToACL(windowRule *schema.ACLRuleTimeWindow) (window *AccessControlTimeWindow) {
+	if windowRule == nil {
+		return nil
+	}
+
+	window = &AccessControlTimeWindow{Location: time.Local}
+
+	// Skip Error Checks since validator checks them.
+	if windowRule.Timezone != "" {
+		window.Location, _ = time.LoadLocation(windowRule.Timezone)
+	}
+
+	for _, dayRule := range windowRule.Days {
+		day, _ := utils.ParseWeekday(dayRule)
+
+		window.Days = append(window.Days, day)
+	}
+
+	if windowRule.StartTime != "" && windowRule.EndTime != "" {
+		window.Start, _ = utils.ParseTimeOfDay(windowRule.StartTime)
+		window.End, _ = utils.ParseTimeOfDay(windowRule.EndTime)
+	}
+
+	if windowRule.NotBefore != "" {
+		window.NotBefore, _, _ = utils.ParseDate(windowRule.NotBefore, window.Location)
+	}
+
+	if windowRule.NotAfter != "" {
+		var dateOnly bool
+
+		window.NotAfter, dateOnly, _ = utils.ParseDate(windowRule.NotAfter, window.Location)
+
+		// A date without a time covers the whole day.
+		if dateOnly {
+			window.NotAfter = window.NotAfter.AddDate(0, 0, 1)
+		}
+	}
+
+	return window
+}
+
 func schemaMethodsToACL(methodRules []string) (methods []string) {
 	for _, method := range methodRules {
 		methods = append(methods, strings.ToUpper(method))
@@ -22,6 +22,23 @@ func TestShouldNotParseInvalidSubjects(t *testing.T) {
 	assert.True(t, subjectsACL[0].IsMatch(Subject{Username: "a", Groups: []string{"z"}}))
 }
 
+func TestShouldConvertLevelToPolicy(t *testing.T) {
+	for _, policy := range []string{bypass, oneFactor, twoFactor, deny} {
+		assert.Equal(t, policy, LevelToPolicy(PolicyToLevel(policy)))
+	}
+
+	assert.Equal(t, deny, LevelToPolicy(Level(42)))
+}
+
+func TestShouldParseRicherSubjects(t *testing.T) {
+	assert.Equal(t, AccessControlNegation{Subject: AccessControlGroup{Name: "contractors"}}, schemaSubjectToACLSubject("!group:contractors"))
+	assert.Equal(t, AccessControlEmail{Address: "*@example.com"}, schemaSubjectToACLSubject("email:*@example.com"))
+	assert.Equal(t, AccessControlAttribute{Name: "tenant", Value: "acme:eu"}, schemaSubjectToACLSubject("attribute:tenant:acme:eu"))
+	assert.Equal(t, AccessControlOAuth2Client{ID: "grafana"}, schemaSubjectToACLSubject("oauth2:client:grafana"))
+	assert.Nil(t, schemaSubjectToACLSubject("attribute:tenant"))
+	assert.Nil(t, schemaSubjectToACLSubject("!groups:contractors"))
+}
+
 func TestShouldSplitDomainCorrectly(t *testing.T) {
 	prefix, suffix := domainToPrefixSuffix("apple.example.com")
 
@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/configuration"
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+var (
+	checkPolicyConfig   string
+	checkPolicyURL      string
+	checkPolicyMethod   string
+	checkPolicyUsername string
+	checkPolicyGroups   []string
+	checkPolicyEmails   []string
+	checkPolicyIP       string
+	checkPolicyTests    string
+)
+
+func init() {
+	AccessControlCheckPolicyCmd.Flags().StringVar(&checkPolicyConfig, "config", "", "Configuration file")
+	AccessControlCheckPolicyCmd.Flags().StringVar(&checkPolicyURL, "url", "", "URL of the request to check")
+	AccessControlCheckPolicyCmd.Flags().StringVar(&checkPolicyMethod, "method", "GET", "HTTP method of the request to check")
+	AccessControlCheckPolicyCmd.Flags().StringVar(&checkPolicyUsername, "username", "", "Username of the subject, the subject is anonymous when not provided")
+	AccessControlCheckPolicyCmd.Flags().StringSliceVar(&checkPolicyGroups, "groups", nil, "Groups of the subject")
+	AccessControlCheckPolicyCmd.Flags().StringSliceVar(&checkPolicyEmails, "emails", nil, "Email addresses of the subject")
+	AccessControlCheckPolicyCmd.Flags().StringVar(&checkPolicyIP, "ip", "", "IP address the request originates from")
+	AccessControlCheckPolicyCmd.Flags().StringVar(&checkPolicyTests, "tests", "", "YAML file of requests and their expected policy to check instead of a single request")
+
+	_ = AccessControlCheckPolicyCmd.MarkFlagRequired("config")
+
+	AccessControlCmd.AddCommand(AccessControlCheckPolicyCmd)
+}
+
+// policyCheck is a request to check and, when it's part of a tests file, the policy expected to apply to it.
+type policyCheck struct {
+	Name     string   `yaml:"name"`
+	URL      string   `yaml:"url"`
+	Method   string   `yaml:"method"`
+	Username string   `yaml:"username"`
+	Groups   []string `yaml:"groups"`
+	Emails   []string `yaml:"emails"`
+	IP       string   `yaml:"ip"`
+	Expected string   `yaml:"expected"`
+}
+
+func (c policyCheck) toSubjectObject() (subject authorization.Subject, object authorization.Object, err error) {
+	targetURL, err := url.ParseRequestURI(c.URL)
+	if err != nil {
+		return subject, object, fmt.Errorf("the url '%s' is invalid: %w", c.URL, err)
+	}
+
+	subject = authorization.Subject{
+		Username: c.Username,
+		Groups:   c.Groups,
+		Emails:   c.Emails,
+	}
+
+	if c.IP != "" {
+		if subject.IP = net.ParseIP(c.IP); subject.IP == nil {
+			return subject, object, fmt.Errorf("the ip '%s' is invalid", c.IP)
+		}
+	}
+
+	method := c.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	return subject, authorization.NewObject(targetURL, strings.ToUpper(method)), nil
+}
+
+func loadAccessControlAuthorizer() *authorization.Authorizer {
+	config, errs := configuration.Read(checkPolicyConfig)
+	if len(errs) != 0 {
+		errors := ""
+		for _, err := range errs {
+			errors += fmt.Sprintf("\t%s\n", err.Error())
+		}
+
+		log.Fatalf("Errors occurred parsing configuration:\n%s", errors)
+	}
+
+	return authorization.NewAuthorizer(&schema.Configuration{AccessControl: config.AccessControl})
+}
+
+func checkPolicy(cmd *cobra.Command, args []string) {
+	authorizer := loadAccessControlAuthorizer()
+
+	if checkPolicyTests != "" {
+		runPolicyTests(authorizer, checkPolicyTests)
+		return
+	}
+
+	if checkPolicyURL == "" {
+		log.Fatalf("Either the --url or the --tests flag must be provided")
+	}
+
+	check := policyCheck{
+		URL:      checkPolicyURL,
+		Method:   checkPolicyMethod,
+		Username: checkPolicyUsername,
+		Groups:   checkPolicyGroups,
+		Emails:   checkPolicyEmails,
+		IP:       checkPolicyIP,
+	}
+
+	subject, object, err := check.toSubjectObject()
+	if err != nil {
+		log.Fatalf("Unable to check the policy: %v", err)
+	}
+
+	fmt.Printf("Performing policy check for request to '%s' method '%s' by subject '%s'.\n\n",
+		object.String(), object.Method, subject.String())
+
+	results := authorizer.GetRuleMatchResults(subject, object)
+
+	if len(results) == 0 {
+		fmt.Printf("No rules are configured, the default policy '%s' will be applied to this request.\n",
+			authorization.LevelToPolicy(authorizer.GetDefaultPolicy()))
+
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "  #\tPolicy\tDomain\tResource\tMethod\tHeaders\tQuery\tNetwork\tSubject\tTime Window\tResult")
+
+	applied := -1
+
+	for i, result := range results {
+		marker := " "
+
+		if applied == -1 && result.IsMatch() {
+			applied = i
+			marker = "*"
+		}
+
+		fmt.Fprintf(writer, "%s %d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", marker, result.Rule.Position,
+			authorization.LevelToPolicy(result.Rule.Policy), hitMiss(result.MatchDomain), hitMiss(result.MatchResources),
+			hitMiss(result.MatchMethods), hitMiss(result.MatchHeaders), hitMiss(result.MatchQuery),
+			hitMiss(result.MatchNetworks), hitMiss(result.MatchSubjects), hitMiss(result.MatchTimeWindow),
+			hitMiss(result.IsMatch()))
+	}
+
+	_ = writer.Flush()
+
+	fmt.Println()
+
+	if applied == -1 {
+		fmt.Printf("No rule matches, the default policy '%s' will be applied to this request.\n",
+			authorization.LevelToPolicy(authorizer.GetDefaultPolicy()))
+	} else {
+		rule := results[applied].Rule
+
+		if rule.Name == "" {
+			fmt.Printf("The policy '%s' of rule #%d will be applied to this request.\n",
+				authorization.LevelToPolicy(rule.Policy), rule.Position)
+		} else {
+			fmt.Printf("The policy '%s' of rule #%d (%s) will be applied to this request.\n",
+				authorization.LevelToPolicy(rule.Policy), rule.Position, rule.Name)
+		}
+	}
+
+	if subject.IsAnonymous() {
+		fmt.Println("The subject is anonymous, the rules with subjects match since the user may still log in.")
+	}
+}
+
+func runPolicyTests(authorizer *authorization.Authorizer, path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Unable to read the tests file: %v", err)
+	}
+
+	var checks []policyCheck
+
+	if err = yaml.Unmarshal(data, &checks); err != nil {
+		log.Fatalf("Unable to parse the tests file: %v", err)
+	}
+
+	failures := 0
+
+	for i, check := range checks {
+		name := check.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		subject, object, err := check.toSubjectObject()
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+
+			failures++
+
+			continue
+		}
+
+		actual := authorization.LevelToPolicy(authorizer.GetRequiredLevel(subject, object))
+
+		if actual != check.Expected {
+			fmt.Printf("FAIL %s: expected the policy '%s' but got '%s' for request to '%s' method '%s' by subject '%s'\n",
+				name, check.Expected, actual, object.String(), object.Method, subject.String())
+
+			failures++
+
+			continue
+		}
+
+		fmt.Printf("PASS %s\n", name)
+	}
+
+	fmt.Printf("\n%d passed, %d failed.\n", len(checks)-failures, failures)
+
+	if failures != 0 {
+		os.Exit(1)
+	}
+}
+
+func hitMiss(match bool) string {
+	if match {
+		return "hit"
+	}
+
+	return "miss"
+}
+
+// AccessControlCmd access control helper command.
+var AccessControlCmd = &cobra.Command{
+	Use:   "access-control",
+	Short: "Commands related to the access control rules",
+}
+
+// AccessControlCheckPolicyCmd command checking which access control rule applies to a request.
+var AccessControlCheckPolicyCmd = &cobra.Command{
+	Use:   "check-policy",
+	Short: "Check which access control rule and policy apply to a request, or to each request of a tests file",
+	Run:   checkPolicy,
+}
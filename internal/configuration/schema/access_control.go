@@ -2,9 +2,10 @@ package schema
 
 // AccessControlConfiguration represents the configuration related to ACLs.
 type AccessControlConfiguration struct {
-	DefaultPolicy string       `mapstructure:"default_policy"`
-	Networks      []ACLNetwork `mapstructure:"networks"`
-	Rules         []ACLRule    `mapstructure:"rules"`
+	DefaultPolicy      string       `mapstructure:"default_policy"`
+	Networks           []ACLNetwork `mapstructure:"networks"`
+	Rules              []ACLRule    `mapstructure:"rules"`
+	ExposeDenialReason bool         `mapstructure:"expose_denial_reason"`
 }
 
 // ACLNetwork represents one ACL network group entry; "weak" coerces a single value into slice.
@@ -15,12 +16,38 @@ type ACLNetwork struct {
 
 // ACLRule represents one ACL rule entry; "weak" coerces a single value into slice.
 type ACLRule struct {
-	Domains   []string   `mapstructure:"domain,weak"`
-	Policy    string     `mapstructure:"policy"`
-	Subjects  [][]string `mapstructure:"subject,weak"`
-	Networks  []string   `mapstructure:"networks"`
-	Resources []string   `mapstructure:"resources"`
-	Methods   []string   `mapstructure:"methods"`
+	Name                string             `mapstructure:"name"`
+	Description         string             `mapstructure:"description"`
+	Domains             []string           `mapstructure:"domain,weak"`
+	DomainsRegex        []string           `mapstructure:"domain_regex,weak"`
+	Policy              string             `mapstructure:"policy"`
+	Subjects            [][]string         `mapstructure:"subject,weak"`
+	Networks            []string           `mapstructure:"networks"`
+	Resources           []string           `mapstructure:"resources"`
+	Methods             []string           `mapstructure:"methods"`
+	Passwordless        string             `mapstructure:"passwordless"`
+	MaxAge              string             `mapstructure:"max_age"`
+	SecondFactorMethods []string           `mapstructure:"second_factor_methods"`
+	Headers             []ACLRuleMatcher   `mapstructure:"headers"`
+	Query               []ACLRuleMatcher   `mapstructure:"query"`
+	TimeWindow          *ACLRuleTimeWindow `mapstructure:"time_window"`
+}
+
+// ACLRuleMatcher represents the configuration of a matcher of a request header or a query parameter.
+type ACLRuleMatcher struct {
+	Key      string `mapstructure:"key"`
+	Operator string `mapstructure:"operator"`
+	Value    string `mapstructure:"value"`
+}
+
+// ACLRuleTimeWindow represents the configuration of the period during which an ACL rule applies.
+type ACLRuleTimeWindow struct {
+	Timezone  string   `mapstructure:"timezone"`
+	Days      []string `mapstructure:"days"`
+	StartTime string   `mapstructure:"start_time"`
+	EndTime   string   `mapstructure:"end_time"`
+	NotBefore string   `mapstructure:"not_before"`
+	NotAfter  string   `mapstructure:"not_after"`
 }
 
 // DefaultACLNetwork represents the default configuration related to access control network group configuration.
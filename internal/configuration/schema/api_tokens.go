@@ -0,0 +1,12 @@
+package schema
+
+// APITokensConfiguration represents the configuration of the API tokens issued by the users to their scripts.
+type APITokensConfiguration struct {
+	MaxLifetime string `mapstructure:"max_lifetime"`
+	AdminsGroup string `mapstructure:"admins_group"`
+}
+
+// DefaultAPITokensConfiguration represents the default values of the APITokensConfiguration.
+var DefaultAPITokensConfiguration = APITokensConfiguration{
+	MaxLifetime: "1y",
+}
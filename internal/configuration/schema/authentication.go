@@ -4,11 +4,16 @@ package schema
 type LDAPAuthenticationBackendConfiguration struct {
 	Implementation       string     `mapstructure:"implementation"`
 	URL                  string     `mapstructure:"url"`
+	URLs                 []string   `mapstructure:"urls"`
+	SRVDomain            string     `mapstructure:"srv_domain"`
+	ServerSelection      string     `mapstructure:"server_selection"`
 	BaseDN               string     `mapstructure:"base_dn"`
 	AdditionalUsersDN    string     `mapstructure:"additional_users_dn"`
 	UsersFilter          string     `mapstructure:"users_filter"`
 	AdditionalGroupsDN   string     `mapstructure:"additional_groups_dn"`
 	GroupsFilter         string     `mapstructure:"groups_filter"`
+	GroupSearchMode      string     `mapstructure:"group_search_mode"`
+	NestedGroups         bool       `mapstructure:"nested_groups"`
 	GroupNameAttribute   string     `mapstructure:"group_name_attribute"`
 	UsernameAttribute    string     `mapstructure:"username_attribute"`
 	MailAttribute        string     `mapstructure:"mail_attribute"`
@@ -17,6 +22,23 @@ type LDAPAuthenticationBackendConfiguration struct {
 	Password             string     `mapstructure:"password"`
 	StartTLS             bool       `mapstructure:"start_tls"`
 	TLS                  *TLSConfig `mapstructure:"tls"`
+
+	Pool           *LDAPConnectionPoolConfiguration `mapstructure:"pool"`
+	CircuitBreaker *LDAPCircuitBreakerConfiguration `mapstructure:"circuit_breaker"`
+}
+
+// LDAPCircuitBreakerConfiguration represents the configuration of the circuit breaker of each LDAP server.
+type LDAPCircuitBreakerConfiguration struct {
+	Failures int    `mapstructure:"failures"`
+	Cooldown string `mapstructure:"cooldown"`
+}
+
+// LDAPConnectionPoolConfiguration represents the configuration of the pool of connections bound with the LDAP user.
+type LDAPConnectionPoolConfiguration struct {
+	Disable             bool   `mapstructure:"disable"`
+	MaxConnections      int    `mapstructure:"max_connections"`
+	IdleTimeout         string `mapstructure:"idle_timeout"`
+	HealthCheckInterval string `mapstructure:"health_check_interval"`
 }
 
 // FileAuthenticationBackendConfiguration represents the configuration related to file-based backend.
@@ -25,6 +47,48 @@ type FileAuthenticationBackendConfiguration struct {
 	Password *PasswordConfiguration `mapstructure:"password"`
 }
 
+// SQLAuthenticationBackendConfiguration represents the configuration related to the SQL backend.
+type SQLAuthenticationBackendConfiguration struct {
+	MySQL      *MySQLStorageConfiguration      `mapstructure:"mysql"`
+	PostgreSQL *PostgreSQLStorageConfiguration `mapstructure:"postgres"`
+	Password   *PasswordConfiguration          `mapstructure:"password"`
+	Queries    SQLAuthenticationBackendQueries `mapstructure:"queries"`
+}
+
+// SQLAuthenticationBackendQueries represents the queries run by the SQL backend. The queries must use the placeholder
+// syntax of the database driver.
+type SQLAuthenticationBackendQueries struct {
+	Password       string `mapstructure:"password"`
+	Details        string `mapstructure:"details"`
+	Emails         string `mapstructure:"emails"`
+	Groups         string `mapstructure:"groups"`
+	UpdatePassword string `mapstructure:"update_password"`
+	CreateUser     string `mapstructure:"create_user"`
+	CreateEmail    string `mapstructure:"create_email"`
+	CreateGroup    string `mapstructure:"create_group"`
+}
+
+// ChainAuthenticationBackendConfiguration represents the configuration of chained authentication backends.
+type ChainAuthenticationBackendConfiguration struct {
+	Duplicates string                                      `mapstructure:"duplicates"`
+	Backends   []ChainedAuthenticationBackendConfiguration `mapstructure:"backends"`
+}
+
+// ChainedAuthenticationBackendConfiguration represents the configuration of a single backend in the chain.
+type ChainedAuthenticationBackendConfiguration struct {
+	Backend         string `mapstructure:"backend"`
+	UsernamePattern string `mapstructure:"username_pattern"`
+}
+
+// UserAttributeConfiguration represents an additional attribute of the users and how it's forwarded to the
+// applications.
+type UserAttributeConfiguration struct {
+	Name          string `mapstructure:"name"`
+	LDAPAttribute string `mapstructure:"ldap_attribute"`
+	Header        string `mapstructure:"header"`
+	Claim         string `mapstructure:"claim"`
+}
+
 // PasswordConfiguration represents the configuration related to password hashing.
 type PasswordConfiguration struct {
 	Iterations  int    `mapstructure:"iterations"`
@@ -33,14 +97,19 @@ type PasswordConfiguration struct {
 	Algorithm   string `mapstrucutre:"algorithm"`
 	Memory      int    `mapstructure:"memory"`
 	Parallelism int    `mapstructure:"parallelism"`
+	Rehash      bool   `mapstructure:"rehash"`
 }
 
 // AuthenticationBackendConfiguration represents the configuration related to the authentication backend.
 type AuthenticationBackendConfiguration struct {
-	DisableResetPassword bool                                    `mapstructure:"disable_reset_password"`
-	RefreshInterval      string                                  `mapstructure:"refresh_interval"`
-	LDAP                 *LDAPAuthenticationBackendConfiguration `mapstructure:"ldap"`
-	File                 *FileAuthenticationBackendConfiguration `mapstructure:"file"`
+	DisableResetPassword bool                                     `mapstructure:"disable_reset_password"`
+	RefreshInterval      string                                   `mapstructure:"refresh_interval"`
+	LDAP                 *LDAPAuthenticationBackendConfiguration  `mapstructure:"ldap"`
+	File                 *FileAuthenticationBackendConfiguration  `mapstructure:"file"`
+	SQL                  *SQLAuthenticationBackendConfiguration   `mapstructure:"sql"`
+	Chain                *ChainAuthenticationBackendConfiguration `mapstructure:"chain"`
+
+	Attributes []UserAttributeConfiguration `mapstructure:"attributes"`
 }
 
 // DefaultPasswordConfiguration represents the default configuration related to Argon2id hashing.
@@ -80,6 +149,23 @@ var DefaultLDAPAuthenticationBackendConfiguration = LDAPAuthenticationBackendCon
 	TLS: &TLSConfig{
 		MinimumVersion: "TLS1.2",
 	},
+	GroupSearchMode: LDAPGroupSearchModeFilter,
+	ServerSelection: LDAPServerSelectionPriority,
+	Pool:            &DefaultLDAPConnectionPoolConfiguration,
+	CircuitBreaker:  &DefaultLDAPCircuitBreakerConfiguration,
+}
+
+// DefaultLDAPCircuitBreakerConfiguration represents the default LDAP circuit breaker config.
+var DefaultLDAPCircuitBreakerConfiguration = LDAPCircuitBreakerConfiguration{
+	Failures: 3,
+	Cooldown: "30s",
+}
+
+// DefaultLDAPConnectionPoolConfiguration represents the default LDAP connection pool config.
+var DefaultLDAPConnectionPoolConfiguration = LDAPConnectionPoolConfiguration{
+	MaxConnections:      5,
+	IdleTimeout:         "5m",
+	HealthCheckInterval: "1m",
 }
 
 // DefaultLDAPAuthenticationBackendImplementationActiveDirectoryConfiguration represents the default LDAP config for the MSAD Implementation.
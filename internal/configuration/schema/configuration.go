@@ -28,4 +28,7 @@ type Configuration struct {
 	Storage               StorageConfiguration               `mapstructure:"storage"`
 	Notifier              *NotifierConfiguration             `mapstructure:"notifier"`
 	Server                ServerConfiguration                `mapstructure:"server"`
+	APITokens             *APITokensConfiguration            `mapstructure:"api_tokens"`
+	Registration          *RegistrationConfiguration         `mapstructure:"registration"`
+	Passwordless          *PasswordlessConfiguration         `mapstructure:"passwordless"`
 }
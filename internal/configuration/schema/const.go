@@ -23,3 +23,63 @@ const LDAPImplementationCustom = "custom"
 
 // LDAPImplementationActiveDirectory is the string for the Active Directory LDAP implementation.
 const LDAPImplementationActiveDirectory = "activedirectory"
+
+const (
+	// LDAPGroupSearchModeFilter is the LDAP group search mode where the groups are searched with the groups filter.
+	LDAPGroupSearchModeFilter = "filter"
+
+	// LDAPGroupSearchModeMemberOf is the LDAP group search mode where the groups are read from the memberOf attribute
+	// of the user.
+	LDAPGroupSearchModeMemberOf = "memberof"
+)
+
+const (
+	// LDAPServerSelectionPriority is the LDAP server selection where the servers are tried in the configured order.
+	LDAPServerSelectionPriority = "priority"
+
+	// LDAPServerSelectionRoundRobin is the LDAP server selection where each connection starts with the next server.
+	LDAPServerSelectionRoundRobin = "round_robin"
+)
+
+const (
+	// ClientCertificateUsernameCommonName is the client certificate username attribute using the common name of the
+	// subject.
+	ClientCertificateUsernameCommonName = "common_name"
+
+	// ClientCertificateUsernameEmail is the client certificate username attribute using the first email address of the
+	// subject alternative names.
+	ClientCertificateUsernameEmail = "email"
+
+	// ClientCertificateUsernameDNS is the client certificate username attribute using the first DNS name of the subject
+	// alternative names.
+	ClientCertificateUsernameDNS = "dns"
+)
+
+const (
+	// AuthenticationLevelOneFactor is the authentication level of the users authenticated by a client certificate or a
+	// trusted header equivalent to the first factor.
+	AuthenticationLevelOneFactor = "one_factor"
+
+	// AuthenticationLevelTwoFactor is the authentication level of the users authenticated by a client certificate or a
+	// trusted header equivalent to both factors.
+	AuthenticationLevelTwoFactor = "two_factor"
+)
+
+const (
+	// AuthenticationBackendFile is the name of the file authentication backend.
+	AuthenticationBackendFile = "file"
+
+	// AuthenticationBackendLDAP is the name of the LDAP authentication backend.
+	AuthenticationBackendLDAP = "ldap"
+
+	// AuthenticationBackendSQL is the name of the SQL authentication backend.
+	AuthenticationBackendSQL = "sql"
+)
+
+const (
+	// ChainDuplicatesFirst is the chain duplicates mode where the first backend knowing a user owns it.
+	ChainDuplicatesFirst = "first"
+
+	// ChainDuplicatesReject is the chain duplicates mode where users known by more than one backend are rejected.
+	ChainDuplicatesReject = "reject"
+)
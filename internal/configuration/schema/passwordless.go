@@ -0,0 +1,13 @@
+package schema
+
+// PasswordlessConfiguration represents the configuration of the passwordless sign in with WebAuthn security keys.
+type PasswordlessConfiguration struct {
+	DisplayName string `mapstructure:"display_name"`
+	Timeout     string `mapstructure:"timeout"`
+}
+
+// DefaultPasswordlessConfiguration represents the default values of the PasswordlessConfiguration.
+var DefaultPasswordlessConfiguration = PasswordlessConfiguration{
+	DisplayName: "Authelia",
+	Timeout:     "1m",
+}
@@ -0,0 +1,9 @@
+package schema
+
+// RegistrationConfiguration represents the configuration of the self-service registration of the users.
+type RegistrationConfiguration struct {
+	RequireApproval bool     `mapstructure:"require_approval"`
+	AdminsGroup     string   `mapstructure:"admins_group"`
+	AllowedDomains  []string `mapstructure:"allowed_domains"`
+	DefaultGroups   []string `mapstructure:"default_groups"`
+}
@@ -7,6 +7,39 @@ type ServerConfiguration struct {
 	WriteBufferSize int    `mapstructure:"write_buffer_size"`
 	EnablePprof     bool   `mapstructure:"enable_endpoint_pprof"`
 	EnableExpvars   bool   `mapstructure:"enable_endpoint_expvars"`
+
+	ClientCertificates *ClientCertificatesConfiguration `mapstructure:"client_certificates"`
+	TrustedHeader      *TrustedHeaderConfiguration      `mapstructure:"trusted_header"`
+	ExtAuthz           *ExtAuthzConfiguration           `mapstructure:"ext_authz"`
+}
+
+// ClientCertificatesConfiguration represents the configuration of the authentication with client certificates.
+type ClientCertificatesConfiguration struct {
+	CertificateAuthority string   `mapstructure:"certificate_authority"`
+	UsernameAttribute    string   `mapstructure:"username_attribute"`
+	AuthenticationLevel  string   `mapstructure:"authentication_level"`
+	Header               string   `mapstructure:"header"`
+	TrustedNetworks      []string `mapstructure:"trusted_networks"`
+}
+
+// TrustedHeaderConfiguration represents the configuration of the authentication of users already authenticated by an
+// upstream gateway which forwards their username in a header.
+type TrustedHeaderConfiguration struct {
+	Header              string   `mapstructure:"header"`
+	TrustedNetworks     []string `mapstructure:"trusted_networks"`
+	AuthenticationLevel string   `mapstructure:"authentication_level"`
+	Secret              string   `mapstructure:"secret"`
+	SignatureHeader     string   `mapstructure:"signature_header"`
+	SignatureLifetime   string   `mapstructure:"signature_lifetime"`
+}
+
+// ExtAuthzConfiguration represents the configuration of the gRPC listener implementing the external authorization API
+// of Envoy.
+type ExtAuthzConfiguration struct {
+	Host            string   `mapstructure:"host"`
+	Port            int      `mapstructure:"port"`
+	PortalURL       string   `mapstructure:"portal_url"`
+	TrustedNetworks []string `mapstructure:"trusted_networks"`
 }
 
 // DefaultServerConfiguration represents the default values of the ServerConfiguration.
@@ -14,3 +47,24 @@ var DefaultServerConfiguration = ServerConfiguration{
 	ReadBufferSize:  4096,
 	WriteBufferSize: 4096,
 }
+
+// DefaultClientCertificatesConfiguration represents the default values of the ClientCertificatesConfiguration.
+var DefaultClientCertificatesConfiguration = ClientCertificatesConfiguration{
+	UsernameAttribute:   ClientCertificateUsernameCommonName,
+	AuthenticationLevel: AuthenticationLevelOneFactor,
+}
+
+// DefaultTrustedHeaderConfiguration represents the default values of the TrustedHeaderConfiguration.
+var DefaultTrustedHeaderConfiguration = TrustedHeaderConfiguration{
+	Header:              "X-Authenticated-User",
+	AuthenticationLevel: AuthenticationLevelOneFactor,
+	SignatureHeader:     "X-Authenticated-User-Signature",
+	SignatureLifetime:   "1m",
+}
+
+// DefaultExtAuthzConfiguration represents the default values of the ExtAuthzConfiguration.
+var DefaultExtAuthzConfiguration = ExtAuthzConfiguration{
+	Host:            "127.0.0.1",
+	Port:            9092,
+	TrustedNetworks: []string{"127.0.0.1", "::1"},
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/utils"
@@ -23,7 +24,26 @@ func IsResourceValid(resource string) (err error) {
 
 // IsSubjectValid check if a subject is valid.
 func IsSubjectValid(subject string) (isValid bool) {
-	return subject == "" || strings.HasPrefix(subject, "user:") || strings.HasPrefix(subject, "group:")
+	if subject == "" {
+		return true
+	}
+
+	subject = strings.TrimPrefix(subject, "!")
+
+	switch {
+	case strings.HasPrefix(subject, "user:"), strings.HasPrefix(subject, "group:"):
+		return true
+	case strings.HasPrefix(subject, "email:"):
+		return len(subject) > len("email:")
+	case strings.HasPrefix(subject, "attribute:"):
+		attribute := strings.SplitN(strings.TrimPrefix(subject, "attribute:"), ":", 2)
+
+		return len(attribute) == 2 && attribute[0] != ""
+	case strings.HasPrefix(subject, "oauth2:client:"):
+		return len(subject) > len("oauth2:client:")
+	default:
+		return false
+	}
 }
 
 // IsNetworkGroupValid check if a network group is valid.
@@ -84,13 +104,25 @@ func ValidateRules(configuration schema.AccessControlConfiguration, validator *s
 		return
 	}
 
+	names := map[string]int{}
+
 	for i, rule := range configuration.Rules {
 		rulePosition := i + 1
 
-		if len(rule.Domains) == 0 {
+		if rule.Name != "" {
+			if position, ok := names[rule.Name]; ok {
+				validator.Push(fmt.Errorf("Name [%s] for rule #%d is invalid, it's already the name of rule #%d", rule.Name, rulePosition, position))
+			} else {
+				names[rule.Name] = rulePosition
+			}
+		}
+
+		if len(rule.Domains) == 0 && len(rule.DomainsRegex) == 0 {
 			validator.Push(fmt.Errorf("Rule #%d is invalid, a policy must have one or more domains", rulePosition))
 		}
 
+		validateDomains(rulePosition, rule, validator)
+
 		if !IsPolicyValid(rule.Policy) {
 			validator.Push(fmt.Errorf("Policy [%s] for rule #%d domain: %s is invalid, a policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'", rule.Policy, rulePosition, rule.Domains))
 		}
@@ -103,12 +135,40 @@ func ValidateRules(configuration schema.AccessControlConfiguration, validator *s
 
 		validateMethods(rulePosition, rule, validator)
 
+		validateMatchers(rulePosition, "Header", rule.Domains, rule.Headers, validator)
+
+		validateMatchers(rulePosition, "Query", rule.Domains, rule.Query, validator)
+
+		if rule.Passwordless != "" && rule.Passwordless != passwordlessAccept && rule.Passwordless != passwordlessReject {
+			validator.Push(fmt.Errorf("Passwordless option [%s] for rule #%d domain: %s is invalid, must either be 'accept' or 'reject'", rule.Passwordless, rulePosition, rule.Domains))
+		}
+
+		validateMaxAge(rulePosition, rule, validator)
+
+		validateSecondFactorMethods(rulePosition, rule, validator)
+
+		validateTimeWindow(rulePosition, rule, validator)
+
 		if rule.Policy == bypassPolicy && len(rule.Subjects) != 0 {
 			validator.Push(fmt.Errorf(errAccessControlInvalidPolicyWithSubjects, rulePosition, rule.Domains, rule.Subjects))
 		}
 	}
 }
 
+func validateDomains(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	for _, domain := range rule.Domains {
+		if strings.Contains(domain, "*") && (strings.HasPrefix(domain, "{user}") || strings.HasPrefix(domain, "{group}")) {
+			validator.Push(fmt.Errorf("Domain %s for rule #%d is invalid, the {user} and {group} prefixes can't be combined with wildcards", domain, rulePosition))
+		}
+	}
+
+	for _, domainRegex := range rule.DomainsRegex {
+		if err := IsResourceValid(domainRegex); err != nil {
+			validator.Push(fmt.Errorf("Domain regex %s for rule #%d is invalid, %s", domainRegex, rulePosition, err))
+		}
+	}
+}
+
 func validateNetworks(rulePosition int, rule schema.ACLRule, configuration schema.AccessControlConfiguration, validator *schema.StructValidator) {
 	for _, network := range rule.Networks {
 		if !IsNetworkValid(network) {
@@ -131,7 +191,7 @@ func validateSubjects(rulePosition int, rule schema.ACLRule, validator *schema.S
 	for _, subjectRule := range rule.Subjects {
 		for _, subject := range subjectRule {
 			if !IsSubjectValid(subject) {
-				validator.Push(fmt.Errorf("Subject %s for rule #%d domain: %s is invalid, must start with 'user:' or 'group:'", subjectRule, rulePosition, rule.Domains))
+				validator.Push(fmt.Errorf("Subject %s for rule #%d domain: %s is invalid, must start with 'user:', 'group:', 'email:', 'attribute:' or 'oauth2:client:' optionally prefixed with '!'", subjectRule, rulePosition, rule.Domains))
 			}
 		}
 	}
@@ -144,3 +204,149 @@ func validateMethods(rulePosition int, rule schema.ACLRule, validator *schema.St
 		}
 	}
 }
+
+func validateMaxAge(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	if rule.MaxAge == "" {
+		return
+	}
+
+	if rule.Policy != oneFactorPolicy && rule.Policy != twoFactorPolicy {
+		validator.Push(fmt.Errorf("Max age for rule #%d domain: %s is invalid, it can only be set with the 'one_factor' or 'two_factor' policy", rulePosition, rule.Domains))
+		return
+	}
+
+	maxAge, err := utils.ParseDurationString(rule.MaxAge)
+
+	switch {
+	case err != nil:
+		validator.Push(fmt.Errorf("Error occurred parsing max age string for rule #%d domain: %s: %s", rulePosition, rule.Domains, err))
+	case maxAge <= 0:
+		validator.Push(fmt.Errorf("Max age for rule #%d domain: %s must be above 0", rulePosition, rule.Domains))
+	}
+}
+
+func validateSecondFactorMethods(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	if len(rule.SecondFactorMethods) == 0 {
+		return
+	}
+
+	if rule.Policy != twoFactorPolicy {
+		validator.Push(fmt.Errorf("Second factor methods for rule #%d domain: %s are invalid, they can only be set with the 'two_factor' policy", rulePosition, rule.Domains))
+		return
+	}
+
+	for _, method := range rule.SecondFactorMethods {
+		if !utils.IsStringInSlice(method, validSecondFactorMethods) {
+			validator.Push(fmt.Errorf("Second factor method %s for rule #%d domain: %s is invalid, must be one of the following methods: %s", method, rulePosition, rule.Domains, strings.Join(validSecondFactorMethods, ", ")))
+		}
+	}
+}
+
+func validateMatchers(rulePosition int, kind string, domains []string, matchers []schema.ACLRuleMatcher, validator *schema.StructValidator) {
+	for _, matcher := range matchers {
+		if matcher.Key == "" {
+			validator.Push(fmt.Errorf("%s matcher for rule #%d domain: %s is invalid, a key must be provided", kind, rulePosition, domains))
+			continue
+		}
+
+		operator := matcher.Operator
+
+		switch {
+		case operator == "":
+			// The operator is inferred from the value.
+		case !utils.IsStringInSlice(operator, validMatcherOperators):
+			validator.Push(fmt.Errorf("%s matcher %s for rule #%d domain: %s is invalid, the operator %s must be one of the following operators: %s", kind, matcher.Key, rulePosition, domains, operator, strings.Join(validMatcherOperators, ", ")))
+		case (operator == operatorPresent || operator == operatorAbsent) && matcher.Value != "":
+			validator.Push(fmt.Errorf("%s matcher %s for rule #%d domain: %s is invalid, a value can't be provided with the operator %s", kind, matcher.Key, rulePosition, domains, operator))
+		case (operator == operatorEqual || operator == operatorPattern) && matcher.Value == "":
+			validator.Push(fmt.Errorf("%s matcher %s for rule #%d domain: %s is invalid, a value must be provided with the operator %s", kind, matcher.Key, rulePosition, domains, operator))
+		case operator == operatorPattern:
+			if err := IsResourceValid(matcher.Value); err != nil {
+				validator.Push(fmt.Errorf("%s matcher %s for rule #%d domain: %s is invalid, %s", kind, matcher.Key, rulePosition, domains, err))
+			}
+		}
+	}
+}
+
+func validateTimeWindow(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	window := rule.TimeWindow
+	if window == nil {
+		return
+	}
+
+	if len(window.Days) == 0 && window.StartTime == "" && window.EndTime == "" && window.NotBefore == "" && window.NotAfter == "" {
+		validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, it must have days, a start and end time or a not before or not after date", rulePosition, rule.Domains))
+		return
+	}
+
+	var err error
+
+	location := time.Local
+
+	if window.Timezone != "" {
+		if location, err = time.LoadLocation(window.Timezone); err != nil {
+			validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, the timezone %s is unknown", rulePosition, rule.Domains, window.Timezone))
+			return
+		}
+	}
+
+	for _, day := range window.Days {
+		if _, err := utils.ParseWeekday(day); err != nil {
+			validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, %s", rulePosition, rule.Domains, err))
+		}
+	}
+
+	validateTimeWindowTimesOfDay(rulePosition, rule, validator)
+
+	var notBefore, notAfter time.Time
+
+	if window.NotBefore != "" {
+		if notBefore, _, err = utils.ParseDate(window.NotBefore, location); err != nil {
+			validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, %s", rulePosition, rule.Domains, err))
+		}
+	}
+
+	if window.NotAfter != "" {
+		var dateOnly bool
+
+		if notAfter, dateOnly, err = utils.ParseDate(window.NotAfter, location); err != nil {
+			validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, %s", rulePosition, rule.Domains, err))
+		} else if dateOnly {
+			// A date without a time covers the whole day.
+			notAfter = notAfter.AddDate(0, 0, 1)
+		}
+	}
+
+	if !notBefore.IsZero() && !notAfter.IsZero() && !notBefore.Before(notAfter) {
+		validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, the not before date must be before the not after date", rulePosition, rule.Domains))
+	}
+}
+
+func validateTimeWindowTimesOfDay(rulePosition int, rule schema.ACLRule, validator *schema.StructValidator) {
+	window := rule.TimeWindow
+
+	if window.StartTime == "" && window.EndTime == "" {
+		return
+	}
+
+	if window.StartTime == "" || window.EndTime == "" {
+		validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, both the start time and the end time must be set", rulePosition, rule.Domains))
+		return
+	}
+
+	start, err := utils.ParseTimeOfDay(window.StartTime)
+	if err != nil {
+		validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, %s", rulePosition, rule.Domains, err))
+		return
+	}
+
+	end, err := utils.ParseTimeOfDay(window.EndTime)
+	if err != nil {
+		validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, %s", rulePosition, rule.Domains, err))
+		return
+	}
+
+	if start == end {
+		validator.Push(fmt.Errorf("Time window for rule #%d domain: %s is invalid, the start time and the end time must be different", rulePosition, rule.Domains))
+	}
+}
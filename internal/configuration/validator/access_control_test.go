@@ -95,6 +95,28 @@ func (suite *AccessControl) TestShouldRaiseErrorsWithEmptyRules() {
 	suite.Assert().EqualError(suite.validator.Errors()[3], "Policy [] for rule #2 domain: [] is invalid, a policy must either be 'deny', 'two_factor', 'one_factor' or 'bypass'")
 }
 
+func (suite *AccessControl) TestShouldRaiseErrorInvalidDomains() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			DomainsRegex: []string{`^(?P<User>\w+)\.example\.com$`},
+			Policy:       "one_factor",
+		},
+		{
+			Domains:      []string{"**.eu.example.com", "{user}.*.example.com"},
+			DomainsRegex: []string{`^(?P<User\w+)\.example\.com$`},
+			Policy:       "one_factor",
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Domain {user}.*.example.com for rule #2 is invalid, the {user} and {group} prefixes can't be combined with wildcards")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Domain regex ^(?P<User\\w+)\\.example\\.com$ for rule #2 is invalid, error parsing regexp: invalid named capture: `(?P<User\\w+)\\.example\\.com$`")
+}
+
 func (suite *AccessControl) TestShouldRaiseErrorInvalidPolicy() {
 	suite.configuration.Rules = []schema.ACLRule{
 		{
@@ -162,6 +184,212 @@ func (suite *AccessControl) TestShouldRaiseErrorInvalidResource() {
 	suite.Assert().EqualError(suite.validator.Errors()[0], "Resource [^/(api.*] for rule #1 domain: [public.example.com] is invalid, error parsing regexp: missing closing ): `^/(api.*`")
 }
 
+func (suite *AccessControl) TestShouldRaiseErrorInvalidPasswordlessOption() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains:      []string{"secure.example.com"},
+			Policy:       "two_factor",
+			Passwordless: "allow",
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 1)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Passwordless option [allow] for rule #1 domain: [secure.example.com] is invalid, must either be 'accept' or 'reject'")
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorInvalidMaxAge() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains: []string{"payroll.example.com"},
+			Policy:  "two_factor",
+			MaxAge:  "1 hour",
+		},
+		{
+			Domains: []string{"payroll.example.com"},
+			Policy:  "one_factor",
+			MaxAge:  "0",
+		},
+		{
+			Domains: []string{"public.example.com"},
+			Policy:  "bypass",
+			MaxAge:  "1h",
+		},
+		{
+			Domains: []string{"secure.example.com"},
+			Policy:  "two_factor",
+			MaxAge:  "15m",
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 3)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Error occurred parsing max age string for rule #1 domain: [payroll.example.com]: could not convert the input string of 1 hour into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Max age for rule #2 domain: [payroll.example.com] must be above 0")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "Max age for rule #3 domain: [public.example.com] is invalid, it can only be set with the 'one_factor' or 'two_factor' policy")
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorInvalidSecondFactorMethods() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains:             []string{"admin.example.com"},
+			Policy:              "two_factor",
+			SecondFactorMethods: []string{"u2f", "sms"},
+		},
+		{
+			Domains:             []string{"public.example.com"},
+			Policy:              "one_factor",
+			SecondFactorMethods: []string{"u2f"},
+		},
+		{
+			Domains:             []string{"secure.example.com"},
+			Policy:              "two_factor",
+			SecondFactorMethods: []string{"u2f", "passwordless"},
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 2)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Second factor method sms for rule #1 domain: [admin.example.com] is invalid, must be one of the following methods: totp, u2f, mobile_push, passwordless")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Second factor methods for rule #2 domain: [public.example.com] are invalid, they can only be set with the 'two_factor' policy")
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorInvalidMatchers() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains: []string{"api.example.com"},
+			Policy:  "two_factor",
+			Headers: []schema.ACLRuleMatcher{
+				{Key: "Content-Type", Operator: "equal", Value: "application/json"},
+				{Key: "X-Forwarded-Proto"},
+				{Operator: "present"},
+				{Key: "X-Api-Version", Operator: "greater", Value: "2"},
+				{Key: "Authorization", Operator: "absent", Value: "Bearer"},
+			},
+			Query: []schema.ACLRuleMatcher{
+				{Key: "token", Operator: "pattern", Value: "^[a-z"},
+				{Key: "type", Operator: "equal"},
+				{Key: "debug", Value: "true"},
+			},
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 5)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Header matcher for rule #1 domain: [api.example.com] is invalid, a key must be provided")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Header matcher X-Api-Version for rule #1 domain: [api.example.com] is invalid, the operator greater must be one of the following operators: present, absent, equal, pattern")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "Header matcher Authorization for rule #1 domain: [api.example.com] is invalid, a value can't be provided with the operator absent")
+	suite.Assert().EqualError(suite.validator.Errors()[3], "Query matcher token for rule #1 domain: [api.example.com] is invalid, error parsing regexp: missing closing ]: `[a-z`")
+	suite.Assert().EqualError(suite.validator.Errors()[4], "Query matcher type for rule #1 domain: [api.example.com] is invalid, a value must be provided with the operator equal")
+}
+
+func (suite *AccessControl) TestShouldRaiseErrorInvalidTimeWindow() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains: []string{"intranet.example.com"},
+			Policy:  "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{
+				Timezone:  "Europe/Paris",
+				Days:      []string{"monday", "Fri"},
+				StartTime: "22:00",
+				EndTime:   "06:00",
+				NotBefore: "2021-06-01",
+				NotAfter:  "2021-06-30T18:00:00+02:00",
+			},
+		},
+		{
+			Domains:    []string{"empty.example.com"},
+			Policy:     "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{Timezone: "UTC"},
+		},
+		{
+			Domains:    []string{"timezone.example.com"},
+			Policy:     "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{Timezone: "Mars/Olympus_Mons", Days: []string{"monday"}},
+		},
+		{
+			Domains: []string{"times.example.com"},
+			Policy:  "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{
+				Days:      []string{"weekend"},
+				StartTime: "8am",
+				EndTime:   "18:00",
+			},
+		},
+		{
+			Domains:    []string{"start.example.com"},
+			Policy:     "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{StartTime: "08:00"},
+		},
+		{
+			Domains:    []string{"same.example.com"},
+			Policy:     "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{StartTime: "08:00", EndTime: "08:00"},
+		},
+		{
+			Domains:    []string{"dates.example.com"},
+			Policy:     "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{NotBefore: "2021-07-01", NotAfter: "30/06/2021"},
+		},
+		{
+			Domains:    []string{"order.example.com"},
+			Policy:     "one_factor",
+			TimeWindow: &schema.ACLRuleTimeWindow{NotBefore: "2021-07-01", NotAfter: "2021-06-30"},
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 8)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Time window for rule #2 domain: [empty.example.com] is invalid, it must have days, a start and end time or a not before or not after date")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Time window for rule #3 domain: [timezone.example.com] is invalid, the timezone Mars/Olympus_Mons is unknown")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "Time window for rule #4 domain: [times.example.com] is invalid, could not convert the input string of weekend into a day of the week")
+	suite.Assert().EqualError(suite.validator.Errors()[3], "Time window for rule #4 domain: [times.example.com] is invalid, could not convert the input string of 8am into a time of day, it must be in the format HH:MM")
+	suite.Assert().EqualError(suite.validator.Errors()[4], "Time window for rule #5 domain: [start.example.com] is invalid, both the start time and the end time must be set")
+	suite.Assert().EqualError(suite.validator.Errors()[5], "Time window for rule #6 domain: [same.example.com] is invalid, the start time and the end time must be different")
+	suite.Assert().EqualError(suite.validator.Errors()[6], "Time window for rule #7 domain: [dates.example.com] is invalid, could not convert the input string of 30/06/2021 into a date, it must be in the format YYYY-MM-DD or RFC3339")
+	suite.Assert().EqualError(suite.validator.Errors()[7], "Time window for rule #8 domain: [order.example.com] is invalid, the not before date must be before the not after date")
+}
+
+func (suite *AccessControl) TestShouldValidateSubjects() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Domains: []string{"public.example.com"},
+			Policy:  "one_factor",
+			Subjects: [][]string{
+				{"group:dev", "!group:contractors"},
+				{"email:*@example.com", "attribute:tenant:acme"},
+				{"oauth2:client:grafana", "!user:john"},
+				{"email:", "attribute:tenant", "attribute::acme", "oauth2:client:", "!", "!!group:dev"},
+			},
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 6)
+
+	for i, subject := range []string{"email:", "attribute:tenant", "attribute::acme", "oauth2:client:", "!", "!!group:dev"} {
+		suite.Assert().False(IsSubjectValid(subject))
+		suite.Assert().EqualError(suite.validator.Errors()[i], "Subject [email: attribute:tenant attribute::acme oauth2:client: ! !!group:dev] for rule #1 domain: [public.example.com] is invalid, must start with 'user:', 'group:', 'email:', 'attribute:' or 'oauth2:client:' optionally prefixed with '!'")
+	}
+}
+
 func (suite *AccessControl) TestShouldRaiseErrorInvalidSubject() {
 	domains := []string{"public.example.com"}
 	subjects := [][]string{{"invalid"}}
@@ -178,10 +406,37 @@ func (suite *AccessControl) TestShouldRaiseErrorInvalidSubject() {
 	suite.Require().Len(suite.validator.Warnings(), 0)
 	suite.Require().Len(suite.validator.Errors(), 2)
 
-	suite.Assert().EqualError(suite.validator.Errors()[0], "Subject [invalid] for rule #1 domain: [public.example.com] is invalid, must start with 'user:' or 'group:'")
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Subject [invalid] for rule #1 domain: [public.example.com] is invalid, must start with 'user:', 'group:', 'email:', 'attribute:' or 'oauth2:client:' optionally prefixed with '!'")
 	suite.Assert().EqualError(suite.validator.Errors()[1], fmt.Sprintf(errAccessControlInvalidPolicyWithSubjects, 1, domains, subjects))
 }
 
+func (suite *AccessControl) TestShouldRaiseErrorDuplicateRuleNames() {
+	suite.configuration.Rules = []schema.ACLRule{
+		{
+			Name:    "admin",
+			Domains: []string{"admin.example.com"},
+			Policy:  "two_factor",
+		},
+		{
+			Domains: []string{"public.example.com"},
+			Policy:  "bypass",
+		},
+		{
+			Name:        "admin",
+			Description: "Administration of the dev team",
+			Domains:     []string{"admin.dev.example.com"},
+			Policy:      "one_factor",
+		},
+	}
+
+	ValidateRules(suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 1)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Name [admin] for rule #3 is invalid, it's already the name of rule #1")
+}
+
 func TestAccessControl(t *testing.T) {
 	suite.Run(t, new(AccessControl))
 }
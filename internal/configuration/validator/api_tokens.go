@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidateAPITokens validates and update the API tokens configuration.
+func ValidateAPITokens(configuration *schema.APITokensConfiguration, validator *schema.StructValidator) {
+	if configuration.MaxLifetime == "" {
+		configuration.MaxLifetime = schema.DefaultAPITokensConfiguration.MaxLifetime
+	}
+
+	maxLifetime, err := utils.ParseDurationString(configuration.MaxLifetime)
+
+	switch {
+	case err != nil:
+		validator.Push(fmt.Errorf("Error occurred parsing api_tokens max_lifetime string: %s", err))
+	case maxLifetime <= 0:
+		validator.Push(fmt.Errorf("api_tokens max_lifetime must be above 0"))
+	}
+}
@@ -0,0 +1,38 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultAPITokensMaxLifetime(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.APITokensConfiguration{}
+
+	ValidateAPITokens(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.DefaultAPITokensConfiguration.MaxLifetime, config.MaxLifetime)
+}
+
+func TestShouldRaiseErrorWhenAPITokensMaxLifetimeIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.APITokensConfiguration{MaxLifetime: "forever"}
+
+	ValidateAPITokens(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing api_tokens max_lifetime string: could not convert the input string of forever into a duration")
+
+	validator = schema.NewStructValidator()
+	config.MaxLifetime = "0"
+
+	ValidateAPITokens(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "api_tokens max_lifetime must be above 0")
+}
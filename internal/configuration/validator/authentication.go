@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
@@ -12,20 +13,35 @@ import (
 
 // ValidateAuthenticationBackend validates and update authentication backend configuration.
 func ValidateAuthenticationBackend(configuration *schema.AuthenticationBackendConfiguration, validator *schema.StructValidator) {
-	if configuration.LDAP == nil && configuration.File == nil {
-		validator.Push(errors.New("Please provide `ldap` or `file` object in `authentication_backend`"))
+	backends := configuredAuthenticationBackends(configuration)
+
+	switch {
+	case len(backends) == 0:
+		validator.Push(errors.New("Please provide `ldap`, `file` or `sql` object in `authentication_backend`"))
+	case configuration.Chain != nil:
+		validateChainAuthenticationBackend(configuration.Chain, backends, validator)
+	case len(backends) > 1:
+		validator.Push(errors.New("You cannot provide more than one of `ldap`, `file` and `sql` objects in `authentication_backend`"))
 	}
 
-	if configuration.LDAP != nil && configuration.File != nil {
-		validator.Push(errors.New("You cannot provide both `ldap` and `file` objects in `authentication_backend`"))
+	if configuration.Chain == nil && len(backends) > 1 {
+		// Without a chain only the first backend is used, the others are reported above.
+		backends = backends[:1]
 	}
 
-	if configuration.File != nil {
-		validateFileAuthenticationBackend(configuration.File, validator)
-	} else if configuration.LDAP != nil {
-		validateLDAPAuthenticationBackend(configuration.LDAP, validator)
+	for _, backend := range backends {
+		switch backend {
+		case schema.AuthenticationBackendFile:
+			validateFileAuthenticationBackend(configuration.File, validator)
+		case schema.AuthenticationBackendLDAP:
+			validateLDAPAuthenticationBackend(configuration.LDAP, validator)
+		case schema.AuthenticationBackendSQL:
+			validateSQLAuthenticationBackend(configuration.SQL, configuration.DisableResetPassword, validator)
+		}
 	}
 
+	validateUserAttributes(configuration.Attributes, validator)
+
 	if configuration.RefreshInterval == "" {
 		configuration.RefreshInterval = schema.RefreshIntervalDefault
 	} else {
@@ -36,7 +52,117 @@ func ValidateAuthenticationBackend(configuration *schema.AuthenticationBackendCo
 	}
 }
 
-//nolint:gocyclo // TODO: Consider refactoring/simplifying, time permitting.
+// validateUserAttributes validates the additional attributes of the users, the LDAP attribute defaults to the name.
+func validateUserAttributes(attributes []schema.UserAttributeConfiguration, validator *schema.StructValidator) {
+	names := make(map[string]bool, len(attributes))
+	headers := make(map[string]bool, len(attributes))
+	claims := make(map[string]bool, len(attributes))
+
+	for i, attribute := range attributes {
+		if !userAttributeNameRegexp.MatchString(attribute.Name) {
+			validator.Push(fmt.Errorf("User attribute %d has an invalid name '%s', it must only contain letters, digits, hyphens and underscores", i+1, attribute.Name))
+			continue
+		}
+
+		if names[attribute.Name] {
+			validator.Push(fmt.Errorf("User attribute '%s' is configured more than once", attribute.Name))
+		}
+
+		names[attribute.Name] = true
+
+		if attribute.LDAPAttribute == "" {
+			attributes[i].LDAPAttribute = attribute.Name
+		}
+
+		if attribute.Header != "" {
+			header := strings.ToLower(attribute.Header)
+
+			switch {
+			case !userAttributeHeaderRegexp.MatchString(attribute.Header):
+				validator.Push(fmt.Errorf("User attribute '%s' has an invalid header '%s'", attribute.Name, attribute.Header))
+			case utils.IsStringInSliceFold(attribute.Header, reservedUserAttributeHeaders):
+				validator.Push(fmt.Errorf("User attribute '%s' can't be forwarded in the header '%s' which is reserved", attribute.Name, attribute.Header))
+			case headers[header]:
+				validator.Push(fmt.Errorf("User attribute '%s' is forwarded in the header '%s' which is already used by another attribute", attribute.Name, attribute.Header))
+			}
+
+			headers[header] = true
+		}
+
+		if attribute.Claim != "" {
+			switch {
+			case utils.IsStringInSlice(attribute.Claim, reservedUserAttributeClaims):
+				validator.Push(fmt.Errorf("User attribute '%s' can't be mapped to the claim '%s' which is reserved", attribute.Name, attribute.Claim))
+			case claims[attribute.Claim]:
+				validator.Push(fmt.Errorf("User attribute '%s' is mapped to the claim '%s' which is already used by another attribute", attribute.Name, attribute.Claim))
+			}
+
+			claims[attribute.Claim] = true
+		}
+	}
+}
+
+// configuredAuthenticationBackends returns the names of the configured backends.
+func configuredAuthenticationBackends(configuration *schema.AuthenticationBackendConfiguration) (backends []string) {
+	if configuration.File != nil {
+		backends = append(backends, schema.AuthenticationBackendFile)
+	}
+
+	if configuration.LDAP != nil {
+		backends = append(backends, schema.AuthenticationBackendLDAP)
+	}
+
+	if configuration.SQL != nil {
+		backends = append(backends, schema.AuthenticationBackendSQL)
+	}
+
+	return backends
+}
+
+func validateChainAuthenticationBackend(configuration *schema.ChainAuthenticationBackendConfiguration, configured []string, validator *schema.StructValidator) {
+	switch configuration.Duplicates {
+	case "":
+		configuration.Duplicates = schema.ChainDuplicatesFirst
+	case schema.ChainDuplicatesFirst, schema.ChainDuplicatesReject:
+		break
+	default:
+		validator.Push(fmt.Errorf("Chain duplicates mode '%s' is invalid, must be '%s' or '%s'", configuration.Duplicates, schema.ChainDuplicatesFirst, schema.ChainDuplicatesReject))
+	}
+
+	if len(configuration.Backends) == 0 {
+		validator.Push(errors.New("Please provide at least one backend in `authentication_backend.chain.backends`"))
+		return
+	}
+
+	chained := make([]string, 0, len(configuration.Backends))
+
+	for _, backend := range configuration.Backends {
+		switch {
+		case !utils.IsStringInSlice(backend.Backend, validAuthenticationBackends):
+			validator.Push(fmt.Errorf("Chained backend '%s' is invalid, must be one of: %s", backend.Backend, strings.Join(validAuthenticationBackends, ", ")))
+			continue
+		case !utils.IsStringInSlice(backend.Backend, configured):
+			validator.Push(fmt.Errorf("Chained backend '%s' is not configured in `authentication_backend`", backend.Backend))
+		case utils.IsStringInSlice(backend.Backend, chained):
+			validator.Push(fmt.Errorf("Chained backend '%s' is listed more than once", backend.Backend))
+		}
+
+		chained = append(chained, backend.Backend)
+
+		if backend.UsernamePattern != "" {
+			if _, err := regexp.Compile(backend.UsernamePattern); err != nil {
+				validator.Push(fmt.Errorf("Chained backend '%s' has an invalid username pattern: %v", backend.Backend, err))
+			}
+		}
+	}
+
+	for _, backend := range configured {
+		if !utils.IsStringInSlice(backend, chained) {
+			validator.Push(fmt.Errorf("Backend '%s' is configured but not listed in `authentication_backend.chain.backends`", backend))
+		}
+	}
+}
+
 func validateFileAuthenticationBackend(configuration *schema.FileAuthenticationBackendConfiguration, validator *schema.StructValidator) {
 	if configuration.Path == "" {
 		validator.Push(errors.New("Please provide a `path` for the users database in `authentication_backend`"))
@@ -45,55 +171,91 @@ func validateFileAuthenticationBackend(configuration *schema.FileAuthenticationB
 	if configuration.Password == nil {
 		configuration.Password = &schema.DefaultPasswordConfiguration
 	} else {
-		if configuration.Password.Algorithm == "" {
-			configuration.Password.Algorithm = schema.DefaultPasswordConfiguration.Algorithm
-		} else {
-			configuration.Password.Algorithm = strings.ToLower(configuration.Password.Algorithm)
-			if configuration.Password.Algorithm != argon2id && configuration.Password.Algorithm != sha512 {
-				validator.Push(fmt.Errorf("Unknown hashing algorithm supplied, valid values are argon2id and sha512, you configured '%s'", configuration.Password.Algorithm))
-			}
-		}
+		validatePasswordConfiguration(configuration.Password, validator)
+	}
+}
 
-		// Iterations (time)
-		if configuration.Password.Iterations == 0 {
-			if configuration.Password.Algorithm == argon2id {
-				configuration.Password.Iterations = schema.DefaultPasswordConfiguration.Iterations
-			} else {
-				configuration.Password.Iterations = schema.DefaultPasswordSHA512Configuration.Iterations
-			}
-		} else if configuration.Password.Iterations < 1 {
-			validator.Push(fmt.Errorf("The number of iterations specified is invalid, must be 1 or more, you configured %d", configuration.Password.Iterations))
+func validateSQLAuthenticationBackend(configuration *schema.SQLAuthenticationBackendConfiguration, disableResetPassword bool, validator *schema.StructValidator) {
+	switch {
+	case configuration.MySQL == nil && configuration.PostgreSQL == nil:
+		validator.Push(errors.New("Please provide a `mysql` or `postgres` object in `authentication_backend.sql`"))
+	case configuration.MySQL != nil && configuration.PostgreSQL != nil:
+		validator.Push(errors.New("You cannot provide both `mysql` and `postgres` objects in `authentication_backend.sql`"))
+	case configuration.MySQL != nil:
+		validateSQLConfiguration(&configuration.MySQL.SQLStorageConfiguration, validator)
+	case configuration.PostgreSQL != nil:
+		validatePostgreSQLConfiguration(configuration.PostgreSQL, validator)
+	}
+
+	if configuration.Queries.Password == "" {
+		validator.Push(errors.New("Please provide a `password` query in `authentication_backend.sql.queries`"))
+	}
+
+	if configuration.Queries.Details == "" {
+		validator.Push(errors.New("Please provide a `details` query in `authentication_backend.sql.queries`"))
+	}
+
+	if configuration.Queries.UpdatePassword == "" && !disableResetPassword {
+		validator.Push(errors.New("Please provide an `update_password` query in `authentication_backend.sql.queries` or disable password reset"))
+	}
+
+	if configuration.Password == nil {
+		configuration.Password = &schema.DefaultPasswordConfiguration
+	} else {
+		validatePasswordConfiguration(configuration.Password, validator)
+	}
+}
+
+//nolint:gocyclo // TODO: Consider refactoring/simplifying, time permitting.
+func validatePasswordConfiguration(configuration *schema.PasswordConfiguration, validator *schema.StructValidator) {
+	if configuration.Algorithm == "" {
+		configuration.Algorithm = schema.DefaultPasswordConfiguration.Algorithm
+	} else {
+		configuration.Algorithm = strings.ToLower(configuration.Algorithm)
+		if configuration.Algorithm != argon2id && configuration.Algorithm != sha512 {
+			validator.Push(fmt.Errorf("Unknown hashing algorithm supplied, valid values are argon2id and sha512, you configured '%s'", configuration.Algorithm))
 		}
+	}
 
-		// Salt Length
-		switch {
-		case configuration.Password.SaltLength == 0:
-			configuration.Password.SaltLength = schema.DefaultPasswordConfiguration.SaltLength
-		case configuration.Password.SaltLength < 8:
-			validator.Push(fmt.Errorf("The salt length must be 2 or more, you configured %d", configuration.Password.SaltLength))
+	// Iterations (time)
+	if configuration.Iterations == 0 {
+		if configuration.Algorithm == argon2id {
+			configuration.Iterations = schema.DefaultPasswordConfiguration.Iterations
+		} else {
+			configuration.Iterations = schema.DefaultPasswordSHA512Configuration.Iterations
 		}
+	} else if configuration.Iterations < 1 {
+		validator.Push(fmt.Errorf("The number of iterations specified is invalid, must be 1 or more, you configured %d", configuration.Iterations))
+	}
 
-		if configuration.Password.Algorithm == argon2id {
-			// Parallelism
-			if configuration.Password.Parallelism == 0 {
-				configuration.Password.Parallelism = schema.DefaultPasswordConfiguration.Parallelism
-			} else if configuration.Password.Parallelism < 1 {
-				validator.Push(fmt.Errorf("Parallelism for argon2id must be 1 or more, you configured %d", configuration.Password.Parallelism))
-			}
+	// Salt Length
+	switch {
+	case configuration.SaltLength == 0:
+		configuration.SaltLength = schema.DefaultPasswordConfiguration.SaltLength
+	case configuration.SaltLength < 8:
+		validator.Push(fmt.Errorf("The salt length must be 2 or more, you configured %d", configuration.SaltLength))
+	}
 
-			// Memory
-			if configuration.Password.Memory == 0 {
-				configuration.Password.Memory = schema.DefaultPasswordConfiguration.Memory
-			} else if configuration.Password.Memory < configuration.Password.Parallelism*8 {
-				validator.Push(fmt.Errorf("Memory for argon2id must be %d or more (parallelism * 8), you configured memory as %d and parallelism as %d", configuration.Password.Parallelism*8, configuration.Password.Memory, configuration.Password.Parallelism))
-			}
+	if configuration.Algorithm == argon2id {
+		// Parallelism
+		if configuration.Parallelism == 0 {
+			configuration.Parallelism = schema.DefaultPasswordConfiguration.Parallelism
+		} else if configuration.Parallelism < 1 {
+			validator.Push(fmt.Errorf("Parallelism for argon2id must be 1 or more, you configured %d", configuration.Parallelism))
+		}
 
-			// Key Length
-			if configuration.Password.KeyLength == 0 {
-				configuration.Password.KeyLength = schema.DefaultPasswordConfiguration.KeyLength
-			} else if configuration.Password.KeyLength < 16 {
-				validator.Push(fmt.Errorf("Key length for argon2id must be 16, you configured %d", configuration.Password.KeyLength))
-			}
+		// Memory
+		if configuration.Memory == 0 {
+			configuration.Memory = schema.DefaultPasswordConfiguration.Memory
+		} else if configuration.Memory < configuration.Parallelism*8 {
+			validator.Push(fmt.Errorf("Memory for argon2id must be %d or more (parallelism * 8), you configured memory as %d and parallelism as %d", configuration.Parallelism*8, configuration.Memory, configuration.Parallelism))
+		}
+
+		// Key Length
+		if configuration.KeyLength == 0 {
+			configuration.KeyLength = schema.DefaultPasswordConfiguration.KeyLength
+		} else if configuration.KeyLength < 16 {
+			validator.Push(fmt.Errorf("Key length for argon2id must be 16, you configured %d", configuration.KeyLength))
 		}
 	}
 }
@@ -104,7 +266,9 @@ func validateLDAPAuthenticationBackend(configuration *schema.LDAPAuthenticationB
 	}
 
 	if configuration.TLS == nil {
-		configuration.TLS = schema.DefaultLDAPAuthenticationBackendConfiguration.TLS
+		// Copy the defaults as the server name is set from the URL below.
+		tlsConfig := *schema.DefaultLDAPAuthenticationBackendConfiguration.TLS
+		configuration.TLS = &tlsConfig
 	}
 
 	if configuration.TLS.MinimumVersion == "" {
@@ -135,9 +299,22 @@ func validateLDAPAuthenticationBackend(configuration *schema.LDAPAuthenticationB
 			"placeholders, {0} has been replaced with {input} and {1} has been replaced with {username}"))
 	}
 
-	if configuration.URL == "" {
+	validateLDAPServers(configuration, validator)
+	validateLDAPGroupSearch(configuration, validator)
+	validateLDAPRequiredParameters(configuration, validator)
+	validateLDAPPoolConfiguration(configuration, validator)
+	validateLDAPCircuitBreakerConfiguration(configuration, validator)
+}
+
+func validateLDAPServers(configuration *schema.LDAPAuthenticationBackendConfiguration, validator *schema.StructValidator) {
+	switch {
+	case configuration.URL == "" && len(configuration.URLs) == 0 && configuration.SRVDomain == "":
 		validator.Push(errors.New("Please provide a URL to the LDAP server"))
-	} else {
+	case configuration.URL != "" && len(configuration.URLs) != 0:
+		validator.Push(errors.New("You cannot provide both `url` and `urls` for the LDAP server"))
+	case configuration.SRVDomain != "" && (configuration.URL != "" || len(configuration.URLs) != 0):
+		validator.Push(errors.New("You cannot provide `srv_domain` along with `url` or `urls` for the LDAP server"))
+	case configuration.URL != "":
 		ldapURL, serverName := validateLDAPURL(configuration.URL, validator)
 
 		configuration.URL = ldapURL
@@ -147,7 +324,99 @@ func validateLDAPAuthenticationBackend(configuration *schema.LDAPAuthenticationB
 		}
 	}
 
-	validateLDAPRequiredParameters(configuration, validator)
+	for i, ldapURL := range configuration.URLs {
+		ldapURL, serverName := validateLDAPURL(ldapURL, validator)
+
+		configuration.URLs[i] = ldapURL
+
+		// The server name is only implied when there is a single server, otherwise each server uses its own hostname.
+		if len(configuration.URLs) == 1 && configuration.TLS.ServerName == "" {
+			configuration.TLS.ServerName = serverName
+		}
+	}
+
+	switch configuration.ServerSelection {
+	case "":
+		configuration.ServerSelection = schema.DefaultLDAPAuthenticationBackendConfiguration.ServerSelection
+	case schema.LDAPServerSelectionPriority, schema.LDAPServerSelectionRoundRobin:
+		break
+	default:
+		validator.Push(fmt.Errorf("The LDAP `server_selection` must be either '%s' or '%s' but it is configured as '%s'", schema.LDAPServerSelectionPriority, schema.LDAPServerSelectionRoundRobin, configuration.ServerSelection))
+	}
+}
+
+func validateLDAPGroupSearch(configuration *schema.LDAPAuthenticationBackendConfiguration, validator *schema.StructValidator) {
+	switch configuration.GroupSearchMode {
+	case "":
+		configuration.GroupSearchMode = schema.DefaultLDAPAuthenticationBackendConfiguration.GroupSearchMode
+	case schema.LDAPGroupSearchModeFilter, schema.LDAPGroupSearchModeMemberOf:
+		break
+	default:
+		validator.Push(fmt.Errorf("The LDAP `group_search_mode` must be either '%s' or '%s' but it is configured as '%s'", schema.LDAPGroupSearchModeFilter, schema.LDAPGroupSearchModeMemberOf, configuration.GroupSearchMode))
+	}
+
+	if !configuration.NestedGroups {
+		return
+	}
+
+	switch {
+	case configuration.GroupSearchMode == schema.LDAPGroupSearchModeMemberOf:
+		validator.Push(fmt.Errorf("Nested groups can't be resolved with the '%s' LDAP `group_search_mode`", schema.LDAPGroupSearchModeMemberOf))
+	case configuration.Implementation != schema.LDAPImplementationActiveDirectory && !strings.Contains(configuration.GroupsFilter, "{dn}"):
+		// The groups containing a group are searched by replacing the {dn} placeholder with the DN of the group.
+		validator.Push(errors.New("The LDAP groups filter must contain the {dn} placeholder to resolve nested groups"))
+	}
+}
+
+func validateLDAPCircuitBreakerConfiguration(configuration *schema.LDAPAuthenticationBackendConfiguration, validator *schema.StructValidator) {
+	if configuration.CircuitBreaker == nil {
+		circuitBreaker := schema.DefaultLDAPCircuitBreakerConfiguration
+		configuration.CircuitBreaker = &circuitBreaker
+
+		return
+	}
+
+	if configuration.CircuitBreaker.Failures < 0 {
+		validator.Push(fmt.Errorf("The LDAP circuit breaker `failures` must be 0 or more but it is configured as %d", configuration.CircuitBreaker.Failures))
+	}
+
+	if configuration.CircuitBreaker.Cooldown == "" {
+		configuration.CircuitBreaker.Cooldown = schema.DefaultLDAPCircuitBreakerConfiguration.Cooldown
+	} else if _, err := utils.ParseDurationString(configuration.CircuitBreaker.Cooldown); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing the LDAP circuit breaker `cooldown` into a duration: %v", err))
+	}
+}
+
+func validateLDAPPoolConfiguration(configuration *schema.LDAPAuthenticationBackendConfiguration, validator *schema.StructValidator) {
+	if configuration.Pool == nil {
+		pool := schema.DefaultLDAPConnectionPoolConfiguration
+		configuration.Pool = &pool
+
+		return
+	}
+
+	if configuration.Pool.Disable {
+		return
+	}
+
+	switch {
+	case configuration.Pool.MaxConnections == 0:
+		configuration.Pool.MaxConnections = schema.DefaultLDAPConnectionPoolConfiguration.MaxConnections
+	case configuration.Pool.MaxConnections < 0:
+		validator.Push(fmt.Errorf("The LDAP pool `max_connections` must be 1 or more but it is configured as %d", configuration.Pool.MaxConnections))
+	}
+
+	if configuration.Pool.IdleTimeout == "" {
+		configuration.Pool.IdleTimeout = schema.DefaultLDAPConnectionPoolConfiguration.IdleTimeout
+	} else if _, err := utils.ParseDurationString(configuration.Pool.IdleTimeout); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing the LDAP pool `idle_timeout` into a duration: %v", err))
+	}
+
+	if configuration.Pool.HealthCheckInterval == "" {
+		configuration.Pool.HealthCheckInterval = schema.DefaultLDAPConnectionPoolConfiguration.HealthCheckInterval
+	} else if _, err := utils.ParseDurationString(configuration.Pool.HealthCheckInterval); err != nil {
+		validator.Push(fmt.Errorf("Error occurred parsing the LDAP pool `health_check_interval` into a duration: %v", err))
+	}
 }
 
 // Wrapper for test purposes to exclude the hostname from the return.
@@ -208,7 +477,10 @@ func validateLDAPRequiredParameters(configuration *schema.LDAPAuthenticationBack
 	}
 
 	if configuration.GroupsFilter == "" {
-		validator.Push(errors.New("Please provide a groups filter with `groups_filter` attribute"))
+		// The groups filter isn't used when the groups are read from the memberOf attribute.
+		if configuration.GroupSearchMode != schema.LDAPGroupSearchModeMemberOf {
+			validator.Push(errors.New("Please provide a groups filter with `groups_filter` attribute"))
+		}
 	} else if !strings.HasPrefix(configuration.GroupsFilter, "(") || !strings.HasSuffix(configuration.GroupsFilter, ")") {
 		validator.Push(errors.New("The groups filter should contain enclosing parenthesis. For instance cn={input} should be (cn={input})"))
 	}
@@ -22,7 +22,7 @@ func TestShouldRaiseErrorWhenBothBackendsProvided(t *testing.T) {
 	ValidateAuthenticationBackend(&backendConfig, validator)
 
 	require.Len(t, validator.Errors(), 1)
-	assert.EqualError(t, validator.Errors()[0], "You cannot provide both `ldap` and `file` objects in `authentication_backend`")
+	assert.EqualError(t, validator.Errors()[0], "You cannot provide more than one of `ldap`, `file` and `sql` objects in `authentication_backend`")
 }
 
 func TestShouldRaiseErrorWhenNoBackendProvided(t *testing.T) {
@@ -32,7 +32,197 @@ func TestShouldRaiseErrorWhenNoBackendProvided(t *testing.T) {
 	ValidateAuthenticationBackend(&backendConfig, validator)
 
 	require.Len(t, validator.Errors(), 1)
-	assert.EqualError(t, validator.Errors()[0], "Please provide `ldap` or `file` object in `authentication_backend`")
+	assert.EqualError(t, validator.Errors()[0], "Please provide `ldap`, `file` or `sql` object in `authentication_backend`")
+}
+
+func newChainedBackendConfiguration() schema.AuthenticationBackendConfiguration {
+	return schema.AuthenticationBackendConfiguration{
+		File: &schema.FileAuthenticationBackendConfiguration{
+			Path: "/tmp",
+		},
+		LDAP: &schema.LDAPAuthenticationBackendConfiguration{
+			URL:                  "ldap://ldap",
+			User:                 "user",
+			Password:             "password",
+			BaseDN:               "base_dn",
+			UsernameAttribute:    "uid",
+			UsersFilter:          "({username_attribute}={input})",
+			GroupsFilter:         "(cn={input})",
+			GroupNameAttribute:   "cn",
+			MailAttribute:        "mail",
+			DisplayNameAttribute: "displayName",
+		},
+		Chain: &schema.ChainAuthenticationBackendConfiguration{
+			Backends: []schema.ChainedAuthenticationBackendConfiguration{
+				{Backend: "file", UsernamePattern: "^svc-"},
+				{Backend: "ldap"},
+			},
+		},
+	}
+}
+
+func TestShouldValidateChainedBackends(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := newChainedBackendConfiguration()
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.ChainDuplicatesFirst, backendConfig.Chain.Duplicates)
+	assert.Equal(t, &schema.DefaultPasswordConfiguration, backendConfig.File.Password)
+	assert.Equal(t, "cn", backendConfig.LDAP.GroupNameAttribute)
+}
+
+func TestShouldValidateEveryChainedBackend(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := newChainedBackendConfiguration()
+	backendConfig.File.Path = ""
+	backendConfig.LDAP.URL = ""
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "Please provide a `path` for the users database in `authentication_backend`")
+	assert.EqualError(t, validator.Errors()[1], "Please provide a URL to the LDAP server")
+}
+
+func TestShouldRaiseErrorsOnInvalidChain(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := newChainedBackendConfiguration()
+	backendConfig.Chain.Duplicates = "last"
+	backendConfig.Chain.Backends = []schema.ChainedAuthenticationBackendConfiguration{
+		{Backend: "file", UsernamePattern: "^svc-("},
+		{Backend: "file"},
+		{Backend: "sql"},
+		{Backend: "radius"},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 6)
+	assert.EqualError(t, validator.Errors()[0], "Chain duplicates mode 'last' is invalid, must be 'first' or 'reject'")
+	assert.EqualError(t, validator.Errors()[1], "Chained backend 'file' has an invalid username pattern: error parsing regexp: missing closing ): `^svc-(`")
+	assert.EqualError(t, validator.Errors()[2], "Chained backend 'file' is listed more than once")
+	assert.EqualError(t, validator.Errors()[3], "Chained backend 'sql' is not configured in `authentication_backend`")
+	assert.EqualError(t, validator.Errors()[4], "Chained backend 'radius' is invalid, must be one of: file, ldap, sql")
+	assert.EqualError(t, validator.Errors()[5], "Backend 'ldap' is configured but not listed in `authentication_backend.chain.backends`")
+}
+
+func TestShouldRaiseErrorWhenChainHasNoBackends(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := newChainedBackendConfiguration()
+	backendConfig.Chain.Backends = nil
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Please provide at least one backend in `authentication_backend.chain.backends`")
+}
+
+func TestShouldValidateUserAttributes(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := schema.AuthenticationBackendConfiguration{
+		File: &schema.FileAuthenticationBackendConfiguration{Path: "/tmp"},
+		Attributes: []schema.UserAttributeConfiguration{
+			{Name: "department", Header: "Remote-Department"},
+			{Name: "employee_id", LDAPAttribute: "employeeNumber", Claim: "employee_id"},
+		},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, "department", backendConfig.Attributes[0].LDAPAttribute)
+	assert.Equal(t, "employeeNumber", backendConfig.Attributes[1].LDAPAttribute)
+}
+
+func TestShouldRaiseErrorsOnInvalidUserAttributes(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := schema.AuthenticationBackendConfiguration{
+		File: &schema.FileAuthenticationBackendConfiguration{Path: "/tmp"},
+		Attributes: []schema.UserAttributeConfiguration{
+			{Name: "cost center"},
+			{Name: "department", Header: "Remote-Department"},
+			{Name: "department", Header: "remote-department"},
+			{Name: "manager", Header: "Remote-Manager:", Claim: "email"},
+			{Name: "title", Header: "Remote-User"},
+			{Name: "employee_id", Claim: "employee_id"},
+			{Name: "badge", Claim: "employee_id"},
+		},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 7)
+	assert.EqualError(t, validator.Errors()[0], "User attribute 1 has an invalid name 'cost center', it must only contain letters, digits, hyphens and underscores")
+	assert.EqualError(t, validator.Errors()[1], "User attribute 'department' is configured more than once")
+	assert.EqualError(t, validator.Errors()[2], "User attribute 'department' is forwarded in the header 'remote-department' which is already used by another attribute")
+	assert.EqualError(t, validator.Errors()[3], "User attribute 'manager' has an invalid header 'Remote-Manager:'")
+	assert.EqualError(t, validator.Errors()[4], "User attribute 'manager' can't be mapped to the claim 'email' which is reserved")
+	assert.EqualError(t, validator.Errors()[5], "User attribute 'title' can't be forwarded in the header 'Remote-User' which is reserved")
+	assert.EqualError(t, validator.Errors()[6], "User attribute 'badge' is mapped to the claim 'employee_id' which is already used by another attribute")
+}
+
+func TestShouldValidateSQLBackend(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := schema.AuthenticationBackendConfiguration{
+		SQL: &schema.SQLAuthenticationBackendConfiguration{
+			PostgreSQL: &schema.PostgreSQLStorageConfiguration{
+				SQLStorageConfiguration: schema.SQLStorageConfiguration{
+					Host:     "postgres",
+					Database: "users",
+					Username: "authelia",
+					Password: "password",
+				},
+			},
+			Queries: schema.SQLAuthenticationBackendQueries{
+				Password:       "SELECT password FROM users WHERE username=$1",
+				Details:        "SELECT username, display_name FROM users WHERE username=$1",
+				UpdatePassword: "UPDATE users SET password=$1 WHERE username=$2",
+			},
+		},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, testModeDisabled, backendConfig.SQL.PostgreSQL.SSLMode)
+	assert.Equal(t, schema.DefaultPasswordConfiguration.Algorithm, backendConfig.SQL.Password.Algorithm)
+}
+
+func TestShouldRaiseErrorsWhenSQLBackendIncomplete(t *testing.T) {
+	validator := schema.NewStructValidator()
+	backendConfig := schema.AuthenticationBackendConfiguration{
+		SQL: &schema.SQLAuthenticationBackendConfiguration{
+			Password: &schema.PasswordConfiguration{Algorithm: "md5"},
+		},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 5)
+	assert.EqualError(t, validator.Errors()[0], "Please provide a `mysql` or `postgres` object in `authentication_backend.sql`")
+	assert.EqualError(t, validator.Errors()[1], "Please provide a `password` query in `authentication_backend.sql.queries`")
+	assert.EqualError(t, validator.Errors()[2], "Please provide a `details` query in `authentication_backend.sql.queries`")
+	assert.EqualError(t, validator.Errors()[3], "Please provide an `update_password` query in `authentication_backend.sql.queries` or disable password reset")
+	assert.EqualError(t, validator.Errors()[4], "Unknown hashing algorithm supplied, valid values are argon2id and sha512, you configured 'md5'")
+
+	validator.Clear()
+
+	backendConfig.DisableResetPassword = true
+	backendConfig.SQL = &schema.SQLAuthenticationBackendConfiguration{
+		MySQL:      &schema.MySQLStorageConfiguration{},
+		PostgreSQL: &schema.PostgreSQLStorageConfiguration{},
+		Queries: schema.SQLAuthenticationBackendQueries{
+			Password: "SELECT password FROM users WHERE username=?",
+			Details:  "SELECT username, display_name FROM users WHERE username=?",
+		},
+	}
+
+	ValidateAuthenticationBackend(&backendConfig, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "You cannot provide both `mysql` and `postgres` objects in `authentication_backend.sql`")
 }
 
 type FileBasedAuthenticationBackend struct {
@@ -489,6 +679,150 @@ func (suite *LDAPAuthenticationBackendSuite) TestShouldNotAllowInvalidTLSValue()
 	suite.Assert().EqualError(suite.validator.Errors()[0], "error occurred validating the LDAP minimum_tls_version key with value SSL2.0: supplied TLS version isn't supported")
 }
 
+func (suite *LDAPAuthenticationBackendSuite) TestShouldSetDefaultPool() {
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasErrors())
+	suite.Assert().Equal(schema.DefaultLDAPConnectionPoolConfiguration, *suite.configuration.LDAP.Pool)
+
+	suite.configuration.LDAP.Pool = &schema.LDAPConnectionPoolConfiguration{IdleTimeout: "10m"}
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasErrors())
+	suite.Assert().Equal(5, suite.configuration.LDAP.Pool.MaxConnections)
+	suite.Assert().Equal("10m", suite.configuration.LDAP.Pool.IdleTimeout)
+	suite.Assert().Equal("1m", suite.configuration.LDAP.Pool.HealthCheckInterval)
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldRaiseErrorOnInvalidPool() {
+	suite.configuration.LDAP.Pool = &schema.LDAPConnectionPoolConfiguration{
+		MaxConnections:      -1,
+		IdleTimeout:         "5 minutes",
+		HealthCheckInterval: "1 minute",
+	}
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Require().Len(suite.validator.Errors(), 3)
+
+	suite.Assert().EqualError(suite.validator.Errors()[0], "The LDAP pool `max_connections` must be 1 or more but it is configured as -1")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "Error occurred parsing the LDAP pool `idle_timeout` into a duration: could not convert the input string of 5 minutes into a duration")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "Error occurred parsing the LDAP pool `health_check_interval` into a duration: could not convert the input string of 1 minute into a duration")
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldNotValidateDisabledPool() {
+	suite.configuration.LDAP.Pool = &schema.LDAPConnectionPoolConfiguration{Disable: true, IdleTimeout: "invalid"}
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasErrors())
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldValidateMultipleURLs() {
+	suite.configuration.LDAP.URL = ""
+	suite.configuration.LDAP.URLs = []string{"ldaps://dc1.example.com", "ldaps://dc2.example.com:636"}
+	suite.configuration.LDAP.ServerSelection = schema.LDAPServerSelectionRoundRobin
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+
+	suite.Assert().Equal([]string{"ldaps://dc1.example.com", "ldaps://dc2.example.com:636"}, suite.configuration.LDAP.URLs)
+	suite.Assert().Equal("", suite.configuration.LDAP.TLS.ServerName)
+	suite.Assert().Equal(schema.DefaultLDAPCircuitBreakerConfiguration, *suite.configuration.LDAP.CircuitBreaker)
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldSetDefaultServerSelection() {
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasErrors())
+	suite.Assert().Equal(schema.LDAPServerSelectionPriority, suite.configuration.LDAP.ServerSelection)
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldRaiseErrorWhenBothURLAndURLsAreProvided() {
+	suite.configuration.LDAP.URLs = []string{"ldap://dc1.example.com"}
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "You cannot provide both `url` and `urls` for the LDAP server")
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldRaiseErrorWhenSRVDomainIsProvidedWithURL() {
+	suite.configuration.LDAP.SRVDomain = "example.com"
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "You cannot provide `srv_domain` along with `url` or `urls` for the LDAP server")
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldRaiseErrorOnInvalidServerSelectionAndCircuitBreaker() {
+	suite.configuration.LDAP.ServerSelection = "random"
+	suite.configuration.LDAP.CircuitBreaker = &schema.LDAPCircuitBreakerConfiguration{Failures: -1, Cooldown: "forever"}
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 3)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "The LDAP `server_selection` must be either 'priority' or 'round_robin' but it is configured as 'random'")
+	suite.Assert().EqualError(suite.validator.Errors()[1], "The LDAP circuit breaker `failures` must be 0 or more but it is configured as -1")
+	suite.Assert().EqualError(suite.validator.Errors()[2], "Error occurred parsing the LDAP circuit breaker `cooldown` into a duration: could not convert the input string of forever into a duration")
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldSetDefaultGroupSearchMode() {
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasErrors())
+	suite.Assert().Equal(schema.LDAPGroupSearchModeFilter, suite.configuration.LDAP.GroupSearchMode)
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldNotRequireGroupsFilterInMemberOfMode() {
+	suite.configuration.LDAP.GroupsFilter = ""
+	suite.configuration.LDAP.GroupSearchMode = schema.LDAPGroupSearchModeMemberOf
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasWarnings())
+	suite.Assert().False(suite.validator.HasErrors())
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldRaiseErrorOnInvalidGroupSearchMode() {
+	suite.configuration.LDAP.GroupSearchMode = "recursive"
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "The LDAP `group_search_mode` must be either 'filter' or 'memberof' but it is configured as 'recursive'")
+}
+
+func (suite *LDAPAuthenticationBackendSuite) TestShouldRaiseErrorWhenNestedGroupsCannotBeResolved() {
+	suite.configuration.LDAP.NestedGroups = true
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "The LDAP groups filter must contain the {dn} placeholder to resolve nested groups")
+
+	suite.validator.Clear()
+	suite.configuration.LDAP.GroupsFilter = "(member={dn})"
+	suite.configuration.LDAP.GroupSearchMode = schema.LDAPGroupSearchModeMemberOf
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Require().Len(suite.validator.Errors(), 1)
+	suite.Assert().EqualError(suite.validator.Errors()[0], "Nested groups can't be resolved with the 'memberof' LDAP `group_search_mode`")
+
+	suite.validator.Clear()
+	suite.configuration.LDAP.GroupSearchMode = schema.LDAPGroupSearchModeFilter
+
+	ValidateAuthenticationBackend(&suite.configuration, suite.validator)
+
+	suite.Assert().False(suite.validator.HasErrors())
+}
+
 func TestLdapAuthenticationBackend(t *testing.T) {
 	suite.Run(t, new(LDAPAuthenticationBackendSuite))
 }
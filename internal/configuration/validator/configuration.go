@@ -73,6 +73,18 @@ func ValidateConfiguration(configuration *schema.Configuration, validator *schem
 
 	ValidateServer(&configuration.Server, validator)
 
+	if configuration.APITokens != nil {
+		ValidateAPITokens(configuration.APITokens, validator)
+	}
+
+	if configuration.Registration != nil {
+		ValidateRegistration(configuration.Registration, configuration.AuthenticationBackend, validator)
+	}
+
+	if configuration.Passwordless != nil {
+		ValidatePasswordless(configuration.Passwordless, validator)
+	}
+
 	ValidateStorage(configuration.Storage, validator)
 
 	if configuration.Notifier == nil {
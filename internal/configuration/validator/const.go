@@ -1,5 +1,11 @@
 package validator
 
+import (
+	"regexp"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
 const (
 	loopback           = "127.0.0.1"
 	oauth2InstalledApp = "urn:ietf:wg:oauth:2.0:oob"
@@ -52,6 +58,14 @@ const (
 	twoFactorPolicy = "two_factor"
 	denyPolicy      = "deny"
 
+	passwordlessAccept = "accept"
+	passwordlessReject = "reject"
+
+	operatorPresent = "present"
+	operatorAbsent  = "absent"
+	operatorEqual   = "equal"
+	operatorPattern = "pattern"
+
 	argon2id = "argon2id"
 	sha512   = "sha512"
 
@@ -76,9 +90,26 @@ const (
 		"https://www.authelia.com/docs/configuration/access-control.html#combining-subjects-and-the-bypass-policy"
 )
 
+var validAuthenticationBackends = []string{schema.AuthenticationBackendFile, schema.AuthenticationBackendLDAP, schema.AuthenticationBackendSQL}
+var userAttributeNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+var userAttributeHeaderRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// reservedUserAttributeHeaders are the headers already forwarded by Authelia.
+var reservedUserAttributeHeaders = []string{"Remote-User", "Remote-Groups", "Remote-Name", "Remote-Email"}
+
+// reservedUserAttributeClaims are the claims already set by Authelia or by the OpenID Connect specification.
+var reservedUserAttributeClaims = []string{
+	"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "acr", "amr", "azp", "at_hash", "c_hash", "jti", "rat",
+	"name", "email", "email_verified", "alt_emails", "groups",
+}
+
 var validLoggingLevels = []string{"trace", "debug", "info", "warn", "error"}
 var validHTTPRequestMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "TRACE", "CONNECT", "OPTIONS"}
 
+var validSecondFactorMethods = []string{"totp", "u2f", "mobile_push", "passwordless"}
+
+var validMatcherOperators = []string{operatorPresent, operatorAbsent, operatorEqual, operatorPattern}
+
 var validOIDCScopes = []string{"openid", "email", "profile", "groups", "offline_access"}
 var validOIDCGrantTypes = []string{"implicit", "refresh_token", "authorization_code", "password", "client_credentials"}
 var validOIDCResponseModes = []string{"form_post", "query", "fragment"}
@@ -95,8 +126,11 @@ var SecretNames = map[string]string{
 	"SMTPPassword":                  "notifier.smtp.password",
 	"MySQLPassword":                 "storage.mysql.password",
 	"PostgreSQLPassword":            "storage.postgres.password",
+	"SQLAuthMySQLPassword":          "authentication_backend.sql.mysql.password",
+	"SQLAuthPostgreSQLPassword":     "authentication_backend.sql.postgres.password",
 	"OpenIDConnectHMACSecret":       "identity_providers.oidc.hmac_secret",
 	"OpenIDConnectIssuerPrivateKey": "identity_providers.oidc.issuer_private_key",
+	"TrustedHeaderSecret":           "server.trusted_header.secret",
 }
 
 // validKeys is a list of valid keys that are not secret names. For the sake of consistency please place any secret in
@@ -129,6 +163,20 @@ var validKeys = []string{
 	"server.path",
 	"server.enable_pprof",
 	"server.enable_expvars",
+	"server.client_certificates.certificate_authority",
+	"server.client_certificates.username_attribute",
+	"server.client_certificates.authentication_level",
+	"server.client_certificates.header",
+	"server.client_certificates.trusted_networks",
+	"server.trusted_header.header",
+	"server.trusted_header.trusted_networks",
+	"server.trusted_header.authentication_level",
+	"server.trusted_header.signature_header",
+	"server.trusted_header.signature_lifetime",
+	"server.ext_authz.host",
+	"server.ext_authz.port",
+	"server.ext_authz.portal_url",
+	"server.ext_authz.trusted_networks",
 
 	// TOTP Keys.
 	"totp.issuer",
@@ -139,6 +187,7 @@ var validKeys = []string{
 	"access_control.rules",
 	"access_control.default_policy",
 	"access_control.networks",
+	"access_control.expose_denial_reason",
 
 	// Session Keys.
 	"session.name",
@@ -207,6 +256,20 @@ var validKeys = []string{
 	"regulation.find_time",
 	"regulation.ban_time",
 
+	// API Tokens Keys.
+	"api_tokens.max_lifetime",
+	"api_tokens.admins_group",
+
+	// Registration Keys.
+	"registration.require_approval",
+	"registration.admins_group",
+	"registration.allowed_domains",
+	"registration.default_groups",
+
+	// Passwordless Keys.
+	"passwordless.display_name",
+	"passwordless.timeout",
+
 	// DUO API Keys.
 	"duo_api.hostname",
 	"duo_api.integration_key",
@@ -218,12 +281,17 @@ var validKeys = []string{
 	// LDAP Authentication Backend Keys.
 	"authentication_backend.ldap.implementation",
 	"authentication_backend.ldap.url",
+	"authentication_backend.ldap.urls",
+	"authentication_backend.ldap.srv_domain",
+	"authentication_backend.ldap.server_selection",
 	"authentication_backend.ldap.base_dn",
 	"authentication_backend.ldap.username_attribute",
 	"authentication_backend.ldap.additional_users_dn",
 	"authentication_backend.ldap.users_filter",
 	"authentication_backend.ldap.additional_groups_dn",
 	"authentication_backend.ldap.groups_filter",
+	"authentication_backend.ldap.group_search_mode",
+	"authentication_backend.ldap.nested_groups",
 	"authentication_backend.ldap.group_name_attribute",
 	"authentication_backend.ldap.mail_attribute",
 	"authentication_backend.ldap.display_name_attribute",
@@ -232,6 +300,12 @@ var validKeys = []string{
 	"authentication_backend.ldap.tls.minimum_version",
 	"authentication_backend.ldap.tls.skip_verify",
 	"authentication_backend.ldap.tls.server_name",
+	"authentication_backend.ldap.pool.disable",
+	"authentication_backend.ldap.pool.max_connections",
+	"authentication_backend.ldap.pool.idle_timeout",
+	"authentication_backend.ldap.pool.health_check_interval",
+	"authentication_backend.ldap.circuit_breaker.failures",
+	"authentication_backend.ldap.circuit_breaker.cooldown",
 
 	// File Authentication Backend Keys.
 	"authentication_backend.file.path",
@@ -241,6 +315,38 @@ var validKeys = []string{
 	"authentication_backend.file.password.salt_length",
 	"authentication_backend.file.password.memory",
 	"authentication_backend.file.password.parallelism",
+	"authentication_backend.file.password.rehash",
+
+	// SQL Authentication Backend Keys.
+	"authentication_backend.sql.mysql.host",
+	"authentication_backend.sql.mysql.port",
+	"authentication_backend.sql.mysql.database",
+	"authentication_backend.sql.mysql.username",
+	"authentication_backend.sql.postgres.host",
+	"authentication_backend.sql.postgres.port",
+	"authentication_backend.sql.postgres.database",
+	"authentication_backend.sql.postgres.username",
+	"authentication_backend.sql.postgres.sslmode",
+	"authentication_backend.sql.password.algorithm",
+	"authentication_backend.sql.password.iterations",
+	"authentication_backend.sql.password.key_length",
+	"authentication_backend.sql.password.salt_length",
+	"authentication_backend.sql.password.memory",
+	"authentication_backend.sql.password.parallelism",
+	"authentication_backend.sql.password.rehash",
+	"authentication_backend.sql.queries.password",
+	"authentication_backend.sql.queries.details",
+	"authentication_backend.sql.queries.emails",
+	"authentication_backend.sql.queries.groups",
+	"authentication_backend.sql.queries.update_password",
+	"authentication_backend.sql.queries.create_user",
+	"authentication_backend.sql.queries.create_email",
+	"authentication_backend.sql.queries.create_group",
+
+	// Chained authentication backend keys.
+	"authentication_backend.chain.duplicates",
+	"authentication_backend.chain.backends",
+	"authentication_backend.attributes",
 
 	// Identity Provider Keys.
 	"identity_providers.oidc.clients",
@@ -249,6 +355,7 @@ var validKeys = []string{
 	"identity_providers.oidc.refresh_token_lifespan",
 	"identity_providers.oidc.authorize_code_lifespan",
 	"identity_providers.oidc.enable_client_debug_messages",
+	"identity_providers.oidc.enable_verify_access_tokens",
 }
 
 var replacedKeys = map[string]string{
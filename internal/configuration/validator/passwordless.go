@@ -0,0 +1,28 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ValidatePasswordless validates and update the passwordless configuration.
+func ValidatePasswordless(configuration *schema.PasswordlessConfiguration, validator *schema.StructValidator) {
+	if configuration.DisplayName == "" {
+		configuration.DisplayName = schema.DefaultPasswordlessConfiguration.DisplayName
+	}
+
+	if configuration.Timeout == "" {
+		configuration.Timeout = schema.DefaultPasswordlessConfiguration.Timeout
+	}
+
+	timeout, err := utils.ParseDurationString(configuration.Timeout)
+
+	switch {
+	case err != nil:
+		validator.Push(fmt.Errorf("Error occurred parsing passwordless timeout string: %s", err))
+	case timeout <= 0:
+		validator.Push(fmt.Errorf("passwordless timeout must be above 0"))
+	}
+}
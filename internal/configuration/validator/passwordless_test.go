@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldSetDefaultPasswordlessValues(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PasswordlessConfiguration{}
+
+	ValidatePasswordless(&config, validator)
+
+	assert.Len(t, validator.Errors(), 0)
+	assert.Equal(t, schema.DefaultPasswordlessConfiguration.DisplayName, config.DisplayName)
+	assert.Equal(t, schema.DefaultPasswordlessConfiguration.Timeout, config.Timeout)
+}
+
+func TestShouldRaiseErrorWhenPasswordlessTimeoutIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.PasswordlessConfiguration{Timeout: "never"}
+
+	ValidatePasswordless(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "Error occurred parsing passwordless timeout string: could not convert the input string of never into a duration")
+
+	validator = schema.NewStructValidator()
+	config.Timeout = "0"
+
+	ValidatePasswordless(&config, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "passwordless timeout must be above 0")
+}
@@ -0,0 +1,33 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+// ValidateRegistration validates and update the registration configuration.
+func ValidateRegistration(configuration *schema.RegistrationConfiguration, backend schema.AuthenticationBackendConfiguration, validator *schema.StructValidator) {
+	switch {
+	case backend.Chain != nil || (backend.File == nil && backend.SQL == nil):
+		validator.Push(fmt.Errorf("registration is only supported by the file and sql authentication backends"))
+	case backend.SQL != nil && backend.SQL.Queries.CreateUser == "":
+		validator.Push(fmt.Errorf("registration requires a `create_user` query in `authentication_backend.sql.queries`"))
+	}
+
+	if configuration.RequireApproval && configuration.AdminsGroup == "" {
+		validator.Push(fmt.Errorf("registration require_approval requires the admins_group approving the registrations"))
+	}
+
+	for i, domain := range configuration.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "@"))
+
+		if domain == "" || strings.Contains(domain, "@") {
+			validator.Push(fmt.Errorf("registration allowed domain '%s' is not a valid domain", configuration.AllowedDomains[i]))
+			continue
+		}
+
+		configuration.AllowedDomains[i] = domain
+	}
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+)
+
+func TestShouldNormalizeRegistrationAllowedDomains(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := &schema.RegistrationConfiguration{
+		AllowedDomains: []string{"@Example.com", "example.org"},
+	}
+
+	ValidateRegistration(config, schema.AuthenticationBackendConfiguration{File: &schema.FileAuthenticationBackendConfiguration{}}, validator)
+
+	require.Len(t, validator.Errors(), 0)
+	assert.Equal(t, []string{"example.com", "example.org"}, config.AllowedDomains)
+}
+
+func TestShouldRaiseErrorWhenRegistrationBackendIsNotSupported(t *testing.T) {
+	validator := schema.NewStructValidator()
+
+	ValidateRegistration(&schema.RegistrationConfiguration{}, schema.AuthenticationBackendConfiguration{LDAP: &schema.LDAPAuthenticationBackendConfiguration{}}, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "registration is only supported by the file and sql authentication backends")
+
+	validator = schema.NewStructValidator()
+
+	ValidateRegistration(&schema.RegistrationConfiguration{}, schema.AuthenticationBackendConfiguration{SQL: &schema.SQLAuthenticationBackendConfiguration{}}, validator)
+
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "registration requires a `create_user` query in `authentication_backend.sql.queries`")
+}
+
+func TestShouldRaiseErrorWhenRegistrationIsInvalid(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := &schema.RegistrationConfiguration{
+		RequireApproval: true,
+		AllowedDomains:  []string{"john@example.com", "@"},
+	}
+
+	ValidateRegistration(config, schema.AuthenticationBackendConfiguration{File: &schema.FileAuthenticationBackendConfiguration{}}, validator)
+
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "registration require_approval requires the admins_group approving the registrations")
+	assert.EqualError(t, validator.Errors()[1], "registration allowed domain 'john@example.com' is not a valid domain")
+	assert.EqualError(t, validator.Errors()[2], "registration allowed domain '@' is not a valid domain")
+}
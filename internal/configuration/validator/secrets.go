@@ -47,6 +47,16 @@ func ValidateSecrets(configuration *schema.Configuration, validator *schema.Stru
 		configuration.AuthenticationBackend.LDAP.Password = getSecretValue(SecretNames["LDAPPassword"], validator, viper)
 	}
 
+	if configuration.AuthenticationBackend.SQL != nil {
+		if configuration.AuthenticationBackend.SQL.MySQL != nil {
+			configuration.AuthenticationBackend.SQL.MySQL.Password = getSecretValue(SecretNames["SQLAuthMySQLPassword"], validator, viper)
+		}
+
+		if configuration.AuthenticationBackend.SQL.PostgreSQL != nil {
+			configuration.AuthenticationBackend.SQL.PostgreSQL.Password = getSecretValue(SecretNames["SQLAuthPostgreSQLPassword"], validator, viper)
+		}
+	}
+
 	if configuration.Notifier != nil && configuration.Notifier.SMTP != nil {
 		configuration.Notifier.SMTP.Password = getSecretValue(SecretNames["SMTPPassword"], validator, viper)
 	}
@@ -59,6 +69,10 @@ func ValidateSecrets(configuration *schema.Configuration, validator *schema.Stru
 		configuration.Storage.PostgreSQL.Password = getSecretValue(SecretNames["PostgreSQLPassword"], validator, viper)
 	}
 
+	if configuration.Server.TrustedHeader != nil {
+		configuration.Server.TrustedHeader.Secret = getSecretValue(SecretNames["TrustedHeaderSecret"], validator, viper)
+	}
+
 	if configuration.IdentityProviders.OIDC != nil {
 		configuration.IdentityProviders.OIDC.HMACSecret = getSecretValue(SecretNames["OpenIDConnectHMACSecret"], validator, viper)
 		configuration.IdentityProviders.OIDC.IssuerPrivateKey = getSecretValue(SecretNames["OpenIDConnectIssuerPrivateKey"], validator, viper)
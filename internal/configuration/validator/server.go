@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"net/url"
 	"path"
 	"strings"
 
@@ -35,4 +36,133 @@ func ValidateServer(configuration *schema.ServerConfiguration, validator *schema
 	} else if configuration.WriteBufferSize < 0 {
 		validator.Push(fmt.Errorf("server write buffer size must be above 0"))
 	}
+
+	if configuration.ClientCertificates != nil {
+		validateClientCertificates(configuration.ClientCertificates, validator)
+	}
+
+	if configuration.TrustedHeader != nil {
+		validateTrustedHeader(configuration.TrustedHeader, validator)
+	}
+
+	if configuration.ExtAuthz != nil {
+		validateExtAuthz(configuration.ExtAuthz, validator)
+	}
+}
+
+func validateClientCertificates(configuration *schema.ClientCertificatesConfiguration, validator *schema.StructValidator) {
+	if configuration.CertificateAuthority == "" {
+		validator.Push(fmt.Errorf("server client certificates must have a certificate authority"))
+	}
+
+	switch configuration.UsernameAttribute {
+	case "":
+		configuration.UsernameAttribute = schema.DefaultClientCertificatesConfiguration.UsernameAttribute
+	case schema.ClientCertificateUsernameCommonName, schema.ClientCertificateUsernameEmail, schema.ClientCertificateUsernameDNS:
+	default:
+		validator.Push(fmt.Errorf("server client certificates username attribute '%s' is invalid, must be '%s', '%s' or '%s'",
+			configuration.UsernameAttribute, schema.ClientCertificateUsernameCommonName, schema.ClientCertificateUsernameEmail, schema.ClientCertificateUsernameDNS))
+	}
+
+	switch configuration.AuthenticationLevel {
+	case "":
+		configuration.AuthenticationLevel = schema.DefaultClientCertificatesConfiguration.AuthenticationLevel
+	case schema.AuthenticationLevelOneFactor, schema.AuthenticationLevelTwoFactor:
+	default:
+		validator.Push(fmt.Errorf("server client certificates authentication level '%s' is invalid, must be '%s' or '%s'",
+			configuration.AuthenticationLevel, schema.AuthenticationLevelOneFactor, schema.AuthenticationLevelTwoFactor))
+	}
+
+	switch {
+	case configuration.Header != "" && len(configuration.TrustedNetworks) == 0:
+		validator.Push(fmt.Errorf("server client certificates header requires the trusted networks of the proxies setting it"))
+	case configuration.Header == "" && len(configuration.TrustedNetworks) != 0:
+		validator.Push(fmt.Errorf("server client certificates trusted networks are only used with a header"))
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		if !IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("server client certificates trusted network '%s' is not a valid IP or CIDR notation", network))
+		}
+	}
+}
+
+func validateTrustedHeader(configuration *schema.TrustedHeaderConfiguration, validator *schema.StructValidator) {
+	if configuration.Header == "" {
+		configuration.Header = schema.DefaultTrustedHeaderConfiguration.Header
+	}
+
+	if utils.IsStringInSliceFold(configuration.Header, reservedUserAttributeHeaders) {
+		validator.Push(fmt.Errorf("server trusted header header '%s' is invalid, it's sent by Authelia to the proxy", configuration.Header))
+	}
+
+	switch configuration.AuthenticationLevel {
+	case "":
+		configuration.AuthenticationLevel = schema.DefaultTrustedHeaderConfiguration.AuthenticationLevel
+	case schema.AuthenticationLevelOneFactor, schema.AuthenticationLevelTwoFactor:
+	default:
+		validator.Push(fmt.Errorf("server trusted header authentication level '%s' is invalid, must be '%s' or '%s'",
+			configuration.AuthenticationLevel, schema.AuthenticationLevelOneFactor, schema.AuthenticationLevelTwoFactor))
+	}
+
+	if len(configuration.TrustedNetworks) == 0 {
+		validator.Push(fmt.Errorf("server trusted header requires the trusted networks of the gateways setting it"))
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		if !IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("server trusted header trusted network '%s' is not a valid IP or CIDR notation", network))
+		}
+	}
+
+	if configuration.Secret == "" {
+		return
+	}
+
+	if configuration.SignatureHeader == "" {
+		configuration.SignatureHeader = schema.DefaultTrustedHeaderConfiguration.SignatureHeader
+	}
+
+	if configuration.SignatureLifetime == "" {
+		configuration.SignatureLifetime = schema.DefaultTrustedHeaderConfiguration.SignatureLifetime
+	}
+
+	lifetime, err := utils.ParseDurationString(configuration.SignatureLifetime)
+
+	switch {
+	case err != nil:
+		validator.Push(fmt.Errorf("Error occurred parsing server trusted header signature_lifetime string: %s", err))
+	case lifetime <= 0:
+		validator.Push(fmt.Errorf("server trusted header signature_lifetime must be above 0"))
+	}
+}
+
+func validateExtAuthz(configuration *schema.ExtAuthzConfiguration, validator *schema.StructValidator) {
+	if configuration.Host == "" {
+		configuration.Host = schema.DefaultExtAuthzConfiguration.Host
+	}
+
+	switch {
+	case configuration.Port == 0:
+		configuration.Port = schema.DefaultExtAuthzConfiguration.Port
+	case configuration.Port < 0 || configuration.Port > 65535:
+		validator.Push(fmt.Errorf("server ext_authz port %d is invalid, must be between 1 and 65535", configuration.Port))
+	}
+
+	if configuration.PortalURL != "" {
+		portalURL, err := url.ParseRequestURI(configuration.PortalURL)
+		if err != nil || portalURL.Scheme != "https" || portalURL.Host == "" {
+			validator.Push(fmt.Errorf("server ext_authz portal url '%s' is invalid, must be an absolute https url", configuration.PortalURL))
+		}
+	}
+
+	if len(configuration.TrustedNetworks) == 0 {
+		configuration.TrustedNetworks = schema.DefaultExtAuthzConfiguration.TrustedNetworks
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		if !IsNetworkValid(network) {
+			validator.Push(fmt.Errorf("server ext_authz trusted network '%s' is not a valid IP or CIDR notation", network))
+		}
+	}
 }
@@ -61,3 +61,149 @@ func TestShouldRaiseOnForwardSlashInPath(t *testing.T) {
 	assert.Len(t, validator.Errors(), 1)
 	assert.Error(t, validator.Errors()[0], "server path must not contain any forward slashes")
 }
+
+func TestShouldSetDefaultClientCertificatesConfig(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		ClientCertificates: &schema.ClientCertificatesConfiguration{
+			CertificateAuthority: "/config/clients-ca.pem",
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+
+	assert.Equal(t, schema.ClientCertificateUsernameCommonName, config.ClientCertificates.UsernameAttribute)
+	assert.Equal(t, schema.AuthenticationLevelOneFactor, config.ClientCertificates.AuthenticationLevel)
+}
+
+func TestShouldRaiseOnInvalidClientCertificatesConfig(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		ClientCertificates: &schema.ClientCertificatesConfiguration{
+			UsernameAttribute:   "serial",
+			AuthenticationLevel: "bypass",
+			Header:              "X-Client-Cert",
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 4)
+	assert.EqualError(t, validator.Errors()[0], "server client certificates must have a certificate authority")
+	assert.EqualError(t, validator.Errors()[1], "server client certificates username attribute 'serial' is invalid, must be 'common_name', 'email' or 'dns'")
+	assert.EqualError(t, validator.Errors()[2], "server client certificates authentication level 'bypass' is invalid, must be 'one_factor' or 'two_factor'")
+	assert.EqualError(t, validator.Errors()[3], "server client certificates header requires the trusted networks of the proxies setting it")
+
+	validator = schema.NewStructValidator()
+	config.ClientCertificates = &schema.ClientCertificatesConfiguration{
+		CertificateAuthority: "/config/clients-ca.pem",
+		TrustedNetworks:      []string{"10.0.0.0/8", "proxy"},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server client certificates trusted networks are only used with a header")
+	assert.EqualError(t, validator.Errors()[1], "server client certificates trusted network 'proxy' is not a valid IP or CIDR notation")
+}
+
+func TestShouldSetDefaultTrustedHeaderConfig(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		TrustedHeader: &schema.TrustedHeaderConfiguration{
+			TrustedNetworks: []string{"10.0.0.0/8"},
+			Secret:          "a_secret",
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+
+	assert.Equal(t, "X-Authenticated-User", config.TrustedHeader.Header)
+	assert.Equal(t, "X-Authenticated-User-Signature", config.TrustedHeader.SignatureHeader)
+	assert.Equal(t, "1m", config.TrustedHeader.SignatureLifetime)
+	assert.Equal(t, schema.AuthenticationLevelOneFactor, config.TrustedHeader.AuthenticationLevel)
+}
+
+func TestShouldRaiseOnInvalidTrustedHeaderConfig(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		TrustedHeader: &schema.TrustedHeaderConfiguration{
+			AuthenticationLevel: "bypass",
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server trusted header authentication level 'bypass' is invalid, must be 'one_factor' or 'two_factor'")
+	assert.EqualError(t, validator.Errors()[1], "server trusted header requires the trusted networks of the gateways setting it")
+
+	validator = schema.NewStructValidator()
+	config.TrustedHeader = &schema.TrustedHeaderConfiguration{
+		TrustedNetworks: []string{"10.0.0.0/8", "gateway"},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server trusted header trusted network 'gateway' is not a valid IP or CIDR notation")
+	assert.Equal(t, "", config.TrustedHeader.SignatureHeader)
+	assert.Equal(t, "", config.TrustedHeader.SignatureLifetime)
+
+	validator = schema.NewStructValidator()
+	config.TrustedHeader = &schema.TrustedHeaderConfiguration{
+		Header:            "remote-user",
+		TrustedNetworks:   []string{"10.0.0.0/8"},
+		Secret:            "a_secret",
+		SignatureLifetime: "forever",
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 2)
+	assert.EqualError(t, validator.Errors()[0], "server trusted header header 'remote-user' is invalid, it's sent by Authelia to the proxy")
+	assert.EqualError(t, validator.Errors()[1], "Error occurred parsing server trusted header signature_lifetime string: could not convert the input string of forever into a duration")
+
+	validator = schema.NewStructValidator()
+	config.TrustedHeader.Header = ""
+	config.TrustedHeader.SignatureLifetime = "0"
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 1)
+	assert.EqualError(t, validator.Errors()[0], "server trusted header signature_lifetime must be above 0")
+}
+
+func TestShouldSetDefaultExtAuthzConfig(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		ExtAuthz: &schema.ExtAuthzConfiguration{},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+
+	assert.Equal(t, "127.0.0.1", config.ExtAuthz.Host)
+	assert.Equal(t, 9092, config.ExtAuthz.Port)
+	assert.Equal(t, "", config.ExtAuthz.PortalURL)
+	assert.Equal(t, []string{"127.0.0.1", "::1"}, config.ExtAuthz.TrustedNetworks)
+}
+
+func TestShouldRaiseOnInvalidExtAuthzConfig(t *testing.T) {
+	validator := schema.NewStructValidator()
+	config := schema.ServerConfiguration{
+		ExtAuthz: &schema.ExtAuthzConfiguration{
+			Port:            70000,
+			PortalURL:       "http://login.example.com",
+			TrustedNetworks: []string{"10.0.0.0/8", "envoy"},
+		},
+	}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 3)
+	assert.EqualError(t, validator.Errors()[0], "server ext_authz port 70000 is invalid, must be between 1 and 65535")
+	assert.EqualError(t, validator.Errors()[1], "server ext_authz portal url 'http://login.example.com' is invalid, must be an absolute https url")
+	assert.EqualError(t, validator.Errors()[2], "server ext_authz trusted network 'envoy' is not a valid IP or CIDR notation")
+
+	validator = schema.NewStructValidator()
+	config.ExtAuthz = &schema.ExtAuthzConfiguration{PortalURL: "https://login.example.com/"}
+
+	ValidateServer(&config, validator)
+	require.Len(t, validator.Errors(), 0)
+}
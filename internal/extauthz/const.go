@@ -0,0 +1,21 @@
+package extauthz
+
+const (
+	serviceName = "envoy.service.auth.v3.Authorization"
+	checkMethod = "/" + serviceName + "/Check"
+
+	verifyPath = "/api/verify"
+)
+
+// forwardedHeaders are the built-in headers forwarded to the backends which are removed from the requests when Authelia
+// doesn't set them, so the clients can't forge them. The headers of the user attributes are handled the same way.
+var forwardedHeaders = []string{"Remote-User", "Remote-Groups", "Remote-Name", "Remote-Email"}
+
+// requestHeadersToOverride are the headers of the request to check which are replaced by the attributes of the request
+// sent by Envoy.
+var requestHeadersToOverride = []string{
+	"X-Original-URL", "X-Forwarded-Proto", "X-Forwarded-Host", "X-Forwarded-Uri", "X-Forwarded-Method",
+}
+
+// responseHeadersToSkip are the headers of the denied responses of the verify endpoint which are not returned to Envoy.
+var responseHeadersToSkip = []string{"Content-Length", "Content-Type", "Date", "Server"}
@@ -0,0 +1,234 @@
+package extauthz
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// AuthorizationServer is the server API of the envoy.service.auth.v3.Authorization gRPC service.
+type AuthorizationServer interface {
+	Check(ctx context.Context, request *CheckRequest) (*CheckResponse, error)
+}
+
+// Server implements the envoy.service.auth.v3.Authorization gRPC service by running the requests to check through the
+// handler of the verify endpoint, so they are authenticated and authorized exactly like the forwarded requests.
+type Server struct {
+	handler   fasthttp.RequestHandler
+	portalURL string
+
+	forwardedHeaders []string
+	trustedNetworks  []*net.IPNet
+}
+
+// NewServer creates a Server checking the requests with the handler of the verify endpoint. Only the Envoy proxies of
+// the trusted networks are allowed to call it. The unauthenticated users are redirected to the portal when its url is
+// configured. The headers of the user attributes are forwarded along with the built-in ones.
+func NewServer(configuration schema.ExtAuthzConfiguration, attributes []schema.UserAttributeConfiguration,
+	verifyHandler fasthttp.RequestHandler) (*Server, error) {
+	server := &Server{
+		handler:          verifyHandler,
+		portalURL:        configuration.PortalURL,
+		forwardedHeaders: append([]string{}, forwardedHeaders...),
+	}
+
+	for _, attribute := range attributes {
+		if attribute.Header != "" {
+			server.forwardedHeaders = append(server.forwardedHeaders, attribute.Header)
+		}
+	}
+
+	for _, network := range configuration.TrustedNetworks {
+		ipNet, err := utils.ParseNetwork(network)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse the ext_authz trusted network %s: %w", network, err)
+		}
+
+		server.trustedNetworks = append(server.trustedNetworks, ipNet)
+	}
+
+	return server, nil
+}
+
+// Register registers the authorization service on the gRPC server.
+func (s *Server) Register(server *grpc.Server) {
+	server.RegisterService(&authorizationServiceDesc, s)
+}
+
+// Check checks whether the request described by the attributes sent by Envoy is allowed to go through. It returns an
+// OkHttpResponse carrying the headers identifying the user when it is, or a DeniedHttpResponse carrying the response
+// of the verify endpoint, e.g. the redirection to the portal, when it's not.
+func (s *Server) Check(ctx context.Context, request *CheckRequest) (*CheckResponse, error) {
+	// The attributes, including the address of the client, are only trusted when they are sent by a trusted Envoy.
+	if ip := peerIP(ctx); ip == nil || !utils.IsIPInNetworks(ip, s.trustedNetworks) {
+		return nil, status.Errorf(codes.PermissionDenied, "the peer %s is not a trusted Envoy proxy", ip)
+	}
+
+	if request.Attributes == nil || request.Attributes.Request == nil || request.Attributes.Request.HTTP == nil {
+		return nil, status.Error(codes.InvalidArgument, "the check request has no HTTP request attributes")
+	}
+
+	requestCtx := &fasthttp.RequestCtx{}
+	requestCtx.Init(s.newVerifyRequest(request.Attributes.Request.HTTP), sourceAddress(request.Attributes.Source), nil)
+
+	s.handler(requestCtx)
+
+	return s.newCheckResponse(&requestCtx.Response), nil
+}
+
+// newVerifyRequest builds the request to the verify endpoint equivalent to the request described by the attributes.
+func (s *Server) newVerifyRequest(attributes *HTTPRequest) *fasthttp.Request {
+	request := &fasthttp.Request{}
+
+	request.Header.SetMethod(fasthttp.MethodGet)
+
+	if s.portalURL == "" {
+		request.SetRequestURI(verifyPath)
+	} else {
+		request.SetRequestURI(verifyPath + "?rd=" + url.QueryEscape(s.portalURL))
+	}
+
+	for name, value := range attributes.Headers {
+		// Skip the HTTP/2 pseudo headers and the headers describing the request which could be forged by the client.
+		if strings.HasPrefix(name, ":") || utils.IsStringInSliceFold(name, requestHeadersToOverride) {
+			continue
+		}
+
+		request.Header.Set(name, value)
+	}
+
+	scheme := attributes.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	request.Header.Set("X-Original-URL", scheme+"://"+attributes.Host+attributes.Path)
+	request.Header.Set("X-Forwarded-Method", attributes.Method)
+
+	return request
+}
+
+// peerIP returns the IP of the gRPC peer sending the check request, nil when it's not known.
+func peerIP(ctx context.Context) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	addr, ok := p.Addr.(*net.TCPAddr)
+	if !ok {
+		return nil
+	}
+
+	return addr.IP
+}
+
+// sourceAddress returns the address of the client of the request, nil when Envoy didn't send it.
+func sourceAddress(source *Peer) net.Addr {
+	if source == nil || source.Address == nil || source.Address.SocketAddress == nil {
+		return nil
+	}
+
+	ip := net.ParseIP(source.Address.SocketAddress.Address)
+	if ip == nil {
+		return nil
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(source.Address.SocketAddress.PortValue)}
+}
+
+// newCheckResponse converts the response of the verify endpoint to the response expected by Envoy. Only the forwarded
+// headers are added to the allowed requests, and the ones without a value are removed so the clients can't forge them.
+func (s *Server) newCheckResponse(response *fasthttp.Response) *CheckResponse {
+	if response.StatusCode() == fasthttp.StatusOK {
+		ok := &OkHTTPResponse{}
+
+		// Only the headers identifying the user are forwarded to the backends, not e.g. the session cookie.
+		for _, name := range s.forwardedHeaders {
+			if value := response.Header.Peek(name); len(value) != 0 {
+				ok.Headers = append(ok.Headers, newHeaderValueOption(name, string(value)))
+			} else {
+				ok.HeadersToRemove = append(ok.HeadersToRemove, name)
+			}
+		}
+
+		return &CheckResponse{Status: &Status{Code: int32(codes.OK)}, OkResponse: ok}
+	}
+
+	denied := &DeniedHTTPResponse{
+		Status: &HTTPStatus{Code: int32(response.StatusCode())},
+		Body:   string(response.Body()),
+	}
+
+	response.Header.VisitAll(func(key, value []byte) {
+		if !utils.IsStringInSliceFold(string(key), responseHeadersToSkip) {
+			denied.Headers = append(denied.Headers, newHeaderValueOption(string(key), string(value)))
+		}
+	})
+
+	if contentType := response.Header.ContentType(); len(denied.Body) != 0 && len(contentType) != 0 {
+		denied.Headers = append(denied.Headers, newHeaderValueOption(fasthttp.HeaderContentType, string(contentType)))
+	}
+
+	code := codes.Unauthenticated
+	if response.StatusCode() == fasthttp.StatusForbidden {
+		code = codes.PermissionDenied
+	}
+
+	return &CheckResponse{Status: &Status{Code: int32(code)}, DeniedResponse: denied}
+}
+
+// newHeaderValueOption returns the option replacing the header with the value.
+func newHeaderValueOption(name, value string) *HeaderValueOption {
+	return &HeaderValueOption{
+		Header: &HeaderValue{Key: name, Value: value},
+		Append: &BoolValue{Value: false},
+	}
+}
+
+var authorizationServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*AuthorizationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    checkHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "envoy/service/auth/v3/external_auth.proto",
+}
+
+func checkHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) { //nolint:revive // The signature is imposed by gRPC.
+	request := &CheckRequest{}
+
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(AuthorizationServer).Check(ctx, request)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: checkMethod,
+	}
+
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(AuthorizationServer).Check(ctx, request.(*CheckRequest))
+	}
+
+	return interceptor(ctx, request, info, handler)
+}
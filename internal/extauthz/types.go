@@ -0,0 +1,237 @@
+package extauthz
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The types below are the subset of the messages of the envoy.service.auth.v3 API used by Authelia. The fields keep the
+// numbers and the types of the protocol buffers definitions of Envoy, so they are compatible on the wire, while the
+// fields Authelia doesn't use are omitted and skipped when decoding.
+
+// CheckRequest is the envoy.service.auth.v3.CheckRequest message.
+type CheckRequest struct {
+	Attributes *AttributeContext `protobuf:"bytes,1,opt,name=attributes,proto3" json:"attributes,omitempty"`
+}
+
+// AttributeContext is the envoy.service.auth.v3.AttributeContext message.
+type AttributeContext struct {
+	Source  *Peer    `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Request *Request `protobuf:"bytes,4,opt,name=request,proto3" json:"request,omitempty"`
+}
+
+// Peer is the envoy.service.auth.v3.AttributeContext.Peer message.
+type Peer struct {
+	Address *Address `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+// Address is the envoy.config.core.v3.Address message.
+type Address struct {
+	SocketAddress *SocketAddress `protobuf:"bytes,1,opt,name=socket_address,json=socketAddress,proto3" json:"socket_address,omitempty"`
+}
+
+// SocketAddress is the envoy.config.core.v3.SocketAddress message.
+type SocketAddress struct {
+	Address   string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	PortValue uint32 `protobuf:"varint,3,opt,name=port_value,json=portValue,proto3" json:"port_value,omitempty"`
+}
+
+// Request is the envoy.service.auth.v3.AttributeContext.Request message.
+type Request struct {
+	HTTP *HTTPRequest `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"`
+}
+
+// HTTPRequest is the envoy.service.auth.v3.AttributeContext.HttpRequest message. The headers are lower cased, include
+// the HTTP/2 pseudo headers and the values of a header sent several times are joined with a comma. The path includes
+// the query string.
+type HTTPRequest struct {
+	ID       string            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Method   string            `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	Headers  map[string]string `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Path     string            `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	Host     string            `protobuf:"bytes,5,opt,name=host,proto3" json:"host,omitempty"`
+	Scheme   string            `protobuf:"bytes,6,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	Protocol string            `protobuf:"bytes,10,opt,name=protocol,proto3" json:"protocol,omitempty"`
+}
+
+// CheckResponse is the envoy.service.auth.v3.CheckResponse message, only one of DeniedResponse and OkResponse is set.
+type CheckResponse struct {
+	Status         *Status             `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	DeniedResponse *DeniedHTTPResponse `protobuf:"bytes,2,opt,name=denied_response,json=deniedResponse,proto3" json:"denied_response,omitempty"`
+	OkResponse     *OkHTTPResponse     `protobuf:"bytes,3,opt,name=ok_response,json=okResponse,proto3" json:"ok_response,omitempty"`
+}
+
+// Status is the google.rpc.Status message.
+type Status struct {
+	Code    int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+// DeniedHTTPResponse is the envoy.service.auth.v3.DeniedHttpResponse message.
+type DeniedHTTPResponse struct {
+	Status  *HTTPStatus          `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Headers []*HeaderValueOption `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`
+	Body    string               `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+// OkHTTPResponse is the envoy.service.auth.v3.OkHttpResponse message.
+type OkHTTPResponse struct {
+	Headers         []*HeaderValueOption `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`
+	HeadersToRemove []string             `protobuf:"bytes,5,rep,name=headers_to_remove,json=headersToRemove,proto3" json:"headers_to_remove,omitempty"`
+}
+
+// HTTPStatus is the envoy.type.v3.HttpStatus message.
+type HTTPStatus struct {
+	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+// HeaderValueOption is the envoy.config.core.v3.HeaderValueOption message.
+type HeaderValueOption struct {
+	Header *HeaderValue `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Append *BoolValue   `protobuf:"bytes,2,opt,name=append,proto3" json:"append,omitempty"`
+}
+
+// HeaderValue is the envoy.config.core.v3.HeaderValue message.
+type HeaderValue struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+// BoolValue is the google.protobuf.BoolValue message.
+type BoolValue struct {
+	Value bool `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+// Reset resets the message to its zero value.
+func (m *CheckRequest) Reset() { *m = CheckRequest{} }
+
+// String returns the text representation of the message.
+func (m *CheckRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*CheckRequest) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *AttributeContext) Reset() { *m = AttributeContext{} }
+
+// String returns the text representation of the message.
+func (m *AttributeContext) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*AttributeContext) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *Peer) Reset() { *m = Peer{} }
+
+// String returns the text representation of the message.
+func (m *Peer) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*Peer) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *Address) Reset() { *m = Address{} }
+
+// String returns the text representation of the message.
+func (m *Address) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*Address) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *SocketAddress) Reset() { *m = SocketAddress{} }
+
+// String returns the text representation of the message.
+func (m *SocketAddress) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*SocketAddress) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *Request) Reset() { *m = Request{} }
+
+// String returns the text representation of the message.
+func (m *Request) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*Request) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *HTTPRequest) Reset() { *m = HTTPRequest{} }
+
+// String returns the text representation of the message.
+func (m *HTTPRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*HTTPRequest) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *CheckResponse) Reset() { *m = CheckResponse{} }
+
+// String returns the text representation of the message.
+func (m *CheckResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*CheckResponse) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *Status) Reset() { *m = Status{} }
+
+// String returns the text representation of the message.
+func (m *Status) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*Status) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *DeniedHTTPResponse) Reset() { *m = DeniedHTTPResponse{} }
+
+// String returns the text representation of the message.
+func (m *DeniedHTTPResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*DeniedHTTPResponse) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *OkHTTPResponse) Reset() { *m = OkHTTPResponse{} }
+
+// String returns the text representation of the message.
+func (m *OkHTTPResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*OkHTTPResponse) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *HTTPStatus) Reset() { *m = HTTPStatus{} }
+
+// String returns the text representation of the message.
+func (m *HTTPStatus) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*HTTPStatus) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *HeaderValueOption) Reset() { *m = HeaderValueOption{} }
+
+// String returns the text representation of the message.
+func (m *HeaderValueOption) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*HeaderValueOption) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *HeaderValue) Reset() { *m = HeaderValue{} }
+
+// String returns the text representation of the message.
+func (m *HeaderValue) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*HeaderValue) ProtoMessage() {}
+
+// Reset resets the message to its zero value.
+func (m *BoolValue) Reset() { *m = BoolValue{} }
+
+// String returns the text representation of the message.
+func (m *BoolValue) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage marks the type as a protocol buffers message.
+func (*BoolValue) ProtoMessage() {}
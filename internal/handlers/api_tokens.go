@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+// generateAPIToken generates a new API token and returns its identifier, its secret and the value handed to the user.
+func generateAPIToken() (id, secret, value string, err error) {
+	idBytes := make([]byte, apiTokenIDLength)
+	if _, err = rand.Read(idBytes); err != nil {
+		return "", "", "", err
+	}
+
+	secretBytes := make([]byte, apiTokenSecretLength)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	id = hex.EncodeToString(idBytes)
+	secret = base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	return id, secret, apiTokenPrefix + id + "." + secret, nil
+}
+
+// parseAPIToken splits the value of an API token into its identifier and its secret.
+func parseAPIToken(value string) (id, secret string, err error) {
+	if !strings.HasPrefix(value, apiTokenPrefix) {
+		return "", "", errors.New("The API token has an invalid format")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, apiTokenPrefix), ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("The API token has an invalid format")
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// hashAPITokenSecret returns the hash of the secret of an API token as it's stored in the storage provider.
+func hashAPITokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// isAPITokenBearer returns true if the header value holds an API token issued by Authelia.
+func isAPITokenBearer(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(bearerPrefix+apiTokenPrefix))
+}
+
+// isAPITokenScopeMatching returns true if the host is covered by one of the scopes of an API token. A scope is either
+// a domain or a wildcard like *.example.com covering all the subdomains of example.com.
+func isAPITokenScopeMatching(scopes []string, host string) bool {
+	host = strings.ToLower(host)
+
+	for _, scope := range scopes {
+		scope = strings.ToLower(scope)
+
+		if strings.HasPrefix(scope, "*.") {
+			if strings.HasSuffix(host, scope[1:]) {
+				return true
+			}
+
+			continue
+		}
+
+		if scope == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadValidAPIToken loads the API token from the storage provider and checks its secret and its expiration.
+func loadValidAPIToken(ctx *middlewares.AutheliaCtx, value string) (*models.APIToken, error) {
+	id, secret, err := parseAPIToken(value)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := ctx.Providers.StorageProvider.LoadAPIToken(id)
+	if err != nil {
+		if err == storage.ErrNoAPIToken {
+			return nil, fmt.Errorf("The API token %s does not exist", id)
+		}
+
+		return nil, fmt.Errorf("Unable to load the API token %s: %w", id, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPITokenSecret(secret)), []byte(token.Hash)) != 1 {
+		return nil, fmt.Errorf("The secret of the API token %s does not match", id)
+	}
+
+	if !ctx.Clock.Now().Before(token.ExpiresAt) {
+		return nil, fmt.Errorf("The API token %s of user %s has expired on %s", id, token.Username, token.ExpiresAt)
+	}
+
+	return token, nil
+}
+
+func verifyAPIToken(header string, auth []byte, targetURL url.URL, ctx *middlewares.AutheliaCtx) (user verifiedUser, err error) {
+	token, err := loadValidAPIToken(ctx, strings.TrimSpace(strings.TrimPrefix(string(auth), bearerPrefix)))
+	if err != nil {
+		return user, fmt.Errorf("Unable to verify the API token of the %s header: %s", header, err)
+	}
+
+	if !isAPITokenScopeMatching(token.Scopes, targetURL.Hostname()) {
+		return user, fmt.Errorf("The API token %s of user %s is not scoped for %s", token.ID, token.Username, targetURL.Hostname())
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(token.Username)
+	if err != nil {
+		return user, fmt.Errorf("Unable to retrieve details of user %s: %s", token.Username, err)
+	}
+
+	return verifiedUser{UserDetails: *details, AuthLevel: authentication.OneFactor}, nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// getClientCertificates returns the certificates presented by the client to the TLS listener or forwarded by a trusted
+// proxy in the client certificate header, nil is returned when the client doesn't present any certificate.
+func getClientCertificates(ctx *middlewares.AutheliaCtx) ([]*x509.Certificate, error) {
+	if state := ctx.TLSConnectionState(); state != nil && len(state.PeerCertificates) != 0 {
+		return state.PeerCertificates, nil
+	}
+
+	verifier := ctx.Providers.ClientCertificates
+
+	if verifier.Header() == "" {
+		return nil, nil
+	}
+
+	value := ctx.Request.Header.Peek(verifier.Header())
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	// The address of the peer is checked rather than X-Forwarded-For which can be set by anyone.
+	if remoteIP := ctx.RequestCtx.RemoteIP(); !verifier.IsTrustedProxy(remoteIP) {
+		return nil, fmt.Errorf("Client certificate header %s has been sent by %s which is not a trusted proxy", verifier.Header(), remoteIP)
+	}
+
+	return verifier.ParseHeader(value)
+}
+
+// verifyClientCertificate returns the details of the user identified by the client certificate, nil is returned when
+// the client certificates are disabled or when the client doesn't present any certificate.
+func verifyClientCertificate(ctx *middlewares.AutheliaCtx) (*authentication.UserDetails, error) {
+	if ctx.Providers.ClientCertificates == nil {
+		return nil, nil
+	}
+
+	certificates, err := getClientCertificates(ctx)
+	if err != nil || certificates == nil {
+		return nil, err
+	}
+
+	username, err := ctx.Providers.ClientCertificates.Verify(certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve details of user %s identified by a client certificate: %w", username, err)
+	}
+
+	return details, nil
+}
+
+// authenticateWithClientCertificate opens a session at the configured authentication level for the user identified by
+// the client certificate presented to the portal. The session is left untouched when no certificate is presented.
+func authenticateWithClientCertificate(ctx *middlewares.AutheliaCtx, userSession *session.UserSession) error {
+	details, err := verifyClientCertificate(ctx)
+	if err != nil || details == nil {
+		return err
+	}
+
+	if err = openPreAuthenticatedSession(ctx, userSession, details, ctx.Providers.ClientCertificates.Level()); err != nil {
+		return err
+	}
+
+	ctx.Logger.Debugf("User %s has been authenticated with a client certificate", details.Username)
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// newTestClientCertificateVerifier creates a verifier trusting a generated authority along with the URL encoded PEM of
+// a client certificate of john signed by this authority.
+func newTestClientCertificateVerifier(t *testing.T, level string) (verifier *authentication.ClientCertificateVerifier, header string) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Clients CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	clientDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "john"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caTemplate, &clientKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "client-certificates")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+
+	verifier, err = authentication.NewClientCertificateVerifier(schema.ClientCertificatesConfiguration{
+		CertificateAuthority: caPath,
+		UsernameAttribute:    schema.ClientCertificateUsernameCommonName,
+		AuthenticationLevel:  level,
+		Header:               "X-Client-Cert",
+		TrustedNetworks:      []string{"10.0.0.1"},
+	}, utils.RealClock{})
+	require.NoError(t, err)
+
+	return verifier, url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER})))
+}
+
+func TestShouldAuthenticateWithClientCertificateHeaderInVerify(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	verifier, header := newTestClientCertificateVerifier(t, schema.AuthenticationLevelTwoFactor)
+	mock.Ctx.Providers.ClientCertificates = verifier
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails("john").
+		Return(&authentication.UserDetails{
+			Username: "john",
+			Emails:   []string{"john@example.com"},
+			Groups:   []string{"dev"},
+		}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	mock.Ctx.Request.Header.Set("X-Client-Cert", header)
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "john", string(mock.Ctx.Response.Header.Peek("Remote-User")))
+	assert.Equal(t, "dev", string(mock.Ctx.Response.Header.Peek("Remote-Groups")))
+
+	// The user is authenticated for the request only.
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+}
+
+func TestShouldNotTrustClientCertificateHeaderFromUntrustedProxy(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	verifier, header := newTestClientCertificateVerifier(t, schema.AuthenticationLevelTwoFactor)
+	mock.Ctx.Providers.ClientCertificates = verifier
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 4242})
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.1")
+	mock.Ctx.Request.Header.Set("X-Client-Cert", header)
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "", string(mock.Ctx.Response.Header.Peek("Remote-User")))
+}
+
+func TestShouldOpenSessionWithClientCertificateInPreAuthenticated(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	verifier, header := newTestClientCertificateVerifier(t, schema.AuthenticationLevelOneFactor)
+	mock.Ctx.Providers.ClientCertificates = verifier
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails("john").
+		Return(&authentication.UserDetails{
+			Username: "john",
+			Emails:   []string{"john@example.com"},
+		}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Client-Cert", header)
+
+	FirstFactorPreAuthenticatedPost(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+
+	userSession := mock.Ctx.GetSession()
+	assert.Equal(t, "john", userSession.Username)
+	assert.Equal(t, authentication.OneFactor, userSession.AuthenticationLevel)
+	assert.Equal(t, []string{"john@example.com"}, userSession.Emails)
+}
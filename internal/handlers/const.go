@@ -1,5 +1,7 @@
 package handlers
 
+import "time"
+
 // TOTPRegistrationAction is the string representation of the action for which the token has been produced.
 const TOTPRegistrationAction = "RegisterTOTPDevice"
 
@@ -9,7 +11,28 @@ const U2FRegistrationAction = "RegisterU2FDevice"
 // ResetPasswordAction is the string representation of the action for which the token has been produced.
 const ResetPasswordAction = "ResetPassword"
 
+// PasswordlessRegistrationAction is the string representation of the action for which the token has been produced.
+const PasswordlessRegistrationAction = "RegisterPasswordlessCredential"
+
+// RegistrationAction is the string representation of the action for which the token has been produced.
+const RegistrationAction = "Register"
+
+// registrationVerificationLifespan is the time a registration waits for its email verification, like the token sent
+// to the user, after which the username can be registered again.
+const registrationVerificationLifespan = 5 * time.Minute
+
+// passwordChangeLifespan is the time the user has to change the password the authentication backend reported as
+// expired or to be changed.
+const passwordChangeLifespan = 5 * time.Minute
+
 const authPrefix = "Basic "
+const bearerPrefix = "Bearer "
+
+// apiTokenPrefix is the prefix of the API tokens issued by Authelia, it tells them apart from the bearer tokens of
+// the protected applications.
+const apiTokenPrefix = "authelia_"
+const apiTokenIDLength = 8
+const apiTokenSecretLength = 32
 
 // ProxyAuthorizationHeader is the basic-auth HTTP header Authelia utilises.
 const ProxyAuthorizationHeader = "Proxy-Authorization"
@@ -25,6 +48,9 @@ const remoteNameHeader = "Remote-Name"
 const remoteEmailHeader = "Remote-Email"
 const remoteGroupsHeader = "Remote-Groups"
 
+// maxDenialReasonLength is the maximum number of characters of the denial reason exposed in the forbidden responses.
+const maxDenialReasonLength = 256
+
 const (
 	// Forbidden means the user is forbidden the access to a resource.
 	Forbidden authorizationMatching = iota
@@ -32,6 +58,16 @@ const (
 	NotAuthorized authorizationMatching = iota
 	// Authorized means the user is authorized given her current permissions.
 	Authorized authorizationMatching = iota
+	// ReauthenticationRequired means the user has the required permissions but authenticated too long ago.
+	ReauthenticationRequired authorizationMatching = iota
+	// SecondFactorMethodRequired means the user completed the second factor with a method the resource doesn't accept.
+	SecondFactorMethodRequired authorizationMatching = iota
+)
+
+// The values of the reauth query parameter telling the portal which factor the user must complete again.
+const (
+	reauthOneFactor = "one_factor"
+	reauthTwoFactor = "two_factor"
 )
 
 const operationFailedMessage = "Operation failed."
@@ -40,6 +76,13 @@ const userBannedMessage = "Please retry in a few minutes."
 const unableToRegisterOneTimePasswordMessage = "Unable to set up one-time passwords." //nolint:gosec
 const unableToRegisterSecurityKeyMessage = "Unable to register your security key."
 const unableToResetPasswordMessage = "Unable to reset your password."
+const unableToChangePasswordMessage = "Unable to change your password."
+const unableToManageAPITokensMessage = "Unable to manage your API tokens."
+const unableToRegisterMessage = "Unable to register your account."
+const registrationDomainNotAllowedMessage = "Registrations are restricted to some email domains."
+const unableToManageRegistrationsMessage = "Unable to manage the registrations."
+const unableToManagePasswordlessCredentialsMessage = "Unable to manage your passwordless security keys."
+const passwordChangeRequiredMessage = "Your password must be changed."
 const mfaValidationFailedMessage = "Authentication failed, please retry later."
 
 const ldapPasswordComplexityCode = "0000052D."
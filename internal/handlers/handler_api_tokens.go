@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+func newAPITokenResponse(token models.APIToken) APITokenResponse {
+	return APITokenResponse{
+		ID:        token.ID,
+		Username:  token.Username,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}
+}
+
+func newAPITokenResponses(tokens []models.APIToken) []APITokenResponse {
+	responses := make([]APITokenResponse, 0, len(tokens))
+
+	for _, token := range tokens {
+		responses = append(responses, newAPITokenResponse(token))
+	}
+
+	return responses
+}
+
+// isAPITokenLevelSufficient returns true if the user is authenticated with the level required to issue API tokens or
+// administrate them, i.e. the second factor when at least one rule requires it.
+func isAPITokenLevelSufficient(ctx *middlewares.AutheliaCtx, userSession session.UserSession) bool {
+	return !ctx.Providers.Authorizer.IsSecondFactorEnabled() || userSession.AuthenticationLevel >= authentication.TwoFactor
+}
+
+// isAPITokensAdmin returns true if the user belongs to the group allowed to administrate the API tokens of all users.
+func isAPITokensAdmin(ctx *middlewares.AutheliaCtx, userSession session.UserSession) bool {
+	return ctx.Configuration.APITokens.AdminsGroup != "" &&
+		utils.IsStringInSlice(ctx.Configuration.APITokens.AdminsGroup, userSession.Groups) &&
+		isAPITokenLevelSufficient(ctx, userSession)
+}
+
+// apiTokenExpiration computes the expiration of a new API token, it can't be later than the maximum lifetime.
+func apiTokenExpiration(ctx *middlewares.AutheliaCtx, expiration string) (time.Time, error) {
+	maxLifetime, err := utils.ParseDurationString(ctx.Configuration.APITokens.MaxLifetime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lifetime := maxLifetime
+
+	if expiration != "" {
+		if lifetime, err = utils.ParseDurationString(expiration); err != nil {
+			return time.Time{}, fmt.Errorf("Unable to parse the expiration %s: %w", expiration, err)
+		}
+
+		if lifetime <= 0 || lifetime > maxLifetime {
+			return time.Time{}, fmt.Errorf("The expiration %s must be above 0 and below the maximum lifetime %s", expiration, ctx.Configuration.APITokens.MaxLifetime)
+		}
+	}
+
+	return ctx.Clock.Now().Add(lifetime), nil
+}
+
+// UserAPITokensGet lists the API tokens issued by the user identified by the session.
+func UserAPITokensGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	tokens, err := ctx.Providers.StorageProvider.LoadAPITokens(userSession.Username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the API tokens of user %s: %s", userSession.Username, err), unableToManageAPITokensMessage)
+		return
+	}
+
+	if err = ctx.SetJSONBody(newAPITokenResponses(tokens)); err != nil {
+		ctx.Logger.Errorf("Unable to set API tokens response in body: %s", err)
+	}
+}
+
+// UserAPITokensPost issues a new API token for the user identified by the session. The value of the token is only
+// sent in this response, only the hash of its secret is stored.
+func UserAPITokensPost(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isAPITokenLevelSufficient(ctx, userSession) {
+		ctx.Logger.Infof("User %s must complete the second factor to issue an API token", userSession.Username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	var requestBody apiTokenRequestBody
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		ctx.Error(err, unableToManageAPITokensMessage)
+		return
+	}
+
+	if len(requestBody.Scopes) == 0 {
+		ctx.Error(errors.New("An API token must be scoped to at least one domain"), unableToManageAPITokensMessage)
+		return
+	}
+
+	expiresAt, err := apiTokenExpiration(ctx, requestBody.Expiration)
+	if err != nil {
+		ctx.Error(err, unableToManageAPITokensMessage)
+		return
+	}
+
+	id, secret, value, err := generateAPIToken()
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate an API token: %s", err), unableToManageAPITokensMessage)
+		return
+	}
+
+	token := models.APIToken{
+		ID:        id,
+		Username:  userSession.Username,
+		Name:      requestBody.Name,
+		Hash:      hashAPITokenSecret(secret),
+		Scopes:    requestBody.Scopes,
+		CreatedAt: ctx.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	if err = ctx.Providers.StorageProvider.SaveAPIToken(token); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save the API token of user %s: %s", userSession.Username, err), unableToManageAPITokensMessage)
+		return
+	}
+
+	ctx.Logger.Debugf("API token %s has been issued to user %s", id, userSession.Username)
+
+	if err = ctx.SetJSONBody(APITokenCreatedResponse{APITokenResponse: newAPITokenResponse(token), Token: value}); err != nil {
+		ctx.Logger.Errorf("Unable to set API token response in body: %s", err)
+	}
+}
+
+// UserAPITokenDelete revokes an API token issued by the user identified by the session.
+func UserAPITokenDelete(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+	id, _ := ctx.UserValue("id").(string)
+
+	token, err := ctx.Providers.StorageProvider.LoadAPIToken(id)
+
+	switch {
+	case err == storage.ErrNoAPIToken, err == nil && token.Username != userSession.Username:
+		ctx.Error(fmt.Errorf("User %s has no API token %s", userSession.Username, id), unableToManageAPITokensMessage)
+		return
+	case err != nil:
+		ctx.Error(fmt.Errorf("Unable to load the API token %s: %s", id, err), unableToManageAPITokensMessage)
+		return
+	}
+
+	if err = ctx.Providers.StorageProvider.DeleteAPIToken(id); err != nil {
+		ctx.Error(fmt.Errorf("Unable to delete the API token %s: %s", id, err), unableToManageAPITokensMessage)
+		return
+	}
+
+	ctx.Logger.Debugf("API token %s has been revoked by user %s", id, userSession.Username)
+
+	ctx.ReplyOK()
+}
+
+// AdminAPITokensGet lists the API tokens issued by all the users or by the user given in the username query arg.
+func AdminAPITokensGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isAPITokensAdmin(ctx, userSession) {
+		ctx.Logger.Infof("User %s is not allowed to administrate the API tokens", userSession.Username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	tokens, err := ctx.Providers.StorageProvider.LoadAPITokens(string(ctx.QueryArgs().Peek("username")))
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the API tokens: %s", err), unableToManageAPITokensMessage)
+		return
+	}
+
+	if err = ctx.SetJSONBody(newAPITokenResponses(tokens)); err != nil {
+		ctx.Logger.Errorf("Unable to set API tokens response in body: %s", err)
+	}
+}
+
+// AdminAPITokenDelete revokes the API token of any user.
+func AdminAPITokenDelete(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isAPITokensAdmin(ctx, userSession) {
+		ctx.Logger.Infof("User %s is not allowed to administrate the API tokens", userSession.Username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	id, _ := ctx.UserValue("id").(string)
+
+	if err := ctx.Providers.StorageProvider.DeleteAPIToken(id); err != nil {
+		ctx.Error(fmt.Errorf("Unable to delete the API token %s: %s", id, err), unableToManageAPITokensMessage)
+		return
+	}
+
+	ctx.Logger.Infof("API token %s has been revoked by administrator %s", id, userSession.Username)
+
+	ctx.ReplyOK()
+}
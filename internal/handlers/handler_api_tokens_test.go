@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+func newAPITokensMock(t *testing.T, level authentication.Level, groups ...string) *mocks.MockAutheliaCtx {
+	mock := mocks.NewMockAutheliaCtx(t)
+	mock.Ctx.Clock = &mock.Clock
+	mock.Ctx.Configuration.APITokens = &schema.APITokensConfiguration{
+		MaxLifetime: "30d",
+		AdminsGroup: "admins",
+	}
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = level
+	userSession.Groups = groups
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	return mock
+}
+
+func newTestAPIToken(t *testing.T, now time.Time, scopes ...string) (token models.APIToken, value string) {
+	id, secret, value, err := generateAPIToken()
+	require.NoError(t, err)
+
+	return models.APIToken{
+		ID:        id,
+		Username:  testUsername,
+		Name:      "backup",
+		Hash:      hashAPITokenSecret(secret),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}, value
+}
+
+func TestShouldParseGeneratedAPIToken(t *testing.T) {
+	id, secret, value, err := generateAPIToken()
+	require.NoError(t, err)
+
+	assert.True(t, isAPITokenBearer([]byte("Bearer "+value)))
+
+	parsedID, parsedSecret, err := parseAPIToken(value)
+	require.NoError(t, err)
+	assert.Equal(t, id, parsedID)
+	assert.Equal(t, secret, parsedSecret)
+
+	_, _, err = parseAPIToken("authelia_abc")
+	assert.EqualError(t, err, "The API token has an invalid format")
+
+	_, _, err = parseAPIToken("abc.def")
+	assert.EqualError(t, err, "The API token has an invalid format")
+}
+
+func TestShouldMatchAPITokenScopes(t *testing.T) {
+	scopes := []string{"home.example.com", "*.example.org"}
+
+	assert.True(t, isAPITokenScopeMatching(scopes, "home.example.com"))
+	assert.True(t, isAPITokenScopeMatching(scopes, "HOME.example.com"))
+	assert.True(t, isAPITokenScopeMatching(scopes, "app.example.org"))
+	assert.True(t, isAPITokenScopeMatching(scopes, "deep.app.example.org"))
+	assert.False(t, isAPITokenScopeMatching(scopes, "example.org"))
+	assert.False(t, isAPITokenScopeMatching(scopes, "app.example.com"))
+	assert.False(t, isAPITokenScopeMatching(nil, "home.example.com"))
+}
+
+func TestShouldIssueAPIToken(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.TwoFactor)
+	defer mock.Close()
+
+	var saved models.APIToken
+
+	mock.StorageProviderMock.EXPECT().
+		SaveAPIToken(gomock.Any()).
+		DoAndReturn(func(token models.APIToken) error {
+			saved = token
+			return nil
+		})
+
+	mock.Ctx.Request.SetBodyString(`{"name":"backup","scopes":["one-factor.example.com"],"expiration":"1w"}`)
+
+	UserAPITokensPost(mock.Ctx)
+
+	response := APITokenCreatedResponse{}
+	mock.GetResponseData(t, &response)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, testUsername, saved.Username)
+	assert.Equal(t, "backup", saved.Name)
+	assert.Equal(t, []string{"one-factor.example.com"}, saved.Scopes)
+	assert.Equal(t, mock.Clock.Now().Add(7*24*time.Hour), saved.ExpiresAt)
+	assert.Equal(t, saved.ID, response.ID)
+
+	id, secret, err := parseAPIToken(response.Token)
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, id)
+	assert.Equal(t, saved.Hash, hashAPITokenSecret(secret))
+	assert.NotEqual(t, secret, saved.Hash)
+}
+
+func TestShouldNotIssueAPITokenBeyondMaxLifetime(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.TwoFactor)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"name":"backup","scopes":["one-factor.example.com"],"expiration":"1y"}`)
+
+	UserAPITokensPost(mock.Ctx)
+
+	mock.Assert200KO(t, unableToManageAPITokensMessage)
+	assert.Equal(t, "The expiration 1y must be above 0 and below the maximum lifetime 30d", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldNotIssueAPITokenWithoutScopes(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.TwoFactor)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"name":"backup"}`)
+
+	UserAPITokensPost(mock.Ctx)
+
+	mock.Assert200KO(t, unableToManageAPITokensMessage)
+	assert.Equal(t, "An API token must be scoped to at least one domain", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldNotIssueAPITokenWithoutSecondFactor(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.OneFactor)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"name":"backup","scopes":["one-factor.example.com"]}`)
+
+	UserAPITokensPost(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldListAPITokensOfUser(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.OneFactor)
+	defer mock.Close()
+
+	token, _ := newTestAPIToken(t, mock.Clock.Now(), "one-factor.example.com")
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPITokens(gomock.Eq(testUsername)).
+		Return([]models.APIToken{token}, nil)
+
+	UserAPITokensGet(mock.Ctx)
+
+	mock.Assert200OK(t, []APITokenResponse{newAPITokenResponse(token)})
+	assert.NotContains(t, string(mock.Ctx.Response.Body()), token.Hash)
+}
+
+func TestShouldRevokeOwnAPITokenOnly(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.OneFactor)
+	defer mock.Close()
+
+	token, _ := newTestAPIToken(t, mock.Clock.Now(), "one-factor.example.com")
+	token.Username = "harry"
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIToken(gomock.Eq(token.ID)).
+		Return(&token, nil)
+
+	mock.Ctx.SetUserValue("id", token.ID)
+
+	UserAPITokenDelete(mock.Ctx)
+
+	mock.Assert200KO(t, unableToManageAPITokensMessage)
+	assert.Equal(t, fmt.Sprintf("User john has no API token %s", token.ID), mock.Hook.LastEntry().Message)
+}
+
+func TestShouldRevokeAPIToken(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.OneFactor)
+	defer mock.Close()
+
+	token, _ := newTestAPIToken(t, mock.Clock.Now(), "one-factor.example.com")
+
+	gomock.InOrder(
+		mock.StorageProviderMock.EXPECT().
+			LoadAPIToken(gomock.Eq(token.ID)).
+			Return(&token, nil),
+		mock.StorageProviderMock.EXPECT().
+			DeleteAPIToken(gomock.Eq(token.ID)).
+			Return(nil),
+	)
+
+	mock.Ctx.SetUserValue("id", token.ID)
+
+	UserAPITokenDelete(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+}
+
+func TestShouldAllowAdminsToRevokeAPITokens(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.TwoFactor, "admins")
+	defer mock.Close()
+
+	mock.StorageProviderMock.EXPECT().
+		DeleteAPIToken(gomock.Eq("abc")).
+		Return(nil)
+
+	mock.Ctx.SetUserValue("id", "abc")
+
+	AdminAPITokenDelete(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+}
+
+func TestShouldForbidNonAdminsToAdministrateAPITokens(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.TwoFactor, "dev")
+	defer mock.Close()
+
+	mock.Ctx.QueryArgs().Add("username", "harry")
+
+	AdminAPITokensGet(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldAuthenticateWithAPITokenInVerify(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.NotAuthenticated)
+	defer mock.Close()
+
+	token, value := newTestAPIToken(t, mock.Clock.Now(), "*.example.com")
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIToken(gomock.Eq(token.ID)).
+		Return(&token, nil)
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(gomock.Eq(testUsername)).
+		Return(&authentication.UserDetails{
+			Username: testUsername,
+			Emails:   []string{"john@example.com"},
+			Groups:   []string{"dev"},
+		}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("Authorization", "Bearer "+value)
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, testUsername, string(mock.Ctx.Response.Header.Peek("Remote-User")))
+}
+
+func TestShouldNotAuthenticateWithAPITokenOutOfScope(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.NotAuthenticated)
+	defer mock.Close()
+
+	token, value := newTestAPIToken(t, mock.Clock.Now(), "home.example.com")
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIToken(gomock.Eq(token.ID)).
+		Return(&token, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+value)
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldNotAuthenticateWithExpiredAPIToken(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.NotAuthenticated)
+	defer mock.Close()
+
+	token, value := newTestAPIToken(t, mock.Clock.Now().Add(-2*time.Hour), "one-factor.example.com")
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIToken(gomock.Eq(token.ID)).
+		Return(&token, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+value)
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldNotAuthenticateWithRevokedAPIToken(t *testing.T) {
+	mock := newAPITokensMock(t, authentication.NotAuthenticated)
+	defer mock.Close()
+
+	token, value := newTestAPIToken(t, mock.Clock.Now(), "one-factor.example.com")
+
+	mock.StorageProviderMock.EXPECT().
+		LoadAPIToken(gomock.Eq(token.ID)).
+		Return(nil, storage.ErrNoAPIToken)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+value)
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/regulation"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+// ChangePasswordPost handler changing the password of a user given its current password. It's only allowed for the user
+// the authentication backend required to change the password while signing in during the first factor. Like the first
+// factor, the attempts are regulated and the response is delayed so its timing doesn't reveal whether the user exists.
+func ChangePasswordPost(msInitialDelay time.Duration, delayEnabled bool) middlewares.RequestHandler {
+	var execDurationMovingAverage = make([]time.Duration, movingAverageWindow)
+
+	var movingAverageCursor = 0
+
+	var mutex = &sync.Mutex{}
+
+	for i := range execDurationMovingAverage {
+		execDurationMovingAverage[i] = msInitialDelay * time.Millisecond
+	}
+
+	return func(ctx *middlewares.AutheliaCtx) {
+		var successful bool
+
+		requestTime := time.Now()
+
+		if delayEnabled {
+			defer delayToPreventTimingAttacks(ctx, requestTime, &successful, &movingAverageCursor, &execDurationMovingAverage, mutex)
+		}
+
+		var requestBody changePasswordRequestBody
+
+		if err := ctx.ParseBody(&requestBody); err != nil {
+			ctx.Error(err, unableToChangePasswordMessage)
+			return
+		}
+
+		userSession := ctx.GetSession()
+
+		if userSession.PasswordChange == nil || userSession.PasswordChange.Username != requestBody.Username ||
+			ctx.Clock.Now().Unix() >= userSession.PasswordChange.ExpiresAt {
+			ctx.Error(fmt.Errorf("No password change of user %s has been required by the authentication backend", requestBody.Username), unableToChangePasswordMessage)
+			return
+		}
+
+		bannedUntil, err := ctx.Providers.Regulator.Regulate(requestBody.Username)
+		if err != nil {
+			if errors.Is(err, regulation.ErrUserIsBanned) {
+				ctx.Error(fmt.Errorf("User %s is banned until %s", requestBody.Username, bannedUntil), userBannedMessage)
+				return
+			}
+
+			ctx.Error(fmt.Errorf("Unable to regulate password change: %s", err), unableToChangePasswordMessage)
+
+			return
+		}
+
+		err = ctx.Providers.UserProvider.UpdatePassword(requestBody.Username, requestBody.Password, requestBody.NewPassword)
+		if err != nil {
+			switch {
+			case utils.IsStringInSliceContains(err.Error(), ldapPasswordComplexityCodes),
+				utils.IsStringInSliceContains(err.Error(), ldapPasswordComplexityErrors):
+				ctx.Error(fmt.Errorf("%s", err), ldapPasswordComplexityCode)
+			default:
+				ctx.Logger.Debugf("Mark authentication attempt made by user %s", requestBody.Username)
+
+				if err := ctx.Providers.Regulator.Mark(requestBody.Username, false); err != nil {
+					ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+				}
+
+				ctx.Error(fmt.Errorf("Unable to change password of user %s: %s", requestBody.Username, err), unableToChangePasswordMessage)
+			}
+
+			return
+		}
+
+		successful = true
+
+		userSession.PasswordChange = nil
+
+		if err := ctx.SaveSession(userSession); err != nil {
+			ctx.Logger.Errorf("Unable to save session of user %s: %s", requestBody.Username, err)
+		}
+
+		ctx.Logger.Debugf("Mark authentication attempt made by user %s", requestBody.Username)
+
+		if err := ctx.Providers.Regulator.Mark(requestBody.Username, true); err != nil {
+			ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+		}
+
+		ctx.Logger.Debugf("Password of user %s has been changed", requestBody.Username)
+
+		ctx.ReplyOK()
+	}
+}
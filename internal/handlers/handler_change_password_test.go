@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+)
+
+type ChangePasswordSuite struct {
+	suite.Suite
+
+	mock *mocks.MockAutheliaCtx
+}
+
+// requirePasswordChange records in the session that the first factor required the user to change the password.
+func requirePasswordChange(t *testing.T, mock *mocks.MockAutheliaCtx, username string) {
+	userSession := mock.Ctx.GetSession()
+	userSession.PasswordChange = &session.PasswordChange{
+		Username:  username,
+		ExpiresAt: mock.Clock.Now().Add(passwordChangeLifespan).Unix(),
+	}
+
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+}
+
+func (s *ChangePasswordSuite) SetupTest() {
+	s.mock = mocks.NewMockAutheliaCtx(s.T())
+	s.mock.Ctx.Clock = &s.mock.Clock
+	requirePasswordChange(s.T(), s.mock, "john")
+}
+
+func (s *ChangePasswordSuite) TearDownTest() {
+	s.mock.Close()
+}
+
+func (s *ChangePasswordSuite) TestShouldFailIfBodyIsInBadFormat() {
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "password"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to validate body: new_password: non zero value required", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Unable to change your password.")
+}
+
+func (s *ChangePasswordSuite) TestShouldChangePassword() {
+	s.mock.UserProviderMock.
+		EXPECT().
+		UpdatePassword(gomock.Eq("john"), gomock.Eq("password"), gomock.Eq("newpassword")).
+		Return(nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   "john",
+			Successful: true,
+			Time:       s.mock.Clock.Now(),
+		}))
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "password",
+		"new_password": "newpassword"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	s.mock.Assert200OK(s.T(), nil)
+	assert.Nil(s.T(), s.mock.Ctx.GetSession().PasswordChange)
+}
+
+func (s *ChangePasswordSuite) TestShouldRejectPasswordChangeNotRequired() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.PasswordChange = nil
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "password",
+		"new_password": "newpassword"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No password change of user john has been required by the authentication backend", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Unable to change your password.")
+}
+
+func (s *ChangePasswordSuite) TestShouldRejectPasswordChangeOfAnotherUser() {
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "harry",
+		"password": "password",
+		"new_password": "newpassword"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No password change of user harry has been required by the authentication backend", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Unable to change your password.")
+}
+
+func (s *ChangePasswordSuite) TestShouldRejectExpiredPasswordChange() {
+	s.mock.Clock.Set(s.mock.Clock.Now().Add(passwordChangeLifespan))
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "password",
+		"new_password": "newpassword"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "No password change of user john has been required by the authentication backend", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Unable to change your password.")
+}
+
+func (s *ChangePasswordSuite) TestShouldMarkFailedAttemptWhenOldPasswordIsIncorrect() {
+	s.mock.UserProviderMock.
+		EXPECT().
+		UpdatePassword(gomock.Eq("john"), gomock.Eq("wrong"), gomock.Eq("newpassword")).
+		Return(authentication.ErrIncorrectPassword)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   "john",
+			Successful: false,
+			Time:       s.mock.Clock.Now(),
+		}))
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "wrong",
+		"new_password": "newpassword"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Unable to change password of user john: incorrect password", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert200KO(s.T(), "Unable to change your password.")
+}
+
+func (s *ChangePasswordSuite) TestShouldReplyPasswordComplexityCode() {
+	s.mock.UserProviderMock.
+		EXPECT().
+		UpdatePassword(gomock.Eq("john"), gomock.Eq("password"), gomock.Eq("weak")).
+		Return(fmt.Errorf("LDAP Result Code 19 \"Constraint Violation\": Password fails quality checking policy"))
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "john",
+		"password": "password",
+		"new_password": "weak"
+	}`)
+
+	ChangePasswordPost(0, false)(s.mock.Ctx)
+
+	s.mock.Assert200KO(s.T(), ldapPasswordComplexityCode)
+}
+
+func TestShouldDelayChangePasswordResponse(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.UserProviderMock.
+		EXPECT().
+		UpdatePassword(gomock.Eq("unknown"), gomock.Eq("password"), gomock.Eq("newpassword")).
+		Return(authentication.ErrUserNotFound)
+
+	mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Any())
+
+	mock.Ctx.Clock = &mock.Clock
+	requirePasswordChange(t, mock, "unknown")
+
+	mock.Ctx.Request.SetBodyString(`{
+		"username": "unknown",
+		"password": "password",
+		"new_password": "newpassword"
+	}`)
+
+	start := time.Now()
+
+	ChangePasswordPost(100, true)(mock.Ctx)
+
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(msMinimumDelay1FA*float64(time.Millisecond)))
+	mock.Assert200KO(t, "Unable to change your password.")
+}
+
+func TestRunChangePasswordSuite(t *testing.T) {
+	suite.Run(t, new(ChangePasswordSuite))
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/regulation"
 	"github.com/authelia/authelia/internal/session"
@@ -92,6 +93,31 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		userPasswordOk, err := ctx.Providers.UserProvider.CheckUserPassword(bodyJSON.Username, bodyJSON.Password)
 
+		// The password is correct but must be changed, the portal routes the user to the password change which checks
+		// the current password again. The attempt is marked as failed since the user isn't signed in, the password
+		// change marks a successful attempt. The session records the change is allowed for this user only.
+		if authentication.IsPasswordChangeRequired(err) {
+			ctx.Logger.Debugf("Mark authentication attempt made by user %s", bodyJSON.Username)
+
+			if err := ctx.Providers.Regulator.Mark(bodyJSON.Username, false); err != nil {
+				ctx.Logger.Errorf("Unable to mark authentication: %s", err.Error())
+			}
+
+			userSession := ctx.GetSession()
+			userSession.PasswordChange = &session.PasswordChange{
+				Username:  bodyJSON.Username,
+				ExpiresAt: ctx.Clock.Now().Add(passwordChangeLifespan).Unix(),
+			}
+
+			if err := ctx.SaveSession(userSession); err != nil {
+				handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to save session of user %s: %s", bodyJSON.Username, err), authenticationFailedMessage)
+				return
+			}
+
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Password of user %s must be changed: %s", bodyJSON.Username, err), passwordChangeRequiredMessage)
+			return
+		}
+
 		if err != nil {
 			ctx.Logger.Debugf("Mark authentication attempt made by user %s", bodyJSON.Username)
 
@@ -167,8 +193,18 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 
 		ctx.Logger.Tracef("Details for user %s => groups: %s, emails %s", bodyJSON.Username, userDetails.Groups, userDetails.Emails)
 
+		// The second factor is kept when the user authenticates again, e.g. because the authentication is older than the
+		// maximum age of an access control rule, so that the user isn't logged out of the other resources.
+		keepSecondFactor := userSession.Username == userDetails.Username && userSession.AuthenticationLevel == authentication.TwoFactor
+		secondFactorMethods := userSession.SecondFactorMethods
+
 		userSession.SetOneFactor(ctx.Clock.Now(), userDetails, keepMeLoggedIn)
 
+		if keepSecondFactor {
+			userSession.AuthenticationLevel = authentication.TwoFactor
+			userSession.SecondFactorMethods = secondFactorMethods
+		}
+
 		if refresh, refreshInterval := getProfileRefreshSettings(ctx.Configuration.AuthenticationBackend); refresh {
 			userSession.RefreshTTL = ctx.Clock.Now().Add(refreshInterval)
 		}
@@ -184,7 +220,7 @@ func FirstFactorPost(msInitialDelay time.Duration, delayEnabled bool) middleware
 		if userSession.OIDCWorkflowSession != nil {
 			handleOIDCWorkflowResponse(ctx)
 		} else {
-			Handle1FAResponse(ctx, bodyJSON.TargetURL, bodyJSON.RequestMethod, userSession.Username, userSession.Groups)
+			Handle1FAResponse(ctx, bodyJSON.TargetURL, bodyJSON.RequestMethod, userSession.Username, userSession.Groups, userSession.Emails, userSession.Attributes)
 		}
 	}
 }
@@ -98,6 +98,38 @@ func (s *FirstFactorSuite) TestShouldCheckAuthenticationIsMarkedWhenInvalidCrede
 	FirstFactorPost(0, false)(s.mock.Ctx)
 }
 
+func (s *FirstFactorSuite) TestShouldReplyPasswordChangeRequiredWhenPasswordExpired() {
+	s.mock.Ctx.Clock = &s.mock.Clock
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		CheckUserPassword(gomock.Eq("test"), gomock.Eq("hello")).
+		Return(false, &authentication.PasswordPolicyError{Username: "test", Err: authentication.ErrPasswordExpired, GraceLogins: -1})
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   "test",
+			Successful: false,
+			Time:       s.mock.Clock.Now(),
+		}))
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "test",
+		"password": "hello"
+	}`)
+
+	FirstFactorPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), "Password of user test must be changed: Password policy of user test: password expired", s.mock.Hook.LastEntry().Message)
+	s.mock.Assert401KO(s.T(), "Your password must be changed.")
+
+	passwordChange := s.mock.Ctx.GetSession().PasswordChange
+	s.Require().NotNil(passwordChange)
+	assert.Equal(s.T(), "test", passwordChange.Username)
+	assert.Equal(s.T(), s.mock.Clock.Now().Add(passwordChangeLifespan).Unix(), passwordChange.ExpiresAt)
+}
+
 func (s *FirstFactorSuite) TestShouldFailIfUserProviderGetDetailsFail() {
 	s.mock.UserProviderMock.
 		EXPECT().
@@ -272,6 +304,52 @@ func (s *FirstFactorSuite) TestShouldSaveUsernameFromAuthenticationBackendInSess
 	assert.Equal(s.T(), []string{"dev", "admins"}, session.Groups)
 }
 
+func (s *FirstFactorSuite) TestShouldKeepSecondFactorWhenUserAuthenticatesAgain() {
+	userSession := s.mock.Ctx.GetSession()
+	userSession.Username = "test"
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.FirstFactorAuthnTimestamp = 1
+	userSession.SecondFactorAuthnTimestamp = 2
+	userSession.SecondFactorMethods = []string{authentication.U2F}
+	s.Require().NoError(s.mock.Ctx.SaveSession(userSession))
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		CheckUserPassword(gomock.Eq("test"), gomock.Eq("hello")).
+		Return(true, nil)
+
+	s.mock.UserProviderMock.
+		EXPECT().
+		GetDetails(gomock.Eq("test")).
+		Return(&authentication.UserDetails{
+			Username: "test",
+			Emails:   []string{"test@example.com"},
+			Groups:   []string{"dev", "admins"},
+		}, nil)
+
+	s.mock.StorageProviderMock.
+		EXPECT().
+		AppendAuthenticationLog(gomock.Any()).
+		Return(nil)
+
+	s.mock.Ctx.Request.SetBodyString(`{
+		"username": "test",
+		"password": "hello",
+		"requestMethod": "GET",
+		"keepMeLoggedIn": false
+	}`)
+	FirstFactorPost(0, false)(s.mock.Ctx)
+
+	assert.Equal(s.T(), 200, s.mock.Ctx.Response.StatusCode())
+
+	session := s.mock.Ctx.GetSession()
+	assert.Equal(s.T(), "test", session.Username)
+	assert.Equal(s.T(), authentication.TwoFactor, session.AuthenticationLevel)
+	assert.Equal(s.T(), s.mock.Ctx.Clock.Now().Unix(), session.FirstFactorAuthnTimestamp)
+	assert.Equal(s.T(), int64(2), session.SecondFactorAuthnTimestamp)
+	assert.Equal(s.T(), []string{authentication.U2F}, session.SecondFactorMethods)
+}
+
 type FirstFactorRedirectionSuite struct {
 	suite.Suite
 
@@ -10,6 +10,7 @@ import (
 	"github.com/ory/fosite/handler/openid"
 	"github.com/ory/fosite/token/jwt"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/oidc"
@@ -50,7 +51,7 @@ func oidcAuthorize(ctx *middlewares.AutheliaCtx, rw http.ResponseWriter, r *http
 		return
 	}
 
-	extraClaims := oidcGrantRequests(ar, requestedScopes, requestedAudience, &userSession)
+	extraClaims := oidcGrantRequests(ar, requestedScopes, requestedAudience, &userSession, ctx.Configuration.AuthenticationBackend.Attributes)
 
 	workflowCreated := time.Unix(userSession.OIDCWorkflowSession.CreatedTimestamp, 0)
 
@@ -96,6 +97,7 @@ func oidcAuthorize(ctx *middlewares.AutheliaCtx, rw http.ResponseWriter, r *http
 			Subject: userSession.Username,
 		},
 		ClientID: clientID,
+		AMR:      oidcAuthenticationMethodsReferences(&userSession),
 	})
 	if err != nil {
 		ctx.Logger.Errorf("Error occurred in NewAuthorizeResponse: %+v", err)
@@ -107,7 +109,10 @@ func oidcAuthorize(ctx *middlewares.AutheliaCtx, rw http.ResponseWriter, r *http
 	ctx.Providers.OpenIDConnect.Fosite.WriteAuthorizeResponse(rw, ar, response)
 }
 
-func oidcGrantRequests(ar fosite.AuthorizeRequester, scopes, audiences []string, userSession *session.UserSession) (extraClaims map[string]interface{}) {
+// oidcGrantRequests grants the requested scopes and audiences and returns the claims of the user matching the scopes,
+// the user attributes configured with a claim are returned with the profile scope.
+func oidcGrantRequests(ar fosite.AuthorizeRequester, scopes, audiences []string, userSession *session.UserSession,
+	attributes []schema.UserAttributeConfiguration) (extraClaims map[string]interface{}) {
 	extraClaims = map[string]interface{}{}
 
 	for _, scope := range scopes {
@@ -118,6 +123,20 @@ func oidcGrantRequests(ar fosite.AuthorizeRequester, scopes, audiences []string,
 			extraClaims["groups"] = userSession.Groups
 		case "profile":
 			extraClaims["name"] = userSession.DisplayName
+
+			for _, attribute := range attributes {
+				if attribute.Claim == "" {
+					continue
+				}
+
+				switch values := userSession.Attributes[attribute.Name]; len(values) {
+				case 0:
+				case 1:
+					extraClaims[attribute.Claim] = values[0]
+				default:
+					extraClaims[attribute.Claim] = values
+				}
+			}
 		case "email":
 			if len(userSession.Emails) != 0 {
 				extraClaims["email"] = userSession.Emails[0]
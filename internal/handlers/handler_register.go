@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/storage"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+var registrationUsernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,99}$`)
+
+// isRegistrationDomainAllowed returns true if the domain of the email is one of the allowed domains or if the
+// registrations aren't restricted to some domains.
+func isRegistrationDomainAllowed(ctx *middlewares.AutheliaCtx, email string) bool {
+	if len(ctx.Configuration.Registration.AllowedDomains) == 0 {
+		return true
+	}
+
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+
+	return utils.IsStringInSlice(domain, ctx.Configuration.Registration.AllowedDomains)
+}
+
+// isRegistrationsAdmin returns true if the user belongs to the group approving the registrations and is authenticated
+// with the second factor when at least one rule requires it.
+func isRegistrationsAdmin(ctx *middlewares.AutheliaCtx, userSession session.UserSession) bool {
+	return ctx.Configuration.Registration.AdminsGroup != "" &&
+		utils.IsStringInSlice(ctx.Configuration.Registration.AdminsGroup, userSession.Groups) &&
+		(!ctx.Providers.Authorizer.IsSecondFactorEnabled() || userSession.AuthenticationLevel >= authentication.TwoFactor)
+}
+
+func userRegistrar(ctx *middlewares.AutheliaCtx) (authentication.UserRegistrar, error) {
+	registrar, ok := ctx.Providers.UserProvider.(authentication.UserRegistrar)
+	if !ok {
+		return nil, errors.New("The authentication backend doesn't support the registration of users")
+	}
+
+	return registrar, nil
+}
+
+// createRegisteredUser creates the user of a registration in the authentication backend and removes the registration.
+func createRegisteredUser(ctx *middlewares.AutheliaCtx, registration models.UserRegistration) error {
+	registrar, err := userRegistrar(ctx)
+	if err != nil {
+		return err
+	}
+
+	details := authentication.UserDetails{
+		Username:    registration.Username,
+		DisplayName: registration.DisplayName,
+		Emails:      []string{registration.Email},
+		Groups:      ctx.Configuration.Registration.DefaultGroups,
+	}
+
+	if err = registrar.CreateUser(details, registration.PasswordHash); err != nil {
+		return fmt.Errorf("Unable to create user %s: %w", registration.Username, err)
+	}
+
+	if err = ctx.Providers.StorageProvider.DeleteUserRegistration(registration.Username); err != nil {
+		// The user is created, the registration is only kept until the username is registered again.
+		ctx.Logger.Errorf("Unable to delete the registration of user %s: %s", registration.Username, err)
+	}
+
+	return nil
+}
+
+func identityRetrieverFromRegistration(ctx *middlewares.AutheliaCtx) (*session.Identity, error) {
+	var requestBody registerStep1RequestBody
+
+	if err := json.Unmarshal(ctx.PostBody(), &requestBody); err != nil {
+		return nil, err
+	}
+
+	_, err := ctx.Providers.UserProvider.GetDetails(requestBody.Username)
+
+	switch {
+	case err == nil:
+		return nil, fmt.Errorf("User %s can't register since it already exists", requestBody.Username)
+	case err != authentication.ErrUserNotFound:
+		return nil, err
+	}
+
+	registration, err := ctx.Providers.StorageProvider.LoadUserRegistration(requestBody.Username)
+
+	switch {
+	case err == nil:
+		if registration.Status == models.RegistrationStatusPendingApproval ||
+			ctx.Clock.Now().Before(registration.CreatedAt.Add(registrationVerificationLifespan)) {
+			return nil, fmt.Errorf("User %s can't register since a registration is already pending", requestBody.Username)
+		}
+	case err != storage.ErrNoUserRegistration:
+		return nil, err
+	}
+
+	registrar, err := userRegistrar(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := registrar.HashPassword(requestBody.Password)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to hash the password of user %s: %w", requestBody.Username, err)
+	}
+
+	displayName := requestBody.DisplayName
+	if displayName == "" {
+		displayName = requestBody.Username
+	}
+
+	err = ctx.Providers.StorageProvider.SaveUserRegistration(models.UserRegistration{
+		Username:     requestBody.Username,
+		DisplayName:  displayName,
+		Email:        requestBody.Email,
+		PasswordHash: hash,
+		Status:       models.RegistrationStatusPendingVerification,
+		CreatedAt:    ctx.Clock.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to save the registration of user %s: %w", requestBody.Username, err)
+	}
+
+	return &session.Identity{
+		Username: requestBody.Username,
+		Email:    requestBody.Email,
+	}, nil
+}
+
+var registerIdentityStart = middlewares.IdentityVerificationStart(middlewares.IdentityVerificationStartArgs{
+	MailTitle:             "Confirm your registration",
+	MailButtonContent:     "Confirm",
+	TargetEndpoint:        "/register/step2",
+	ActionClaim:           RegistrationAction,
+	IdentityRetrieverFunc: identityRetrieverFromRegistration,
+})
+
+// RegisterIdentityStart the handler for initiating the registration of a user by verifying its email address.
+// The request is validated before the identity verification which replies with 200 whatever happens in the backend to
+// prevent the enumeration of the users.
+func RegisterIdentityStart(ctx *middlewares.AutheliaCtx) {
+	var requestBody registerStep1RequestBody
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		ctx.Error(err, unableToRegisterMessage)
+		return
+	}
+
+	if !registrationUsernameRegexp.MatchString(requestBody.Username) {
+		ctx.Error(fmt.Errorf("Username %s is not valid", requestBody.Username), unableToRegisterMessage)
+		return
+	}
+
+	if !isRegistrationDomainAllowed(ctx, requestBody.Email) {
+		ctx.Error(fmt.Errorf("Email %s of user %s is not in an allowed domain", requestBody.Email, requestBody.Username),
+			registrationDomainNotAllowedMessage)
+		return
+	}
+
+	registerIdentityStart(ctx)
+}
+
+func registerIdentityFinish(ctx *middlewares.AutheliaCtx, username string) {
+	registration, err := ctx.Providers.StorageProvider.LoadUserRegistration(username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the registration of user %s: %s", username, err), unableToRegisterMessage)
+		return
+	}
+
+	if registration.Status != models.RegistrationStatusPendingVerification {
+		ctx.Error(fmt.Errorf("The email of user %s has already been verified", username), unableToRegisterMessage)
+		return
+	}
+
+	if ctx.Configuration.Registration.RequireApproval {
+		registration.Status = models.RegistrationStatusPendingApproval
+
+		if err = ctx.Providers.StorageProvider.SaveUserRegistration(*registration); err != nil {
+			ctx.Error(fmt.Errorf("Unable to save the registration of user %s: %s", username, err), unableToRegisterMessage)
+			return
+		}
+
+		ctx.Logger.Infof("Registration of user %s is waiting for approval", username)
+	} else {
+		if err = createRegisteredUser(ctx, *registration); err != nil {
+			ctx.Error(err, unableToRegisterMessage)
+			return
+		}
+
+		ctx.Logger.Infof("User %s has registered", username)
+	}
+
+	response := registerStep2Response{ApprovalRequired: ctx.Configuration.Registration.RequireApproval}
+
+	if err = ctx.SetJSONBody(response); err != nil {
+		ctx.Logger.Errorf("Unable to set registration response in body: %s", err)
+	}
+}
+
+// RegisterIdentityFinish the handler for finishing the registration once the email address is verified. The user is
+// created unless the registrations must be approved by an administrator.
+var RegisterIdentityFinish = middlewares.IdentityVerificationFinish(
+	middlewares.IdentityVerificationFinishArgs{ActionClaim: RegistrationAction}, registerIdentityFinish)
+
+// AdminRegistrationsGet lists the registrations waiting for approval.
+func AdminRegistrationsGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isRegistrationsAdmin(ctx, userSession) {
+		ctx.Logger.Infof("User %s is not allowed to approve the registrations", userSession.Username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	registrations, err := ctx.Providers.StorageProvider.LoadUserRegistrations(models.RegistrationStatusPendingApproval)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the registrations: %s", err), unableToManageRegistrationsMessage)
+		return
+	}
+
+	responses := make([]RegistrationResponse, 0, len(registrations))
+
+	for _, registration := range registrations {
+		responses = append(responses, RegistrationResponse{
+			Username:    registration.Username,
+			DisplayName: registration.DisplayName,
+			Email:       registration.Email,
+			CreatedAt:   registration.CreatedAt,
+		})
+	}
+
+	if err = ctx.SetJSONBody(responses); err != nil {
+		ctx.Logger.Errorf("Unable to set registrations response in body: %s", err)
+	}
+}
+
+// AdminRegistrationApprovePost approves a registration, the user is created and notified by email.
+func AdminRegistrationApprovePost(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isRegistrationsAdmin(ctx, userSession) {
+		ctx.Logger.Infof("User %s is not allowed to approve the registrations", userSession.Username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	username, _ := ctx.UserValue("username").(string)
+
+	registration, err := ctx.Providers.StorageProvider.LoadUserRegistration(username)
+
+	switch {
+	case err == storage.ErrNoUserRegistration, err == nil && registration.Status != models.RegistrationStatusPendingApproval:
+		ctx.Error(fmt.Errorf("No registration of user %s is waiting for approval", username), unableToManageRegistrationsMessage)
+		return
+	case err != nil:
+		ctx.Error(fmt.Errorf("Unable to load the registration of user %s: %s", username, err), unableToManageRegistrationsMessage)
+		return
+	}
+
+	if err = createRegisteredUser(ctx, *registration); err != nil {
+		ctx.Error(err, unableToManageRegistrationsMessage)
+		return
+	}
+
+	ctx.Logger.Infof("Registration of user %s has been approved by administrator %s", username, userSession.Username)
+
+	body := fmt.Sprintf("Hi %s,\n\nYour registration has been approved, you can now sign in with the username %s.\n",
+		registration.DisplayName, username)
+
+	if err = ctx.Providers.Notifier.Send(registration.Email, "Your registration has been approved", body, ""); err != nil {
+		ctx.Logger.Errorf("Unable to notify user %s of the approval of the registration: %s", username, err)
+	}
+
+	ctx.ReplyOK()
+}
+
+// AdminRegistrationDelete rejects a registration.
+func AdminRegistrationDelete(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isRegistrationsAdmin(ctx, userSession) {
+		ctx.Logger.Infof("User %s is not allowed to approve the registrations", userSession.Username)
+		ctx.ReplyForbidden()
+
+		return
+	}
+
+	username, _ := ctx.UserValue("username").(string)
+
+	if err := ctx.Providers.StorageProvider.DeleteUserRegistration(username); err != nil {
+		ctx.Error(fmt.Errorf("Unable to delete the registration of user %s: %s", username, err), unableToManageRegistrationsMessage)
+		return
+	}
+
+	ctx.Logger.Infof("Registration of user %s has been rejected by administrator %s", username, userSession.Username)
+
+	ctx.ReplyOK()
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// isPasswordlessRegistrationAllowed returns true if the user is authenticated with the second factor when at least one
+// rule requires it, a passwordless credential grants the second factor level on its own.
+func isPasswordlessRegistrationAllowed(ctx *middlewares.AutheliaCtx, userSession session.UserSession) bool {
+	return !ctx.Providers.Authorizer.IsSecondFactorEnabled() || userSession.AuthenticationLevel >= authentication.TwoFactor
+}
+
+var passwordlessIdentityStart = middlewares.IdentityVerificationStart(middlewares.IdentityVerificationStartArgs{
+	MailTitle:             "Register your passwordless security key",
+	MailButtonContent:     "Register",
+	TargetEndpoint:        "/passwordless/register",
+	ActionClaim:           PasswordlessRegistrationAction,
+	IdentityRetrieverFunc: identityRetrieverFromSession,
+})
+
+// PasswordlessIdentityStart the handler for initiating the identity validation before registering a passwordless
+// credential.
+func PasswordlessIdentityStart(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if !isPasswordlessRegistrationAllowed(ctx, userSession) {
+		ctx.Error(fmt.Errorf("User %s must be authenticated with the second factor to register a passwordless credential",
+			userSession.Username), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	passwordlessIdentityStart(ctx)
+}
+
+func passwordlessIdentityFinish(ctx *middlewares.AutheliaCtx, username string) {
+	userSession := ctx.GetSession()
+
+	if !isPasswordlessRegistrationAllowed(ctx, userSession) {
+		ctx.Error(fmt.Errorf("User %s must be authenticated with the second factor to register a passwordless credential",
+			username), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	w, err := newWebauthn(ctx)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to configure WebAuthn: %s", err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	credentials, err := ctx.Providers.StorageProvider.LoadWebauthnCredentialsByUsername(username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the WebAuthn credentials of user %s: %s", username, err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	user, err := newWebauthnUser(username, userSession.DisplayName, credentials)
+	if err != nil {
+		ctx.Error(err, unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(credentials))
+
+	for _, credential := range credentials {
+		exclusions = append(exclusions, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: credential.ID,
+		})
+	}
+
+	options, sessionData, err := w.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate the WebAuthn registration options: %s", err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	userSession.Webauthn = sessionData
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save WebAuthn session data in session: %s", err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	if err = ctx.SetJSONBody(options); err != nil {
+		ctx.Logger.Errorf("Unable to set WebAuthn registration options in body: %s", err)
+	}
+}
+
+// PasswordlessIdentityFinish the handler for finishing the identity validation, it replies with the options of the
+// WebAuthn registration of a resident credential.
+var PasswordlessIdentityFinish = middlewares.IdentityVerificationFinish(
+	middlewares.IdentityVerificationFinishArgs{
+		ActionClaim:          PasswordlessRegistrationAction,
+		IsTokenUserValidFunc: isTokenUserValidFor2FARegistration,
+	}, passwordlessIdentityFinish)
+
+// PasswordlessRegisterPost handler validating the credential created by the authenticator to complete the
+// registration of a passwordless credential.
+func PasswordlessRegisterPost(ctx *middlewares.AutheliaCtx) {
+	var requestBody passwordlessRegisterRequestBody
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		ctx.Error(fmt.Errorf("Unable to parse response body: %s", err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	if userSession.Webauthn == nil {
+		ctx.Error(fmt.Errorf("WebAuthn registration has not been initiated yet"), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	sessionData := *userSession.Webauthn
+
+	// The session data is cleared whatever the outcome of the registration so that it can't be replayed.
+	userSession.Webauthn = nil
+
+	if err := ctx.SaveSession(userSession); err != nil {
+		ctx.Error(fmt.Errorf("Unable to clear WebAuthn session data in session for user %s: %s", userSession.Username, err),
+			unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(requestBody.Credential))
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to parse WebAuthn credential: %s", err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	w, err := newWebauthn(ctx)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to configure WebAuthn: %s", err), unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	user := &webauthnUser{handle: sessionData.UserID, username: userSession.Username, displayName: userSession.DisplayName}
+
+	credential, err := w.CreateCredential(user, sessionData, parsed)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to verify WebAuthn registration of user %s: %s", userSession.Username, err),
+			unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	description := requestBody.Description
+	if description == "" {
+		description = "Security key"
+	}
+
+	now := ctx.Clock.Now()
+
+	err = ctx.Providers.StorageProvider.SaveWebauthnCredential(models.WebauthnCredential{
+		ID:              credential.ID,
+		Username:        userSession.Username,
+		UserHandle:      sessionData.UserID,
+		Description:     description,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		SignCount:       credential.Authenticator.SignCount,
+		CreatedAt:       now,
+		LastUsedAt:      now,
+	})
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to save WebAuthn credential of user %s: %s", userSession.Username, err),
+			unableToRegisterSecurityKeyMessage)
+		return
+	}
+
+	ctx.Logger.Debugf("Registered passwordless credential for user %s", userSession.Username)
+
+	ctx.ReplyOK()
+}
+
+// UserPasswordlessCredentialsGet lists the passwordless credentials registered by the user identified by the session.
+func UserPasswordlessCredentialsGet(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	credentials, err := ctx.Providers.StorageProvider.LoadWebauthnCredentialsByUsername(userSession.Username)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to load the WebAuthn credentials of user %s: %s", userSession.Username, err),
+			unableToManagePasswordlessCredentialsMessage)
+		return
+	}
+
+	responses := make([]PasswordlessCredentialResponse, 0, len(credentials))
+
+	for _, credential := range credentials {
+		responses = append(responses, PasswordlessCredentialResponse{
+			ID:          base64.RawURLEncoding.EncodeToString(credential.ID),
+			Description: credential.Description,
+			CreatedAt:   credential.CreatedAt,
+			LastUsedAt:  credential.LastUsedAt,
+		})
+	}
+
+	if err = ctx.SetJSONBody(responses); err != nil {
+		ctx.Logger.Errorf("Unable to set passwordless credentials response in body: %s", err)
+	}
+}
+
+// UserPasswordlessCredentialDelete removes a passwordless credential registered by the user identified by the session.
+func UserPasswordlessCredentialDelete(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+	id, _ := ctx.UserValue("id").(string)
+
+	credentialID, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		ctx.Error(fmt.Errorf("WebAuthn credential ID %s is not valid: %s", id, err), unableToManagePasswordlessCredentialsMessage)
+		return
+	}
+
+	if err = ctx.Providers.StorageProvider.DeleteWebauthnCredential(userSession.Username, credentialID); err != nil {
+		ctx.Error(fmt.Errorf("Unable to delete the WebAuthn credential %s of user %s: %s", id, userSession.Username, err),
+			unableToManagePasswordlessCredentialsMessage)
+		return
+	}
+
+	ctx.Logger.Debugf("Passwordless credential %s has been removed by user %s", id, userSession.Username)
+
+	ctx.ReplyOK()
+}
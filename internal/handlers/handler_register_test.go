@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/storage"
+)
+
+// testUserRegistrar is a user provider supporting the registration of users, the users created are recorded.
+type testUserRegistrar struct {
+	*mocks.MockUserProvider
+
+	created []authentication.UserDetails
+	hashes  []string
+	err     error
+}
+
+func (r *testUserRegistrar) HashPassword(password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (r *testUserRegistrar) CreateUser(details authentication.UserDetails, passwordHash string) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.created = append(r.created, details)
+	r.hashes = append(r.hashes, passwordHash)
+
+	return nil
+}
+
+func newRegistrationMock(t *testing.T, requireApproval bool) (*mocks.MockAutheliaCtx, *testUserRegistrar) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	mock.Ctx.Clock = &mock.Clock
+	mock.Ctx.Configuration.JWTSecret = "abc"
+	mock.Ctx.Configuration.Registration = &schema.RegistrationConfiguration{
+		RequireApproval: requireApproval,
+		AdminsGroup:     "admins",
+		AllowedDomains:  []string{"example.com"},
+		DefaultGroups:   []string{"users"},
+	}
+
+	registrar := &testUserRegistrar{MockUserProvider: mock.UserProviderMock}
+	mock.Ctx.Providers.UserProvider = registrar
+
+	return mock, registrar
+}
+
+func newTestUserRegistration(now time.Time, status string) models.UserRegistration {
+	return models.UserRegistration{
+		Username:     "harry",
+		DisplayName:  "Harry Potter",
+		Email:        "harry@example.com",
+		PasswordHash: "hash:password",
+		Status:       status,
+		CreatedAt:    now,
+	}
+}
+
+func TestShouldRejectRegistrationWithInvalidUsername(t *testing.T) {
+	mock, _ := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"username":"../harry","email":"harry@example.com","password":"password"}`)
+
+	RegisterIdentityStart(mock.Ctx)
+
+	mock.Assert200KO(t, unableToRegisterMessage)
+	assert.Equal(t, "Username ../harry is not valid", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldRejectRegistrationWithInvalidEmail(t *testing.T) {
+	mock, _ := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"username":"harry","email":"harry","password":"password"}`)
+
+	RegisterIdentityStart(mock.Ctx)
+
+	mock.Assert200KO(t, unableToRegisterMessage)
+}
+
+func TestShouldRejectRegistrationOutsideAllowedDomains(t *testing.T) {
+	mock, _ := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"username":"harry","email":"harry@example.org","password":"password"}`)
+
+	RegisterIdentityStart(mock.Ctx)
+
+	mock.Assert200KO(t, registrationDomainNotAllowedMessage)
+	assert.Equal(t, "Email harry@example.org of user harry is not in an allowed domain", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldStartRegistration(t *testing.T) {
+	mock, _ := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	mock.Ctx.Request.Header.Add("X-Forwarded-Proto", "http")
+	mock.Ctx.Request.Header.Add("X-Forwarded-Host", "host")
+	mock.Ctx.Request.SetBodyString(`{"username":"harry","display_name":"Harry Potter","email":"harry@EXAMPLE.com","password":"password"}`)
+
+	gomock.InOrder(
+		mock.UserProviderMock.EXPECT().
+			GetDetails(gomock.Eq("harry")).
+			Return(nil, authentication.ErrUserNotFound),
+		mock.StorageProviderMock.EXPECT().
+			LoadUserRegistration(gomock.Eq("harry")).
+			Return(nil, storage.ErrNoUserRegistration),
+		mock.StorageProviderMock.EXPECT().
+			SaveUserRegistration(gomock.Eq(models.UserRegistration{
+				Username:     "harry",
+				DisplayName:  "Harry Potter",
+				Email:        "harry@EXAMPLE.com",
+				PasswordHash: "hash:password",
+				Status:       models.RegistrationStatusPendingVerification,
+				CreatedAt:    mock.Clock.Now(),
+			})).
+			Return(nil),
+		mock.StorageProviderMock.EXPECT().
+			SaveIdentityVerificationToken(gomock.Any()).
+			Return(nil),
+		mock.NotifierMock.EXPECT().
+			Send(gomock.Eq("harry@EXAMPLE.com"), gomock.Eq("Confirm your registration"), gomock.Any(), gomock.Any()).
+			Return(nil),
+	)
+
+	RegisterIdentityStart(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+}
+
+func TestShouldNotStartRegistrationOfExistingUser(t *testing.T) {
+	mock, _ := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"username":"john","email":"john@example.com","password":"password"}`)
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(gomock.Eq("john")).
+		Return(&authentication.UserDetails{Username: "john"}, nil)
+
+	RegisterIdentityStart(mock.Ctx)
+
+	// The reply is the same as a successful registration to prevent the enumeration of the users.
+	mock.Assert200OK(t, nil)
+	assert.Equal(t, "User john can't register since it already exists", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldNotStartRegistrationWhenOneIsPending(t *testing.T) {
+	mock, _ := newRegistrationMock(t, true)
+	defer mock.Close()
+
+	mock.Ctx.Request.SetBodyString(`{"username":"harry","email":"harry@example.com","password":"password"}`)
+
+	registration := newTestUserRegistration(mock.Clock.Now().Add(-time.Hour), models.RegistrationStatusPendingApproval)
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(gomock.Eq("harry")).
+		Return(nil, authentication.ErrUserNotFound)
+
+	mock.StorageProviderMock.EXPECT().
+		LoadUserRegistration(gomock.Eq("harry")).
+		Return(&registration, nil)
+
+	RegisterIdentityStart(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+	assert.Equal(t, "User harry can't register since a registration is already pending", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldCreateUserWhenRegistrationIsVerified(t *testing.T) {
+	mock, registrar := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingVerification)
+
+	gomock.InOrder(
+		mock.StorageProviderMock.EXPECT().
+			LoadUserRegistration(gomock.Eq("harry")).
+			Return(&registration, nil),
+		mock.StorageProviderMock.EXPECT().
+			DeleteUserRegistration(gomock.Eq("harry")).
+			Return(nil),
+	)
+
+	registerIdentityFinish(mock.Ctx, "harry")
+
+	mock.Assert200OK(t, registerStep2Response{ApprovalRequired: false})
+	require.Len(t, registrar.created, 1)
+	assert.Equal(t, authentication.UserDetails{
+		Username:    "harry",
+		DisplayName: "Harry Potter",
+		Emails:      []string{"harry@example.com"},
+		Groups:      []string{"users"},
+	}, registrar.created[0])
+	assert.Equal(t, []string{"hash:password"}, registrar.hashes)
+}
+
+func TestShouldQueueVerifiedRegistrationForApproval(t *testing.T) {
+	mock, registrar := newRegistrationMock(t, true)
+	defer mock.Close()
+
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingVerification)
+	pending := registration
+	pending.Status = models.RegistrationStatusPendingApproval
+
+	gomock.InOrder(
+		mock.StorageProviderMock.EXPECT().
+			LoadUserRegistration(gomock.Eq("harry")).
+			Return(&registration, nil),
+		mock.StorageProviderMock.EXPECT().
+			SaveUserRegistration(gomock.Eq(pending)).
+			Return(nil),
+	)
+
+	registerIdentityFinish(mock.Ctx, "harry")
+
+	mock.Assert200OK(t, registerStep2Response{ApprovalRequired: true})
+	assert.Len(t, registrar.created, 0)
+}
+
+func TestShouldNotVerifyRegistrationTwice(t *testing.T) {
+	mock, _ := newRegistrationMock(t, true)
+	defer mock.Close()
+
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingApproval)
+
+	mock.StorageProviderMock.EXPECT().
+		LoadUserRegistration(gomock.Eq("harry")).
+		Return(&registration, nil)
+
+	registerIdentityFinish(mock.Ctx, "harry")
+
+	mock.Assert200KO(t, unableToRegisterMessage)
+	assert.Equal(t, "The email of user harry has already been verified", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldFailRegistrationWhenUserCreationFails(t *testing.T) {
+	mock, registrar := newRegistrationMock(t, false)
+	defer mock.Close()
+
+	registrar.err = authentication.ErrUserAlreadyExists
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingVerification)
+
+	mock.StorageProviderMock.EXPECT().
+		LoadUserRegistration(gomock.Eq("harry")).
+		Return(&registration, nil)
+
+	registerIdentityFinish(mock.Ctx, "harry")
+
+	mock.Assert200KO(t, unableToRegisterMessage)
+	assert.Equal(t, "Unable to create user harry: user already exists", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldForbidNonAdminsToListRegistrations(t *testing.T) {
+	mock, _ := newRegistrationMock(t, true)
+	defer mock.Close()
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.Groups = []string{"dev"}
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	AdminRegistrationsGet(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+}
+
+func newRegistrationsAdminMock(t *testing.T) (*mocks.MockAutheliaCtx, *testUserRegistrar) {
+	mock, registrar := newRegistrationMock(t, true)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.Groups = []string{"admins"}
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	return mock, registrar
+}
+
+func TestShouldListRegistrationsWaitingForApproval(t *testing.T) {
+	mock, _ := newRegistrationsAdminMock(t)
+	defer mock.Close()
+
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingApproval)
+
+	mock.StorageProviderMock.EXPECT().
+		LoadUserRegistrations(gomock.Eq(models.RegistrationStatusPendingApproval)).
+		Return([]models.UserRegistration{registration}, nil)
+
+	AdminRegistrationsGet(mock.Ctx)
+
+	mock.Assert200OK(t, []RegistrationResponse{{
+		Username:    "harry",
+		DisplayName: "Harry Potter",
+		Email:       "harry@example.com",
+		CreatedAt:   registration.CreatedAt,
+	}})
+}
+
+func TestShouldApproveRegistration(t *testing.T) {
+	mock, registrar := newRegistrationsAdminMock(t)
+	defer mock.Close()
+
+	mock.Ctx.SetUserValue("username", "harry")
+
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingApproval)
+
+	gomock.InOrder(
+		mock.StorageProviderMock.EXPECT().
+			LoadUserRegistration(gomock.Eq("harry")).
+			Return(&registration, nil),
+		mock.StorageProviderMock.EXPECT().
+			DeleteUserRegistration(gomock.Eq("harry")).
+			Return(nil),
+		mock.NotifierMock.EXPECT().
+			Send(gomock.Eq("harry@example.com"), gomock.Eq("Your registration has been approved"), gomock.Any(), gomock.Eq("")).
+			Return(nil),
+	)
+
+	AdminRegistrationApprovePost(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+	require.Len(t, registrar.created, 1)
+	assert.Equal(t, "harry", registrar.created[0].Username)
+}
+
+func TestShouldNotApproveUnverifiedRegistration(t *testing.T) {
+	mock, registrar := newRegistrationsAdminMock(t)
+	defer mock.Close()
+
+	mock.Ctx.SetUserValue("username", "harry")
+
+	registration := newTestUserRegistration(mock.Clock.Now(), models.RegistrationStatusPendingVerification)
+
+	mock.StorageProviderMock.EXPECT().
+		LoadUserRegistration(gomock.Eq("harry")).
+		Return(&registration, nil)
+
+	AdminRegistrationApprovePost(mock.Ctx)
+
+	mock.Assert200KO(t, unableToManageRegistrationsMessage)
+	assert.Equal(t, "No registration of user harry is waiting for approval", mock.Hook.LastEntry().Message)
+	assert.Len(t, registrar.created, 0)
+}
+
+func TestShouldFailRejectingRegistration(t *testing.T) {
+	mock, _ := newRegistrationsAdminMock(t)
+	defer mock.Close()
+
+	mock.Ctx.SetUserValue("username", "harry")
+
+	mock.StorageProviderMock.EXPECT().
+		DeleteUserRegistration(gomock.Eq("harry")).
+		Return(errors.New("database is down"))
+
+	AdminRegistrationDelete(mock.Ctx)
+
+	mock.Assert200KO(t, unableToManageRegistrationsMessage)
+	assert.Equal(t, "Unable to delete the registration of user harry: database is down", mock.Hook.LastEntry().Message)
+}
@@ -27,7 +27,7 @@ func ResetPasswordPost(ctx *middlewares.AutheliaCtx) {
 		return
 	}
 
-	err = ctx.Providers.UserProvider.UpdatePassword(*userSession.PasswordResetUsername, requestBody.Password)
+	err = ctx.Providers.UserProvider.UpdatePassword(*userSession.PasswordResetUsername, "", requestBody.Password)
 
 	if err != nil {
 		switch {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/duo"
 	"github.com/authelia/authelia/internal/middlewares"
 )
@@ -64,7 +65,7 @@ func SecondFactorDuoPost(duoAPI duo.API) middlewares.RequestHandler {
 			return
 		}
 
-		userSession.SetTwoFactor(ctx.Clock.Now())
+		userSession.SetTwoFactor(ctx.Clock.Now(), authentication.Push)
 
 		err = ctx.SaveSession(userSession)
 		if err != nil {
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/regulation"
+)
+
+// PasswordlessSignRequestPost handler replying with the options of a usernameless WebAuthn assertion. No credential is
+// listed so that the authenticator offers the resident credentials it holds for the portal.
+func PasswordlessSignRequestPost(ctx *middlewares.AutheliaCtx) {
+	w, err := newWebauthn(ctx)
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to configure WebAuthn: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	challenge, err := protocol.CreateChallenge()
+	if err != nil {
+		ctx.Error(fmt.Errorf("Unable to generate WebAuthn challenge: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	options := protocol.CredentialAssertion{
+		Response: protocol.PublicKeyCredentialRequestOptions{
+			Challenge:        challenge,
+			Timeout:          w.Config.Timeout,
+			RelyingPartyID:   w.Config.RPID,
+			UserVerification: protocol.VerificationRequired,
+		},
+	}
+
+	userSession := ctx.GetSession()
+	userSession.Webauthn = &webauthn.SessionData{
+		Challenge:        base64.RawURLEncoding.EncodeToString(challenge),
+		UserVerification: protocol.VerificationRequired,
+	}
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		ctx.Error(fmt.Errorf("Unable to save WebAuthn session data in session: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	if err = ctx.SetJSONBody(options); err != nil {
+		ctx.Logger.Errorf("Unable to set WebAuthn assertion options in body: %s", err)
+	}
+}
+
+func markPasswordlessAuthentication(ctx *middlewares.AutheliaCtx, username string, successful bool) {
+	ctx.Logger.Debugf("Mark authentication attempt made by user %s", username)
+
+	if err := ctx.Providers.Regulator.Mark(username, successful); err != nil {
+		ctx.Logger.Errorf("Unable to mark authentication: %s", err)
+	}
+}
+
+// PasswordlessSignPost handler validating the assertion of a resident credential. The user is identified by the user
+// handle returned by the authenticator and, since the authenticator verified the user, signed in with the second
+// factor level.
+func PasswordlessSignPost(ctx *middlewares.AutheliaCtx) {
+	var requestBody passwordlessSignRequestBody
+
+	if err := ctx.ParseBody(&requestBody); err != nil {
+		handleAuthenticationUnauthorized(ctx, err, authenticationFailedMessage)
+		return
+	}
+
+	userSession := ctx.GetSession()
+
+	if userSession.Webauthn == nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Passwordless sign in has not been initiated yet"), authenticationFailedMessage)
+		return
+	}
+
+	sessionData := *userSession.Webauthn
+
+	// The challenge is cleared before the assertion is verified so that it can't be replayed.
+	userSession.Webauthn = nil
+
+	if err := ctx.SaveSession(userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to clear WebAuthn session data in session: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(requestBody.Credential))
+	if err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to parse WebAuthn assertion: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	handle := parsed.Response.UserHandle
+	if len(handle) == 0 {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("The authenticator did not return a user handle"), authenticationFailedMessage)
+		return
+	}
+
+	credentials, err := ctx.Providers.StorageProvider.LoadWebauthnCredentialsByUserHandle(handle)
+
+	switch {
+	case err != nil:
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to load the WebAuthn credentials: %s", err), authenticationFailedMessage)
+		return
+	case len(credentials) == 0:
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("No WebAuthn credential is bound to the user handle returned by the authenticator"), authenticationFailedMessage)
+		return
+	}
+
+	username := credentials[0].Username
+
+	bannedUntil, err := ctx.Providers.Regulator.Regulate(username)
+	if err != nil {
+		if err == regulation.ErrUserIsBanned {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("User %s is banned until %s", username, bannedUntil), userBannedMessage)
+			return
+		}
+
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to regulate authentication: %s", err), authenticationFailedMessage)
+
+		return
+	}
+
+	w, err := newWebauthn(ctx)
+	if err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to configure WebAuthn: %s", err), authenticationFailedMessage)
+		return
+	}
+
+	sessionData.UserID = handle
+	user := &webauthnUser{handle: handle, username: username, credentials: credentials}
+
+	credential, err := w.ValidateLogin(user, sessionData, parsed)
+	if err != nil {
+		markPasswordlessAuthentication(ctx, username, false)
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to verify WebAuthn assertion of user %s: %s", username, err), authenticationFailedMessage)
+
+		return
+	}
+
+	if credential.Authenticator.CloneWarning {
+		markPasswordlessAuthentication(ctx, username, false)
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("The signature counter of the WebAuthn credential of user %s went backwards, the authenticator might be cloned", username), authenticationFailedMessage)
+
+		return
+	}
+
+	// The user might have been removed from the authentication backend since the credential was registered.
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		markPasswordlessAuthentication(ctx, username, false)
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err), authenticationFailedMessage)
+
+		return
+	}
+
+	markPasswordlessAuthentication(ctx, username, true)
+
+	err = ctx.Providers.StorageProvider.UpdateWebauthnCredentialSignCount(credential.ID, credential.Authenticator.SignCount, ctx.Clock.Now())
+	if err != nil {
+		ctx.Logger.Errorf("Unable to update the signature counter of the WebAuthn credential of user %s: %s", username, err)
+	}
+
+	if err = openPreAuthenticatedSession(ctx, &userSession, details, authentication.TwoFactor); err != nil {
+		handleAuthenticationUnauthorized(ctx, err, authenticationFailedMessage)
+		return
+	}
+
+	userSession.Passwordless = true
+	userSession.SecondFactorMethods = []string{authentication.Passwordless}
+
+	if err = ctx.SaveSession(userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to save session of user %s: %s", username, err), authenticationFailedMessage)
+		return
+	}
+
+	ctx.Logger.Debugf("User %s has been authenticated with a passwordless credential", username)
+
+	if userSession.OIDCWorkflowSession != nil {
+		handleOIDCWorkflowResponse(ctx)
+	} else {
+		Handle2FAResponse(ctx, requestBody.TargetURL)
+	}
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/models"
+)
+
+var testPasswordlessUserHandle = []byte("0123456789abcdef0123456789abcdef")
+
+// testAuthenticator is a software authenticator holding a single resident credential.
+type testAuthenticator struct {
+	id  []byte
+	key *ecdsa.PrivateKey
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &testAuthenticator{id: []byte("test-credential"), key: key}
+}
+
+// PublicKey returns the public key of the credential in the COSE format, i.e. the CBOR map
+// {1: 2 (EC2), 3: -7 (ES256), -1: 1 (P-256), -2: x, -3: y}.
+func (a *testAuthenticator) PublicKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	key = append(key, x...)
+	key = append(key, 0x22, 0x58, 0x20)
+
+	return append(key, y...)
+}
+
+// Assert builds the JSON assertion of the credential for the given challenge.
+func (a *testAuthenticator) Assert(t *testing.T, challenge string, counter uint32) json.RawMessage {
+	rpIDHash := sha256.Sum256([]byte("home.example.com"))
+
+	authenticatorData := append([]byte{}, rpIDHash[:]...)
+	// The user was present and verified.
+	authenticatorData = append(authenticatorData, 0x05)
+	authenticatorData = append(authenticatorData, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(authenticatorData[33:], counter)
+
+	clientData, err := json.Marshal(map[string]string{
+		"type":      "webauthn.get",
+		"challenge": challenge,
+		"origin":    "https://home.example.com",
+	})
+	require.NoError(t, err)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	encode := base64.RawURLEncoding.EncodeToString
+
+	assertion, err := json.Marshal(map[string]interface{}{
+		"id":    encode(a.id),
+		"rawId": encode(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientData),
+			"authenticatorData": encode(authenticatorData),
+			"signature":         encode(signature),
+			"userHandle":        encode(testPasswordlessUserHandle),
+		},
+	})
+	require.NoError(t, err)
+
+	return assertion
+}
+
+func newPasswordlessMockAutheliaCtx(t *testing.T) *mocks.MockAutheliaCtx {
+	mock := mocks.NewMockAutheliaCtx(t)
+	mock.Ctx.Clock = &mock.Clock
+	mock.Ctx.Configuration.Passwordless = &schema.PasswordlessConfiguration{
+		DisplayName: "Authelia",
+		Timeout:     "1m",
+	}
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+	mock.Ctx.Request.Header.Set("X-Forwarded-Host", "home.example.com")
+
+	return mock
+}
+
+func setPasswordlessChallenge(t *testing.T, mock *mocks.MockAutheliaCtx, challenge string) {
+	userSession := mock.Ctx.GetSession()
+	userSession.Webauthn = &webauthn.SessionData{
+		Challenge:        challenge,
+		UserVerification: protocol.VerificationRequired,
+	}
+
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+}
+
+func setPasswordlessSignBody(t *testing.T, mock *mocks.MockAutheliaCtx, credential json.RawMessage) {
+	body, err := json.Marshal(passwordlessSignRequestBody{Credential: credential})
+	require.NoError(t, err)
+
+	mock.Ctx.Request.SetBody(body)
+}
+
+func TestShouldReplyWithUsernamelessAssertionOptions(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	PasswordlessSignRequestPost(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	var response struct {
+		Status string                       `json:"status"`
+		Data   protocol.CredentialAssertion `json:"data"`
+	}
+
+	require.NoError(t, json.Unmarshal(mock.Ctx.Response.Body(), &response))
+	assert.Equal(t, "OK", response.Status)
+	assert.Equal(t, "home.example.com", response.Data.Response.RelyingPartyID)
+	assert.Equal(t, protocol.VerificationRequired, response.Data.Response.UserVerification)
+	assert.Equal(t, 60000, response.Data.Response.Timeout)
+	assert.Len(t, response.Data.Response.AllowedCredentials, 0)
+
+	userSession := mock.Ctx.GetSession()
+	require.NotNil(t, userSession.Webauthn)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(response.Data.Response.Challenge), userSession.Webauthn.Challenge)
+}
+
+func TestShouldFailPasswordlessSignRequestWithoutForwardedHost(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Request.Header.Del("X-Forwarded-Host")
+
+	PasswordlessSignRequestPost(mock.Ctx)
+
+	mock.Assert200KO(t, "Authentication failed. Check your credentials.")
+	assert.Nil(t, mock.Ctx.GetSession().Webauthn)
+}
+
+func TestShouldFailPasswordlessSignWhenNotInitiated(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setPasswordlessSignBody(t, mock, newTestAuthenticator(t).Assert(t, "challenge", 1))
+
+	PasswordlessSignPost(mock.Ctx)
+
+	mock.Assert401KO(t, "Authentication failed. Check your credentials.")
+	assert.Equal(t, "Passwordless sign in has not been initiated yet", mock.Hook.LastEntry().Message)
+}
+
+func TestShouldFailPasswordlessSignWithUnknownUserHandle(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setPasswordlessChallenge(t, mock, "challenge")
+	setPasswordlessSignBody(t, mock, newTestAuthenticator(t).Assert(t, "challenge", 1))
+
+	mock.StorageProviderMock.EXPECT().
+		LoadWebauthnCredentialsByUserHandle(testPasswordlessUserHandle).
+		Return(nil, nil)
+
+	PasswordlessSignPost(mock.Ctx)
+
+	mock.Assert401KO(t, "Authentication failed. Check your credentials.")
+	assert.Nil(t, mock.Ctx.GetSession().Webauthn)
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+}
+
+func TestShouldSignInWithPasswordlessCredential(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	authenticator := newTestAuthenticator(t)
+
+	setPasswordlessChallenge(t, mock, "challenge")
+	setPasswordlessSignBody(t, mock, authenticator.Assert(t, "challenge", 5))
+
+	mock.StorageProviderMock.EXPECT().
+		LoadWebauthnCredentialsByUserHandle(testPasswordlessUserHandle).
+		Return([]models.WebauthnCredential{{
+			ID:         authenticator.id,
+			Username:   testUsername,
+			UserHandle: testPasswordlessUserHandle,
+			PublicKey:  authenticator.PublicKey(),
+			SignCount:  4,
+		}}, nil)
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(testUsername).
+		Return(&authentication.UserDetails{
+			Username: testUsername,
+			Emails:   []string{"john@example.com"},
+			Groups:   []string{"dev"},
+		}, nil)
+
+	mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: true,
+			Time:       mock.Clock.Now(),
+		}))
+
+	mock.StorageProviderMock.EXPECT().
+		UpdateWebauthnCredentialSignCount(authenticator.id, uint32(5), mock.Clock.Now()).
+		Return(nil)
+
+	PasswordlessSignPost(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+
+	userSession := mock.Ctx.GetSession()
+	assert.Equal(t, testUsername, userSession.Username)
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.True(t, userSession.Passwordless)
+	assert.Equal(t, []string{authentication.Passwordless}, userSession.SecondFactorMethods)
+	assert.Nil(t, userSession.Webauthn)
+}
+
+func TestShouldRejectPasswordlessAssertionOfAnotherChallenge(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	authenticator := newTestAuthenticator(t)
+
+	setPasswordlessChallenge(t, mock, "challenge")
+	setPasswordlessSignBody(t, mock, authenticator.Assert(t, "another-challenge", 5))
+
+	mock.StorageProviderMock.EXPECT().
+		LoadWebauthnCredentialsByUserHandle(testPasswordlessUserHandle).
+		Return([]models.WebauthnCredential{{
+			ID:         authenticator.id,
+			Username:   testUsername,
+			UserHandle: testPasswordlessUserHandle,
+			PublicKey:  authenticator.PublicKey(),
+		}}, nil)
+
+	mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: false,
+			Time:       mock.Clock.Now(),
+		}))
+
+	PasswordlessSignPost(mock.Ctx)
+
+	mock.Assert401KO(t, "Authentication failed. Check your credentials.")
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+	assert.Contains(t, mock.Hook.LastEntry().Message, fmt.Sprintf("Unable to verify WebAuthn assertion of user %s", testUsername))
+}
+
+func TestShouldRejectClonedPasswordlessCredential(t *testing.T) {
+	mock := newPasswordlessMockAutheliaCtx(t)
+	defer mock.Close()
+
+	authenticator := newTestAuthenticator(t)
+
+	setPasswordlessChallenge(t, mock, "challenge")
+	setPasswordlessSignBody(t, mock, authenticator.Assert(t, "challenge", 3))
+
+	mock.StorageProviderMock.EXPECT().
+		LoadWebauthnCredentialsByUserHandle(testPasswordlessUserHandle).
+		Return([]models.WebauthnCredential{{
+			ID:         authenticator.id,
+			Username:   testUsername,
+			UserHandle: testPasswordlessUserHandle,
+			PublicKey:  authenticator.PublicKey(),
+			SignCount:  10,
+		}}, nil)
+
+	mock.StorageProviderMock.EXPECT().
+		AppendAuthenticationLog(gomock.Eq(models.AuthenticationAttempt{
+			Username:   testUsername,
+			Successful: false,
+			Time:       mock.Clock.Now(),
+		}))
+
+	PasswordlessSignPost(mock.Ctx)
+
+	mock.Assert401KO(t, "Authentication failed. Check your credentials.")
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+	assert.False(t, mock.Ctx.GetSession().Passwordless)
+}
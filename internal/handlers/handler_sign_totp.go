@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/middlewares"
 )
 
@@ -43,7 +44,7 @@ func SecondFactorTOTPPost(totpVerifier TOTPVerifier) middlewares.RequestHandler
 			return
 		}
 
-		userSession.SetTwoFactor(ctx.Clock.Now())
+		userSession.SetTwoFactor(ctx.Clock.Now(), authentication.TOTP)
 
 		err = ctx.SaveSession(userSession)
 		if err != nil {
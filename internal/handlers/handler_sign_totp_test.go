@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"github.com/tstranex/u2f"
 
+	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/mocks"
 	"github.com/authelia/authelia/internal/session"
 )
@@ -78,6 +79,10 @@ func (s *HandlerSignTOTPSuite) TestShouldNotReturnRedirectURL() {
 
 	SecondFactorTOTPPost(verifier)(s.mock.Ctx)
 	s.mock.Assert200OK(s.T(), nil)
+
+	userSession := s.mock.Ctx.GetSession()
+	s.Assert().Equal(authentication.TwoFactor, userSession.AuthenticationLevel)
+	s.Assert().Equal([]string{authentication.TOTP}, userSession.SecondFactorMethods)
 }
 
 func (s *HandlerSignTOTPSuite) TestShouldRedirectUserToSafeTargetURL() {
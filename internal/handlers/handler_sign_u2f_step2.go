@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/middlewares"
 )
 
@@ -46,7 +47,7 @@ func SecondFactorU2FSignPost(u2fVerifier U2FVerifier) middlewares.RequestHandler
 			return
 		}
 
-		userSession.SetTwoFactor(ctx.Clock.Now())
+		userSession.SetTwoFactor(ctx.Clock.Now(), authentication.U2F)
 
 		err = ctx.SaveSession(userSession)
 		if err != nil {
@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
-	"net"
 	"net/url"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
 
 	"github.com/authelia/authelia/internal/authentication"
@@ -53,78 +54,120 @@ func parseBasicAuth(header, auth string) (username, password string, err error)
 	return cs[:s], cs[s+1:], nil
 }
 
-// isTargetURLAuthorized check whether the given user is authorized to access the resource.
-func isTargetURLAuthorized(authorizer *authorization.Authorizer, targetURL url.URL,
-	username string, userGroups []string, clientIP net.IP, method []byte, authLevel authentication.Level) authorizationMatching {
-	level := authorizer.GetRequiredLevel(
-		authorization.Subject{
-			Username: username,
-			Groups:   userGroups,
-			IP:       clientIP,
-		},
-		authorization.NewObjectRaw(&targetURL, method))
+// isTargetURLAuthorized check whether the given user is authorized to access the resource. The session is nil when the
+// user is not authenticated by the session cookie, the maximum age of the authentication and the second factor methods
+// are only checked otherwise.
+func isTargetURLAuthorized(authorizer *authorization.Authorizer, object authorization.Object, subject authorization.Subject,
+	authLevel authentication.Level, userSession *session.UserSession, now time.Time) (authorizationMatching, authorization.Requirements) {
+	subject.Passwordless = userSession != nil && userSession.Passwordless
+
+	requirements := authorizer.GetRequirements(subject, object)
+
+	level := requirements.Level
 
 	switch {
 	case level == authorization.Bypass:
-		return Authorized
-	case level == authorization.Denied && username != "":
+		return Authorized, requirements
+	case level == authorization.Denied && subject.Username != "":
 		// If the user is not anonymous, it means that we went through
 		// all the rules related to that user and knowing who he is we can
 		// deduce the access is forbidden
 		// For anonymous users though, we cannot be sure that she
 		// could not be granted the rights to access the resource. Consequently
 		// for anonymous users we send Unauthorized instead of Forbidden
-		return Forbidden
+		return Forbidden, requirements
 	case level == authorization.OneFactor && authLevel >= authentication.OneFactor,
 		level == authorization.TwoFactor && authLevel >= authentication.TwoFactor:
-		return Authorized
+		if level == authorization.TwoFactor && !isSecondFactorMethodAccepted(requirements, userSession) {
+			return SecondFactorMethodRequired, requirements
+		}
+
+		if isAuthenticationTooOld(requirements, userSession, now) {
+			return ReauthenticationRequired, requirements
+		}
+
+		return Authorized, requirements
+	}
+
+	return NotAuthorized, requirements
+}
+
+// isSecondFactorMethodAccepted returns true if the user completed one of the second factor methods accepted by the rule.
+// The users who are not authenticated by the session cookie didn't complete any method.
+func isSecondFactorMethodAccepted(requirements authorization.Requirements, userSession *session.UserSession) bool {
+	if userSession == nil {
+		return requirements.IsSecondFactorMethodAccepted(nil)
 	}
 
-	return NotAuthorized
+	return requirements.IsSecondFactorMethodAccepted(userSession.SecondFactorMethods)
+}
+
+// isAuthenticationTooOld returns true if the user completed the factor of the required level longer ago than the
+// maximum age allowed.
+func isAuthenticationTooOld(requirements authorization.Requirements, userSession *session.UserSession, now time.Time) bool {
+	if requirements.MaxAge == 0 || userSession == nil {
+		return false
+	}
+
+	authenticatedTime, err := userSession.AuthenticatedTime(requirements.Level)
+	if err != nil {
+		return false
+	}
+
+	return now.Sub(authenticatedTime) > requirements.MaxAge
 }
 
 // verifyBasicAuth verify that the provided username and password are correct and
 // that the user is authorized to target the resource.
-func verifyBasicAuth(header string, auth []byte, targetURL url.URL, ctx *middlewares.AutheliaCtx) (username, name string, groups, emails []string, authLevel authentication.Level, err error) { //nolint:unparam
+func verifyBasicAuth(header string, auth []byte, targetURL url.URL, ctx *middlewares.AutheliaCtx) (user verifiedUser, err error) { //nolint:unparam
 	username, password, err := parseBasicAuth(header, string(auth))
 
 	if err != nil {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to parse content of %s header: %s", header, err)
+		return user, fmt.Errorf("Unable to parse content of %s header: %s", header, err)
 	}
 
 	authenticated, err := ctx.Providers.UserProvider.CheckUserPassword(username, password)
 
 	if err != nil {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to check credentials extracted from %s header: %s", header, err)
+		return user, fmt.Errorf("Unable to check credentials extracted from %s header: %s", header, err)
 	}
 
 	// If the user is not correctly authenticated, send a 401.
 	if !authenticated {
 		// Request Basic Authentication otherwise
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("User %s is not authenticated", username)
+		return user, fmt.Errorf("User %s is not authenticated", username)
 	}
 
 	details, err := ctx.Providers.UserProvider.GetDetails(username)
 
 	if err != nil {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
+		return user, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
 	}
 
-	return username, details.DisplayName, details.Groups, details.Emails, authentication.OneFactor, nil
+	details.Username = username
+
+	return verifiedUser{UserDetails: *details, AuthLevel: authentication.OneFactor}, nil
 }
 
-// setForwardedHeaders set the forwarded User, Groups, Name and Email headers.
-func setForwardedHeaders(headers *fasthttp.ResponseHeader, username, name string, groups, emails []string) {
-	if username != "" {
-		headers.Set(remoteUserHeader, username)
-		headers.Set(remoteGroupsHeader, strings.Join(groups, ","))
-		headers.Set(remoteNameHeader, name)
+// setForwardedHeaders set the forwarded User, Groups, Name and Email headers along with the headers of the user
+// attributes configured with a header.
+func setForwardedHeaders(headers *fasthttp.ResponseHeader, user verifiedUser, attributesConfig []schema.UserAttributeConfiguration) {
+	if user.Username != "" {
+		headers.Set(remoteUserHeader, user.Username)
+		headers.Set(remoteGroupsHeader, strings.Join(user.Groups, ","))
+		headers.Set(remoteNameHeader, user.DisplayName)
 
-		if emails != nil {
-			headers.Set(remoteEmailHeader, emails[0])
+		if user.Emails != nil {
+			headers.Set(remoteEmailHeader, user.Emails[0])
 		} else {
 			headers.Set(remoteEmailHeader, "")
 		}
+
+		for _, attribute := range attributesConfig {
+			if attribute.Header != "" {
+				headers.Set(attribute.Header, strings.Join(user.Attributes[attribute.Name], ","))
+			}
+		}
 	}
 }
 
@@ -150,28 +193,28 @@ func hasUserBeenInactiveTooLong(ctx *middlewares.AutheliaCtx) (bool, error) { //
 
 // verifySessionCookie verifies if a user is identified by a cookie.
 func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userSession *session.UserSession, refreshProfile bool,
-	refreshProfileInterval time.Duration) (username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+	refreshProfileInterval time.Duration) (user verifiedUser, err error) {
 	// No username in the session means the user is anonymous.
 	isUserAnonymous := userSession.Username == ""
 
 	if isUserAnonymous && userSession.AuthenticationLevel != authentication.NotAuthenticated {
-		return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("An anonymous user cannot be authenticated. That might be the sign of a compromise")
+		return user, fmt.Errorf("An anonymous user cannot be authenticated. That might be the sign of a compromise")
 	}
 
 	if !userSession.KeepMeLoggedIn && !isUserAnonymous {
 		inactiveLongEnough, err := hasUserBeenInactiveTooLong(ctx)
 		if err != nil {
-			return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to check if user has been inactive for a long time: %s", err)
+			return user, fmt.Errorf("Unable to check if user has been inactive for a long time: %s", err)
 		}
 
 		if inactiveLongEnough {
 			// Destroy the session a new one will be regenerated on next request.
 			err := ctx.Providers.SessionProvider.DestroySession(ctx.RequestCtx)
 			if err != nil {
-				return "", "", nil, nil, authentication.NotAuthenticated, fmt.Errorf("Unable to destroy user session after long inactivity: %s", err)
+				return user, fmt.Errorf("Unable to destroy user session after long inactivity: %s", err)
 			}
 
-			return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, authentication.NotAuthenticated, fmt.Errorf("User %s has been inactive for too long", userSession.Username)
+			return newSessionVerifiedUser(userSession, authentication.NotAuthenticated), fmt.Errorf("User %s has been inactive for too long", userSession.Username)
 		}
 	}
 
@@ -183,16 +226,30 @@ func verifySessionCookie(ctx *middlewares.AutheliaCtx, targetURL *url.URL, userS
 				ctx.Logger.Error(fmt.Errorf("Unable to destroy user session after provider refresh didn't find the user: %s", err))
 			}
 
-			return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, authentication.NotAuthenticated, err
+			return newSessionVerifiedUser(userSession, authentication.NotAuthenticated), err
 		}
 
 		ctx.Logger.Warnf("Error occurred while attempting to update user details from LDAP: %s", err)
 	}
 
-	return userSession.Username, userSession.DisplayName, userSession.Groups, userSession.Emails, userSession.AuthenticationLevel, nil
+	return newSessionVerifiedUser(userSession, userSession.AuthenticationLevel), nil
+}
+
+// newSessionVerifiedUser returns the user of the session authenticated with the given level.
+func newSessionVerifiedUser(userSession *session.UserSession, authLevel authentication.Level) verifiedUser {
+	return verifiedUser{
+		UserDetails: authentication.UserDetails{
+			Username:    userSession.Username,
+			DisplayName: userSession.DisplayName,
+			Groups:      userSession.Groups,
+			Emails:      userSession.Emails,
+			Attributes:  userSession.Attributes,
+		},
+		AuthLevel: authLevel,
+	}
 }
 
-func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, isBasicAuth bool, username string, method []byte) {
+func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, isBasicAuth bool, username string, method []byte, reauthQuery string) {
 	friendlyUsername := "<anonymous>"
 	if username != "" {
 		friendlyUsername = username
@@ -227,6 +284,11 @@ func handleUnauthorized(ctx *middlewares.AutheliaCtx, targetURL fmt.Stringer, is
 			redirectionURL = fmt.Sprintf("%s?rd=%s", rd, url.QueryEscape(targetURL.String()))
 		}
 
+		// The portal asks the user to complete the factor again instead of considering the user authenticated.
+		if reauthQuery != "" {
+			redirectionURL = fmt.Sprintf("%s&%s", redirectionURL, reauthQuery)
+		}
+
 		ctx.Logger.Infof("Access to %s (method %s) is not authorized to user %s, redirecting to %s", targetURL.String(), friendlyMethod, friendlyUsername, redirectionURL)
 
 		switch rm {
@@ -332,8 +394,9 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 	emailsDiff := utils.IsStringSlicesDifferent(userSession.Emails, details.Emails)
 	groupsDiff := utils.IsStringSlicesDifferent(userSession.Groups, details.Groups)
 	nameDiff := userSession.DisplayName != details.DisplayName
+	attributesDiff := isUserAttributesDifferent(userSession.Attributes, details.Attributes)
 
-	if !groupsDiff && !emailsDiff && !nameDiff {
+	if !groupsDiff && !emailsDiff && !nameDiff && !attributesDiff {
 		ctx.Logger.Tracef("Updated profile not detected for %s.", userSession.Username)
 		// Only update TTL if the user has a interval set.
 		// We get to this check when there were no changes.
@@ -352,6 +415,7 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 		userSession.Emails = details.Emails
 		userSession.Groups = details.Groups
 		userSession.DisplayName = details.DisplayName
+		userSession.Attributes = details.Attributes
 
 		// Only update TTL if the user has a interval set.
 		if refreshProfileInterval != schema.RefreshIntervalAlways {
@@ -365,6 +429,22 @@ func verifySessionHasUpToDateProfile(ctx *middlewares.AutheliaCtx, targetURL *ur
 	return nil
 }
 
+// isUserAttributesDifferent checks whether the values of any user attribute differ.
+func isUserAttributesDifferent(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return true
+	}
+
+	for name, values := range a {
+		other, ok := b[name]
+		if !ok || len(values) != len(other) || utils.IsStringSlicesDifferent(values, other) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func getProfileRefreshSettings(cfg schema.AuthenticationBackendConfiguration) (refresh bool, refreshInterval time.Duration) {
 	if cfg.LDAP != nil {
 		if cfg.RefreshInterval == schema.ProfileRefreshDisabled {
@@ -385,7 +465,7 @@ func getProfileRefreshSettings(cfg schema.AuthenticationBackendConfiguration) (r
 	return refresh, refreshInterval
 }
 
-func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile bool, refreshProfileInterval time.Duration) (isBasicAuth bool, username, name string, groups, emails []string, authLevel authentication.Level, err error) {
+func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile bool, refreshProfileInterval time.Duration) (isBasicAuth bool, user verifiedUser, err error) {
 	authHeader := ProxyAuthorizationHeader
 	if bytes.Equal(ctx.QueryArgs().Peek("auth"), []byte("basic")) {
 		authHeader = AuthorizationHeader
@@ -393,6 +473,13 @@ func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile
 	}
 
 	authValue := ctx.Request.Header.Peek(authHeader)
+
+	// API tokens are also accepted in the Authorization header since it's where most of the clients send bearer tokens.
+	if authValue == nil && ctx.Configuration.APITokens != nil && isAPITokenBearer(ctx.Request.Header.Peek(AuthorizationHeader)) {
+		authHeader = AuthorizationHeader
+		authValue = ctx.Request.Header.Peek(AuthorizationHeader)
+	}
+
 	if authValue != nil {
 		isBasicAuth = true
 	} else if isBasicAuth {
@@ -401,15 +488,50 @@ func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile
 	}
 
 	if isBasicAuth {
-		username, name, groups, emails, authLevel, err = verifyBasicAuth(authHeader, authValue, *targetURL, ctx)
+		if ctx.Configuration.APITokens != nil && isAPITokenBearer(authValue) {
+			user, err = verifyAPIToken(authHeader, authValue, *targetURL, ctx)
+			return
+		}
+
+		if isOAuth2AccessTokenVerificationEnabled(ctx) && bytes.HasPrefix(authValue, []byte(bearerPrefix)) {
+			user, err = verifyOAuth2AccessToken(authHeader, authValue, *targetURL, ctx)
+			return
+		}
+
+		user, err = verifyBasicAuth(authHeader, authValue, *targetURL, ctx)
+
 		return
 	}
 
 	userSession := ctx.GetSession()
-	username, name, groups, emails, authLevel, err = verifySessionCookie(ctx, targetURL, &userSession, refreshProfile, refreshProfileInterval)
+	user, err = verifySessionCookie(ctx, targetURL, &userSession, refreshProfile, refreshProfileInterval)
+
+	// Anonymous users forwarded by a trusted gateway are authenticated for this request only.
+	if err == nil && user.Username == "" {
+		details, headerErr := verifyTrustedHeader(ctx)
+
+		switch {
+		case headerErr != nil:
+			ctx.Logger.Warnf("Unable to authenticate with the trusted header: %s", headerErr)
+		case details != nil:
+			return false, verifiedUser{UserDetails: *details, AuthLevel: ctx.Providers.TrustedHeader.Level()}, nil
+		}
+	}
+
+	// Anonymous users presenting a client certificate are authenticated for this request only.
+	if err == nil && user.Username == "" {
+		details, certErr := verifyClientCertificate(ctx)
+
+		switch {
+		case certErr != nil:
+			ctx.Logger.Warnf("Unable to authenticate with the client certificate: %s", certErr)
+		case details != nil:
+			return false, verifiedUser{UserDetails: *details, AuthLevel: ctx.Providers.ClientCertificates.Level()}, nil
+		}
+	}
 
 	sessionUsername := ctx.Request.Header.Peek(SessionUsernameHeader)
-	if sessionUsername != nil && !strings.EqualFold(string(sessionUsername), username) {
+	if sessionUsername != nil && !strings.EqualFold(string(sessionUsername), user.Username) {
 		ctx.Logger.Warnf("Possible cookie hijack or attempt to bypass security detected destroying the session and sending 401 response")
 
 		err = ctx.Providers.SessionProvider.DestroySession(ctx.RequestCtx)
@@ -420,12 +542,73 @@ func verifyAuth(ctx *middlewares.AutheliaCtx, targetURL *url.URL, refreshProfile
 					SessionUsernameHeader, err))
 		}
 
-		err = fmt.Errorf("Could not match user %s to their %s header with a value of %s when visiting %s", username, SessionUsernameHeader, sessionUsername, targetURL.String())
+		err = fmt.Errorf("Could not match user %s to their %s header with a value of %s when visiting %s", user.Username, SessionUsernameHeader, sessionUsername, targetURL.String())
 	}
 
 	return
 }
 
+// logAuthorizationDecision emits the structured audit event of the authorization decision made for a request.
+func logAuthorizationDecision(ctx *middlewares.AutheliaCtx, subject authorization.Subject, object authorization.Object,
+	requirements authorization.Requirements, authorized authorizationMatching) {
+	entry := ctx.Logger.WithFields(logrus.Fields{
+		"audit":         "authorization",
+		"username":      subject.Username,
+		"groups":        strings.Join(subject.Groups, ","),
+		"client_id":     subject.ClientID,
+		"target_url":    object.String(),
+		"target_method": object.Method,
+		"rule":          requirements.RulePosition,
+		"rule_name":     requirements.RuleName,
+		"policy":        authorization.LevelToPolicy(requirements.Level),
+		"result":        authorized.String(),
+	})
+
+	// The allowed decisions are the bulk of the requests, they are only logged at debug level to keep the volume low.
+	if authorized == Authorized {
+		entry.Debug("Authorization decision")
+		return
+	}
+
+	entry.Info("Authorization decision")
+}
+
+// replyForbidden replies 403 with the description of the rule denying the access as the reason when exposing the
+// denial reasons is enabled.
+func replyForbidden(ctx *middlewares.AutheliaCtx, requirements authorization.Requirements) {
+	reason := sanitizeDenialReason(requirements.RuleDescription)
+
+	if !ctx.Configuration.AccessControl.ExposeDenialReason || reason == "" {
+		ctx.ReplyForbidden()
+		return
+	}
+
+	ctx.RequestCtx.Error(fmt.Sprintf("%s: %s", fasthttp.StatusMessage(fasthttp.StatusForbidden), reason), fasthttp.StatusForbidden)
+}
+
+// sanitizeDenialReason strips the characters which are not printable from the reason, collapses its whitespaces and
+// truncates it to at most maxDenialReasonLength characters.
+func sanitizeDenialReason(reason string) string {
+	reason = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+
+		return r
+	}, reason)
+
+	runes := []rune(strings.Join(strings.Fields(reason), " "))
+	if len(runes) > maxDenialReasonLength {
+		runes = runes[:maxDenialReasonLength]
+	}
+
+	return string(runes)
+}
+
 // VerifyGet returns the handler verifying if a request is allowed to go through.
 func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.RequestHandler {
 	refreshProfile, refreshProfileInterval := getProfileRefreshSettings(cfg)
@@ -457,37 +640,71 @@ func VerifyGet(cfg schema.AuthenticationBackendConfiguration) middlewares.Reques
 			return
 		}
 
-		isBasicAuth, username, name, groups, emails, authLevel, err := verifyAuth(ctx, targetURL, refreshProfile, refreshProfileInterval)
+		isBasicAuth, user, err := verifyAuth(ctx, targetURL, refreshProfile, refreshProfileInterval)
 
 		method := ctx.XForwardedMethod()
 
 		if err != nil {
 			ctx.Logger.Error(fmt.Sprintf("Error caught when verifying user authorization: %s", err))
 
-			if err := updateActivityTimestamp(ctx, isBasicAuth, username); err != nil {
+			if err := updateActivityTimestamp(ctx, isBasicAuth, user.Username); err != nil {
 				ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
 				return
 			}
 
-			handleUnauthorized(ctx, targetURL, isBasicAuth, username, method)
+			handleUnauthorized(ctx, targetURL, isBasicAuth, user.Username, method, "")
 
 			return
 		}
 
-		authorized := isTargetURLAuthorized(ctx.Providers.Authorizer, *targetURL, username,
-			groups, ctx.RemoteIP(), method, authLevel)
+		// The session is only considered when the request is authenticated by the session cookie.
+		var authSession *session.UserSession
+
+		if userSession := ctx.GetSession(); !isBasicAuth && user.Username != "" && userSession.Username == user.Username {
+			authSession = &userSession
+		}
+
+		object := authorization.NewObjectRaw(targetURL, method, &ctx.Request.Header)
+
+		subject := authorization.Subject{
+			Username:   user.Username,
+			Groups:     user.Groups,
+			IP:         ctx.RemoteIP(),
+			Emails:     user.Emails,
+			Attributes: user.Attributes,
+			ClientID:   user.ClientID,
+		}
+
+		authorized, requirements := isTargetURLAuthorized(ctx.Providers.Authorizer, object, subject, user.AuthLevel,
+			authSession, ctx.Clock.Now())
+
+		logAuthorizationDecision(ctx, subject, object, requirements, authorized)
 
 		switch authorized {
 		case Forbidden:
-			ctx.Logger.Infof("Access to %s is forbidden to user %s", targetURL.String(), username)
-			ctx.ReplyForbidden()
+			ctx.Logger.Infof("Access to %s is forbidden to user %s (%s)", targetURL.String(), user.Username,
+				requirements.RuleString())
+			replyForbidden(ctx, requirements)
 		case NotAuthorized:
-			handleUnauthorized(ctx, targetURL, isBasicAuth, username, method)
+			handleUnauthorized(ctx, targetURL, isBasicAuth, user.Username, method, "")
+		case SecondFactorMethodRequired:
+			ctx.Logger.Infof("Access to %s requires user %s to complete one of the second factor methods %s",
+				targetURL.String(), user.Username, strings.Join(requirements.SecondFactorMethods, ", "))
+			handleUnauthorized(ctx, targetURL, isBasicAuth, user.Username, method,
+				fmt.Sprintf("reauth=%s&methods=%s", reauthTwoFactor, url.QueryEscape(strings.Join(requirements.SecondFactorMethods, ","))))
+		case ReauthenticationRequired:
+			ctx.Logger.Infof("Access to %s requires user %s to authenticate again", targetURL.String(), user.Username)
+
+			if requirements.Level == authorization.TwoFactor {
+				handleUnauthorized(ctx, targetURL, isBasicAuth, user.Username, method, "reauth="+reauthTwoFactor)
+			} else {
+				handleUnauthorized(ctx, targetURL, isBasicAuth, user.Username, method, "reauth="+reauthOneFactor)
+			}
 		case Authorized:
-			setForwardedHeaders(&ctx.Response.Header, username, name, groups, emails)
+			setForwardedHeaders(&ctx.Response.Header, user, cfg.Attributes)
 		}
 
-		if err := updateActivityTimestamp(ctx, isBasicAuth, username); err != nil {
+		if err := updateActivityTimestamp(ctx, isBasicAuth, user.Username); err != nil {
 			ctx.Error(fmt.Errorf("Unable to update last activity: %s", err), operationFailedMessage)
 		}
 	}
@@ -5,10 +5,12 @@ import (
 	"net"
 	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -161,12 +163,94 @@ func TestShouldCheckAuthorizationMatching(t *testing.T) {
 			username = testUsername
 		}
 
-		matching := isTargetURLAuthorized(authorizer, *url, username, []string{}, net.ParseIP("127.0.0.1"), []byte("GET"), rule.AuthLevel)
+		matching, _ := isTargetURLAuthorized(authorizer, authorization.NewObject(url, "GET"), authorization.Subject{Username: username, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, rule.AuthLevel, nil, time.Now())
 		assert.Equal(t, rule.ExpectedMatching, matching, "policy=%s, authLevel=%v, expected=%v, actual=%v",
 			rule.Policy, rule.AuthLevel, rule.ExpectedMatching, matching)
 	}
 }
 
+func TestShouldCheckAuthorizationMatchingWithMaxAge(t *testing.T) {
+	now := time.Now()
+
+	authorizer := authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{
+				{
+					Domains: []string{"one-factor.example.com"},
+					Policy:  "one_factor",
+					MaxAge:  "1h",
+				},
+				{
+					Domains: []string{"two-factor.example.com"},
+					Policy:  "two_factor",
+					MaxAge:  "1h",
+				},
+			},
+		}})
+
+	userSession := &session.UserSession{
+		Username:                   testUsername,
+		AuthenticationLevel:        authentication.TwoFactor,
+		FirstFactorAuthnTimestamp:  now.Add(-2 * time.Hour).Unix(),
+		SecondFactorAuthnTimestamp: now.Add(-30 * time.Minute).Unix(),
+	}
+
+	oneFactorURL, _ := url.ParseRequestURI("https://one-factor.example.com")
+	twoFactorURL, _ := url.ParseRequestURI("https://two-factor.example.com")
+
+	matching, requirements := isTargetURLAuthorized(authorizer, authorization.NewObject(oneFactorURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, userSession, now)
+	assert.Equal(t, ReauthenticationRequired, matching)
+	assert.Equal(t, authorization.OneFactor, requirements.Level)
+
+	matching, requirements = isTargetURLAuthorized(authorizer, authorization.NewObject(twoFactorURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, userSession, now)
+	assert.Equal(t, Authorized, matching)
+	assert.Equal(t, authorization.TwoFactor, requirements.Level)
+
+	matching, _ = isTargetURLAuthorized(authorizer, authorization.NewObject(twoFactorURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, userSession, now.Add(time.Hour))
+	assert.Equal(t, ReauthenticationRequired, matching)
+
+	// The users authenticated for the request only always have a fresh authentication.
+	matching, _ = isTargetURLAuthorized(authorizer, authorization.NewObject(oneFactorURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, nil, now)
+	assert.Equal(t, Authorized, matching)
+}
+
+func TestShouldCheckAuthorizationMatchingWithSecondFactorMethods(t *testing.T) {
+	authorizer := authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				Domains:             []string{"admin.example.com"},
+				Policy:              "two_factor",
+				SecondFactorMethods: []string{"u2f", "passwordless"},
+			}},
+		}})
+
+	targetURL, _ := url.ParseRequestURI("https://admin.example.com")
+
+	userSession := &session.UserSession{
+		Username:            testUsername,
+		AuthenticationLevel: authentication.TwoFactor,
+		SecondFactorMethods: []string{authentication.TOTP},
+	}
+
+	matching, requirements := isTargetURLAuthorized(authorizer, authorization.NewObject(targetURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, userSession, time.Now())
+	assert.Equal(t, SecondFactorMethodRequired, matching)
+	assert.Equal(t, []string{"u2f", "passwordless"}, requirements.SecondFactorMethods)
+
+	userSession.SecondFactorMethods = append(userSession.SecondFactorMethods, authentication.U2F)
+
+	matching, _ = isTargetURLAuthorized(authorizer, authorization.NewObject(targetURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, userSession, time.Now())
+	assert.Equal(t, Authorized, matching)
+
+	// The users authenticated for the request only didn't complete any method.
+	matching, _ = isTargetURLAuthorized(authorizer, authorization.NewObject(targetURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.TwoFactor, nil, time.Now())
+	assert.Equal(t, SecondFactorMethodRequired, matching)
+
+	matching, _ = isTargetURLAuthorized(authorizer, authorization.NewObject(targetURL, "GET"), authorization.Subject{Username: testUsername, Groups: []string{}, IP: net.ParseIP("127.0.0.1")}, authentication.OneFactor, userSession, time.Now())
+	assert.Equal(t, NotAuthorized, matching)
+}
+
 // Test verifyBasicAuth.
 func TestShouldVerifyWrongCredentials(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
@@ -177,7 +261,7 @@ func TestShouldVerifyWrongCredentials(t *testing.T) {
 		Return(false, nil)
 
 	url, _ := url.ParseRequestURI("https://test.example.com")
-	_, _, _, _, _, err := verifyBasicAuth(ProxyAuthorizationHeader, []byte("Basic am9objpwYXNzd29yZA=="), *url, mock.Ctx)
+	_, err := verifyBasicAuth(ProxyAuthorizationHeader, []byte("Basic am9objpwYXNzd29yZA=="), *url, mock.Ctx)
 
 	assert.Error(t, err)
 }
@@ -730,6 +814,152 @@ func TestShouldRedirectWhenSessionInactiveForTooLongAndRDParamProvided(t *testin
 	assert.Equal(t, clock.Now().Unix(), newUserSession.LastActivity)
 }
 
+func TestShouldRedirectWithReauthenticationHintWhenAuthenticationIsTooOld(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Clock.Set(time.Now())
+	mock.Ctx.Clock = &mock.Clock
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				Domains: []string{"payroll.example.com"},
+				Policy:  "two_factor",
+				MaxAge:  "15m",
+			}},
+		}})
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.LastActivity = mock.Clock.Now().Unix()
+	userSession.FirstFactorAuthnTimestamp = mock.Clock.Now().Add(-30 * 24 * time.Hour).Unix()
+	userSession.SecondFactorAuthnTimestamp = mock.Clock.Now().Add(-30 * 24 * time.Hour).Unix()
+	userSession.RefreshTTL = mock.Clock.Now().Add(5 * time.Minute)
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.QueryArgs().Add("rd", "https://login.example.com")
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://payroll.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-Method", "GET")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, "Found. Redirecting to https://login.example.com?rd=https%3A%2F%2Fpayroll.example.com&rm=GET&reauth=two_factor",
+		string(mock.Ctx.Response.Body()))
+	assert.Equal(t, 302, mock.Ctx.Response.StatusCode())
+
+	// The user is not logged out.
+	newUserSession := mock.Ctx.GetSession()
+	assert.Equal(t, testUsername, newUserSession.Username)
+	assert.Equal(t, authentication.TwoFactor, newUserSession.AuthenticationLevel)
+}
+
+func TestShouldRedirectWithSecondFactorMethodsHintWhenMethodIsNotAccepted(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Clock.Set(time.Now())
+	mock.Ctx.Clock = &mock.Clock
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				Domains:             []string{"admin.example.com"},
+				Policy:              "two_factor",
+				SecondFactorMethods: []string{"u2f", "passwordless"},
+			}},
+		}})
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.LastActivity = mock.Clock.Now().Unix()
+	userSession.SecondFactorMethods = []string{authentication.TOTP}
+	userSession.RefreshTTL = mock.Clock.Now().Add(5 * time.Minute)
+
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.QueryArgs().Add("rd", "https://login.example.com")
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://admin.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-Method", "GET")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, "Found. Redirecting to https://login.example.com?rd=https%3A%2F%2Fadmin.example.com&rm=GET&reauth=two_factor&methods=u2f%2Cpasswordless",
+		string(mock.Ctx.Response.Body()))
+	assert.Equal(t, 302, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldMatchRequestHeadersInVerify(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				Domains: []string{"api.example.com"},
+				Policy:  "bypass",
+				Headers: []schema.ACLRuleMatcher{{Key: "Content-Type", Value: "application/json"}},
+			}},
+		}})
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://api.example.com/status")
+	mock.Ctx.Request.Header.Set("Content-Type", "application/json")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	mock.Ctx.Request.Header.Set("Content-Type", "text/html")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldMatchDomainRegexAgainstUserAttributesInVerify(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Clock.Set(time.Now())
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				DomainsRegex: []string{`^(?P<tenant>\w+)\.example\.com$`},
+				Policy:       "one_factor",
+			}},
+		}})
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.Attributes = map[string][]string{"tenant": {"acme"}}
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.RefreshTTL = mock.Clock.Now().Add(5 * time.Minute)
+
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://acme.example.com")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://initech.example.com")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+}
+
 func TestShouldRedirectWithCorrectStatusCodeBasedOnRequestMethod(t *testing.T) {
 	mock := mocks.NewMockAutheliaCtx(t)
 	defer mock.Close()
@@ -1262,3 +1492,160 @@ func TestGetProfileRefreshSettings(t *testing.T) {
 	assert.Equal(t, true, refresh)
 	assert.Equal(t, time.Duration(0), interval)
 }
+
+func TestShouldForwardUserAttributesInHeaders(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	cfg := verifyGetCfg
+	cfg.Attributes = []schema.UserAttributeConfiguration{
+		{Name: "department", LDAPAttribute: "department", Header: "Remote-Department"},
+		{Name: "employee_id", LDAPAttribute: "employeeNumber", Claim: "employee_id"},
+		{Name: "cost_centers", LDAPAttribute: "costCenter", Header: "Remote-Cost-Centers"},
+	}
+
+	user := &authentication.UserDetails{
+		Username: "john",
+		Emails:   []string{"john@example.com"},
+		Attributes: map[string][]string{
+			"department":   {"engineering"},
+			"employee_id":  {"1234"},
+			"cost_centers": {"cc1", "cc2"},
+		},
+	}
+
+	mock.UserProviderMock.EXPECT().GetDetails("john").Return(user, nil).Times(1)
+
+	clock := mocks.TestingClock{}
+	clock.Set(time.Now())
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = user.Username
+	userSession.Emails = user.Emails
+	userSession.AuthenticationLevel = authentication.TwoFactor
+	userSession.LastActivity = clock.Now().Unix()
+	userSession.RefreshTTL = clock.Now().Add(-1 * time.Minute)
+	userSession.Attributes = map[string][]string{"department": {"sales"}}
+	err := mock.Ctx.SaveSession(userSession)
+	require.NoError(t, err)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	VerifyGet(cfg)(mock.Ctx)
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	assert.Equal(t, user.Attributes, mock.Ctx.GetSession().Attributes)
+	assert.Equal(t, "engineering", string(mock.Ctx.Response.Header.Peek("Remote-Department")))
+	assert.Equal(t, "cc1,cc2", string(mock.Ctx.Response.Header.Peek("Remote-Cost-Centers")))
+	assert.Nil(t, mock.Ctx.Response.Header.Peek("Employee-Id"))
+}
+
+func TestShouldExposeSanitizedDenialReasonAndEmitAuditEvent(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Clock.Set(time.Now())
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "one_factor",
+			Rules: []schema.ACLRule{{
+				Name:        "payroll",
+				Description: "The payroll is\n restricted to\tthe accounting team.\x07",
+				Domains:     []string{"payroll.example.com"},
+				Policy:      "deny",
+			}},
+		}})
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.Groups = []string{"dev"}
+	userSession.AuthenticationLevel = authentication.OneFactor
+	userSession.RefreshTTL = mock.Clock.Now().Add(5 * time.Minute)
+
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://payroll.example.com/salaries")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "Forbidden", string(mock.Ctx.Response.Body()))
+
+	var event *logrus.Entry
+
+	for i := range mock.Hook.Entries {
+		if mock.Hook.Entries[i].Data["audit"] == "authorization" {
+			event = &mock.Hook.Entries[i]
+		}
+	}
+
+	require.NotNil(t, event)
+	assert.Equal(t, "Authorization decision", event.Message)
+	assert.Equal(t, logrus.InfoLevel, event.Level)
+	assert.Equal(t, testUsername, event.Data["username"])
+	assert.Equal(t, "dev", event.Data["groups"])
+	assert.Equal(t, "https://payroll.example.com/salaries", event.Data["target_url"])
+	assert.Equal(t, 1, event.Data["rule"])
+	assert.Equal(t, "payroll", event.Data["rule_name"])
+	assert.Equal(t, "deny", event.Data["policy"])
+	assert.Equal(t, "forbidden", event.Data["result"])
+
+	mock.Ctx.Configuration.AccessControl.ExposeDenialReason = true
+	mock.Ctx.Response.Reset()
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "Forbidden: The payroll is restricted to the accounting team.", string(mock.Ctx.Response.Body()))
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://deny.example.com")
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{DefaultPolicy: "deny"}})
+	mock.Ctx.Response.Reset()
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "Forbidden", string(mock.Ctx.Response.Body()))
+}
+
+func TestShouldEmitAuthorizedAuditEventAtDebugLevel(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Logger.Logger.SetLevel(logrus.DebugLevel)
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(testUsername).
+		Return(&authentication.UserDetails{Username: testUsername}, nil)
+
+	userSession := mock.Ctx.GetSession()
+	userSession.Username = testUsername
+	userSession.AuthenticationLevel = authentication.OneFactor
+
+	require.NoError(t, mock.Ctx.SaveSession(userSession))
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+
+	var event *logrus.Entry
+
+	for i := range mock.Hook.Entries {
+		if mock.Hook.Entries[i].Data["audit"] == "authorization" {
+			event = &mock.Hook.Entries[i]
+		}
+	}
+
+	require.NotNil(t, event)
+	assert.Equal(t, logrus.DebugLevel, event.Level)
+	assert.Equal(t, "authorized", event.Data["result"])
+}
+
+func TestShouldSanitizeDenialReason(t *testing.T) {
+	assert.Equal(t, "", sanitizeDenialReason(""))
+	assert.Equal(t, "Ask the IT team.", sanitizeDenialReason("  Ask\r\nthe\u200b IT team.\x00 "))
+	assert.Len(t, sanitizeDenialReason(strings.Repeat("é", 300)), 2*maxDenialReasonLength)
+}
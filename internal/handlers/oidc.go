@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ory/fosite"
 	"github.com/ory/fosite/handler/openid"
 	"github.com/ory/fosite/token/jwt"
 
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
 	"github.com/authelia/authelia/internal/oidc"
 	"github.com/authelia/authelia/internal/session"
 	"github.com/authelia/authelia/internal/utils"
@@ -30,3 +37,112 @@ func newOpenIDSession(subject string) *oidc.OpenIDSession {
 		Extra: map[string]interface{}{},
 	}
 }
+
+// oidcAuthenticationMethodsReferences returns the authentication methods references of the methods completed by the
+// user of the session.
+func oidcAuthenticationMethodsReferences(userSession *session.UserSession) (amr []string) {
+	if userSession.AuthenticationLevel == authentication.NotAuthenticated {
+		return nil
+	}
+
+	if userSession.Passwordless {
+		amr = append(amr, oidc.AMRHardwareKey)
+	} else {
+		amr = append(amr, oidc.AMRPassword)
+	}
+
+	for _, method := range userSession.SecondFactorMethods {
+		var reference string
+
+		switch method {
+		case authentication.TOTP:
+			reference = oidc.AMROneTimePassword
+		case authentication.U2F:
+			reference = oidc.AMRHardwareKey
+		case authentication.Push:
+			reference = oidc.AMRMultipleChannel
+		}
+
+		if reference != "" && !utils.IsStringInSlice(reference, amr) {
+			amr = append(amr, reference)
+		}
+	}
+
+	if userSession.AuthenticationLevel == authentication.TwoFactor {
+		amr = append(amr, oidc.AMRMultiFactor)
+	}
+
+	return amr
+}
+
+// authenticationLevelFromAMR returns the authentication level matching the authentication methods references.
+func authenticationLevelFromAMR(amr []string) authentication.Level {
+	switch {
+	case utils.IsStringInSlice(oidc.AMRMultiFactor, amr):
+		return authentication.TwoFactor
+	case len(amr) != 0:
+		return authentication.OneFactor
+	default:
+		return authentication.NotAuthenticated
+	}
+}
+
+// isAudienceMatchingTargetURL returns true if one of the audiences is the origin of the target URL.
+func isAudienceMatchingTargetURL(audience []string, targetURL url.URL) bool {
+	origin := fmt.Sprintf("%s://%s", targetURL.Scheme, targetURL.Host)
+
+	for _, a := range audience {
+		if strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOAuth2AccessTokenVerificationEnabled returns true if the access tokens issued by the OpenID Connect provider are
+// accepted by the verify endpoint, which must be explicitly enabled since they sign the users in.
+func isOAuth2AccessTokenVerificationEnabled(ctx *middlewares.AutheliaCtx) bool {
+	return ctx.Providers.OpenIDConnect.Fosite != nil && ctx.Configuration.IdentityProviders.OIDC != nil &&
+		ctx.Configuration.IdentityProviders.OIDC.EnableVerifyAccessTokens
+}
+
+// verifyOAuth2AccessToken verifies an access token issued by the OpenID Connect provider to a client on behalf of a user.
+// The token is only accepted for the target URLs whose origin was granted as an audience, and the user is authenticated
+// with the level of the methods completed when signing in before the token was issued.
+func verifyOAuth2AccessToken(header string, auth []byte, targetURL url.URL, ctx *middlewares.AutheliaCtx) (user verifiedUser, err error) {
+	token := strings.TrimSpace(strings.TrimPrefix(string(auth), bearerPrefix))
+
+	_, requester, err := ctx.Providers.OpenIDConnect.Fosite.IntrospectToken(ctx, token, fosite.AccessToken, newOpenIDSession(""))
+	if err != nil {
+		return user, fmt.Errorf("Unable to verify the access token of the %s header: %s", header, fosite.ErrorToRFC6749Error(err).GetDescription())
+	}
+
+	clientID := requester.GetClient().GetID()
+
+	username := requester.GetSession().GetSubject()
+	if username == "" {
+		return user, fmt.Errorf("The access token of client %s was not issued on behalf of a user", clientID)
+	}
+
+	if !isAudienceMatchingTargetURL(requester.GetGrantedAudience(), targetURL) {
+		return user, fmt.Errorf("The access token of client %s was not granted the audience %s://%s", clientID, targetURL.Scheme, targetURL.Host)
+	}
+
+	var authLevel authentication.Level
+
+	if openIDSession, ok := requester.GetSession().(*oidc.OpenIDSession); ok {
+		authLevel = authenticationLevelFromAMR(openIDSession.AMR)
+	}
+
+	if authLevel == authentication.NotAuthenticated {
+		return user, fmt.Errorf("The access token of client %s doesn't record how user %s authenticated", clientID, username)
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		return user, fmt.Errorf("Unable to retrieve details of user %s: %s", username, err)
+	}
+
+	return verifiedUser{UserDetails: *details, ClientID: clientID, AuthLevel: authLevel}, nil
+}
@@ -3,9 +3,17 @@ package handlers
 import (
 	"testing"
 
+	"github.com/ory/fosite"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+	"github.com/authelia/authelia/internal/oidc"
 	"github.com/authelia/authelia/internal/session"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 func TestShouldDetectIfConsentIsMissing(t *testing.T) {
@@ -31,3 +39,245 @@ func TestShouldDetectIfConsentIsMissing(t *testing.T) {
 	requestedAudience = []string{"https://not.authelia.com"}
 	assert.True(t, isConsentMissing(workflow, requestedScopes, requestedAudience))
 }
+
+func TestShouldGrantUserAttributesClaimsWithProfileScope(t *testing.T) {
+	ar := fosite.NewAuthorizeRequest()
+	ar.Client = &fosite.DefaultClient{ID: "client"}
+
+	attributes := []schema.UserAttributeConfiguration{
+		{Name: "department", Header: "Remote-Department"},
+		{Name: "employee_id", Claim: "employee_id"},
+		{Name: "cost_centers", Claim: "cost_centers"},
+		{Name: "manager", Claim: "manager"},
+	}
+
+	userSession := &session.UserSession{
+		DisplayName: "John Doe",
+		Attributes: map[string][]string{
+			"department":   {"engineering"},
+			"employee_id":  {"1234"},
+			"cost_centers": {"cc1", "cc2"},
+		},
+	}
+
+	claims := oidcGrantRequests(ar, []string{"openid", "profile"}, nil, userSession, attributes)
+
+	assert.Equal(t, map[string]interface{}{
+		"name":         "John Doe",
+		"employee_id":  "1234",
+		"cost_centers": []string{"cc1", "cc2"},
+	}, claims)
+	assert.Equal(t, fosite.Arguments{"client"}, ar.GetGrantedAudience())
+
+	claims = oidcGrantRequests(fosite.NewAuthorizeRequest(), []string{"openid"}, []string{"client"}, userSession, attributes)
+	assert.Empty(t, claims)
+}
+
+// setOAuth2Clients sets an OpenID Connect provider with the clients allowed to use the client credentials grant whose
+// access tokens are accepted by the verify endpoint.
+func setOAuth2Clients(t *testing.T, mock *mocks.MockAutheliaCtx, clients ...schema.OpenIDConnectClientConfiguration) {
+	key, _ := utils.GenerateRsaKeyPair(2048)
+
+	for i := range clients {
+		clients[i].GrantTypes = []string{"client_credentials"}
+		clients[i].RedirectURIs = []string{"https://" + clients[i].ID + ".example.com/callback"}
+	}
+
+	configuration := &schema.OpenIDConnectConfiguration{
+		IssuerPrivateKey:         utils.ExportRsaPrivateKeyAsPemStr(key),
+		HMACSecret:               "asbdhaaskmdlkamdklasmdlkams",
+		EnableVerifyAccessTokens: true,
+		Clients:                  clients,
+	}
+
+	provider, err := oidc.NewOpenIDConnectProvider(configuration)
+	require.NoError(t, err)
+
+	mock.Ctx.Configuration.IdentityProviders.OIDC = configuration
+	mock.Ctx.Providers.OpenIDConnect = provider
+}
+
+// issueOAuth2AccessToken issues an access token to the client on behalf of the user, granted the audience and recording
+// the authentication methods references.
+func issueOAuth2AccessToken(t *testing.T, mock *mocks.MockAutheliaCtx, clientID, username, audience string, amr ...string) string {
+	client, err := mock.Ctx.Providers.OpenIDConnect.Store.GetClient(mock.Ctx, clientID)
+	require.NoError(t, err)
+
+	openIDSession := newOpenIDSession(username)
+	openIDSession.AMR = amr
+
+	request := fosite.NewAccessRequest(openIDSession)
+	request.GrantTypes = fosite.Arguments{"client_credentials"}
+	request.Client = client
+
+	if audience != "" {
+		request.GrantAudience(audience)
+	}
+
+	response, err := mock.Ctx.Providers.OpenIDConnect.Fosite.NewAccessResponse(mock.Ctx, request)
+	require.NoError(t, err)
+
+	return response.GetAccessToken()
+}
+
+func TestShouldVerifyOAuth2AccessTokenWithClientSubject(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setOAuth2Clients(t, mock,
+		schema.OpenIDConnectClientConfiguration{ID: "grafana", Secret: "grafana-secret", Policy: "two_factor"},
+		schema.OpenIDConnectClientConfiguration{ID: "wiki", Secret: "wiki-secret", Policy: "one_factor"})
+
+	mock.Ctx.Providers.Authorizer = authorization.NewAuthorizer(&schema.Configuration{
+		AccessControl: schema.AccessControlConfiguration{
+			DefaultPolicy: "deny",
+			Rules: []schema.ACLRule{{
+				Domains:  []string{"grafana.example.com"},
+				Policy:   "two_factor",
+				Subjects: [][]string{{"oauth2:client:grafana", "!group:contractors"}},
+			}},
+		}})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(testUsername).
+		Return(&authentication.UserDetails{
+			Username: testUsername,
+			Emails:   []string{"john@example.com"},
+			Groups:   []string{"dev"},
+		}, nil).
+		Times(2)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://grafana.example.com/api/dashboards")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "grafana", testUsername,
+		"https://grafana.example.com", oidc.AMRPassword, oidc.AMROneTimePassword, oidc.AMRMultiFactor))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, []byte(testUsername), mock.Ctx.Response.Header.Peek("Remote-User"))
+
+	mock.Ctx.Response.Reset()
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "wiki", testUsername,
+		"https://grafana.example.com", oidc.AMRPassword, oidc.AMRHardwareKey, oidc.AMRMultiFactor))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 403, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldNotVerifyOAuth2AccessTokenUnlessEnabled(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setOAuth2Clients(t, mock, schema.OpenIDConnectClientConfiguration{ID: "grafana", Secret: "grafana-secret", Policy: "one_factor"})
+	mock.Ctx.Configuration.IdentityProviders.OIDC.EnableVerifyAccessTokens = false
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "grafana", testUsername,
+		"https://one-factor.example.com", oidc.AMRPassword))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "", string(mock.Ctx.Response.Header.Peek("Remote-User")))
+}
+
+func TestShouldRejectInvalidOAuth2AccessTokens(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setOAuth2Clients(t, mock,
+		schema.OpenIDConnectClientConfiguration{ID: "grafana", Secret: "grafana-secret", Policy: "two_factor"})
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://bypass.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer invalid")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	require.GreaterOrEqual(t, len(mock.Hook.Entries), 2)
+	assert.Contains(t, mock.Hook.Entries[len(mock.Hook.Entries)-2].Message, "Unable to verify the access token of the Proxy-Authorization header")
+
+	mock.Ctx.Response.Reset()
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "grafana", "", "https://bypass.example.com", oidc.AMRPassword))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Contains(t, mock.Hook.Entries[len(mock.Hook.Entries)-2].Message, "The access token of client grafana was not issued on behalf of a user")
+}
+
+func TestShouldRejectOAuth2AccessTokensNotGrantedTheTargetAudience(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	setOAuth2Clients(t, mock,
+		schema.OpenIDConnectClientConfiguration{ID: "grafana", Secret: "grafana-secret", Policy: "two_factor"})
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "grafana", testUsername,
+		"grafana", oidc.AMRPassword, oidc.AMROneTimePassword, oidc.AMRMultiFactor))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Contains(t, mock.Hook.Entries[len(mock.Hook.Entries)-2].Message,
+		"The access token of client grafana was not granted the audience https://two-factor.example.com")
+}
+
+func TestShouldAuthenticateOAuth2AccessTokensWithTheLevelOfTheSignIn(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	// The policy of the client doesn't raise the level of a user who only completed the first factor.
+	setOAuth2Clients(t, mock,
+		schema.OpenIDConnectClientConfiguration{ID: "grafana", Secret: "grafana-secret", Policy: "two_factor"})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails(testUsername).
+		Return(&authentication.UserDetails{Username: testUsername}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "grafana", testUsername,
+		"https://two-factor.example.com", oidc.AMRPassword))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+
+	mock.Ctx.Response.Reset()
+	mock.Ctx.Request.Header.Set("Proxy-Authorization", "Bearer "+issueOAuth2AccessToken(t, mock, "grafana", testUsername,
+		"https://two-factor.example.com"))
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Contains(t, mock.Hook.Entries[len(mock.Hook.Entries)-2].Message,
+		"The access token of client grafana doesn't record how user john authenticated")
+}
+
+func TestShouldReturnAuthenticationMethodsReferencesOfSession(t *testing.T) {
+	testCases := []struct {
+		name     string
+		session  session.UserSession
+		expected []string
+	}{
+		{"Anonymous", session.UserSession{}, nil},
+		{"OneFactor", session.UserSession{AuthenticationLevel: authentication.OneFactor}, []string{oidc.AMRPassword}},
+		{"TOTP", session.UserSession{AuthenticationLevel: authentication.TwoFactor, SecondFactorMethods: []string{authentication.TOTP}},
+			[]string{oidc.AMRPassword, oidc.AMROneTimePassword, oidc.AMRMultiFactor}},
+		{"U2FAndPush", session.UserSession{AuthenticationLevel: authentication.TwoFactor, SecondFactorMethods: []string{authentication.U2F, authentication.Push}},
+			[]string{oidc.AMRPassword, oidc.AMRHardwareKey, oidc.AMRMultipleChannel, oidc.AMRMultiFactor}},
+		{"Passwordless", session.UserSession{AuthenticationLevel: authentication.TwoFactor, Passwordless: true, SecondFactorMethods: []string{authentication.Passwordless}},
+			[]string{oidc.AMRHardwareKey, oidc.AMRMultiFactor}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amr := oidcAuthenticationMethodsReferences(&tc.session)
+
+			assert.Equal(t, tc.expected, amr)
+			assert.Equal(t, tc.session.AuthenticationLevel, authenticationLevelFromAMR(amr))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// FirstFactorPreAuthenticatedPost signs in the anonymous users forwarded by a trusted gateway or presenting a client
+// certificate. The portal calls it once before fetching the state so that the state query has no side effect.
+func FirstFactorPreAuthenticatedPost(ctx *middlewares.AutheliaCtx) {
+	userSession := ctx.GetSession()
+
+	if userSession.Username != "" {
+		ctx.ReplyOK()
+		return
+	}
+
+	if err := authenticateWithTrustedHeader(ctx, &userSession); err != nil {
+		handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to authenticate with the trusted header: %w", err), authenticationFailedMessage)
+		return
+	}
+
+	if userSession.Username == "" {
+		if err := authenticateWithClientCertificate(ctx, &userSession); err != nil {
+			handleAuthenticationUnauthorized(ctx, fmt.Errorf("Unable to authenticate with the client certificate: %w", err), authenticationFailedMessage)
+			return
+		}
+	}
+
+	ctx.ReplyOK()
+}
+
+// openPreAuthenticatedSession opens a session at the given level for a user authenticated by other means than the
+// portal like a client certificate or a trusted header. The pending OpenID Connect workflow is kept.
+func openPreAuthenticatedSession(ctx *middlewares.AutheliaCtx, userSession *session.UserSession,
+	details *authentication.UserDetails, level authentication.Level) error {
+	newSession := session.NewDefaultUserSession()
+	newSession.OIDCWorkflowSession = userSession.OIDCWorkflowSession
+
+	if err := ctx.SaveSession(newSession); err != nil {
+		return fmt.Errorf("Unable to reset the session for user %s: %w", details.Username, err)
+	}
+
+	if err := ctx.Providers.SessionProvider.RegenerateSession(ctx.RequestCtx); err != nil {
+		return fmt.Errorf("Unable to regenerate session for user %s: %w", details.Username, err)
+	}
+
+	newSession.SetOneFactor(ctx.Clock.Now(), details, false)
+
+	if level == authentication.TwoFactor {
+		newSession.SetTwoFactor(ctx.Clock.Now(), "")
+	}
+
+	if refresh, refreshInterval := getProfileRefreshSettings(ctx.Configuration.AuthenticationBackend); refresh {
+		newSession.RefreshTTL = ctx.Clock.Now().Add(refreshInterval)
+	}
+
+	if err := ctx.SaveSession(newSession); err != nil {
+		return fmt.Errorf("Unable to save session of user %s: %w", details.Username, err)
+	}
+
+	*userSession = newSession
+
+	return nil
+}
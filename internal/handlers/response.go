@@ -48,7 +48,8 @@ func handleOIDCWorkflowResponse(ctx *middlewares.AutheliaCtx) {
 }
 
 // Handle1FAResponse handle the redirection upon 1FA authentication.
-func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI, requestMethod string, username string, groups []string) {
+func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI, requestMethod string, username string, groups,
+	emails []string, attributes map[string][]string) {
 	if targetURI == "" {
 		if !ctx.Providers.Authorizer.IsSecondFactorEnabled() && ctx.Configuration.DefaultRedirectionURL != "" {
 			err := ctx.SetJSONBody(redirectResponse{Redirect: ctx.Configuration.DefaultRedirectionURL})
@@ -70,9 +71,11 @@ func Handle1FAResponse(ctx *middlewares.AutheliaCtx, targetURI, requestMethod st
 
 	requiredLevel := ctx.Providers.Authorizer.GetRequiredLevel(
 		authorization.Subject{
-			Username: username,
-			Groups:   groups,
-			IP:       ctx.RemoteIP(),
+			Username:   username,
+			Groups:     groups,
+			Emails:     emails,
+			Attributes: attributes,
+			IP:         ctx.RemoteIP(),
 		},
 		authorization.NewObject(targetURL, requestMethod))
 
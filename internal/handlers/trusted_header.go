@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/session"
+)
+
+// verifyTrustedHeader returns the details of the user forwarded by a trusted gateway in the trusted header, nil is
+// returned when the trusted header is disabled or not sent.
+func verifyTrustedHeader(ctx *middlewares.AutheliaCtx) (*authentication.UserDetails, error) {
+	verifier := ctx.Providers.TrustedHeader
+	if verifier == nil {
+		return nil, nil
+	}
+
+	value := ctx.Request.Header.Peek(verifier.Header())
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	var signature []byte
+	if verifier.SignatureHeader() != "" {
+		signature = ctx.Request.Header.Peek(verifier.SignatureHeader())
+	}
+
+	// The address of the peer is checked rather than X-Forwarded-For which can be set by anyone.
+	username, err := verifier.Verify(ctx.RequestCtx.RemoteIP(), value, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := ctx.Providers.UserProvider.GetDetails(username)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve details of user %s identified by the trusted header: %w", username, err)
+	}
+
+	return details, nil
+}
+
+// authenticateWithTrustedHeader opens a session at the configured authentication level for the user forwarded by a
+// trusted gateway to the portal. The session is left untouched when the trusted header is not sent.
+func authenticateWithTrustedHeader(ctx *middlewares.AutheliaCtx, userSession *session.UserSession) error {
+	details, err := verifyTrustedHeader(ctx)
+	if err != nil || details == nil {
+		return err
+	}
+
+	if err = openPreAuthenticatedSession(ctx, userSession, details, ctx.Providers.TrustedHeader.Level()); err != nil {
+		return err
+	}
+
+	ctx.Logger.Debugf("User %s has been authenticated with the trusted header", details.Username)
+
+	return nil
+}
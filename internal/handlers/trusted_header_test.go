@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authelia/authelia/internal/authentication"
+	"github.com/authelia/authelia/internal/configuration/schema"
+	"github.com/authelia/authelia/internal/mocks"
+)
+
+func newTestTrustedHeaderVerifier(t *testing.T, level string) *authentication.TrustedHeaderVerifier {
+	verifier, err := authentication.NewTrustedHeaderVerifier(schema.TrustedHeaderConfiguration{
+		Header:              "X-Forwarded-User",
+		TrustedNetworks:     []string{"10.0.0.1"},
+		AuthenticationLevel: level,
+	}, nil)
+	require.NoError(t, err)
+
+	return verifier
+}
+
+func TestShouldAuthenticateWithTrustedHeaderInVerify(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.TrustedHeader = newTestTrustedHeaderVerifier(t, schema.AuthenticationLevelOneFactor)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails("john").
+		Return(&authentication.UserDetails{
+			Username: "john",
+			Emails:   []string{"john@example.com"},
+			Groups:   []string{"dev"},
+		}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-User", "john")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 200, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "john", string(mock.Ctx.Response.Header.Peek("Remote-User")))
+	assert.Equal(t, "dev", string(mock.Ctx.Response.Header.Peek("Remote-Groups")))
+
+	// The user is authenticated for the request only.
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+}
+
+func TestShouldRequireConfiguredLevelWithTrustedHeaderInVerify(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.TrustedHeader = newTestTrustedHeaderVerifier(t, schema.AuthenticationLevelOneFactor)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails("john").
+		Return(&authentication.UserDetails{Username: "john"}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://two-factor.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-User", "john")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+}
+
+func TestShouldNotTrustHeaderFromUntrustedGateway(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.TrustedHeader = newTestTrustedHeaderVerifier(t, schema.AuthenticationLevelTwoFactor)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 4242})
+
+	mock.Ctx.Request.Header.Set("X-Original-URL", "https://one-factor.example.com")
+	mock.Ctx.Request.Header.Set("X-Forwarded-For", "10.0.0.1")
+	mock.Ctx.Request.Header.Set("X-Forwarded-User", "john")
+
+	VerifyGet(verifyGetCfg)(mock.Ctx)
+
+	assert.Equal(t, 401, mock.Ctx.Response.StatusCode())
+	assert.Equal(t, "", string(mock.Ctx.Response.Header.Peek("Remote-User")))
+}
+
+func TestShouldOpenSessionWithTrustedHeaderInPreAuthenticated(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.TrustedHeader = newTestTrustedHeaderVerifier(t, schema.AuthenticationLevelTwoFactor)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242})
+
+	mock.UserProviderMock.EXPECT().
+		GetDetails("john").
+		Return(&authentication.UserDetails{
+			Username: "john",
+			Groups:   []string{"dev"},
+		}, nil)
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-User", "john")
+
+	FirstFactorPreAuthenticatedPost(mock.Ctx)
+
+	mock.Assert200OK(t, nil)
+
+	userSession := mock.Ctx.GetSession()
+	assert.Equal(t, "john", userSession.Username)
+	assert.Equal(t, authentication.TwoFactor, userSession.AuthenticationLevel)
+	assert.Equal(t, []string{"dev"}, userSession.Groups)
+}
+
+func TestShouldNotOpenSessionWithTrustedHeaderInState(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.TrustedHeader = newTestTrustedHeaderVerifier(t, schema.AuthenticationLevelTwoFactor)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242})
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-User", "john")
+
+	StateGet(mock.Ctx)
+
+	mock.Assert200OK(t, StateResponse{})
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+}
+
+func TestShouldNotOpenSessionWithTrustedHeaderFromUntrustedGatewayInPreAuthenticated(t *testing.T) {
+	mock := mocks.NewMockAutheliaCtx(t)
+	defer mock.Close()
+
+	mock.Ctx.Providers.TrustedHeader = newTestTrustedHeaderVerifier(t, schema.AuthenticationLevelTwoFactor)
+	mock.Ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 4242})
+
+	mock.Ctx.Request.Header.Set("X-Forwarded-User", "john")
+
+	FirstFactorPreAuthenticatedPost(mock.Ctx)
+
+	mock.Assert401KO(t, "Authentication failed. Check your credentials.")
+	assert.Equal(t, "", mock.Ctx.GetSession().Username)
+}
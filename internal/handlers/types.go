@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/tstranex/u2f"
 
 	"github.com/authelia/authelia/internal/authentication"
@@ -11,6 +14,34 @@ type MethodList = []string
 
 type authorizationMatching int
 
+// verifiedUser is the user authenticated by one of the methods accepted by the verify endpoint.
+type verifiedUser struct {
+	authentication.UserDetails
+
+	// ClientID is the ID of the OpenID Connect client the access token was issued to, if any.
+	ClientID string
+
+	AuthLevel authentication.Level
+}
+
+// String returns the name of the authorization decision as it appears in the audit events.
+func (m authorizationMatching) String() string {
+	switch m {
+	case Forbidden:
+		return "forbidden"
+	case NotAuthorized:
+		return "not_authorized"
+	case Authorized:
+		return "authorized"
+	case ReauthenticationRequired:
+		return "reauthentication_required"
+	case SecondFactorMethodRequired:
+		return "second_factor_method_required"
+	default:
+		return "unknown"
+	}
+}
+
 // UserInfo is the model of user info and second factor preferences.
 type UserInfo struct {
 	// The users display name.
@@ -77,7 +108,80 @@ type resetPasswordStep1RequestBody struct {
 	Username string `json:"username"`
 }
 
+// changePasswordRequestBody model of the password change request body.
+type changePasswordRequestBody struct {
+	Username    string `json:"username" valid:"required"`
+	Password    string `json:"password" valid:"required"`
+	NewPassword string `json:"new_password" valid:"required"`
+}
+
 // resetPasswordStep2RequestBody model of the reset password (step2) request body.
 type resetPasswordStep2RequestBody struct {
 	Password string `json:"password"`
 }
+
+// apiTokenRequestBody model of the request body of the API token creation endpoint.
+type apiTokenRequestBody struct {
+	Name       string   `json:"name" valid:"required"`
+	Scopes     []string `json:"scopes"`
+	Expiration string   `json:"expiration"`
+}
+
+// APITokenResponse represents an API token in the responses of the API token endpoints, its secret is never included.
+type APITokenResponse struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// APITokenCreatedResponse represents the response sent when an API token is issued. It's the only time the value of
+// the token is sent to the user.
+type APITokenCreatedResponse struct {
+	APITokenResponse
+
+	Token string `json:"token"`
+}
+
+// registerStep1RequestBody model of the registration (step1) request body.
+type registerStep1RequestBody struct {
+	Username    string `json:"username" valid:"required"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email" valid:"required,email"`
+	Password    string `json:"password" valid:"required"`
+}
+
+// registerStep2Response represents the response sent once the email of a registration is verified.
+type registerStep2Response struct {
+	ApprovalRequired bool `json:"approval_required"`
+}
+
+// RegistrationResponse represents a registration waiting for approval in the responses of the registration endpoints.
+type RegistrationResponse struct {
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// passwordlessRegisterRequestBody model of the request body completing the registration of a passwordless credential.
+type passwordlessRegisterRequestBody struct {
+	Description string          `json:"description"`
+	Credential  json.RawMessage `json:"credential"`
+}
+
+// passwordlessSignRequestBody model of the request body completing a passwordless sign in.
+type passwordlessSignRequestBody struct {
+	Credential json.RawMessage `json:"credential"`
+	TargetURL  string          `json:"targetURL"`
+}
+
+// PasswordlessCredentialResponse represents a passwordless credential in the responses of the credentials endpoints.
+type PasswordlessCredentialResponse struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
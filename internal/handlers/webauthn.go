@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+
+	"github.com/authelia/authelia/internal/middlewares"
+	"github.com/authelia/authelia/internal/models"
+	"github.com/authelia/authelia/internal/utils"
+)
+
+const webauthnUserHandleLength = 32
+
+// webauthnUser is the user as seen by the WebAuthn library, it is identified by an opaque user handle stored in the
+// resident credentials so that the authenticator can tell who signs in without asking for a username.
+type webauthnUser struct {
+	handle      []byte
+	username    string
+	displayName string
+	credentials []models.WebauthnCredential
+}
+
+// WebAuthnID returns the user handle of the user.
+func (u webauthnUser) WebAuthnID() []byte {
+	return u.handle
+}
+
+// WebAuthnName returns the username of the user.
+func (u webauthnUser) WebAuthnName() string {
+	return u.username
+}
+
+// WebAuthnDisplayName returns the display name of the user.
+func (u webauthnUser) WebAuthnDisplayName() string {
+	return u.displayName
+}
+
+// WebAuthnIcon returns no icon since the users don't have any.
+func (u webauthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+// WebAuthnCredentials returns the credentials registered by the user.
+func (u webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+
+	for _, credential := range u.credentials {
+		credentials = append(credentials, webauthn.Credential{
+			ID:              credential.ID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Authenticator: webauthn.Authenticator{
+				SignCount: credential.SignCount,
+			},
+		})
+	}
+
+	return credentials
+}
+
+// newWebauthnUser builds the WebAuthn user owning the given credentials. The handle of the existing credentials is
+// reused so that all the credentials of a user share the same handle, a random one is generated otherwise.
+func newWebauthnUser(username, displayName string, credentials []models.WebauthnCredential) (*webauthnUser, error) {
+	user := &webauthnUser{
+		username:    username,
+		displayName: displayName,
+		credentials: credentials,
+	}
+
+	if len(credentials) != 0 {
+		user.handle = credentials[0].UserHandle
+
+		return user, nil
+	}
+
+	user.handle = make([]byte, webauthnUserHandleLength)
+
+	if _, err := rand.Read(user.handle); err != nil {
+		return nil, fmt.Errorf("Unable to generate the WebAuthn user handle: %w", err)
+	}
+
+	return user, nil
+}
+
+// newWebauthn creates the WebAuthn relying party of the portal, identified by the domain the request was sent to.
+func newWebauthn(ctx *middlewares.AutheliaCtx) (*webauthn.WebAuthn, error) {
+	if ctx.XForwardedProto() == nil {
+		return nil, errMissingXForwardedProto
+	}
+
+	if ctx.XForwardedHost() == nil {
+		return nil, errMissingXForwardedHost
+	}
+
+	host := string(ctx.XForwardedHost())
+	rpID := host
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		rpID = hostname
+	}
+
+	// Skip Error Check since validator checks it.
+	timeout, _ := utils.ParseDurationString(ctx.Configuration.Passwordless.Timeout)
+
+	return webauthn.New(&webauthn.Config{
+		RPDisplayName:         ctx.Configuration.Passwordless.DisplayName,
+		RPID:                  rpID,
+		RPOrigin:              fmt.Sprintf("%s://%s", ctx.XForwardedProto(), host),
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeout: int(timeout.Milliseconds()),
+	})
+}
@@ -34,9 +34,11 @@ type Providers struct {
 	Regulator       *regulation.Regulator
 	OpenIDConnect   oidc.OpenIDConnectProvider
 
-	UserProvider    authentication.UserProvider
-	StorageProvider storage.Provider
-	Notifier        notification.Notifier
+	UserProvider       authentication.UserProvider
+	ClientCertificates *authentication.ClientCertificateVerifier
+	TrustedHeader      *authentication.TrustedHeaderVerifier
+	StorageProvider    storage.Provider
+	Notifier           notification.Notifier
 }
 
 // RequestHandler represents an Authelia request handler.
@@ -104,8 +104,8 @@ func NewMockAutheliaCtx(t *testing.T) *MockAutheliaCtx {
 	mockAuthelia.NotifierMock = NewMockNotifier(mockAuthelia.Ctrl)
 	providers.Notifier = mockAuthelia.NotifierMock
 
-	providers.Authorizer = authorization.NewAuthorizer(
-		&configuration)
+	providers.Authorizer = authorization.NewAuthorizerWithClock(
+		&configuration, &mockAuthelia.Clock)
 
 	providers.SessionProvider = session.NewProvider(
 		configuration.Session, nil)
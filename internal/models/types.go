@@ -11,3 +11,67 @@ type AuthenticationAttempt struct {
 	// The time of the attempt.
 	Time time.Time
 }
+
+// APIToken represent an API token issued by a user to authenticate its scripts.
+type APIToken struct {
+	// The identifier of the token, the public part of the token.
+	ID string
+	// The user who issued the token.
+	Username string
+	// The name given to the token by the user.
+	Name string
+	// The hash of the secret part of the token.
+	Hash string
+	// The domains the token can be used for.
+	Scopes []string
+	// The time the token has been issued at.
+	CreatedAt time.Time
+	// The time the token expires at.
+	ExpiresAt time.Time
+}
+
+const (
+	// RegistrationStatusPendingVerification is the status of a registration waiting for the user to verify the email.
+	RegistrationStatusPendingVerification = "pending_verification"
+
+	// RegistrationStatusPendingApproval is the status of a registration waiting for an administrator to approve it.
+	RegistrationStatusPendingApproval = "pending_approval"
+)
+
+// UserRegistration represents a user who registered but isn't created in the authentication backend yet.
+type UserRegistration struct {
+	// The username chosen by the user.
+	Username string
+	// The display name of the user.
+	DisplayName string
+	// The email address of the user.
+	Email string
+	// The hash of the password chosen by the user.
+	PasswordHash string
+	// The status of the registration.
+	Status string
+	// The time the user registered at.
+	CreatedAt time.Time
+}
+
+// WebauthnCredential represents a resident WebAuthn credential registered by a user to sign in without password.
+type WebauthnCredential struct {
+	// The identifier of the credential generated by the authenticator.
+	ID []byte
+	// The user who registered the credential.
+	Username string
+	// The opaque handle identifying the user to the authenticators.
+	UserHandle []byte
+	// The name given to the credential by the user.
+	Description string
+	// The public key of the credential.
+	PublicKey []byte
+	// The attestation format provided by the authenticator.
+	AttestationType string
+	// The signature counter of the authenticator, used to detect cloned authenticators.
+	SignCount uint32
+	// The time the credential has been registered at.
+	CreatedAt time.Time
+	// The time the credential has last been used at.
+	LastUsedAt time.Time
+}
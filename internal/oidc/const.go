@@ -8,3 +8,12 @@ var scopeDescriptions = map[string]string{
 }
 
 var audienceDescriptions = map[string]string{}
+
+// Authentication methods references, see RFC8176.
+const (
+	AMRPassword        = "pwd"
+	AMROneTimePassword = "otp"
+	AMRHardwareKey     = "hwk"
+	AMRMultipleChannel = "mca"
+	AMRMultiFactor     = "mfa"
+)
@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+)
+
+// Clone copies the session, it's used when refreshing the tokens so the session of the new tokens keeps its type.
+func (s *OpenIDSession) Clone() fosite.Session {
+	if s == nil {
+		return nil
+	}
+
+	clone := &OpenIDSession{
+		ClientID: s.ClientID,
+		AMR:      append([]string(nil), s.AMR...),
+	}
+
+	if s.DefaultSession != nil {
+		clone.DefaultSession = s.DefaultSession.Clone().(*openid.DefaultSession)
+	}
+
+	if s.Extra != nil {
+		clone.Extra = make(map[string]interface{}, len(s.Extra))
+
+		for key, value := range s.Extra {
+			clone.Extra[key] = value
+		}
+	}
+
+	return clone
+}
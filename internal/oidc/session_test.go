@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	"testing"
+
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldCloneOpenIDSession(t *testing.T) {
+	session := &OpenIDSession{
+		DefaultSession: &openid.DefaultSession{
+			Claims:  &jwt.IDTokenClaims{Subject: "john"},
+			Headers: &jwt.Headers{},
+			Subject: "john",
+		},
+		Extra:    map[string]interface{}{"key": "value"},
+		ClientID: "grafana",
+		AMR:      []string{AMRPassword, AMROneTimePassword, AMRMultiFactor},
+	}
+
+	clone, ok := session.Clone().(*OpenIDSession)
+	require.True(t, ok)
+
+	assert.Equal(t, session, clone)
+
+	clone.AMR[0] = AMRHardwareKey
+	clone.Extra["key"] = "other"
+	clone.Claims.Subject = "harry"
+
+	assert.Equal(t, AMRPassword, session.AMR[0])
+	assert.Equal(t, "value", session.Extra["key"])
+	assert.Equal(t, "john", session.Claims.Subject)
+}
@@ -116,4 +116,8 @@ type OpenIDSession struct {
 
 	Extra    map[string]interface{} `json:"extra"`
 	ClientID string
+
+	// AMR are the authentication methods references (RFC8176) of the methods the user completed to sign in before the
+	// tokens were issued.
+	AMR []string `json:"amr,omitempty"`
 }
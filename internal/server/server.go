@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/tls"
 	"embed"
 	"io/fs"
 	"io/ioutil"
@@ -17,9 +18,12 @@ import (
 	"github.com/valyala/fasthttp/expvarhandler"
 	"github.com/valyala/fasthttp/fasthttpadaptor"
 	"github.com/valyala/fasthttp/pprofhandler"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/duo"
+	"github.com/authelia/authelia/internal/extauthz"
 	"github.com/authelia/authelia/internal/handlers"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/middlewares"
@@ -32,14 +36,16 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 	autheliaMiddleware := middlewares.AutheliaMiddleware(configuration, providers)
 	rememberMe := strconv.FormatBool(configuration.Session.RememberMeDuration != "0")
 	resetPassword := strconv.FormatBool(!configuration.AuthenticationBackend.DisableResetPassword)
+	registration := strconv.FormatBool(configuration.Registration != nil)
+	passwordless := strconv.FormatBool(configuration.Passwordless != nil)
 
 	embeddedPath, _ := fs.Sub(assets, "public_html")
 	embeddedFS := fasthttpadaptor.NewFastHTTPHandler(http.FileServer(http.FS(embeddedPath)))
 	rootFiles := []string{"favicon.ico", "manifest.json", "robots.txt"}
 
-	serveIndexHandler := ServeTemplatedFile(embeddedAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, configuration.Session.Name, configuration.Theme)
-	serveSwaggerHandler := ServeTemplatedFile(swaggerAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, configuration.Session.Name, configuration.Theme)
-	serveSwaggerAPIHandler := ServeTemplatedFile(swaggerAssets, apiFile, configuration.Server.Path, rememberMe, resetPassword, configuration.Session.Name, configuration.Theme)
+	serveIndexHandler := ServeTemplatedFile(embeddedAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, registration, passwordless, configuration.Session.Name, configuration.Theme)
+	serveSwaggerHandler := ServeTemplatedFile(swaggerAssets, indexFile, configuration.Server.Path, rememberMe, resetPassword, registration, passwordless, configuration.Session.Name, configuration.Theme)
+	serveSwaggerAPIHandler := ServeTemplatedFile(swaggerAssets, apiFile, configuration.Server.Path, rememberMe, resetPassword, registration, passwordless, configuration.Session.Name, configuration.Theme)
 
 	r := router.New()
 	r.GET("/", serveIndexHandler)
@@ -63,10 +69,16 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 	r.HEAD("/api/verify", autheliaMiddleware(handlers.VerifyGet(configuration.AuthenticationBackend)))
 
 	r.POST("/api/firstfactor", autheliaMiddleware(handlers.FirstFactorPost(1000, true)))
+	r.POST("/api/firstfactor/pre_authenticated", autheliaMiddleware(handlers.FirstFactorPreAuthenticatedPost))
 	r.POST("/api/logout", autheliaMiddleware(handlers.LogoutPost))
 
 	// Only register endpoints if forgot password is not disabled.
 	if !configuration.AuthenticationBackend.DisableResetPassword {
+		// Change of the password required by the authentication backend before signing in, only LDAP reports it.
+		if configuration.AuthenticationBackend.LDAP != nil {
+			r.POST("/api/password/change", autheliaMiddleware(handlers.ChangePasswordPost(1000, true)))
+		}
+
 		// Password reset related endpoints.
 		r.POST("/api/reset-password/identity/start", autheliaMiddleware(
 			handlers.ResetPasswordIdentityStart))
@@ -76,12 +88,66 @@ func registerRoutes(configuration schema.Configuration, providers middlewares.Pr
 			handlers.ResetPasswordPost))
 	}
 
+	// Self-service registration endpoints.
+	if configuration.Registration != nil {
+		r.POST("/api/register/identity/start", autheliaMiddleware(
+			handlers.RegisterIdentityStart))
+		r.POST("/api/register/identity/finish", autheliaMiddleware(
+			handlers.RegisterIdentityFinish))
+
+		if configuration.Registration.AdminsGroup != "" {
+			r.GET("/api/admin/registrations", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.AdminRegistrationsGet)))
+			r.POST("/api/admin/registrations/{username}/approve", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.AdminRegistrationApprovePost)))
+			r.DELETE("/api/admin/registrations/{username}", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.AdminRegistrationDelete)))
+		}
+	}
+
+	// Passwordless sign in with WebAuthn resident credentials.
+	if configuration.Passwordless != nil {
+		r.POST("/api/firstfactor/passwordless/sign_request", autheliaMiddleware(
+			handlers.PasswordlessSignRequestPost))
+		r.POST("/api/firstfactor/passwordless/sign", autheliaMiddleware(
+			handlers.PasswordlessSignPost))
+
+		r.POST("/api/passwordless/identity/start", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.PasswordlessIdentityStart)))
+		r.POST("/api/passwordless/identity/finish", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.PasswordlessIdentityFinish)))
+		r.POST("/api/passwordless/register", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.PasswordlessRegisterPost)))
+
+		r.GET("/api/user/passwordless/credentials", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.UserPasswordlessCredentialsGet)))
+		r.DELETE("/api/user/passwordless/credentials/{id}", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.UserPasswordlessCredentialDelete)))
+	}
+
 	// Information about the user.
 	r.GET("/api/user/info", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.UserInfoGet)))
 	r.POST("/api/user/info/2fa_method", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.MethodPreferencePost)))
 
+	// API tokens endpoints.
+	if configuration.APITokens != nil {
+		r.GET("/api/user/tokens", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.UserAPITokensGet)))
+		r.POST("/api/user/tokens", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.UserAPITokensPost)))
+		r.DELETE("/api/user/tokens/{id}", autheliaMiddleware(
+			middlewares.RequireFirstFactor(handlers.UserAPITokenDelete)))
+
+		if configuration.APITokens.AdminsGroup != "" {
+			r.GET("/api/admin/tokens", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.AdminAPITokensGet)))
+			r.DELETE("/api/admin/tokens/{id}", autheliaMiddleware(
+				middlewares.RequireFirstFactor(handlers.AdminAPITokenDelete)))
+		}
+	}
+
 	// TOTP related endpoints.
 	r.POST("/api/secondfactor/totp/identity/start", autheliaMiddleware(
 		middlewares.RequireFirstFactor(handlers.SecondFactorTOTPIdentityStart)))
@@ -163,6 +229,10 @@ func StartServer(configuration schema.Configuration, providers middlewares.Provi
 		WriteBufferSize:       configuration.Server.WriteBufferSize,
 	}
 
+	if configuration.Server.ExtAuthz != nil {
+		go startExtAuthzServer(configuration, providers)
+	}
+
 	addrPattern := net.JoinHostPort(configuration.Host, strconv.Itoa(configuration.Port))
 
 	listener, err := net.Listen("tcp", addrPattern)
@@ -187,11 +257,65 @@ func StartServer(configuration schema.Configuration, providers middlewares.Provi
 		}
 	}
 
-	if configuration.TLSCert != "" && configuration.TLSKey != "" {
+	switch {
+	case configuration.TLSCert != "" && configuration.TLSKey != "" && providers.ClientCertificates != nil:
+		certificate, err := tls.LoadX509KeyPair(configuration.TLSCert, configuration.TLSKey)
+		if err != nil {
+			logger.Fatalf("Error loading TLS certificate: %s", err)
+		}
+
+		// The client certificates are optional so the users without certificate can still use the portal.
+		tlsConfig := &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    providers.ClientCertificates.CertificateAuthorities(),
+			MinVersion:   tls.VersionTLS12,
+		}
+
+		logger.Infof("Authelia is listening for TLS connections with client certificates on %s%s", addrPattern, configuration.Server.Path)
+		logger.Fatal(server.Serve(tls.NewListener(listener, tlsConfig)))
+	case configuration.TLSCert != "" && configuration.TLSKey != "":
 		logger.Infof("Authelia is listening for TLS connections on %s%s", addrPattern, configuration.Server.Path)
 		logger.Fatal(server.ServeTLS(listener, configuration.TLSCert, configuration.TLSKey))
-	} else {
+	default:
 		logger.Infof("Authelia is listening for non-TLS connections on %s%s", addrPattern, configuration.Server.Path)
 		logger.Fatal(server.Serve(listener))
 	}
 }
+
+// startExtAuthzServer starts the gRPC server implementing the external authorization API of Envoy, it uses the TLS
+// certificate of the HTTP server when one is configured.
+func startExtAuthzServer(configuration schema.Configuration, providers middlewares.Providers) {
+	logger := logging.Logger()
+
+	var options []grpc.ServerOption
+
+	if configuration.TLSCert != "" && configuration.TLSKey != "" {
+		tlsCredentials, err := credentials.NewServerTLSFromFile(configuration.TLSCert, configuration.TLSKey)
+		if err != nil {
+			logger.Fatalf("Error loading TLS certificate of the ext_authz server: %s", err)
+		}
+
+		options = append(options, grpc.Creds(tlsCredentials))
+	}
+
+	verifyHandler := middlewares.AutheliaMiddleware(configuration, providers)(handlers.VerifyGet(configuration.AuthenticationBackend))
+
+	extAuthzServer, err := extauthz.NewServer(*configuration.Server.ExtAuthz, configuration.AuthenticationBackend.Attributes, verifyHandler)
+	if err != nil {
+		logger.Fatalf("Error initializing ext_authz server: %s", err)
+	}
+
+	server := grpc.NewServer(options...)
+	extAuthzServer.Register(server)
+
+	addrPattern := net.JoinHostPort(configuration.Server.ExtAuthz.Host, strconv.Itoa(configuration.Server.ExtAuthz.Port))
+
+	listener, err := net.Listen("tcp", addrPattern)
+	if err != nil {
+		logger.Fatalf("Error initializing ext_authz listener: %s", err)
+	}
+
+	logger.Infof("Authelia is listening for Envoy external authorization gRPC requests on %s", addrPattern)
+	logger.Fatal(server.Serve(listener))
+}
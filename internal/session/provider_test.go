@@ -95,7 +95,7 @@ func TestShouldSetSessionAuthenticationLevels(t *testing.T) {
 		FirstFactorAuthnTimestamp: timeOneFactor.Unix(),
 	}, session)
 
-	session.SetTwoFactor(timeTwoFactor)
+	session.SetTwoFactor(timeTwoFactor, authentication.TOTP)
 
 	err = provider.SaveSession(ctx, session)
 	require.NoError(t, err)
@@ -109,6 +109,7 @@ func TestShouldSetSessionAuthenticationLevels(t *testing.T) {
 		LastActivity:               timeTwoFactor.Unix(),
 		FirstFactorAuthnTimestamp:  timeOneFactor.Unix(),
 		SecondFactorAuthnTimestamp: timeTwoFactor.Unix(),
+		SecondFactorMethods:        []string{authentication.TOTP},
 	}, session)
 
 	authAt, err = session.AuthenticatedTime(authorization.OneFactor)
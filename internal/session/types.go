@@ -3,6 +3,7 @@ package session
 import (
 	"time"
 
+	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/fasthttp/session/v2"
 	"github.com/fasthttp/session/v2/providers/redis"
 	"github.com/tstranex/u2f"
@@ -33,6 +34,9 @@ type UserSession struct {
 	Groups []string
 	Emails []string
 
+	// Attributes are the additional attributes of the user retrieved from the authentication backend.
+	Attributes map[string][]string
+
 	KeepMeLoggedIn      bool
 	AuthenticationLevel authentication.Level
 	LastActivity        int64
@@ -40,6 +44,9 @@ type UserSession struct {
 	FirstFactorAuthnTimestamp  int64
 	SecondFactorAuthnTimestamp int64
 
+	// SecondFactorMethods are the second factor methods completed by the user since the first factor.
+	SecondFactorMethods []string
+
 	// The challenge generated in first step of U2F registration (after identity verification) or authentication.
 	// This is used reused in the second phase to check that the challenge has been completed.
 	U2FChallenge *u2f.Challenge
@@ -47,6 +54,13 @@ type UserSession struct {
 	// This is used in second phase of a U2F authentication.
 	U2FRegistration *U2FRegistration
 
+	// The WebAuthn ceremony in progress, generated when registering a passwordless credential or before signing in
+	// with it and checked when the authenticator replies.
+	Webauthn *webauthn.SessionData
+
+	// Passwordless is true if the user signed in with a security key only.
+	Passwordless bool
+
 	// Represent an OIDC workflow session initiated by the client if not null.
 	OIDCWorkflowSession *OIDCWorkflowSession
 
@@ -54,9 +68,20 @@ type UserSession struct {
 	// while doing the query actually updating the password.
 	PasswordResetUsername *string
 
+	// PasswordChange is set when the authentication backend reported that the password of the user must be changed
+	// before signing in, and checked while changing the password.
+	PasswordChange *PasswordChange
+
 	RefreshTTL time.Time
 }
 
+// PasswordChange is the password change required by the authentication backend for a user who entered a correct
+// password, only allowed until it expires.
+type PasswordChange struct {
+	Username  string
+	ExpiresAt int64
+}
+
 // Identity identity of the user who is being verified.
 type Identity struct {
 	Username string
@@ -6,6 +6,7 @@ import (
 
 	"github.com/authelia/authelia/internal/authentication"
 	"github.com/authelia/authelia/internal/authorization"
+	"github.com/authelia/authelia/internal/utils"
 )
 
 // NewDefaultUserSession create a default user session.
@@ -22,6 +23,8 @@ func (s *UserSession) SetOneFactor(now time.Time, details *authentication.UserDe
 	s.FirstFactorAuthnTimestamp = now.Unix()
 	s.LastActivity = now.Unix()
 	s.AuthenticationLevel = authentication.OneFactor
+	s.SecondFactorMethods = nil
+	s.Passwordless = false
 
 	s.KeepMeLoggedIn = keepMeLoggedIn
 
@@ -29,13 +32,19 @@ func (s *UserSession) SetOneFactor(now time.Time, details *authentication.UserDe
 	s.DisplayName = details.DisplayName
 	s.Groups = details.Groups
 	s.Emails = details.Emails
+	s.Attributes = details.Attributes
 }
 
-// SetTwoFactor sets the expected property values for two factor authentication.
-func (s *UserSession) SetTwoFactor(now time.Time) {
+// SetTwoFactor sets the expected property values for two factor authentication with the given method. The method is
+// empty when the user didn't complete any second factor method of the portal.
+func (s *UserSession) SetTwoFactor(now time.Time, method string) {
 	s.SecondFactorAuthnTimestamp = now.Unix()
 	s.LastActivity = now.Unix()
 	s.AuthenticationLevel = authentication.TwoFactor
+
+	if method != "" && !utils.IsStringInSlice(method, s.SecondFactorMethods) {
+		s.SecondFactorMethods = append(s.SecondFactorMethods, method)
+	}
 }
 
 // AuthenticatedTime returns the unix timestamp this session authenticated successfully at the given level.
@@ -4,7 +4,7 @@ import (
 	"fmt"
 )
 
-const storageSchemaCurrentVersion = SchemaVersion(1)
+const storageSchemaCurrentVersion = SchemaVersion(4)
 const storageSchemaUpgradeMessage = "Storage schema upgraded to v"
 const storageSchemaUpgradeErrorText = "storage schema upgrade failed at v"
 
@@ -14,6 +14,9 @@ const identityVerificationTokensTableName = "identity_verification_tokens"
 const totpSecretsTableName = "totp_secrets"
 const u2fDeviceHandlesTableName = "u2f_devices"
 const authenticationLogsTableName = "authentication_logs"
+const apiTokensTableName = "api_tokens"
+const userRegistrationsTableName = "user_registrations"
+const webauthnCredentialsTableName = "webauthn_credentials"
 const configTableName = "config"
 
 // sqlUpgradeCreateTableStatements is a map of the schema version number, plus a map of the table name and the statement used to create it.
@@ -27,6 +30,15 @@ var sqlUpgradeCreateTableStatements = map[SchemaVersion]map[string]string{
 		authenticationLogsTableName:         "CREATE TABLE %s (username VARCHAR(100), successful BOOL, time INTEGER)",
 		configTableName:                     "CREATE TABLE %s (category VARCHAR(32) NOT NULL, key_name VARCHAR(32) NOT NULL, value TEXT, PRIMARY KEY (category, key_name))",
 	},
+	SchemaVersion(2): {
+		apiTokensTableName: "CREATE TABLE %s (id VARCHAR(32) PRIMARY KEY, username VARCHAR(100) NOT NULL, name VARCHAR(100) NOT NULL, hash VARCHAR(64) NOT NULL, scopes TEXT NOT NULL, created_at BIGINT NOT NULL, expires_at BIGINT NOT NULL)",
+	},
+	SchemaVersion(3): {
+		userRegistrationsTableName: "CREATE TABLE %s (username VARCHAR(100) PRIMARY KEY, display_name VARCHAR(100) NOT NULL, email VARCHAR(255) NOT NULL, hash TEXT NOT NULL, status VARCHAR(32) NOT NULL, created_at BIGINT NOT NULL)",
+	},
+	SchemaVersion(4): {
+		webauthnCredentialsTableName: "CREATE TABLE %s (credential_id VARCHAR(512) PRIMARY KEY, username VARCHAR(100) NOT NULL, user_handle VARCHAR(64) NOT NULL, description VARCHAR(100) NOT NULL, public_key TEXT NOT NULL, attestation_type VARCHAR(32) NOT NULL, sign_count BIGINT NOT NULL, created_at BIGINT NOT NULL, last_used_at BIGINT NOT NULL)",
+	},
 }
 
 // sqlUpgradesCreateTableIndexesStatements is a map of t he schema version number, plus a slice of statements to create all of the indexes.
@@ -34,6 +46,13 @@ var sqlUpgradesCreateTableIndexesStatements = map[SchemaVersion][]string{
 	SchemaVersion(1): {
 		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_time_idx ON %s (username, time)", authenticationLogsTableName),
 	},
+	SchemaVersion(2): {
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_api_tokens_idx ON %s (username)", apiTokensTableName),
+	},
+	SchemaVersion(4): {
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_webauthn_credentials_idx ON %s (username)", webauthnCredentialsTableName),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS handle_webauthn_credentials_idx ON %s (user_handle)", webauthnCredentialsTableName),
+	},
 }
 
 const unitTestUser = "john"
@@ -8,4 +8,10 @@ var (
 
 	// ErrNoTOTPSecret error thrown when no TOTP secret has been found in DB.
 	ErrNoTOTPSecret = errors.New("No TOTP secret registered")
+
+	// ErrNoAPIToken error thrown when no API token has been found in DB.
+	ErrNoAPIToken = errors.New("No API token found")
+
+	// ErrNoUserRegistration error thrown when no user registration has been found in DB.
+	ErrNoUserRegistration = errors.New("No user registration found")
 )
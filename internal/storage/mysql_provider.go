@@ -39,6 +39,23 @@ func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProv
 			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES (?, ?, ?)", authenticationLogsTableName),
 			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
 
+			sqlInsertAPIToken:         fmt.Sprintf("INSERT INTO %s (id, username, name, hash, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)", apiTokensTableName),
+			sqlGetAPITokenByID:        fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE id=?", apiTokensTableName),
+			sqlGetAPITokensByUsername: fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE username=? ORDER BY created_at", apiTokensTableName),
+			sqlGetAPITokens:           fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s ORDER BY username, created_at", apiTokensTableName),
+			sqlDeleteAPIToken:         fmt.Sprintf("DELETE FROM %s WHERE id=?", apiTokensTableName),
+
+			sqlUpsertUserRegistration:        fmt.Sprintf("REPLACE INTO %s (username, display_name, email, hash, status, created_at) VALUES (?, ?, ?, ?, ?, ?)", userRegistrationsTableName),
+			sqlGetUserRegistrationByUsername: fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE username=?", userRegistrationsTableName),
+			sqlGetUserRegistrationsByStatus:  fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE status=? ORDER BY created_at", userRegistrationsTableName),
+			sqlDeleteUserRegistration:        fmt.Sprintf("DELETE FROM %s WHERE username=?", userRegistrationsTableName),
+
+			sqlInsertWebauthnCredential:             fmt.Sprintf("INSERT INTO %s (credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", webauthnCredentialsTableName),
+			sqlGetWebauthnCredentialsByUsername:     fmt.Sprintf("SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE username=? ORDER BY created_at", webauthnCredentialsTableName),
+			sqlGetWebauthnCredentialsByUserHandle:   fmt.Sprintf("SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE user_handle=? ORDER BY created_at", webauthnCredentialsTableName),
+			sqlUpdateWebauthnCredentialSignCount:    fmt.Sprintf("UPDATE %s SET sign_count=?, last_used_at=? WHERE credential_id=?", webauthnCredentialsTableName),
+			sqlDeleteWebauthnCredentialByUsernameID: fmt.Sprintf("DELETE FROM %s WHERE username=? AND credential_id=?", webauthnCredentialsTableName),
+
 			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema=database()",
 
 			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", configTableName),
@@ -48,6 +65,20 @@ func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProv
 
 	provider.sqlUpgradesCreateTableStatements[SchemaVersion(1)][authenticationLogsTableName] = "CREATE TABLE %s (username VARCHAR(100), successful BOOL, time INTEGER, INDEX usr_time_idx (username, time))"
 
+	db, err := OpenMySQLDatabase(configuration)
+	if err != nil {
+		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
+	}
+
+	if err := provider.initialize(db); err != nil {
+		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
+	}
+
+	return &provider
+}
+
+// OpenMySQLDatabase opens a handle to the MySQL database described by the configuration.
+func OpenMySQLDatabase(configuration schema.MySQLStorageConfiguration) (*sql.DB, error) {
 	connectionString := configuration.Username
 
 	if configuration.Password != "" {
@@ -68,14 +99,5 @@ func NewMySQLProvider(configuration schema.MySQLStorageConfiguration) *MySQLProv
 		connectionString += fmt.Sprintf("/%s", configuration.Database)
 	}
 
-	db, err := sql.Open("mysql", connectionString)
-	if err != nil {
-		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
-	}
-
-	if err := provider.initialize(db); err != nil {
-		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
-	}
-
-	return &provider
+	return sql.Open("mysql", connectionString)
 }
@@ -41,6 +41,23 @@ func NewPostgreSQLProvider(configuration schema.PostgreSQLStorageConfiguration)
 			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES ($1, $2, $3)", authenticationLogsTableName),
 			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>$1 AND username=$2 ORDER BY time DESC", authenticationLogsTableName),
 
+			sqlInsertAPIToken:         fmt.Sprintf("INSERT INTO %s (id, username, name, hash, scopes, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)", apiTokensTableName),
+			sqlGetAPITokenByID:        fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE id=$1", apiTokensTableName),
+			sqlGetAPITokensByUsername: fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE username=$1 ORDER BY created_at", apiTokensTableName),
+			sqlGetAPITokens:           fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s ORDER BY username, created_at", apiTokensTableName),
+			sqlDeleteAPIToken:         fmt.Sprintf("DELETE FROM %s WHERE id=$1", apiTokensTableName),
+
+			sqlUpsertUserRegistration:        fmt.Sprintf("INSERT INTO %s (username, display_name, email, hash, status, created_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (username) DO UPDATE SET display_name=$2, email=$3, hash=$4, status=$5, created_at=$6", userRegistrationsTableName),
+			sqlGetUserRegistrationByUsername: fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE username=$1", userRegistrationsTableName),
+			sqlGetUserRegistrationsByStatus:  fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE status=$1 ORDER BY created_at", userRegistrationsTableName),
+			sqlDeleteUserRegistration:        fmt.Sprintf("DELETE FROM %s WHERE username=$1", userRegistrationsTableName),
+
+			sqlInsertWebauthnCredential:             fmt.Sprintf("INSERT INTO %s (credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", webauthnCredentialsTableName),
+			sqlGetWebauthnCredentialsByUsername:     fmt.Sprintf("SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE username=$1 ORDER BY created_at", webauthnCredentialsTableName),
+			sqlGetWebauthnCredentialsByUserHandle:   fmt.Sprintf("SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE user_handle=$1 ORDER BY created_at", webauthnCredentialsTableName),
+			sqlUpdateWebauthnCredentialSignCount:    fmt.Sprintf("UPDATE %s SET sign_count=$1, last_used_at=$2 WHERE credential_id=$3", webauthnCredentialsTableName),
+			sqlDeleteWebauthnCredentialByUsernameID: fmt.Sprintf("DELETE FROM %s WHERE username=$1 AND credential_id=$2", webauthnCredentialsTableName),
+
 			sqlGetExistingTables: "SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema='public'",
 
 			sqlConfigSetValue: fmt.Sprintf("INSERT INTO %s (category, key_name, value) VALUES ($1, $2, $3) ON CONFLICT (category, key_name) DO UPDATE SET value=$3", configTableName),
@@ -48,6 +65,20 @@ func NewPostgreSQLProvider(configuration schema.PostgreSQLStorageConfiguration)
 		},
 	}
 
+	db, err := OpenPostgreSQLDatabase(configuration)
+	if err != nil {
+		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
+	}
+
+	if err := provider.initialize(db); err != nil {
+		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
+	}
+
+	return &provider
+}
+
+// OpenPostgreSQLDatabase opens a handle to the PostgreSQL database described by the configuration.
+func OpenPostgreSQLDatabase(configuration schema.PostgreSQLStorageConfiguration) (*sql.DB, error) {
 	args := make([]string, 0)
 	if configuration.Username != "" {
 		args = append(args, fmt.Sprintf("user='%s'", configuration.Username))
@@ -75,14 +106,5 @@ func NewPostgreSQLProvider(configuration schema.PostgreSQLStorageConfiguration)
 
 	connectionString := strings.Join(args, " ")
 
-	db, err := sql.Open("pgx", connectionString)
-	if err != nil {
-		provider.log.Fatalf("Unable to connect to SQL database: %v", err)
-	}
-
-	if err := provider.initialize(db); err != nil {
-		provider.log.Fatalf("Unable to initialize SQL database: %v", err)
-	}
-
-	return &provider
+	return sql.Open("pgx", connectionString)
 }
@@ -25,4 +25,20 @@ type Provider interface {
 
 	AppendAuthenticationLog(attempt models.AuthenticationAttempt) error
 	LoadLatestAuthenticationLogs(username string, fromDate time.Time) ([]models.AuthenticationAttempt, error)
+
+	SaveAPIToken(token models.APIToken) error
+	LoadAPIToken(id string) (*models.APIToken, error)
+	LoadAPITokens(username string) ([]models.APIToken, error)
+	DeleteAPIToken(id string) error
+
+	SaveUserRegistration(registration models.UserRegistration) error
+	LoadUserRegistration(username string) (*models.UserRegistration, error)
+	LoadUserRegistrations(status string) ([]models.UserRegistration, error)
+	DeleteUserRegistration(username string) error
+
+	SaveWebauthnCredential(credential models.WebauthnCredential) error
+	LoadWebauthnCredentialsByUsername(username string) ([]models.WebauthnCredential, error)
+	LoadWebauthnCredentialsByUserHandle(userHandle []byte) ([]models.WebauthnCredential, error)
+	UpdateWebauthnCredentialSignCount(id []byte, signCount uint32, lastUsedAt time.Time) error
+	DeleteWebauthnCredential(username string, id []byte) error
 }
@@ -209,3 +209,191 @@ func (mr *MockProviderMockRecorder) LoadLatestAuthenticationLogs(username, fromD
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadLatestAuthenticationLogs", reflect.TypeOf((*MockProvider)(nil).LoadLatestAuthenticationLogs), username, fromDate)
 }
+
+// SaveAPIToken mocks base method
+func (m *MockProvider) SaveAPIToken(token models.APIToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAPIToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAPIToken indicates an expected call of SaveAPIToken
+func (mr *MockProviderMockRecorder) SaveAPIToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAPIToken", reflect.TypeOf((*MockProvider)(nil).SaveAPIToken), token)
+}
+
+// LoadAPIToken mocks base method
+func (m *MockProvider) LoadAPIToken(id string) (*models.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAPIToken", id)
+	ret0, _ := ret[0].(*models.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAPIToken indicates an expected call of LoadAPIToken
+func (mr *MockProviderMockRecorder) LoadAPIToken(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAPIToken", reflect.TypeOf((*MockProvider)(nil).LoadAPIToken), id)
+}
+
+// LoadAPITokens mocks base method
+func (m *MockProvider) LoadAPITokens(username string) ([]models.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAPITokens", username)
+	ret0, _ := ret[0].([]models.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAPITokens indicates an expected call of LoadAPITokens
+func (mr *MockProviderMockRecorder) LoadAPITokens(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAPITokens", reflect.TypeOf((*MockProvider)(nil).LoadAPITokens), username)
+}
+
+// DeleteAPIToken mocks base method
+func (m *MockProvider) DeleteAPIToken(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIToken", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIToken indicates an expected call of DeleteAPIToken
+func (mr *MockProviderMockRecorder) DeleteAPIToken(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIToken", reflect.TypeOf((*MockProvider)(nil).DeleteAPIToken), id)
+}
+
+// SaveUserRegistration mocks base method
+func (m *MockProvider) SaveUserRegistration(registration models.UserRegistration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUserRegistration", registration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUserRegistration indicates an expected call of SaveUserRegistration
+func (mr *MockProviderMockRecorder) SaveUserRegistration(registration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserRegistration", reflect.TypeOf((*MockProvider)(nil).SaveUserRegistration), registration)
+}
+
+// LoadUserRegistration mocks base method
+func (m *MockProvider) LoadUserRegistration(username string) (*models.UserRegistration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadUserRegistration", username)
+	ret0, _ := ret[0].(*models.UserRegistration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadUserRegistration indicates an expected call of LoadUserRegistration
+func (mr *MockProviderMockRecorder) LoadUserRegistration(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadUserRegistration", reflect.TypeOf((*MockProvider)(nil).LoadUserRegistration), username)
+}
+
+// LoadUserRegistrations mocks base method
+func (m *MockProvider) LoadUserRegistrations(status string) ([]models.UserRegistration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadUserRegistrations", status)
+	ret0, _ := ret[0].([]models.UserRegistration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadUserRegistrations indicates an expected call of LoadUserRegistrations
+func (mr *MockProviderMockRecorder) LoadUserRegistrations(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadUserRegistrations", reflect.TypeOf((*MockProvider)(nil).LoadUserRegistrations), status)
+}
+
+// DeleteUserRegistration mocks base method
+func (m *MockProvider) DeleteUserRegistration(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRegistration", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserRegistration indicates an expected call of DeleteUserRegistration
+func (mr *MockProviderMockRecorder) DeleteUserRegistration(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRegistration", reflect.TypeOf((*MockProvider)(nil).DeleteUserRegistration), username)
+}
+
+// SaveWebauthnCredential mocks base method
+func (m *MockProvider) SaveWebauthnCredential(credential models.WebauthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebauthnCredential", credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebauthnCredential indicates an expected call of SaveWebauthnCredential
+func (mr *MockProviderMockRecorder) SaveWebauthnCredential(credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebauthnCredential", reflect.TypeOf((*MockProvider)(nil).SaveWebauthnCredential), credential)
+}
+
+// LoadWebauthnCredentialsByUsername mocks base method
+func (m *MockProvider) LoadWebauthnCredentialsByUsername(username string) ([]models.WebauthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadWebauthnCredentialsByUsername", username)
+	ret0, _ := ret[0].([]models.WebauthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadWebauthnCredentialsByUsername indicates an expected call of LoadWebauthnCredentialsByUsername
+func (mr *MockProviderMockRecorder) LoadWebauthnCredentialsByUsername(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadWebauthnCredentialsByUsername", reflect.TypeOf((*MockProvider)(nil).LoadWebauthnCredentialsByUsername), username)
+}
+
+// LoadWebauthnCredentialsByUserHandle mocks base method
+func (m *MockProvider) LoadWebauthnCredentialsByUserHandle(userHandle []byte) ([]models.WebauthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadWebauthnCredentialsByUserHandle", userHandle)
+	ret0, _ := ret[0].([]models.WebauthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadWebauthnCredentialsByUserHandle indicates an expected call of LoadWebauthnCredentialsByUserHandle
+func (mr *MockProviderMockRecorder) LoadWebauthnCredentialsByUserHandle(userHandle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadWebauthnCredentialsByUserHandle", reflect.TypeOf((*MockProvider)(nil).LoadWebauthnCredentialsByUserHandle), userHandle)
+}
+
+// UpdateWebauthnCredentialSignCount mocks base method
+func (m *MockProvider) UpdateWebauthnCredentialSignCount(id []byte, signCount uint32, lastUsedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebauthnCredentialSignCount", id, signCount, lastUsedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebauthnCredentialSignCount indicates an expected call of UpdateWebauthnCredentialSignCount
+func (mr *MockProviderMockRecorder) UpdateWebauthnCredentialSignCount(id, signCount, lastUsedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebauthnCredentialSignCount", reflect.TypeOf((*MockProvider)(nil).UpdateWebauthnCredentialSignCount), id, signCount, lastUsedAt)
+}
+
+// DeleteWebauthnCredential mocks base method
+func (m *MockProvider) DeleteWebauthnCredential(username string, id []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebauthnCredential", username, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebauthnCredential indicates an expected call of DeleteWebauthnCredential
+func (mr *MockProviderMockRecorder) DeleteWebauthnCredential(username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebauthnCredential", reflect.TypeOf((*MockProvider)(nil).DeleteWebauthnCredential), username, id)
+}
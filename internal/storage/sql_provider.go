@@ -3,11 +3,14 @@ package storage
 import (
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/authelia/authelia/internal/configuration/schema"
 	"github.com/authelia/authelia/internal/logging"
 	"github.com/authelia/authelia/internal/models"
 	"github.com/authelia/authelia/internal/utils"
@@ -39,12 +42,41 @@ type SQLProvider struct {
 	sqlInsertAuthenticationLog     string
 	sqlGetLatestAuthenticationLogs string
 
+	sqlInsertAPIToken         string
+	sqlGetAPITokenByID        string
+	sqlGetAPITokensByUsername string
+	sqlGetAPITokens           string
+	sqlDeleteAPIToken         string
+
+	sqlUpsertUserRegistration        string
+	sqlGetUserRegistrationByUsername string
+	sqlGetUserRegistrationsByStatus  string
+	sqlDeleteUserRegistration        string
+
+	sqlInsertWebauthnCredential             string
+	sqlGetWebauthnCredentialsByUsername     string
+	sqlGetWebauthnCredentialsByUserHandle   string
+	sqlUpdateWebauthnCredentialSignCount    string
+	sqlDeleteWebauthnCredentialByUsernameID string
+
 	sqlGetExistingTables string
 
 	sqlConfigSetValue string
 	sqlConfigGetValue string
 }
 
+// OpenSQLDatabase opens a handle to whichever of the MySQL or PostgreSQL databases is configured.
+func OpenSQLDatabase(mysql *schema.MySQLStorageConfiguration, postgres *schema.PostgreSQLStorageConfiguration) (*sql.DB, error) {
+	switch {
+	case mysql != nil:
+		return OpenMySQLDatabase(*mysql)
+	case postgres != nil:
+		return OpenPostgreSQLDatabase(*postgres)
+	default:
+		return nil, errors.New("no SQL database configured")
+	}
+}
+
 func (p *SQLProvider) initialize(db *sql.DB) error {
 	p.db = db
 	p.log = logging.Logger()
@@ -111,6 +143,27 @@ func (p *SQLProvider) upgrade() error {
 				return p.handleUpgradeFailure(tx, 1, err)
 			}
 
+			fallthrough
+		case 1:
+			err := p.upgradeSchemaToVersion002(tx, tables)
+			if err != nil {
+				return p.handleUpgradeFailure(tx, 2, err)
+			}
+
+			fallthrough
+		case 2:
+			err := p.upgradeSchemaToVersion003(tx, tables)
+			if err != nil {
+				return p.handleUpgradeFailure(tx, 3, err)
+			}
+
+			fallthrough
+		case 3:
+			err := p.upgradeSchemaToVersion004(tx, tables)
+			if err != nil {
+				return p.handleUpgradeFailure(tx, 4, err)
+			}
+
 			fallthrough
 		default:
 			err := tx.Commit()
@@ -283,3 +336,261 @@ func (p *SQLProvider) LoadLatestAuthenticationLogs(username string, fromDate tim
 
 	return attempts, nil
 }
+
+// SaveAPIToken save an API token in the database.
+func (p *SQLProvider) SaveAPIToken(token models.APIToken) error {
+	_, err := p.db.Exec(p.sqlInsertAPIToken,
+		token.ID,
+		token.Username,
+		token.Name,
+		token.Hash,
+		strings.Join(token.Scopes, ","),
+		token.CreatedAt.Unix(),
+		token.ExpiresAt.Unix())
+
+	return err
+}
+
+// LoadAPIToken load an API token given its identifier from the database.
+func (p *SQLProvider) LoadAPIToken(id string) (*models.APIToken, error) {
+	token, err := scanAPIToken(p.db.QueryRow(p.sqlGetAPITokenByID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoAPIToken
+		}
+
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// LoadAPITokens load the API tokens issued by a given user from the database or the tokens of all the users when the
+// username is empty.
+func (p *SQLProvider) LoadAPITokens(username string) ([]models.APIToken, error) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	if username == "" {
+		rows, err = p.db.Query(p.sqlGetAPITokens)
+	} else {
+		rows, err = p.db.Query(p.sqlGetAPITokensByUsername, username)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens := make([]models.APIToken, 0, 10)
+
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// DeleteAPIToken delete an API token from the database given its identifier.
+func (p *SQLProvider) DeleteAPIToken(id string) error {
+	_, err := p.db.Exec(p.sqlDeleteAPIToken, id)
+	return err
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	var (
+		token                models.APIToken
+		scopes               string
+		createdAt, expiresAt int64
+	)
+
+	if err := row.Scan(&token.ID, &token.Username, &token.Name, &token.Hash, &scopes, &createdAt, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+
+	token.CreatedAt = time.Unix(createdAt, 0)
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return &token, nil
+}
+
+// SaveUserRegistration save a user registration in the database, replacing the previous registration of the username.
+func (p *SQLProvider) SaveUserRegistration(registration models.UserRegistration) error {
+	_, err := p.db.Exec(p.sqlUpsertUserRegistration,
+		registration.Username,
+		registration.DisplayName,
+		registration.Email,
+		registration.PasswordHash,
+		registration.Status,
+		registration.CreatedAt.Unix())
+
+	return err
+}
+
+// LoadUserRegistration load the registration of a given username from the database.
+func (p *SQLProvider) LoadUserRegistration(username string) (*models.UserRegistration, error) {
+	registration, err := scanUserRegistration(p.db.QueryRow(p.sqlGetUserRegistrationByUsername, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoUserRegistration
+		}
+
+		return nil, err
+	}
+
+	return registration, nil
+}
+
+// LoadUserRegistrations load the user registrations with the given status from the database.
+func (p *SQLProvider) LoadUserRegistrations(status string) ([]models.UserRegistration, error) {
+	rows, err := p.db.Query(p.sqlGetUserRegistrationsByStatus, status)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	registrations := make([]models.UserRegistration, 0, 10)
+
+	for rows.Next() {
+		registration, err := scanUserRegistration(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		registrations = append(registrations, *registration)
+	}
+
+	return registrations, rows.Err()
+}
+
+// DeleteUserRegistration delete the registration of a given username from the database.
+func (p *SQLProvider) DeleteUserRegistration(username string) error {
+	_, err := p.db.Exec(p.sqlDeleteUserRegistration, username)
+	return err
+}
+
+func scanUserRegistration(row rowScanner) (*models.UserRegistration, error) {
+	var (
+		registration models.UserRegistration
+		createdAt    int64
+	)
+
+	err := row.Scan(&registration.Username, &registration.DisplayName, &registration.Email, &registration.PasswordHash,
+		&registration.Status, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	registration.CreatedAt = time.Unix(createdAt, 0)
+
+	return &registration, nil
+}
+
+// SaveWebauthnCredential save a newly registered WebAuthn credential in the database.
+func (p *SQLProvider) SaveWebauthnCredential(credential models.WebauthnCredential) error {
+	_, err := p.db.Exec(p.sqlInsertWebauthnCredential,
+		base64.RawURLEncoding.EncodeToString(credential.ID),
+		credential.Username,
+		base64.RawURLEncoding.EncodeToString(credential.UserHandle),
+		credential.Description,
+		base64.StdEncoding.EncodeToString(credential.PublicKey),
+		credential.AttestationType,
+		credential.SignCount,
+		credential.CreatedAt.Unix(),
+		credential.LastUsedAt.Unix())
+
+	return err
+}
+
+// LoadWebauthnCredentialsByUsername load the WebAuthn credentials registered by a given username from the database.
+func (p *SQLProvider) LoadWebauthnCredentialsByUsername(username string) ([]models.WebauthnCredential, error) {
+	return p.loadWebauthnCredentials(p.sqlGetWebauthnCredentialsByUsername, username)
+}
+
+// LoadWebauthnCredentialsByUserHandle load the WebAuthn credentials bound to a given user handle from the database.
+func (p *SQLProvider) LoadWebauthnCredentialsByUserHandle(userHandle []byte) ([]models.WebauthnCredential, error) {
+	return p.loadWebauthnCredentials(p.sqlGetWebauthnCredentialsByUserHandle, base64.RawURLEncoding.EncodeToString(userHandle))
+}
+
+func (p *SQLProvider) loadWebauthnCredentials(query string, arg string) ([]models.WebauthnCredential, error) {
+	rows, err := p.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	credentials := make([]models.WebauthnCredential, 0, 5)
+
+	for rows.Next() {
+		credential, err := scanWebauthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = append(credentials, *credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+// UpdateWebauthnCredentialSignCount update the signature counter and the last usage time of a WebAuthn credential.
+func (p *SQLProvider) UpdateWebauthnCredentialSignCount(id []byte, signCount uint32, lastUsedAt time.Time) error {
+	_, err := p.db.Exec(p.sqlUpdateWebauthnCredentialSignCount, signCount, lastUsedAt.Unix(), base64.RawURLEncoding.EncodeToString(id))
+	return err
+}
+
+// DeleteWebauthnCredential delete a WebAuthn credential registered by a given username from the database.
+func (p *SQLProvider) DeleteWebauthnCredential(username string, id []byte) error {
+	_, err := p.db.Exec(p.sqlDeleteWebauthnCredentialByUsernameID, username, base64.RawURLEncoding.EncodeToString(id))
+	return err
+}
+
+func scanWebauthnCredential(row rowScanner) (*models.WebauthnCredential, error) {
+	var (
+		credential                models.WebauthnCredential
+		id, userHandle, publicKey string
+		createdAt, lastUsedAt     int64
+	)
+
+	err := row.Scan(&id, &credential.Username, &userHandle, &credential.Description, &publicKey,
+		&credential.AttestationType, &credential.SignCount, &createdAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.ID, err = base64.RawURLEncoding.DecodeString(id); err != nil {
+		return nil, err
+	}
+
+	if credential.UserHandle, err = base64.RawURLEncoding.DecodeString(userHandle); err != nil {
+		return nil, err
+	}
+
+	if credential.PublicKey, err = base64.StdEncoding.DecodeString(publicKey); err != nil {
+		return nil, err
+	}
+
+	credential.CreatedAt = time.Unix(createdAt, 0)
+	credential.LastUsedAt = time.Unix(lastUsedAt, 0)
+
+	return &credential, nil
+}
@@ -16,7 +16,7 @@ import (
 	"github.com/authelia/authelia/internal/models"
 )
 
-const currentSchemaMockSchemaVersion = "1"
+const currentSchemaMockSchemaVersion = "4"
 
 func TestSQLInitializeDatabase(t *testing.T) {
 	provider, mock := NewSQLMockProvider()
@@ -50,6 +50,45 @@ func TestSQLInitializeDatabase(t *testing.T) {
 		WithArgs("schema", "version", "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", apiTokensTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_api_tokens_idx ON %s .*", apiTokensTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", userRegistrationsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", webauthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_webauthn_credentials_idx ON %s .*", webauthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS handle_webauthn_credentials_idx ON %s .*", webauthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
 	mock.ExpectCommit()
 
 	err := provider.initialize(provider.db)
@@ -83,6 +122,45 @@ func TestSQLUpgradeDatabase(t *testing.T) {
 		WithArgs("schema", "version", "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", apiTokensTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_api_tokens_idx ON %s .*", apiTokensTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", userRegistrationsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "3").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE TABLE %s .*", webauthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS usr_webauthn_credentials_idx ON %s .*", webauthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS handle_webauthn_credentials_idx ON %s .*", webauthnCredentialsTableName)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(category, key_name, value\\) VALUES \\(\\?, \\?, \\?\\)", configTableName)).
+		WithArgs("schema", "version", "4").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
 	mock.ExpectCommit()
 
 	err := provider.initialize(provider.db)
@@ -100,14 +178,17 @@ func TestSQLProviderMethodsAuthenticationLogs(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
 		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
 		WithArgs(args...).
 		WillReturnRows(sqlmock.NewRows([]string{"value"}).
-			AddRow("1"))
+			AddRow(currentSchemaMockSchemaVersion))
 
 	err := provider.initialize(provider.db)
 	assert.NoError(t, err)
@@ -174,7 +255,10 @@ func TestSQLProviderMethodsPreferred(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -225,7 +309,10 @@ func TestSQLProviderMethodsTOTP(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -287,7 +374,10 @@ func TestSQLProviderMethodsU2F(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -348,7 +438,10 @@ func TestSQLProviderMethodsIdentityVerificationTokens(t *testing.T) {
 			AddRow(totpSecretsTableName).
 			AddRow(u2fDeviceHandlesTableName).
 			AddRow(authenticationLogsTableName).
-			AddRow(configTableName))
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
 
 	args := []driver.Value{"schema", "version"}
 	mock.ExpectQuery(
@@ -398,3 +491,270 @@ func TestSQLProviderMethodsIdentityVerificationTokens(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
+
+func TestSQLProviderMethodsAPITokens(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
+
+	args := []driver.Value{"schema", "version"}
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	token := models.APIToken{
+		ID:        "abc",
+		Username:  unitTestUser,
+		Name:      "backup",
+		Hash:      "0123456789abcdef",
+		Scopes:    []string{"home.example.com", "*.example.org"},
+		CreatedAt: time.Unix(1577880000, 0),
+		ExpiresAt: time.Unix(1609416000, 0),
+	}
+
+	mock.ExpectExec(
+		fmt.Sprintf("INSERT INTO %s \\(id, username, name, hash, scopes, created_at, expires_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?\\)", apiTokensTableName)).
+		WithArgs(token.ID, token.Username, token.Name, token.Hash, "home.example.com,*.example.org", int64(1577880000), int64(1609416000)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = provider.SaveAPIToken(token)
+	assert.NoError(t, err)
+
+	columns := []string{"id", "username", "name", "hash", "scopes", "created_at", "expires_at"}
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE id=\\?", apiTokensTableName)).
+		WithArgs(token.ID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(token.ID, token.Username, token.Name, token.Hash, "home.example.com,*.example.org", 1577880000, 1609416000))
+
+	loaded, err := provider.LoadAPIToken(token.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &token, loaded)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE id=\\?", apiTokensTableName)).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = provider.LoadAPIToken("unknown")
+	assert.EqualError(t, err, "No API token found")
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE username=\\? ORDER BY created_at", apiTokensTableName)).
+		WithArgs(unitTestUser).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(token.ID, token.Username, token.Name, token.Hash, "home.example.com,*.example.org", 1577880000, 1609416000))
+
+	tokens, err := provider.LoadAPITokens(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.APIToken{token}, tokens)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s ORDER BY username, created_at", apiTokensTableName)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(token.ID, token.Username, token.Name, token.Hash, "home.example.com,*.example.org", 1577880000, 1609416000).
+			AddRow("def", "harry", "ci", "fedcba9876543210", "", 1577880000, 1609416000))
+
+	tokens, err = provider.LoadAPITokens("")
+	assert.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "harry", tokens[1].Username)
+	assert.Nil(t, tokens[1].Scopes)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE id=\\?", apiTokensTableName)).
+		WithArgs(token.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.DeleteAPIToken(token.ID)
+	assert.NoError(t, err)
+}
+
+func TestSQLProviderMethodsUserRegistrations(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
+
+	args := []driver.Value{"schema", "version"}
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	registration := models.UserRegistration{
+		Username:     unitTestUser,
+		DisplayName:  "John Doe",
+		Email:        "john@example.com",
+		PasswordHash: "$6$rounds=50000$aFr56HjK3DrB8t3S$zhPQiS85cgBlNhUKKE6n/AHMlpqrvYSnSL3fEVkK0yHFQ.oFFAd8D4OhPAy18K5U61Z2eBhxQXExGU/eknXlY1",
+		Status:       models.RegistrationStatusPendingApproval,
+		CreatedAt:    time.Unix(1577880000, 0),
+	}
+
+	mock.ExpectExec(
+		fmt.Sprintf("REPLACE INTO %s \\(username, display_name, email, hash, status, created_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?\\)", userRegistrationsTableName)).
+		WithArgs(registration.Username, registration.DisplayName, registration.Email, registration.PasswordHash, registration.Status, int64(1577880000)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = provider.SaveUserRegistration(registration)
+	assert.NoError(t, err)
+
+	columns := []string{"username", "display_name", "email", "hash", "status", "created_at"}
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE username=\\?", userRegistrationsTableName)).
+		WithArgs(unitTestUser).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(registration.Username, registration.DisplayName, registration.Email, registration.PasswordHash, registration.Status, 1577880000))
+
+	loaded, err := provider.LoadUserRegistration(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, &registration, loaded)
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE username=\\?", userRegistrationsTableName)).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = provider.LoadUserRegistration("unknown")
+	assert.EqualError(t, err, "No user registration found")
+
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE status=\\? ORDER BY created_at", userRegistrationsTableName)).
+		WithArgs(models.RegistrationStatusPendingApproval).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(registration.Username, registration.DisplayName, registration.Email, registration.PasswordHash, registration.Status, 1577880000))
+
+	registrations, err := provider.LoadUserRegistrations(models.RegistrationStatusPendingApproval)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UserRegistration{registration}, registrations)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE username=\\?", userRegistrationsTableName)).
+		WithArgs(unitTestUser).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.DeleteUserRegistration(unitTestUser)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLProviderMethodsWebauthnCredentials(t *testing.T) {
+	provider, mock := NewSQLMockProvider()
+
+	mock.ExpectQuery(
+		"SELECT name FROM sqlite_master WHERE type='table'").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).
+			AddRow(userPreferencesTableName).
+			AddRow(identityVerificationTokensTableName).
+			AddRow(totpSecretsTableName).
+			AddRow(u2fDeviceHandlesTableName).
+			AddRow(authenticationLogsTableName).
+			AddRow(configTableName).
+			AddRow(apiTokensTableName).
+			AddRow(userRegistrationsTableName).
+			AddRow(webauthnCredentialsTableName))
+
+	args := []driver.Value{"schema", "version"}
+	mock.ExpectQuery(
+		fmt.Sprintf("SELECT value FROM %s WHERE category=\\? AND key_name=\\?", configTableName)).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).
+			AddRow(currentSchemaMockSchemaVersion))
+
+	err := provider.initialize(provider.db)
+	assert.NoError(t, err)
+
+	credential := models.WebauthnCredential{
+		ID:              []byte("credential-id"),
+		Username:        unitTestUser,
+		UserHandle:      []byte("user-handle"),
+		Description:     "YubiKey",
+		PublicKey:       []byte("public-key"),
+		AttestationType: "none",
+		SignCount:       12,
+		CreatedAt:       time.Unix(1577880000, 0),
+		LastUsedAt:      time.Unix(1577880000, 0),
+	}
+
+	mock.ExpectExec(
+		fmt.Sprintf("INSERT INTO %s \\(credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)", webauthnCredentialsTableName)).
+		WithArgs("Y3JlZGVudGlhbC1pZA", unitTestUser, "dXNlci1oYW5kbGU", "YubiKey", "cHVibGljLWtleQ==", "none", 12, int64(1577880000), int64(1577880000)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = provider.SaveWebauthnCredential(credential)
+	assert.NoError(t, err)
+
+	columns := []string{"credential_id", "username", "user_handle", "description", "public_key", "attestation_type", "sign_count", "created_at", "last_used_at"}
+	query := "SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE %s=\\? ORDER BY created_at"
+
+	mock.ExpectQuery(
+		fmt.Sprintf(query, webauthnCredentialsTableName, "username")).
+		WithArgs(unitTestUser).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("Y3JlZGVudGlhbC1pZA", unitTestUser, "dXNlci1oYW5kbGU", "YubiKey", "cHVibGljLWtleQ==", "none", 12, 1577880000, 1577880000))
+
+	credentials, err := provider.LoadWebauthnCredentialsByUsername(unitTestUser)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebauthnCredential{credential}, credentials)
+
+	mock.ExpectQuery(
+		fmt.Sprintf(query, webauthnCredentialsTableName, "user_handle")).
+		WithArgs("dXNlci1oYW5kbGU").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("Y3JlZGVudGlhbC1pZA", unitTestUser, "dXNlci1oYW5kbGU", "YubiKey", "cHVibGljLWtleQ==", "none", 12, 1577880000, 1577880000))
+
+	credentials, err = provider.LoadWebauthnCredentialsByUserHandle([]byte("user-handle"))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebauthnCredential{credential}, credentials)
+
+	mock.ExpectExec(
+		fmt.Sprintf("UPDATE %s SET sign_count=\\?, last_used_at=\\? WHERE credential_id=\\?", webauthnCredentialsTableName)).
+		WithArgs(13, int64(1577890000), "Y3JlZGVudGlhbC1pZA").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.UpdateWebauthnCredentialSignCount(credential.ID, 13, time.Unix(1577890000, 0))
+	assert.NoError(t, err)
+
+	mock.ExpectExec(
+		fmt.Sprintf("DELETE FROM %s WHERE username=\\? AND credential_id=\\?", webauthnCredentialsTableName)).
+		WithArgs(unitTestUser, "Y3JlZGVudGlhbC1pZA").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = provider.DeleteWebauthnCredential(unitTestUser, credential.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
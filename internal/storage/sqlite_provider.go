@@ -38,6 +38,23 @@ func NewSQLiteProvider(path string) *SQLiteProvider {
 			sqlInsertAuthenticationLog:     fmt.Sprintf("INSERT INTO %s (username, successful, time) VALUES (?, ?, ?)", authenticationLogsTableName),
 			sqlGetLatestAuthenticationLogs: fmt.Sprintf("SELECT successful, time FROM %s WHERE time>? AND username=? ORDER BY time DESC", authenticationLogsTableName),
 
+			sqlInsertAPIToken:         fmt.Sprintf("INSERT INTO %s (id, username, name, hash, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)", apiTokensTableName),
+			sqlGetAPITokenByID:        fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE id=?", apiTokensTableName),
+			sqlGetAPITokensByUsername: fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s WHERE username=? ORDER BY created_at", apiTokensTableName),
+			sqlGetAPITokens:           fmt.Sprintf("SELECT id, username, name, hash, scopes, created_at, expires_at FROM %s ORDER BY username, created_at", apiTokensTableName),
+			sqlDeleteAPIToken:         fmt.Sprintf("DELETE FROM %s WHERE id=?", apiTokensTableName),
+
+			sqlUpsertUserRegistration:        fmt.Sprintf("REPLACE INTO %s (username, display_name, email, hash, status, created_at) VALUES (?, ?, ?, ?, ?, ?)", userRegistrationsTableName),
+			sqlGetUserRegistrationByUsername: fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE username=?", userRegistrationsTableName),
+			sqlGetUserRegistrationsByStatus:  fmt.Sprintf("SELECT username, display_name, email, hash, status, created_at FROM %s WHERE status=? ORDER BY created_at", userRegistrationsTableName),
+			sqlDeleteUserRegistration:        fmt.Sprintf("DELETE FROM %s WHERE username=?", userRegistrationsTableName),
+
+			sqlInsertWebauthnCredential:             fmt.Sprintf("INSERT INTO %s (credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", webauthnCredentialsTableName),
+			sqlGetWebauthnCredentialsByUsername:     fmt.Sprintf("SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE username=? ORDER BY created_at", webauthnCredentialsTableName),
+			sqlGetWebauthnCredentialsByUserHandle:   fmt.Sprintf("SELECT credential_id, username, user_handle, description, public_key, attestation_type, sign_count, created_at, last_used_at FROM %s WHERE user_handle=? ORDER BY created_at", webauthnCredentialsTableName),
+			sqlUpdateWebauthnCredentialSignCount:    fmt.Sprintf("UPDATE %s SET sign_count=?, last_used_at=? WHERE credential_id=?", webauthnCredentialsTableName),
+			sqlDeleteWebauthnCredentialByUsernameID: fmt.Sprintf("DELETE FROM %s WHERE username=? AND credential_id=?", webauthnCredentialsTableName),
+
 			sqlGetExistingTables: "SELECT name FROM sqlite_master WHERE type='table'",
 
 			sqlConfigSetValue: fmt.Sprintf("REPLACE INTO %s (category, key_name, value) VALUES (?, ?, ?)", configTableName),
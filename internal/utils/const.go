@@ -40,6 +40,12 @@ const (
 	// Month is an int based representation of the time unit.
 	Month = Year / 12
 
+	// TimeOfDayLayout is the layout of a time of day in the 24-hour notation.
+	TimeOfDayLayout = "15:04"
+
+	// DateLayout is the layout of a date without a time.
+	DateLayout = "2006-01-02"
+
 	clean   = "clean"
 	tagged  = "tagged"
 	unknown = "unknown"
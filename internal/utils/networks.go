@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// ParseNetwork parses an IP or a network in CIDR notation, an IP is parsed as the network containing only this IP.
+func ParseNetwork(network string) (*net.IPNet, error) {
+	if !strings.Contains(network, "/") {
+		if ip := net.ParseIP(network); ip != nil && ip.To4() != nil {
+			network += "/32"
+		} else {
+			network += "/128"
+		}
+	}
+
+	_, ipNet, err := net.ParseCIDR(network)
+
+	return ipNet, err
+}
+
+// IsIPInNetworks returns true if the IP belongs to one of the networks.
+func IsIPInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
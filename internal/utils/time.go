@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -49,3 +50,42 @@ func ParseDurationString(input string) (time.Duration, error) {
 
 	return duration, nil
 }
+
+// ParseTimeOfDay parses a time of day in the 24-hour notation (e.g. 08:30) to the duration elapsed since midnight.
+func ParseTimeOfDay(input string) (time.Duration, error) {
+	t, err := time.Parse(TimeOfDayLayout, input)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert the input string of %s into a time of day, it must be in the format HH:MM", input)
+	}
+
+	return time.Duration(t.Hour())*Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseWeekday parses the english name or the three letter abbreviation of a day of the week, case insensitively.
+func ParseWeekday(input string) (time.Weekday, error) {
+	name := strings.ToLower(input)
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		dayName := strings.ToLower(day.String())
+
+		if name == dayName || name == dayName[:3] {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not convert the input string of %s into a day of the week", input)
+}
+
+// ParseDate parses either a date (e.g. 2021-06-30) in the given location or an RFC3339 timestamp. The returned boolean
+// is true when the input is a date without a time.
+func ParseDate(input string, location *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.ParseInLocation(DateLayout, input, location); err == nil {
+		return t, true, nil
+	}
+
+	if t, err = time.Parse(time.RFC3339, input); err == nil {
+		return t, false, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("could not convert the input string of %s into a date, it must be in the format YYYY-MM-DD or RFC3339", input)
+}
@@ -76,3 +76,49 @@ func TestShouldTimeIntervalsMakeSense(t *testing.T) {
 	assert.Equal(t, Year, Day*365)
 	assert.Equal(t, Month, Year/12)
 }
+
+func TestShouldParseTimeOfDay(t *testing.T) {
+	timeOfDay, err := ParseTimeOfDay("08:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, timeOfDay)
+
+	timeOfDay, err = ParseTimeOfDay("00:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), timeOfDay)
+
+	_, err = ParseTimeOfDay("8am")
+	assert.EqualError(t, err, "could not convert the input string of 8am into a time of day, it must be in the format HH:MM")
+
+	_, err = ParseTimeOfDay("24:00")
+	assert.Error(t, err)
+}
+
+func TestShouldParseWeekday(t *testing.T) {
+	day, err := ParseWeekday("Monday")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Monday, day)
+
+	day, err = ParseWeekday("sun")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Sunday, day)
+
+	_, err = ParseWeekday("weekend")
+	assert.EqualError(t, err, "could not convert the input string of weekend into a day of the week")
+}
+
+func TestShouldParseDate(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+
+	date, dateOnly, err := ParseDate("2021-06-30", location)
+	assert.NoError(t, err)
+	assert.True(t, dateOnly)
+	assert.Equal(t, time.Date(2021, 6, 30, 0, 0, 0, 0, location), date)
+
+	date, dateOnly, err = ParseDate("2021-06-30T18:00:00Z", location)
+	assert.NoError(t, err)
+	assert.False(t, dateOnly)
+	assert.True(t, time.Date(2021, 6, 30, 18, 0, 0, 0, time.UTC).Equal(date))
+
+	_, _, err = ParseDate("30/06/2021", location)
+	assert.EqualError(t, err, "could not convert the input string of 30/06/2021 into a date, it must be in the format YYYY-MM-DD or RFC3339")
+}
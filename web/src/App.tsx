@@ -9,8 +9,12 @@ import {
     FirstFactorRoute,
     ResetPasswordStep2Route,
     ResetPasswordStep1Route,
+    RegisterStep1Route,
+    RegisterStep2Route,
+    ChangePasswordRoute,
     RegisterSecurityKeyRoute,
     RegisterOneTimePasswordRoute,
+    RegisterPasswordlessRoute,
     LogoutRoute,
     ConsentRoute,
 } from "@constants/Routes";
@@ -18,12 +22,16 @@ import NotificationsContext from "@hooks/NotificationsContext";
 import { Notification } from "@models/Notifications";
 import * as themes from "@themes/index";
 import { getBasePath } from "@utils/BasePath";
-import { getRememberMe, getResetPassword, getTheme } from "@utils/Configuration";
+import { getPasswordless, getRegistration, getRememberMe, getResetPassword, getTheme } from "@utils/Configuration";
+import ChangePassword from "@views/ChangePassword/ChangePassword";
 import RegisterOneTimePassword from "@views/DeviceRegistration/RegisterOneTimePassword";
+import RegisterPasswordless from "@views/DeviceRegistration/RegisterPasswordless";
 import RegisterSecurityKey from "@views/DeviceRegistration/RegisterSecurityKey";
 import ConsentView from "@views/LoginPortal/ConsentView/ConsentView";
 import LoginPortal from "@views/LoginPortal/LoginPortal";
 import SignOut from "@views/LoginPortal/SignOut/SignOut";
+import RegisterStep1 from "@views/Register/RegisterStep1";
+import RegisterStep2 from "@views/Register/RegisterStep2";
 import ResetPasswordStep1 from "@views/ResetPassword/ResetPasswordStep1";
 import ResetPasswordStep2 from "@views/ResetPassword/ResetPasswordStep2";
 
@@ -71,12 +79,24 @@ const App: React.FC = () => {
                         <Route path={ResetPasswordStep2Route} exact>
                             <ResetPasswordStep2 />
                         </Route>
+                        <Route path={RegisterStep1Route} exact>
+                            <RegisterStep1 />
+                        </Route>
+                        <Route path={RegisterStep2Route} exact>
+                            <RegisterStep2 />
+                        </Route>
+                        <Route path={ChangePasswordRoute} exact>
+                            <ChangePassword />
+                        </Route>
                         <Route path={RegisterSecurityKeyRoute} exact>
                             <RegisterSecurityKey />
                         </Route>
                         <Route path={RegisterOneTimePasswordRoute} exact>
                             <RegisterOneTimePassword />
                         </Route>
+                        <Route path={RegisterPasswordlessRoute} exact>
+                            <RegisterPasswordless />
+                        </Route>
                         <Route path={LogoutRoute} exact>
                             <SignOut />
                         </Route>
@@ -84,7 +104,12 @@ const App: React.FC = () => {
                             <ConsentView />
                         </Route>
                         <Route path={FirstFactorRoute}>
-                            <LoginPortal rememberMe={getRememberMe()} resetPassword={getResetPassword()} />
+                            <LoginPortal
+                                rememberMe={getRememberMe()}
+                                resetPassword={getResetPassword()}
+                                registration={getRegistration()}
+                                passwordless={getPasswordless()}
+                            />
                         </Route>
                         <Route path="/">
                             <Redirect to={FirstFactorRoute} />
@@ -9,6 +9,10 @@ export const SecondFactorPushRoute: string = "/2fa/push-notification";
 
 export const ResetPasswordStep1Route: string = "/reset-password/step1";
 export const ResetPasswordStep2Route: string = "/reset-password/step2";
+export const RegisterStep1Route: string = "/register/step1";
+export const RegisterStep2Route: string = "/register/step2";
+export const ChangePasswordRoute: string = "/change-password";
 export const RegisterSecurityKeyRoute: string = "/security-key/register";
 export const RegisterOneTimePasswordRoute: string = "/one-time-password/register";
+export const RegisterPasswordlessRoute: string = "/passwordless/register";
 export const LogoutRoute: string = "/logout";
@@ -0,0 +1,14 @@
+import queryString from "query-string";
+import { useLocation } from "react-router";
+
+export function useReauthentication() {
+    const location = useLocation();
+    const queryParams = queryString.parse(location.search);
+    return queryParams && "reauth" in queryParams ? (queryParams["reauth"] as string) : undefined;
+}
+
+export function useSecondFactorMethods() {
+    const location = useLocation();
+    const queryParams = queryString.parse(location.search);
+    return queryParams && "methods" in queryParams ? (queryParams["methods"] as string) : undefined;
+}
@@ -8,6 +8,7 @@ const basePath = getBasePath();
 export const ConsentPath = basePath + "/api/oidc/consent";
 
 export const FirstFactorPath = basePath + "/api/firstfactor";
+export const FirstFactorPreAuthenticatedPath = basePath + "/api/firstfactor/pre_authenticated";
 export const InitiateTOTPRegistrationPath = basePath + "/api/secondfactor/totp/identity/start";
 export const CompleteTOTPRegistrationPath = basePath + "/api/secondfactor/totp/identity/finish";
 
@@ -26,6 +27,25 @@ export const CompleteResetPasswordPath = basePath + "/api/reset-password/identit
 // Do the password reset during completion.
 export const ResetPasswordPath = basePath + "/api/reset-password";
 
+export const InitiatePasswordlessSignInPath = basePath + "/api/firstfactor/passwordless/sign_request";
+export const CompletePasswordlessSignInPath = basePath + "/api/firstfactor/passwordless/sign";
+
+export const InitiatePasswordlessRegistrationPath = basePath + "/api/passwordless/identity/start";
+export const CompletePasswordlessRegistrationStep1Path = basePath + "/api/passwordless/identity/finish";
+export const CompletePasswordlessRegistrationStep2Path = basePath + "/api/passwordless/register";
+
+export const InitiateRegistrationPath = basePath + "/api/register/identity/start";
+export const CompleteRegistrationPath = basePath + "/api/register/identity/finish";
+
+// Note: If you change this const you must also do so in the backend at internal/handlers/const.go.
+export const RegistrationDomainNotAllowedMessage = "Registrations are restricted to some email domains.";
+
+// Change the password given the current password when the authentication backend requires it.
+export const ChangePasswordPath = basePath + "/api/password/change";
+
+// Note: If you change this const you must also do so in the backend at internal/handlers/const.go.
+export const PasswordChangeRequiredMessage = "Your password must be changed.";
+
 export const LogoutPath = basePath + "/api/logout";
 export const StatePath = basePath + "/api/state";
 export const UserInfoPath = basePath + "/api/user/info";
@@ -0,0 +1,6 @@
+import { ChangePasswordPath } from "@services/Api";
+import { PostWithOptionalResponse } from "@services/Client";
+
+export async function changePassword(username: string, password: string, newPassword: string) {
+    return PostWithOptionalResponse(ChangePasswordPath, { username, password, new_password: newPassword });
+}
@@ -1,4 +1,4 @@
-import { FirstFactorPath } from "@services/Api";
+import { FirstFactorPath, FirstFactorPreAuthenticatedPath } from "@services/Api";
 import { PostWithOptionalResponse } from "@services/Client";
 import { SignInResponse } from "@services/SignIn";
 
@@ -34,3 +34,8 @@ export async function postFirstFactor(
     const res = await PostWithOptionalResponse<SignInResponse>(FirstFactorPath, data);
     return res ? res : ({} as SignInResponse);
 }
+
+// Signs in the user forwarded by a trusted gateway or presenting a client certificate, if any.
+export async function postFirstFactorPreAuthenticated() {
+    await PostWithOptionalResponse(FirstFactorPreAuthenticatedPath);
+}
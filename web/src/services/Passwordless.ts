@@ -0,0 +1,133 @@
+import {
+    InitiatePasswordlessSignInPath,
+    CompletePasswordlessSignInPath,
+    InitiatePasswordlessRegistrationPath,
+    CompletePasswordlessRegistrationStep1Path,
+    CompletePasswordlessRegistrationStep2Path,
+} from "@services/Api";
+import { Post, PostWithOptionalResponse } from "@services/Client";
+import { SignInResponse } from "@services/SignIn";
+
+// The binary members of the WebAuthn options are sent either in base64 or in base64url by the backend.
+function decodeBase64(value: string): ArrayBuffer {
+    const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+    const padded = base64 + "=".repeat((4 - (base64.length % 4)) % 4);
+    return Uint8Array.from(atob(padded), (c) => c.charCodeAt(0)).buffer;
+}
+
+function encodeBase64URL(value: ArrayBuffer): string {
+    const binary = String.fromCharCode(...Array.from(new Uint8Array(value)));
+    return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+export function isPasswordlessSupported() {
+    return window.PublicKeyCredential !== undefined && navigator.credentials !== undefined;
+}
+
+interface CredentialDescriptor {
+    type: PublicKeyCredentialType;
+    id: string;
+}
+
+interface CredentialCreationOptions {
+    publicKey: {
+        challenge: string;
+        rp: PublicKeyCredentialRpEntity;
+        user: {
+            id: string;
+            name: string;
+            displayName: string;
+        };
+        pubKeyCredParams: PublicKeyCredentialParameters[];
+        authenticatorSelection?: AuthenticatorSelectionCriteria;
+        timeout?: number;
+        excludeCredentials?: CredentialDescriptor[];
+        attestation?: AttestationConveyancePreference;
+    };
+}
+
+interface CredentialRequestOptions {
+    publicKey: {
+        challenge: string;
+        timeout?: number;
+        rpId?: string;
+        userVerification?: UserVerificationRequirement;
+    };
+}
+
+export async function initiatePasswordlessRegistrationProcess() {
+    return PostWithOptionalResponse(InitiatePasswordlessRegistrationPath);
+}
+
+export async function completePasswordlessRegistrationProcess(processToken: string, description: string) {
+    const options = await Post<CredentialCreationOptions>(CompletePasswordlessRegistrationStep1Path, {
+        token: processToken,
+    });
+
+    const credential = (await navigator.credentials.create({
+        publicKey: {
+            ...options.publicKey,
+            challenge: decodeBase64(options.publicKey.challenge),
+            user: { ...options.publicKey.user, id: decodeBase64(options.publicKey.user.id) },
+            excludeCredentials: (options.publicKey.excludeCredentials || []).map((c) => ({
+                type: c.type,
+                id: decodeBase64(c.id),
+            })),
+        },
+    })) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error("No credential has been created by the authenticator");
+    }
+
+    const response = credential.response as AuthenticatorAttestationResponse;
+    return PostWithOptionalResponse(CompletePasswordlessRegistrationStep2Path, {
+        description,
+        credential: {
+            id: credential.id,
+            rawId: encodeBase64URL(credential.rawId),
+            type: credential.type,
+            response: {
+                clientDataJSON: encodeBase64URL(response.clientDataJSON),
+                attestationObject: encodeBase64URL(response.attestationObject),
+            },
+        },
+    });
+}
+
+interface CompletePasswordlessSigninBody {
+    credential: object;
+    targetURL?: string;
+}
+
+export async function performPasswordlessSignIn(targetURL: string | undefined) {
+    const options = await Post<CredentialRequestOptions>(InitiatePasswordlessSignInPath);
+
+    const credential = (await navigator.credentials.get({
+        publicKey: {
+            ...options.publicKey,
+            challenge: decodeBase64(options.publicKey.challenge),
+        },
+    })) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error("No credential has been returned by the authenticator");
+    }
+
+    const response = credential.response as AuthenticatorAssertionResponse;
+    const body: CompletePasswordlessSigninBody = {
+        credential: {
+            id: credential.id,
+            rawId: encodeBase64URL(credential.rawId),
+            type: credential.type,
+            response: {
+                clientDataJSON: encodeBase64URL(response.clientDataJSON),
+                authenticatorData: encodeBase64URL(response.authenticatorData),
+                signature: encodeBase64URL(response.signature),
+                userHandle: response.userHandle ? encodeBase64URL(response.userHandle) : undefined,
+            },
+        },
+    };
+    if (targetURL) {
+        body.targetURL = targetURL;
+    }
+    return PostWithOptionalResponse<SignInResponse>(CompletePasswordlessSignInPath, body);
+}
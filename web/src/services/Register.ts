@@ -0,0 +1,20 @@
+import { InitiateRegistrationPath, CompleteRegistrationPath } from "@services/Api";
+import { Post, PostWithOptionalResponse } from "@services/Client";
+
+interface RegistrationCompletedResponse {
+    approval_required: boolean;
+}
+
+export async function initiateRegistrationProcess(
+    username: string,
+    displayName: string,
+    email: string,
+    password: string,
+) {
+    return PostWithOptionalResponse(InitiateRegistrationPath, { username, display_name: displayName, email, password });
+}
+
+export async function completeRegistrationProcess(token: string) {
+    const res = await Post<RegistrationCompletedResponse>(CompleteRegistrationPath, { token });
+    return { approvalRequired: res.approval_required };
+}
@@ -15,6 +15,14 @@ export function getResetPassword() {
     return getEmbeddedVariable("resetpassword") === "true";
 }
 
+export function getRegistration() {
+    return getEmbeddedVariable("registration") === "true";
+}
+
+export function getPasswordless() {
+    return getEmbeddedVariable("passwordless") === "true";
+}
+
 export function getTheme() {
     return getEmbeddedVariable("theme");
 }
@@ -0,0 +1,151 @@
+import React, { useState } from "react";
+
+import { Grid, Button, makeStyles } from "@material-ui/core";
+import classnames from "classnames";
+import queryString from "query-string";
+import { useHistory, useLocation } from "react-router";
+
+import FixedTextField from "@components/FixedTextField";
+import { FirstFactorRoute } from "@constants/Routes";
+import { useNotifications } from "@hooks/NotificationsContext";
+import LoginLayout from "@layouts/LoginLayout";
+import { changePassword } from "@services/ChangePassword";
+
+const ChangePassword = function () {
+    const style = useStyles();
+    const location = useLocation();
+    const history = useHistory();
+    const [formDisabled, setFormDisabled] = useState(false);
+    const [password, setPassword] = useState("");
+    const [password1, setPassword1] = useState("");
+    const [password2, setPassword2] = useState("");
+    const [errorPassword, setErrorPassword] = useState(false);
+    const [errorPassword1, setErrorPassword1] = useState(false);
+    const [errorPassword2, setErrorPassword2] = useState(false);
+    const { createSuccessNotification, createErrorNotification } = useNotifications();
+
+    const queryParams = queryString.parse(location.search);
+    const username = queryParams && "username" in queryParams ? (queryParams["username"] as string) : "";
+
+    const doChangePassword = async () => {
+        if (password === "" || password1 === "" || password2 === "") {
+            setErrorPassword(password === "");
+            setErrorPassword1(password1 === "");
+            setErrorPassword2(password2 === "");
+            return;
+        }
+        if (password1 !== password2) {
+            setErrorPassword1(true);
+            setErrorPassword2(true);
+            createErrorNotification("Passwords do not match.");
+            return;
+        }
+
+        try {
+            setFormDisabled(true);
+            await changePassword(username, password, password1);
+            createSuccessNotification("Password has been changed, sign in with your new password.");
+            setTimeout(() => history.push(FirstFactorRoute), 1500);
+        } catch (err) {
+            console.error(err);
+            setFormDisabled(false);
+            if (err.message.includes("0000052D.")) {
+                createErrorNotification("Your supplied password does not meet the password policy requirements.");
+            } else {
+                createErrorNotification("There was an issue changing the password.");
+            }
+        }
+    };
+
+    const handleCancelClick = () => history.push(FirstFactorRoute);
+
+    return (
+        <LoginLayout title="Your password must be changed" id="change-password-stage">
+            <Grid container className={style.root} spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="password-textfield"
+                        label="Current password"
+                        variant="outlined"
+                        type="password"
+                        value={password}
+                        disabled={formDisabled}
+                        onChange={(e) => setPassword(e.target.value)}
+                        error={errorPassword}
+                        className={classnames(style.fullWidth)}
+                        autoComplete="current-password"
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="password1-textfield"
+                        label="New password"
+                        variant="outlined"
+                        type="password"
+                        value={password1}
+                        disabled={formDisabled}
+                        onChange={(e) => setPassword1(e.target.value)}
+                        error={errorPassword1}
+                        className={classnames(style.fullWidth)}
+                        autoComplete="new-password"
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="password2-textfield"
+                        label="Repeat new password"
+                        variant="outlined"
+                        type="password"
+                        value={password2}
+                        disabled={formDisabled}
+                        onChange={(e) => setPassword2(e.target.value)}
+                        error={errorPassword2}
+                        onKeyPress={(ev) => {
+                            if (ev.key === "Enter") {
+                                doChangePassword();
+                                ev.preventDefault();
+                            }
+                        }}
+                        className={classnames(style.fullWidth)}
+                        autoComplete="new-password"
+                    />
+                </Grid>
+                <Grid item xs={6}>
+                    <Button
+                        id="change-button"
+                        variant="contained"
+                        color="primary"
+                        disabled={formDisabled}
+                        onClick={doChangePassword}
+                        className={style.fullWidth}
+                    >
+                        Change
+                    </Button>
+                </Grid>
+                <Grid item xs={6}>
+                    <Button
+                        id="cancel-button"
+                        variant="contained"
+                        color="primary"
+                        onClick={handleCancelClick}
+                        className={style.fullWidth}
+                    >
+                        Cancel
+                    </Button>
+                </Grid>
+            </Grid>
+        </LoginLayout>
+    );
+};
+
+export default ChangePassword;
+
+const useStyles = makeStyles((theme) => ({
+    root: {
+        marginTop: theme.spacing(2),
+        marginBottom: theme.spacing(2),
+    },
+    fullWidth: {
+        width: "100%",
+    },
+}));
@@ -0,0 +1,100 @@
+import React, { useState } from "react";
+
+import { makeStyles, Typography, Button, Grid } from "@material-ui/core";
+import { useHistory, useLocation } from "react-router";
+
+import FingerTouchIcon from "@components/FingerTouchIcon";
+import FixedTextField from "@components/FixedTextField";
+import { useNotifications } from "@hooks/NotificationsContext";
+import LoginLayout from "@layouts/LoginLayout";
+import { FirstFactorPath } from "@services/Api";
+import { completePasswordlessRegistrationProcess } from "@services/Passwordless";
+import { extractIdentityToken } from "@utils/IdentityToken";
+
+const RegisterPasswordless = function () {
+    const style = useStyles();
+    const history = useHistory();
+    const location = useLocation();
+    const { createSuccessNotification, createErrorNotification } = useNotifications();
+    const [description, setDescription] = useState("");
+    const [registrationInProgress, setRegistrationInProgress] = useState(false);
+
+    const processToken = extractIdentityToken(location.search);
+
+    const handleBackClick = () => {
+        history.push(FirstFactorPath);
+    };
+
+    // The authenticator is only called upon a user gesture since some browsers require it.
+    const handleRegisterClick = async () => {
+        if (!processToken) {
+            createErrorNotification("No verification token provided");
+            return;
+        }
+        try {
+            setRegistrationInProgress(true);
+            await completePasswordlessRegistrationProcess(processToken, description);
+            createSuccessNotification("Your security key can now be used to sign in without password.");
+            history.push(FirstFactorPath);
+        } catch (err) {
+            console.error(err);
+            setRegistrationInProgress(false);
+            createErrorNotification(
+                "Failed to register your security key. The identity verification process might have timed out.",
+            );
+        }
+    };
+
+    return (
+        <LoginLayout title="Register Passwordless Security Key" id="register-passwordless-stage">
+            <div className={style.icon}>
+                <FingerTouchIcon size={64} animated={registrationInProgress} />
+            </div>
+            <Typography className={style.instruction}>
+                Name your security key, then touch it and verify your identity with its PIN or biometrics
+            </Typography>
+            <Grid container spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="description-textfield"
+                        label="Name"
+                        variant="outlined"
+                        fullWidth
+                        disabled={registrationInProgress}
+                        value={description}
+                        onChange={(e) => setDescription(e.target.value)}
+                    />
+                </Grid>
+                <Grid item xs={6}>
+                    <Button
+                        id="register-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={registrationInProgress}
+                        onClick={handleRegisterClick}
+                    >
+                        Register
+                    </Button>
+                </Grid>
+                <Grid item xs={6}>
+                    <Button id="cancel-button" variant="contained" color="primary" fullWidth onClick={handleBackClick}>
+                        Cancel
+                    </Button>
+                </Grid>
+            </Grid>
+        </LoginLayout>
+    );
+};
+
+export default RegisterPasswordless;
+
+const useStyles = makeStyles((theme) => ({
+    icon: {
+        paddingTop: theme.spacing(4),
+        paddingBottom: theme.spacing(4),
+    },
+    instruction: {
+        paddingBottom: theme.spacing(4),
+    },
+}));
@@ -4,21 +4,35 @@ import { Grid, makeStyles, Button } from "@material-ui/core";
 import { useHistory } from "react-router";
 
 import { LogoutRoute as SignOutRoute } from "@constants/Routes";
+import { useNotifications } from "@hooks/NotificationsContext";
 import LoginLayout from "@layouts/LoginLayout";
+import { initiatePasswordlessRegistrationProcess, isPasswordlessSupported } from "@services/Passwordless";
 import Authenticated from "@views/LoginPortal/Authenticated";
 
 export interface Props {
     name: string;
+    passwordless: boolean;
 }
 
 const AuthenticatedView = function (props: Props) {
     const style = useStyles();
     const history = useHistory();
+    const { createInfoNotification, createErrorNotification } = useNotifications();
 
     const handleLogoutClick = () => {
         history.push(SignOutRoute);
     };
 
+    const handleRegisterPasswordlessClick = async () => {
+        try {
+            await initiatePasswordlessRegistrationProcess();
+            createInfoNotification("An email has been sent to your address to complete the process.");
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("There was a problem initiating the registration process");
+        }
+    };
+
     return (
         <LoginLayout id="authenticated-stage" title={`Hi ${props.name}`} showBrand>
             <Grid container>
@@ -30,6 +44,17 @@ const AuthenticatedView = function (props: Props) {
                 <Grid item xs={12} className={style.mainContainer}>
                     <Authenticated />
                 </Grid>
+                {props.passwordless && isPasswordlessSupported() ? (
+                    <Grid item xs={12}>
+                        <Button
+                            color="primary"
+                            onClick={handleRegisterPasswordlessClick}
+                            id="register-passwordless-button"
+                        >
+                            Register a passwordless security key
+                        </Button>
+                    </Grid>
+                ) : null}
             </Grid>
         </LoginLayout>
     );
@@ -5,17 +5,21 @@ import classnames from "classnames";
 import { useHistory } from "react-router";
 
 import FixedTextField from "@components/FixedTextField";
-import { ResetPasswordStep1Route } from "@constants/Routes";
+import { ChangePasswordRoute, RegisterStep1Route, ResetPasswordStep1Route } from "@constants/Routes";
 import { useNotifications } from "@hooks/NotificationsContext";
 import { useRedirectionURL } from "@hooks/RedirectionURL";
 import { useRequestMethod } from "@hooks/RequestMethod";
 import LoginLayout from "@layouts/LoginLayout";
+import { PasswordChangeRequiredMessage } from "@services/Api";
 import { postFirstFactor } from "@services/FirstFactor";
+import { isPasswordlessSupported, performPasswordlessSignIn } from "@services/Passwordless";
 
 export interface Props {
     disabled: boolean;
     rememberMe: boolean;
     resetPassword: boolean;
+    registration: boolean;
+    passwordless: boolean;
 
     onAuthenticationStart: () => void;
     onAuthenticationFailure: () => void;
@@ -66,6 +70,10 @@ const FirstFactorForm = function (props: Props) {
             props.onAuthenticationSuccess(res ? res.redirect : undefined);
         } catch (err) {
             console.error(err);
+            if (err.response && err.response.data && err.response.data.message === PasswordChangeRequiredMessage) {
+                history.push(`${ChangePasswordRoute}?username=${encodeURIComponent(username)}`);
+                return;
+            }
             createErrorNotification("Incorrect username or password.");
             props.onAuthenticationFailure();
             setPassword("");
@@ -73,10 +81,26 @@ const FirstFactorForm = function (props: Props) {
         }
     };
 
+    const handlePasswordlessSignIn = async () => {
+        props.onAuthenticationStart();
+        try {
+            const res = await performPasswordlessSignIn(redirectionURL);
+            props.onAuthenticationSuccess(res ? res.redirect : undefined);
+        } catch (err) {
+            console.error(err);
+            createErrorNotification("There was a problem signing in with your security key.");
+            props.onAuthenticationFailure();
+        }
+    };
+
     const handleResetPasswordClick = () => {
         history.push(ResetPasswordStep1Route);
     };
 
+    const handleRegisterClick = () => {
+        history.push(RegisterStep1Route);
+    };
+
     return (
         <LoginLayout id="first-factor-stage" title="Sign in" showBrand>
             <Grid container spacing={2}>
@@ -199,6 +223,32 @@ const FirstFactorForm = function (props: Props) {
                         Sign in
                     </Button>
                 </Grid>
+                {props.passwordless && isPasswordlessSupported() ? (
+                    <Grid item xs={12}>
+                        <Button
+                            id="passwordless-sign-in-button"
+                            variant="outlined"
+                            color="primary"
+                            fullWidth
+                            disabled={disabled}
+                            onClick={handlePasswordlessSignIn}
+                        >
+                            Sign in with a security key
+                        </Button>
+                    </Grid>
+                ) : null}
+                {props.registration ? (
+                    <Grid item xs={12}>
+                        <Link
+                            id="register-button"
+                            component="button"
+                            onClick={handleRegisterClick}
+                            className={style.resetLink}
+                        >
+                            Don't have an account? Register
+                        </Link>
+                    </Grid>
+                ) : null}
             </Grid>
         </LoginLayout>
     );
@@ -1,4 +1,4 @@
-import React, { useEffect, Fragment, ReactNode, useState, useCallback } from "react";
+import React, { useEffect, Fragment, ReactNode, useState, useCallback, useMemo } from "react";
 
 import { Switch, Route, Redirect, useHistory, useLocation } from "react-router";
 
@@ -13,12 +13,15 @@ import {
 import { useConfiguration } from "@hooks/Configuration";
 import { useNotifications } from "@hooks/NotificationsContext";
 import { useRedirectionURL } from "@hooks/RedirectionURL";
+import { useReauthentication, useSecondFactorMethods } from "@hooks/Reauthentication";
 import { useRedirector } from "@hooks/Redirector";
 import { useRequestMethod } from "@hooks/RequestMethod";
 import { useAutheliaState } from "@hooks/State";
 import { useUserPreferences as userUserInfo } from "@hooks/UserInfo";
 import { SecondFactorMethod } from "@models/Methods";
+import { postFirstFactorPreAuthenticated } from "@services/FirstFactor";
 import { AuthenticationLevel } from "@services/State";
+import { Method2FA, toEnum, toString } from "@services/UserPreferences";
 import LoadingPage from "@views/LoadingPage/LoadingPage";
 import AuthenticatedView from "@views/LoginPortal/AuthenticatedView/AuthenticatedView";
 import FirstFactorForm from "@views/LoginPortal/FirstFactor/FirstFactorForm";
@@ -27,6 +30,8 @@ import SecondFactorForm from "@views/LoginPortal/SecondFactor/SecondFactorForm";
 export interface Props {
     rememberMe: boolean;
     resetPassword: boolean;
+    registration: boolean;
+    passwordless: boolean;
 }
 
 const LoginPortal = function (props: Props) {
@@ -34,6 +39,9 @@ const LoginPortal = function (props: Props) {
     const location = useLocation();
     const redirectionURL = useRedirectionURL();
     const requestMethod = useRequestMethod();
+    const reauthentication = useReauthentication();
+    const secondFactorMethods = useSecondFactorMethods();
+    const [reauthenticated, setReauthenticated] = useState(false);
     const { createErrorNotification } = useNotifications();
     const [firstFactorDisabled, setFirstFactorDisabled] = useState(true);
     const redirector = useRedirector();
@@ -44,9 +52,38 @@ const LoginPortal = function (props: Props) {
 
     const redirect = useCallback((url: string) => history.push(url), [history]);
 
-    // Fetch the state when portal is mounted.
+    // The verify endpoint asks the user to complete a factor again when the authentication is older than the maximum
+    // age of an access control rule or when the rule doesn't accept the second factor methods completed by the user.
+    // The factor is considered incomplete until then but the user stays logged in.
+    const reauthenticationPending = reauthentication !== undefined && !reauthenticated;
+    const acceptedMethods = useMemo(
+        () =>
+            (secondFactorMethods ? secondFactorMethods.split(",") : []).filter(
+                (method) => method === "totp" || method === "u2f" || method === "mobile_push",
+            ) as Method2FA[],
+        [secondFactorMethods],
+    );
+    let authenticationLevel = state ? state.authentication_level : undefined;
+    if (authenticationLevel !== undefined && reauthenticationPending) {
+        if (reauthentication === "one_factor" || (secondFactorMethods !== undefined && acceptedMethods.length === 0)) {
+            // A passwordless sign in starts from the first factor too.
+            authenticationLevel = AuthenticationLevel.Unauthenticated;
+        } else if (reauthentication === "two_factor") {
+            authenticationLevel = Math.min(authenticationLevel, AuthenticationLevel.OneFactor);
+        }
+    }
+
+    // Sign in the users forwarded by a trusted gateway or presenting a client certificate, then fetch the state when
+    // portal is mounted.
     useEffect(() => {
-        fetchState();
+        (async () => {
+            try {
+                await postFirstFactorPreAuthenticated();
+            } catch (err) {
+                console.error(err);
+            }
+            fetchState();
+        })();
     }, [fetchState]);
 
     // Fetch preferences and configuration when user is authenticated.
@@ -59,10 +96,10 @@ const LoginPortal = function (props: Props) {
 
     // Enable first factor when user is unauthenticated.
     useEffect(() => {
-        if (state && state.authentication_level > AuthenticationLevel.Unauthenticated) {
+        if (authenticationLevel !== undefined && authenticationLevel > AuthenticationLevel.Unauthenticated) {
             setFirstFactorDisabled(true);
         }
-    }, [state, setFirstFactorDisabled]);
+    }, [authenticationLevel, setFirstFactorDisabled]);
 
     // Display an error when state fetching fails
     useEffect(() => {
@@ -87,21 +124,31 @@ const LoginPortal = function (props: Props) {
 
     // Redirect to the correct stage if not enough authenticated
     useEffect(() => {
-        if (state) {
+        if (authenticationLevel !== undefined) {
+            const requestMethodSuffix = requestMethod ? `&rm=${requestMethod}` : "";
+            const methodsSuffix = secondFactorMethods ? `&methods=${encodeURIComponent(secondFactorMethods)}` : "";
+            const reauthenticationSuffix = reauthenticationPending ? `&reauth=${reauthentication}${methodsSuffix}` : "";
             const redirectionSuffix = redirectionURL
-                ? `?rd=${encodeURIComponent(redirectionURL)}${requestMethod ? `&rm=${requestMethod}` : ""}`
+                ? `?rd=${encodeURIComponent(redirectionURL)}${requestMethodSuffix}${reauthenticationSuffix}`
                 : "";
 
-            if (state.authentication_level === AuthenticationLevel.Unauthenticated) {
+            if (authenticationLevel === AuthenticationLevel.Unauthenticated) {
                 setFirstFactorDisabled(false);
                 redirect(`${FirstFactorRoute}${redirectionSuffix}`);
-            } else if (state.authentication_level >= AuthenticationLevel.OneFactor && userInfo && configuration) {
+            } else if (authenticationLevel >= AuthenticationLevel.OneFactor && userInfo && configuration) {
                 if (!configuration.second_factor_enabled) {
                     redirect(AuthenticatedRoute);
                 } else {
-                    if (userInfo.method === SecondFactorMethod.U2F) {
+                    // The preferred method is used unless the verify endpoint requested another one.
+                    let method = userInfo.method;
+                    const preferredMethodAccepted = acceptedMethods.includes(toString(method));
+                    if (reauthenticationPending && acceptedMethods.length > 0 && !preferredMethodAccepted) {
+                        method = toEnum(acceptedMethods[0]);
+                    }
+
+                    if (method === SecondFactorMethod.U2F) {
                         redirect(`${SecondFactorU2FRoute}${redirectionSuffix}`);
-                    } else if (userInfo.method === SecondFactorMethod.MobilePush) {
+                    } else if (method === SecondFactorMethod.MobilePush) {
                         redirect(`${SecondFactorPushRoute}${redirectionSuffix}`);
                     } else {
                         redirect(`${SecondFactorTOTPRoute}${redirectionSuffix}`);
@@ -109,7 +156,19 @@ const LoginPortal = function (props: Props) {
                 }
             }
         }
-    }, [state, redirectionURL, requestMethod, redirect, userInfo, setFirstFactorDisabled, configuration]);
+    }, [
+        authenticationLevel,
+        redirectionURL,
+        requestMethod,
+        reauthentication,
+        reauthenticationPending,
+        secondFactorMethods,
+        acceptedMethods,
+        redirect,
+        userInfo,
+        setFirstFactorDisabled,
+        configuration,
+    ]);
 
     const handleAuthSuccess = async (redirectionURL: string | undefined) => {
         if (redirectionURL) {
@@ -117,13 +176,14 @@ const LoginPortal = function (props: Props) {
             redirector(redirectionURL);
         } else {
             // Refresh state
+            setReauthenticated(true);
             fetchState();
         }
     };
 
     const firstFactorReady =
-        state !== undefined &&
-        state.authentication_level === AuthenticationLevel.Unauthenticated &&
+        authenticationLevel !== undefined &&
+        authenticationLevel === AuthenticationLevel.Unauthenticated &&
         location.pathname === FirstFactorRoute;
 
     return (
@@ -134,6 +194,8 @@ const LoginPortal = function (props: Props) {
                         disabled={firstFactorDisabled}
                         rememberMe={props.rememberMe}
                         resetPassword={props.resetPassword}
+                        registration={props.registration}
+                        passwordless={props.passwordless}
                         onAuthenticationStart={() => setFirstFactorDisabled(true)}
                         onAuthenticationFailure={() => setFirstFactorDisabled(false)}
                         onAuthenticationSuccess={handleAuthSuccess}
@@ -141,9 +203,9 @@ const LoginPortal = function (props: Props) {
                 </ComponentOrLoading>
             </Route>
             <Route path={SecondFactorRoute}>
-                {state && userInfo && configuration ? (
+                {authenticationLevel !== undefined && userInfo && configuration ? (
                     <SecondFactorForm
-                        authenticationLevel={state.authentication_level}
+                        authenticationLevel={authenticationLevel}
                         userInfo={userInfo}
                         configuration={configuration}
                         onMethodChanged={() => fetchUserInfo()}
@@ -152,7 +214,7 @@ const LoginPortal = function (props: Props) {
                 ) : null}
             </Route>
             <Route path={AuthenticatedRoute} exact>
-                {userInfo ? <AuthenticatedView name={userInfo.display_name} /> : null}
+                {userInfo ? <AuthenticatedView name={userInfo.display_name} passwordless={props.passwordless} /> : null}
             </Route>
             {/* By default we route to first factor page */}
             <Route path="/">
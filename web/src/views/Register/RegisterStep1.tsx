@@ -0,0 +1,186 @@
+import React, { useState } from "react";
+
+import { Grid, Button, makeStyles } from "@material-ui/core";
+import { useHistory } from "react-router";
+
+import FixedTextField from "@components/FixedTextField";
+import { FirstFactorRoute } from "@constants/Routes";
+import { useNotifications } from "@hooks/NotificationsContext";
+import LoginLayout from "@layouts/LoginLayout";
+import { RegistrationDomainNotAllowedMessage } from "@services/Api";
+import { initiateRegistrationProcess } from "@services/Register";
+
+const RegisterStep1 = function () {
+    const style = useStyles();
+    const [username, setUsername] = useState("");
+    const [displayName, setDisplayName] = useState("");
+    const [email, setEmail] = useState("");
+    const [password1, setPassword1] = useState("");
+    const [password2, setPassword2] = useState("");
+    const [errorUsername, setErrorUsername] = useState(false);
+    const [errorEmail, setErrorEmail] = useState(false);
+    const [errorPassword, setErrorPassword] = useState(false);
+    const [formDisabled, setFormDisabled] = useState(false);
+    const { createInfoNotification, createErrorNotification } = useNotifications();
+    const history = useHistory();
+
+    const doInitiateRegistrationProcess = async () => {
+        setErrorUsername(username === "");
+        setErrorEmail(email === "");
+        setErrorPassword(password1 === "" || password1 !== password2);
+
+        if (username === "" || email === "" || password1 === "") {
+            return;
+        }
+        if (password1 !== password2) {
+            createErrorNotification("Passwords do not match.");
+            return;
+        }
+
+        try {
+            setFormDisabled(true);
+            await initiateRegistrationProcess(username, displayName, email, password1);
+            createInfoNotification("An email has been sent to your address to complete the registration.");
+        } catch (err) {
+            console.error(err);
+            setFormDisabled(false);
+            if (err.message.includes(RegistrationDomainNotAllowedMessage)) {
+                setErrorEmail(true);
+                createErrorNotification(RegistrationDomainNotAllowedMessage);
+            } else {
+                createErrorNotification("There was an issue initiating the registration process.");
+            }
+        }
+    };
+
+    const handleKeyPress = (ev: React.KeyboardEvent) => {
+        if (ev.key === "Enter") {
+            doInitiateRegistrationProcess();
+            ev.preventDefault();
+        }
+    };
+
+    const handleRegisterClick = () => {
+        doInitiateRegistrationProcess();
+    };
+
+    const handleCancelClick = () => {
+        history.push(FirstFactorRoute);
+    };
+
+    return (
+        <LoginLayout title="Register" id="register-step1-stage">
+            <Grid container className={style.root} spacing={2}>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="username-textfield"
+                        label="Username"
+                        variant="outlined"
+                        fullWidth
+                        required
+                        disabled={formDisabled}
+                        error={errorUsername}
+                        value={username}
+                        onChange={(e) => setUsername(e.target.value)}
+                        onKeyPress={handleKeyPress}
+                        autoCapitalize="none"
+                        autoComplete="username"
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="display-name-textfield"
+                        label="Display name"
+                        variant="outlined"
+                        fullWidth
+                        disabled={formDisabled}
+                        value={displayName}
+                        onChange={(e) => setDisplayName(e.target.value)}
+                        onKeyPress={handleKeyPress}
+                        autoComplete="name"
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="email-textfield"
+                        label="Email"
+                        variant="outlined"
+                        type="email"
+                        fullWidth
+                        required
+                        disabled={formDisabled}
+                        error={errorEmail}
+                        value={email}
+                        onChange={(e) => setEmail(e.target.value)}
+                        onKeyPress={handleKeyPress}
+                        autoComplete="email"
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="password1-textfield"
+                        label="Password"
+                        variant="outlined"
+                        type="password"
+                        fullWidth
+                        required
+                        disabled={formDisabled}
+                        error={errorPassword}
+                        value={password1}
+                        onChange={(e) => setPassword1(e.target.value)}
+                        onKeyPress={handleKeyPress}
+                        autoComplete="new-password"
+                    />
+                </Grid>
+                <Grid item xs={12}>
+                    <FixedTextField
+                        id="password2-textfield"
+                        label="Repeat password"
+                        variant="outlined"
+                        type="password"
+                        fullWidth
+                        required
+                        disabled={formDisabled}
+                        error={errorPassword}
+                        value={password2}
+                        onChange={(e) => setPassword2(e.target.value)}
+                        onKeyPress={handleKeyPress}
+                        autoComplete="new-password"
+                    />
+                </Grid>
+                <Grid item xs={6}>
+                    <Button
+                        id="register-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        disabled={formDisabled}
+                        onClick={handleRegisterClick}
+                    >
+                        Register
+                    </Button>
+                </Grid>
+                <Grid item xs={6}>
+                    <Button
+                        id="cancel-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        onClick={handleCancelClick}
+                    >
+                        Cancel
+                    </Button>
+                </Grid>
+            </Grid>
+        </LoginLayout>
+    );
+};
+
+export default RegisterStep1;
+
+const useStyles = makeStyles((theme) => ({
+    root: {
+        marginTop: theme.spacing(2),
+        marginBottom: theme.spacing(2),
+    },
+}));
@@ -0,0 +1,81 @@
+import React, { useState, useCallback, useEffect } from "react";
+
+import { Grid, Button, Typography, makeStyles } from "@material-ui/core";
+import { useHistory, useLocation } from "react-router";
+
+import { FirstFactorRoute } from "@constants/Routes";
+import { useNotifications } from "@hooks/NotificationsContext";
+import LoginLayout from "@layouts/LoginLayout";
+import { completeRegistrationProcess } from "@services/Register";
+import { extractIdentityToken } from "@utils/IdentityToken";
+
+const RegisterStep2 = function () {
+    const style = useStyles();
+    const location = useLocation();
+    const [message, setMessage] = useState("Verifying your email address...");
+    const { createErrorNotification } = useNotifications();
+    const history = useHistory();
+    // Get the token from the query param to give it back to the API to verify the email address.
+    const processToken = extractIdentityToken(location.search);
+
+    const completeProcess = useCallback(async () => {
+        if (!processToken) {
+            setMessage("Your email address could not be verified.");
+            createErrorNotification("No verification token provided");
+            return;
+        }
+
+        try {
+            const { approvalRequired } = await completeRegistrationProcess(processToken);
+            if (approvalRequired) {
+                setMessage(
+                    "Your email address has been verified. An administrator must approve your registration before you can sign in.",
+                );
+            } else {
+                setMessage("Your account has been created, you can now sign in.");
+            }
+        } catch (err) {
+            console.error(err);
+            setMessage("Your email address could not be verified.");
+            createErrorNotification(
+                "There was an issue completing the registration. The verification token might have expired.",
+            );
+        }
+    }, [processToken, createErrorNotification]);
+
+    useEffect(() => {
+        completeProcess();
+    }, [completeProcess]);
+
+    const handleSignInClick = () => history.push(FirstFactorRoute);
+
+    return (
+        <LoginLayout title="Register" id="register-step2-stage">
+            <Grid container className={style.root} spacing={2}>
+                <Grid item xs={12}>
+                    <Typography id="registration-message">{message}</Typography>
+                </Grid>
+                <Grid item xs={12}>
+                    <Button
+                        id="sign-in-button"
+                        variant="contained"
+                        color="primary"
+                        fullWidth
+                        onClick={handleSignInClick}
+                    >
+                        Sign in
+                    </Button>
+                </Grid>
+            </Grid>
+        </LoginLayout>
+    );
+};
+
+export default RegisterStep2;
+
+const useStyles = makeStyles((theme) => ({
+    root: {
+        marginTop: theme.spacing(2),
+        marginBottom: theme.spacing(2),
+    },
+}));